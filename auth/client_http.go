@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/resilience"
 	"github.com/google/uuid"
 )
 
@@ -36,6 +37,14 @@ func NewHTTPAuthClient(baseURL string) *HTTPAuthClient {
 	}
 }
 
+// permsRetryPolicy: 3 attempts, ~50ms then ~150ms apart — the lookup sits on
+// the request path, so retries must stay well under a user-visible delay.
+var permsRetryPolicy = resilience.Policy{
+	MaxAttempts: 3,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    150 * time.Millisecond,
+}
+
 // authHTTPStatusError is returned when tms-auth answers with a non-200 status.
 // Callers and the retry loop branch on Status rather than parsing the message.
 type authHTTPStatusError struct {
@@ -67,30 +76,16 @@ func (c *HTTPAuthClient) ResolveUserPerms(ctx context.Context, userID uuid.UUID)
 		return nil, fmt.Errorf("HTTPAuthClient can only resolve the caller's own perms (actor=%s, requested=%s)", actor.ID, userID)
 	}
 
-	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		if attempt > 0 {
-			delay := 50 * time.Millisecond
-			if attempt >= 2 {
-				delay = 150 * time.Millisecond
-			}
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(delay):
-			}
-		}
-
-		perms, err := c.doResolve(ctx, *actor.Token)
-		if err == nil {
-			return perms, nil
-		}
-		lastErr = err
-		if !isRetryablePermsError(err) {
-			return nil, err
-		}
+	var perms []string
+	err = resilience.Do(ctx, permsRetryPolicy, isRetryablePermsError, func(ctx context.Context) error {
+		var err error
+		perms, err = c.doResolve(ctx, *actor.Token)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, lastErr
+	return perms, nil
 }
 
 func (c *HTTPAuthClient) doResolve(ctx context.Context, token string) ([]string, error) {
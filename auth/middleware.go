@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,6 +19,9 @@ import (
 // It is also the revocation gate: on the same pass it reads the user's
 // tokens_valid_after cutoff (one extra Redis GET) and rejects any access token
 // issued before it — the token-level counterpart to deleting a refresh session.
+// Tokens carrying a session id are additionally checked against that one
+// session's revocation marker and recorded in the session registry (see
+// sessions.go).
 // This is why an already-issued token stops working the moment a session is
 // revoked or a user is terminated, instead of living out its whole TTL.
 //
@@ -42,8 +46,8 @@ func IdentifyUserPerms(pr *PermResolver, revocationExemptPrefixes ...string) gin
 
 		// Revocation gate. Skipped for exempt routes (see above) and for tokens
 		// with no iat to compare (guest/share tokens never reach here anyway).
-		if actor.Claims != nil && actor.Claims.IssuedAt != nil &&
-			!hasAnyPrefix(ctx.FullPath(), revocationExemptPrefixes) {
+		exempt := hasAnyPrefix(ctx.FullPath(), revocationExemptPrefixes)
+		if actor.Claims != nil && actor.Claims.IssuedAt != nil && !exempt {
 			revoked, rerr := TokenRevoked(ctx.Request.Context(), actor.ID, actor.Claims.IssuedAt.Time)
 			if rerr != nil {
 				// Fail OPEN: a Redis outage must not lock out every user. The
@@ -52,14 +56,28 @@ func IdentifyUserPerms(pr *PermResolver, revocationExemptPrefixes ...string) gin
 				slog.Warn("token revocation check failed, allowing request", "userID", actor.ID, "err", rerr)
 			} else if revoked {
 				slog.Info("rejected revoked access token", "userID", actor.ID)
-				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-					"error":   consts.CodeTokenRevoked,
-					"message": consts.MsgTokenRevoked,
-				})
+				abortRevoked(ctx, actor)
 				return
 			}
 		}
 
+		// Per-session gate: the same decision for one session rather than the
+		// whole user, then a throttled last-seen touch of the session registry.
+		// Both fail open for the same reason as the cutoff above.
+		if sid := SessionIDFromClaims(actor.Claims); sid != "" && !exempt {
+			revoked, rerr := SessionRevoked(ctx.Request.Context(), sid)
+			if rerr != nil {
+				slog.Warn("session revocation check failed, allowing request", "userID", actor.ID, "err", rerr)
+			} else if revoked {
+				slog.Info("rejected access token of revoked session", "userID", actor.ID, "sessionID", sid)
+				abortRevoked(ctx, actor)
+				return
+			}
+			if terr := TouchSession(ctx.Request.Context(), actor, middleware.GetClientOrigin(ctx.Request.Context())); terr != nil {
+				slog.Debug("session registry touch failed", "userID", actor.ID, "err", terr)
+			}
+		}
+
 		perms, err := pr.GetUserPerms(ctx.Request.Context(), actor.ID)
 		if err != nil {
 			// Unresolved ≠ denied. Substituting []string{} made every @hasPerm
@@ -82,6 +100,25 @@ func IdentifyUserPerms(pr *PermResolver, revocationExemptPrefixes ...string) gin
 	}
 }
 
+// abortRevoked answers 401 with the token_revoked code, shared by the per-user
+// cutoff and the per-session gate so the FE handles both identically. The
+// rejection is audited like any other access denial, so support sees "revoked"
+// rather than a missing decision.
+func abortRevoked(ctx *gin.Context, actor *consts.Actor) {
+	middleware.AuditDenial(ctx.Request.Context(), middleware.AccessDecision{
+		Reason:     middleware.AccessTokenRevoked,
+		Resolution: middleware.ResolutionRevoked,
+		ActorID:    actor.ID,
+		CompanyID:  actor.GetCompanyID(),
+		RequestID:  middleware.GetRequestID(ctx.Request.Context()),
+		Gate:       "auth",
+	})
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":   consts.CodeTokenRevoked,
+		"message": consts.MsgTokenRevoked,
+	})
+}
+
 // hasAnyPrefix reports whether path starts with any of prefixes. Empty prefixes
 // (the subgraph case) never match, so the revocation gate stays fully on.
 func hasAnyPrefix(path string, prefixes []string) bool {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/TMS360/backend-pkg/cache"
 	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/observability/metrics"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"golang.org/x/sync/singleflight"
@@ -62,9 +63,13 @@ func (pr *PermResolver) GetUserPerms(ctx context.Context, userID uuid.UUID) ([]s
 
 	var cached []string
 	if err := cache.GetGlobal(ctx, key, &cached); err == nil {
+		metrics.ObservePermCache(metrics.PermCacheHit)
 		return cached, nil
 	} else if !errors.Is(err, redis.Nil) {
+		metrics.ObservePermCache(metrics.PermCacheError)
 		slog.Debug("perm cache read failed", "userID", userID, "err", err)
+	} else {
+		metrics.ObservePermCache(metrics.PermCacheMiss)
 	}
 
 	v, err, _ := pr.sf.Do(userID.String(), func() (interface{}, error) {
@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Per-session revocation and the active-session registry.
+//
+// RevokeUserTokens is a per-USER cutoff: right for "terminate this employee",
+// wrong for "this one laptop was stolen" — it signs the user out of every
+// device. A session id on the token (the `sid` claim tms-auth stamps, falling
+// back to `jti` for tokens that only carry that) lets us end exactly one
+// session: session_revoked:<sid> is checked by IdentifyUserPerms next to the
+// cutoff, on the same request pass.
+//
+// The registry is what makes the revoke usable: every authenticated request
+// touches session:<sid> with the device, IP (middleware.ClientOrigin) and
+// last-seen time, so a "where you're signed in" screen can list sessions and
+// revoke one. Like the cutoff, every key is global (user and session ids are
+// globally unique) so the user's own request and an admin's revoke address the
+// same key.
+
+// SessionTTL is how long an idle session stays listed. It matches
+// TokensValidAfterTTL: a session nobody has used for longer than the
+// longest-lived token cannot be presenting a live token any more.
+const SessionTTL = TokensValidAfterTTL
+
+// SessionTouchInterval throttles last-seen writes. A page fires dozens of
+// GraphQL operations; recording each would turn the registry into a
+// write-per-request hot key for no extra information.
+const SessionTouchInterval = time.Minute
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// refreshTTL is the refresh-token lifetime set by SetRefreshTTL; zero means
+// it was never configured.
+var refreshTTL atomic.Int64
+
+// SetRefreshTTL tells the package how long tms-auth's refresh tokens live
+// (config.JWTConfig.RefreshTTL). A revoked session's marker must outlive the
+// session's refresh token, or the session becomes usable again when the
+// marker expires. Call it once at startup.
+func SetRefreshTTL(d time.Duration) { refreshTTL.Store(int64(d)) }
+
+// RefreshTTL is the configured refresh-token lifetime, or TokensValidAfterTTL
+// when SetRefreshTTL was not called.
+func RefreshTTL() time.Duration {
+	if d := time.Duration(refreshTTL.Load()); d > 0 {
+		return d
+	}
+	return TokensValidAfterTTL
+}
+
+// SessionInfo is one entry of the active-session registry.
+type SessionInfo struct {
+	ID         string     `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	CompanyID  *uuid.UUID `json:"company_id,omitempty"`
+	Device     string     `json:"device"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	IP         string     `json:"ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+}
+
+func sessionKey(sessionID string) string        { return "session:" + sessionID }
+func sessionTouchKey(sessionID string) string   { return "session_touch:" + sessionID }
+func sessionRevokedKey(sessionID string) string { return "session_revoked:" + sessionID }
+func userSessionsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user_sessions:%s", userID.String())
+}
+
+// SessionIDFromClaims returns the session a token belongs to: the `sid` claim,
+// else the `jti`. Empty means the token predates session ids and can only be
+// revoked through the per-user cutoff.
+func SessionIDFromClaims(c *consts.UserClaims) string {
+	if c == nil {
+		return ""
+	}
+	if c.SessionID != "" {
+		return c.SessionID
+	}
+	return c.ID
+}
+
+// RevokeSession ends one session: every access token carrying its id is
+// refused on its next request, while the user's other sessions keep working.
+// expiresAt is the expiry of the longest-lived token the session may still
+// present; the marker lives exactly that long (plus clock skew) and then goes
+// inert. Pass the zero time when it is unknown — the marker then lives
+// TokensValidAfterTTL, like the per-user cutoff.
+//
+// Like RevokeUserTokens this is a best-effort security marker: tms-auth must
+// still delete the refresh session so no NEW token is minted for it.
+func RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string, expiresAt time.Time) error {
+	rdb := cache.Client()
+	if rdb == nil || sessionID == "" {
+		return errors.New("session revocation unavailable")
+	}
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, sessionRevokedKey(sessionID), time.Now().Unix(), SessionRevocationTTL(expiresAt, time.Now()))
+	pipe.Del(ctx, sessionKey(sessionID), sessionTouchKey(sessionID))
+	pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SessionRevocationTTL is the lifetime of a session_revoked marker: until the
+// token expires, with RevocationClockSkew of slack so a replica whose clock
+// runs behind still sees the marker. Split out so it is testable without Redis.
+func SessionRevocationTTL(expiresAt, now time.Time) time.Duration {
+	if expiresAt.IsZero() {
+		return TokensValidAfterTTL
+	}
+	ttl := expiresAt.Sub(now) + RevocationClockSkew
+	if ttl < RevocationClockSkew {
+		return RevocationClockSkew
+	}
+	return ttl
+}
+
+// RevokeUserSession is the self-service / admin entry point: it ends sessionID
+// only if it belongs to userID, so a caller cannot revoke someone else's
+// session by guessing an id. Returns ErrSessionNotFound otherwise. The
+// session's tokens are not at hand, so the marker lives as long as a refresh
+// token minted right now could (RefreshTTL).
+func RevokeUserSession(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	rdb := cache.Client()
+	if rdb == nil {
+		return errors.New("session registry unavailable")
+	}
+	member, err := rdb.SIsMember(ctx, userSessionsKey(userID), sessionID).Result()
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrSessionNotFound
+	}
+	return RevokeSession(ctx, userID, sessionID, time.Now().Add(RefreshTTL()))
+}
+
+// SessionRevoked reports whether sessionID was revoked. Fail-open exactly like
+// TokenRevoked: a key-miss and any Redis error both answer "not revoked", the
+// error being returned only so the caller can log the degradation.
+func SessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	rdb := cache.Client()
+	if rdb == nil || sessionID == "" {
+		return false, nil
+	}
+	n, err := rdb.Exists(ctx, sessionRevokedKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ListUserSessions returns the user's live sessions, most recently seen first.
+// Entries whose record has expired are pruned from the index on the way.
+func ListUserSessions(ctx context.Context, userID uuid.UUID) ([]SessionInfo, error) {
+	rdb := cache.Client()
+	if rdb == nil {
+		return nil, errors.New("session registry unavailable")
+	}
+	ids, err := rdb.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []SessionInfo{}, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionKey(id)
+	}
+	vals, err := rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]SessionInfo, 0, len(ids))
+	var stale []interface{}
+	for i, v := range vals {
+		raw, ok := v.(string)
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		var s SessionInfo
+		if json.Unmarshal([]byte(raw), &s) != nil {
+			stale = append(stale, ids[i])
+			continue
+		}
+		out = append(out, s)
+	}
+	if len(stale) > 0 {
+		_ = rdb.SRem(ctx, userSessionsKey(userID), stale...).Err()
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].LastSeenAt.After(out[j].LastSeenAt) })
+	return out, nil
+}
+
+// TouchSession records that actor's session was used just now from origin,
+// creating the registry entry on first sight. Writes are throttled to one per
+// SessionTouchInterval per session (a SET NX gate), so the hot path costs one
+// round-trip and usually nothing more.
+func TouchSession(ctx context.Context, actor *consts.Actor, origin *middleware.ClientOrigin) error {
+	rdb := cache.Client()
+	if rdb == nil || actor == nil || actor.Claims == nil {
+		return nil
+	}
+	sid := SessionIDFromClaims(actor.Claims)
+	if sid == "" {
+		return nil
+	}
+	first, err := rdb.SetNX(ctx, sessionTouchKey(sid), 1, SessionTouchInterval).Result()
+	if err != nil || !first {
+		return err
+	}
+
+	now := time.Now()
+	info := SessionInfo{ID: sid, UserID: actor.ID, CompanyID: actor.GetCompanyID(), CreatedAt: now}
+	if raw, err := rdb.Get(ctx, sessionKey(sid)).Bytes(); err == nil {
+		_ = json.Unmarshal(raw, &info)
+	} else if !errors.Is(err, redis.Nil) {
+		return err
+	}
+	info.LastSeenAt = now
+	if origin != nil {
+		if origin.IP != "" {
+			info.IP = origin.IP
+		}
+		if origin.UserAgent != "" {
+			info.UserAgent = origin.UserAgent
+			info.Device = DescribeDevice(origin.UserAgent)
+		}
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, sessionKey(sid), data, SessionTTL)
+	pipe.SAdd(ctx, userSessionsKey(actor.ID), sid)
+	pipe.Expire(ctx, userSessionsKey(actor.ID), SessionTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// DescribeDevice turns a user-agent into the short "Chrome on macOS" label a
+// session list shows. It is deliberately coarse — the full user-agent is kept
+// alongside for support.
+func DescribeDevice(ua string) string {
+	if ua == "" {
+		return "Unknown device"
+	}
+	browser := ""
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "okhttp"), strings.Contains(ua, "Dart/"), strings.Contains(ua, "CFNetwork"):
+		browser = "Mobile app"
+	}
+	platform := ""
+	switch {
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "CFNetwork"):
+		platform = "iOS"
+	case strings.Contains(ua, "Android"):
+		platform = "Android"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "Windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "Linux"):
+		platform = "Linux"
+	}
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "Unknown device"
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A revoked session must stay revoked for as long as its refresh token could
+// still mint access tokens — not the fixed cutoff TTL.
+func TestRevokeUserSession_MarkerOutlivesRefreshToken(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache.Init(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	SetRefreshTTL(30 * 24 * time.Hour)
+	t.Cleanup(func() { SetRefreshTTL(0) })
+
+	ctx := context.Background()
+	user := uuid.New()
+	require.NoError(t, cache.Client().SAdd(ctx, userSessionsKey(user), "sid-1").Err())
+
+	require.NoError(t, RevokeUserSession(ctx, user, "sid-1"))
+	assert.GreaterOrEqual(t, mr.TTL(sessionRevokedKey("sid-1")), 30*24*time.Hour)
+
+	mr.FastForward(29 * 24 * time.Hour)
+	revoked, err := SessionRevoked(ctx, "sid-1")
+	require.NoError(t, err)
+	assert.True(t, revoked, "still revoked past the fixed cutoff TTL")
+
+	assert.ErrorIs(t, RevokeUserSession(ctx, user, "someone-elses"), ErrSessionNotFound)
+}
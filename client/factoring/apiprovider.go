@@ -0,0 +1,463 @@
+package factoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// API factor transport. Every factor in this family takes one batch as a
+// single multipart/form-data POST:
+//
+//	POST {base}{submitPath}
+//	  documents  <INVOICE#>.pdf  (one part per invoice, batch order)
+//	  manifest   invoices_YYYYMMDD_HHMMSS.csv  (BuildAPIManifest, LAST part)
+//
+// and answers with the verdict per invoice:
+//
+//	{"submission_id": "...", "invoices": [
+//	  {"invoice_number": "...", "status": "accepted|rejected|pending",
+//	   "reference": "...", "reason": "..."}]}
+//
+// What differs per factor — paths, how the access key is presented — is data
+// in apiSpecs. The manifest part goes last for the same reason it does on
+// SFTP: a factor that streams the body may start ingesting on it.
+//
+// The factors' intake hosts are not hardcoded: they have not been verified
+// against a published contract, so each factor stays disabled — out of
+// EnabledProviderTypes and rejected by NewProviderFromCredential — until its
+// FACTORING_<FACTOR>_API_URL env var names the host agreed with the factor.
+// On dev / stage / local (isNonProdAppEnv) TEST_<FACTOR>_API_URL takes
+// precedence, so the factoringtest fake server or a sandbox can stand in for
+// the factor without touching the stored credential.
+const (
+	// apiSubmitTimeout bounds one attempt of a submission; the client's
+	// overall deadline is apiSubmitPolicy.TotalTimeout().
+	apiSubmitTimeout = 2 * time.Minute
+
+	envOTRAPIURL   = "FACTORING_OTR_API_URL"
+	envApexAPIURL  = "FACTORING_APEX_API_URL"
+	envDenimAPIURL = "FACTORING_DENIM_API_URL"
+
+	envTestOTRAPIURL   = "TEST_OTR_API_URL"
+	envTestApexAPIURL  = "TEST_APEX_API_URL"
+	envTestDenimAPIURL = "TEST_DENIM_API_URL"
+)
+
+// apiSubmitPolicy retries a submission after a dropped connection or a
+// 429/5xx. The request carries an Idempotency-Key, so a resend is recognised
+// by the factor instead of funding the batch twice.
+var apiSubmitPolicy = func() resilience.Policy {
+	p := resilience.DefaultPolicy()
+	p.AttemptTimeout = apiSubmitTimeout
+	return p
+}()
+
+// apiAuth is how a factor expects the credential's AccessKey.
+type apiAuth int
+
+const (
+	apiAuthKeyHeader apiAuth = iota // X-API-Key: <access key>
+	apiAuthBearer                   // Authorization: Bearer <access key>
+	apiAuthBasic                    // basic auth, Username : AccessKey
+)
+
+// apiSpec is the per-factor half of APIProvider.
+type apiSpec struct {
+	configURL  string // env var holding the production base URL
+	envURL     string // non-prod override
+	submitPath string
+	pingPath   string // cheapest authenticated GET, used by TestConnection
+	auth       apiAuth
+}
+
+// apiSpecs lists every API factor. IsAPI and the registry read it, so a new
+// factor of this family is one constant, one entry here and one constructor.
+var apiSpecs = map[ProviderType]apiSpec{
+	ProviderOTRAPI: {
+		configURL:  envOTRAPIURL,
+		envURL:     envTestOTRAPIURL,
+		submitPath: "/invoices/batches",
+		pingPath:   "/account",
+		auth:       apiAuthKeyHeader,
+	},
+	ProviderApexAPI: {
+		configURL:  envApexAPIURL,
+		envURL:     envTestApexAPIURL,
+		submitPath: "/schedules",
+		pingPath:   "/clients/me",
+		auth:       apiAuthBearer,
+	},
+	ProviderDenimAPI: {
+		configURL:  envDenimAPIURL,
+		envURL:     envTestDenimAPIURL,
+		submitPath: "/factoring/submissions",
+		pingPath:   "/factoring/account",
+		auth:       apiAuthBasic,
+	},
+}
+
+// APIProvider implements Provider for factors with a REST intake (OTR, Apex,
+// Denim). Unlike the SFTP drops the upload is answered: SubmitResult carries
+// the factor's submission id and one InvoiceStatus per invoice.
+//
+// Reads AccessKey from the universal Credential, plus Username for factors
+// using basic auth. Password is ignored.
+type APIProvider struct {
+	username     string
+	accessKey    string
+	baseURL      string
+	spec         apiSpec
+	providerType ProviderType
+	httpClient   *http.Client
+	dryRunDir    string
+}
+
+// NewOTRAPI builds an APIProvider for OTR Solutions.
+func NewOTRAPI(cred Credential, opts ...ProviderOption) *APIProvider {
+	return newAPIProvider(cred, ProviderOTRAPI, opts)
+}
+
+// NewApexAPI builds an APIProvider for Apex Capital.
+func NewApexAPI(cred Credential, opts ...ProviderOption) *APIProvider {
+	return newAPIProvider(cred, ProviderApexAPI, opts)
+}
+
+// NewDenimAPI builds an APIProvider for Denim. Denim authenticates with
+// basic auth, so the credential's Username is required as well.
+func NewDenimAPI(cred Credential, opts ...ProviderOption) *APIProvider {
+	return newAPIProvider(cred, ProviderDenimAPI, opts)
+}
+
+func newAPIProvider(cred Credential, pt ProviderType, opts []ProviderOption) *APIProvider {
+	o := applyProviderOptions(opts)
+	spec := apiSpecs[pt]
+	return &APIProvider{
+		username:     strings.TrimSpace(cred.Username),
+		accessKey:    cred.AccessKey,
+		baseURL:      apiBaseURL(spec),
+		spec:         spec,
+		providerType: pt,
+		httpClient: &http.Client{
+			Timeout:   apiSubmitPolicy.TotalTimeout(),
+			Transport: resilience.Chain(nil, resilience.Retry(apiSubmitPolicy)),
+		},
+		dryRunDir: o.dryRunDir,
+	}
+}
+
+// apiBaseURL is the factor's configured base URL; "" while it is disabled.
+func apiBaseURL(spec apiSpec) string {
+	base := firstNonEmptyEnv(spec.configURL)
+	if isNonProdAppEnv() {
+		if v := firstNonEmptyEnv(spec.envURL); v != "" {
+			base = v
+		}
+	}
+	return strings.TrimRight(base, "/")
+}
+
+// apiEnabled reports whether the API factor pt has a base URL configured.
+func apiEnabled(pt ProviderType) bool {
+	spec, ok := apiSpecs[pt]
+	return ok && apiBaseURL(spec) != ""
+}
+
+// BuildManifest renders the API manifest — the same bytes SubmitBatch ships
+// as the last part.
+func (p *APIProvider) BuildManifest(invoices []InvoiceLine) ([]byte, error) {
+	return BuildAPIManifest(invoices)
+}
+
+// TestConnection performs the factor's cheapest authenticated GET. Nothing
+// is submitted.
+func (p *APIProvider) TestConnection(ctx context.Context) error {
+	if p.dryRunDir != "" {
+		return nil
+	}
+	req, err := p.newRequest(ctx, http.MethodGet, p.spec.pingPath, nil)
+	if err != nil {
+		return err
+	}
+	_, err = p.do(req)
+	return err
+}
+
+// SubmitBatch validates the batch against the factor's rules, then posts
+// every PDF and the manifest (last) in one multipart request.
+//
+// The request carries an Idempotency-Key derived from the provider, the batch
+// number and batch.SubmittedAt — the same inputs as the deterministic CSV
+// file name — so a reclaimed retry of the same batch is recognised by the
+// factor instead of becoming a second submission (DEV-840). SubmittedAt is
+// therefore required: a key stamped with the current time would make every
+// retry a new submission.
+//
+// Progress ticks follow the request body as the transport reads it: one per
+// PDF part, and the final one once the factor has answered.
+func (p *APIProvider) SubmitBatch(ctx context.Context, batch Batch, onProgress ProgressFunc) (SubmitResult, error) {
+	if err := batch.validate(); err != nil {
+		return SubmitResult{}, err
+	}
+	if err := ValidateInvoicesForProvider(p.providerType, batch.Invoices); err != nil {
+		return SubmitResult{}, err
+	}
+
+	csvBytes, err := p.BuildManifest(batch.Invoices)
+	if err != nil {
+		return SubmitResult{}, err
+	}
+
+	if batch.SubmittedAt.IsZero() {
+		return SubmitResult{}, fmt.Errorf("factoring/api: %s: batch %s has no SubmittedAt to key the submission on",
+			p.providerType, batch.BatchNumber)
+	}
+	stamp := batch.SubmittedAt.UTC().Format("20060102_150405")
+	csvFileName := fmt.Sprintf("invoices_%s.csv", stamp)
+
+	// total = every PDF plus the manifest part.
+	total := len(batch.PDFs) + 1
+	if p.dryRunDir != "" {
+		return p.dryRun(ctx, batch, csvFileName, csvBytes, total, onProgress)
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	names := make([]string, 0, total)
+	marks := make([]int64, 0, len(batch.PDFs))
+	for i, pdf := range batch.PDFs {
+		fileName := sanitizePDFName(pdf.InvoiceNumber)
+		if err := writeFilePart(mw, "documents", fileName, "application/pdf", pdf.Bytes); err != nil {
+			return SubmitResult{}, fmt.Errorf("factoring/api: encode pdf[%d] %s: %w", i, pdf.InvoiceNumber, err)
+		}
+		names = append(names, fileName)
+		marks = append(marks, int64(body.Len()))
+	}
+	if err := writeFilePart(mw, "manifest", csvFileName, "text/csv", csvBytes); err != nil {
+		return SubmitResult{}, fmt.Errorf("factoring/api: encode manifest: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return SubmitResult{}, fmt.Errorf("factoring/api: encode request: %w", err)
+	}
+	names = append(names, csvFileName)
+
+	tracker := &uploadTracker{marks: marks, names: names, total: total, report: onProgress}
+	payload := body.Bytes()
+	req, err := p.newRequest(ctx, http.MethodPost, p.spec.submitPath, tracker.reader(payload))
+	if err != nil {
+		return SubmitResult{}, err
+	}
+	req.ContentLength = int64(len(payload))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(tracker.reader(payload)), nil }
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("%s-%s-%s", p.providerType, batch.BatchNumber, stamp))
+
+	respBody, err := p.do(req)
+	if err != nil {
+		return SubmitResult{CSVFileName: csvFileName}, err
+	}
+	var resp apiSubmitResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		// The factor took the request; only its answer is unreadable. Report
+		// what was sent so the row is not treated as never shipped.
+		return SubmitResult{CSVFileName: csvFileName, Uploaded: names},
+			fmt.Errorf("factoring/api: %s: decode response: %w", p.providerType, err)
+	}
+	if onProgress != nil {
+		onProgress(Progress{Phase: "uploading", Done: total, Total: total, Detail: csvFileName})
+	}
+
+	return SubmitResult{
+		CSVFileName:  csvFileName,
+		Uploaded:     names,
+		SubmissionID: resp.SubmissionID,
+		Invoices:     resp.statuses(batch.Invoices),
+	}, nil
+}
+
+// dryRun writes the parts SubmitBatch would post, in the same order, into
+// dryRunDir. No factor answers, so every invoice is pending.
+func (p *APIProvider) dryRun(ctx context.Context, batch Batch, csvFileName string, csvBytes []byte, total int, onProgress ProgressFunc) (SubmitResult, error) {
+	client, err := localDial(p.dryRunDir)(ctx, sftpDialer{})
+	if err != nil {
+		return SubmitResult{}, err
+	}
+	res := SubmitResult{CSVFileName: csvFileName}
+	ship := func(name string, content []byte) error {
+		remote, err := client.Upload("", name, content)
+		if err != nil {
+			return err
+		}
+		res.Uploaded = append(res.Uploaded, remote)
+		if onProgress != nil {
+			onProgress(Progress{Phase: "uploading", Done: len(res.Uploaded), Total: total, Detail: name})
+		}
+		return nil
+	}
+	for _, pdf := range batch.PDFs {
+		if err := ship(sanitizePDFName(pdf.InvoiceNumber), pdf.Bytes); err != nil {
+			return res, err
+		}
+	}
+	if err := ship(csvFileName, csvBytes); err != nil {
+		return res, err
+	}
+	for _, inv := range batch.Invoices {
+		res.Invoices = append(res.Invoices, InvoiceStatus{InvoiceNumber: inv.InvoiceNumber, State: InvoicePending, Reason: "dry run"})
+	}
+	return res, nil
+}
+
+// apiSubmitResponse is the factor's answer to a submission.
+type apiSubmitResponse struct {
+	SubmissionID string `json:"submission_id"`
+	Invoices     []struct {
+		InvoiceNumber string `json:"invoice_number"`
+		Status        string `json:"status"`
+		Reference     string `json:"reference"`
+		Reason        string `json:"reason"`
+	} `json:"invoices"`
+}
+
+// statuses lines the verdicts up with the batch. An invoice the answer does
+// not mention is pending — it was delivered, the factor just has not said —
+// and an unknown status word is pending too rather than guessed.
+func (r apiSubmitResponse) statuses(invoices []InvoiceLine) []InvoiceStatus {
+	byNumber := make(map[string]InvoiceStatus, len(r.Invoices))
+	for _, inv := range r.Invoices {
+		st := InvoiceStatus{InvoiceNumber: inv.InvoiceNumber, Reference: inv.Reference, Reason: inv.Reason}
+		switch state := InvoiceState(strings.ToLower(strings.TrimSpace(inv.Status))); state {
+		case InvoiceAccepted, InvoiceRejected, InvoicePending:
+			st.State = state
+		default:
+			st.State = InvoicePending
+			if st.Reason == "" {
+				st.Reason = fmt.Sprintf("unrecognised status %q", inv.Status)
+			}
+		}
+		byNumber[inv.InvoiceNumber] = st
+	}
+	out := make([]InvoiceStatus, len(invoices))
+	for i, inv := range invoices {
+		st, ok := byNumber[inv.InvoiceNumber]
+		if !ok {
+			st = InvoiceStatus{InvoiceNumber: inv.InvoiceNumber, State: InvoicePending, Reason: "not reported by factor"}
+		}
+		out[i] = st
+	}
+	return out
+}
+
+func (p *APIProvider) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	if p.baseURL == "" {
+		return nil, fmt.Errorf("factoring/api: %s: not enabled, %s is not set", p.providerType, p.spec.configURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("factoring/api: %s: build request: %w", p.providerType, err)
+	}
+	switch p.spec.auth {
+	case apiAuthBearer:
+		req.Header.Set("Authorization", "Bearer "+p.accessKey)
+	case apiAuthBasic:
+		req.SetBasicAuth(p.username, p.accessKey)
+	default:
+		req.Header.Set("X-API-Key", p.accessKey)
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// do sends req and returns the body of a 2xx answer. 401/403 is an
+// *AuthError; any other non-2xx carries the start of the factor's message.
+func (p *APIProvider) do(req *http.Request) ([]byte, error) {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("factoring/api: %s: %s %s: %w", p.providerType, req.Method, req.URL.Path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("factoring/api: %s: read response: %w", p.providerType, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, &AuthError{ProviderType: p.providerType, Cause: fmt.Errorf("HTTP %d", resp.StatusCode)}
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("factoring/api: %s: %s %s: HTTP %d: %s",
+			p.providerType, req.Method, req.URL.Path, resp.StatusCode, truncateMessage(string(body), 200))
+	}
+	return body, nil
+}
+
+func writeFilePart(mw *multipart.Writer, field, fileName, contentType string, content []byte) error {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, field, fileName))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// uploadTracker turns body reads into Progress ticks: marks[i] is the body
+// offset at which PDF i has been fully handed to the transport. It is shared
+// by every replay of the body, so a retried request never reports a file
+// twice and Done stays monotonic.
+type uploadTracker struct {
+	mu     sync.Mutex
+	marks  []int64
+	names  []string
+	total  int
+	next   int
+	report ProgressFunc
+}
+
+func (t *uploadTracker) reader(payload []byte) io.Reader {
+	return &trackedReader{r: bytes.NewReader(payload), t: t}
+}
+
+func (t *uploadTracker) advance(offset int64) {
+	if t.report == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for t.next < len(t.marks) && offset >= t.marks[t.next] {
+		t.report(Progress{Phase: "uploading", Done: t.next + 1, Total: t.total, Detail: t.names[t.next]})
+		t.next++
+	}
+}
+
+type trackedReader struct {
+	r    *bytes.Reader
+	read int64
+	t    *uploadTracker
+}
+
+func (r *trackedReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.read += int64(n)
+	r.t.advance(r.read)
+	return n, err
+}
+
+func truncateMessage(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
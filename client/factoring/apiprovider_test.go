@@ -0,0 +1,179 @@
+package factoring
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/client/factoring/factoringtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAPITestProvider(t *testing.T, pt ProviderType, opts ...factoringtest.Option) (*APIProvider, *factoringtest.Server) {
+	t.Helper()
+	srv := factoringtest.NewServer("key-1", opts...)
+	t.Cleanup(srv.Close)
+	t.Setenv("APP_ENV", "dev")
+	t.Setenv(apiSpecs[pt].envURL, srv.URL+"/v1")
+	p, err := NewProviderFromCredential(Credential{ProviderType: pt, Username: "carrier-7", AccessKey: "key-1"})
+	require.NoError(t, err)
+	return p.(*APIProvider), srv
+}
+
+func TestAPISubmitBatch_PerInvoiceStatus(t *testing.T) {
+	p, srv := newAPITestProvider(t, ProviderOTRAPI, factoringtest.Reject("IN-000001", "debtor not approved"))
+
+	var ticks []Progress
+	res, err := p.SubmitBatch(context.Background(), rtsBatch(), func(pr Progress) { ticks = append(ticks, pr) })
+	require.NoError(t, err)
+
+	assert.Equal(t, "sub-1", res.SubmissionID)
+	assert.Equal(t, "invoices_20260111_143052.csv", res.CSVFileName)
+	assert.Equal(t, []string{"IN-000000.pdf", "IN-000001.pdf", "invoices_20260111_143052.csv"}, res.Uploaded)
+	require.Len(t, res.Invoices, 2)
+	assert.Equal(t, InvoiceAccepted, res.Invoices[0].State)
+	assert.Equal(t, "sub-1-1", res.Invoices[0].Reference)
+	assert.Equal(t, InvoiceStatus{InvoiceNumber: "IN-000001", State: InvoiceRejected, Reason: "debtor not approved"}, res.Invoices[1])
+
+	require.Len(t, ticks, 3)
+	for i, tick := range ticks {
+		assert.Equal(t, i+1, tick.Done)
+		assert.Equal(t, 3, tick.Total)
+	}
+
+	subs := srv.Submissions()
+	require.Len(t, subs, 1)
+	assert.Equal(t, []string{"IN-000000.pdf", "IN-000001.pdf"}, subs[0].DocumentOrder)
+	assert.Equal(t, []byte("pdf1"), subs[0].Documents["IN-000000.pdf"])
+	assert.Equal(t, apiManifestHeader, subs[0].Manifest[0])
+	assert.Equal(t, []string{"IN-000000", "ABC Logistics, LLC", "5555555", "2026-01-11", "1000.00", "IN-000000.pdf"}, subs[0].Manifest[1])
+	assert.Equal(t, "otr_api-IB-000001-20260111_143052", subs[0].IdempotencyKey)
+}
+
+func TestAPISubmitBatch_EachAuthStyle(t *testing.T) {
+	for _, pt := range []ProviderType{ProviderOTRAPI, ProviderApexAPI, ProviderDenimAPI} {
+		t.Run(string(pt), func(t *testing.T) {
+			p, _ := newAPITestProvider(t, pt)
+			require.NoError(t, p.TestConnection(context.Background()))
+
+			res, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+			require.NoError(t, err)
+			assert.Len(t, res.Invoices, 2)
+		})
+	}
+}
+
+// A retried request reuses the Idempotency-Key: the factor answers the first
+// submission again instead of funding the batch twice.
+func TestAPISubmitBatch_RetryIsIdempotent(t *testing.T) {
+	p, srv := newAPITestProvider(t, ProviderApexAPI, factoringtest.FailNext(http.StatusServiceUnavailable))
+
+	var ticks []Progress
+	res, err := p.SubmitBatch(context.Background(), rtsBatch(), func(pr Progress) { ticks = append(ticks, pr) })
+	require.NoError(t, err)
+	assert.Equal(t, 2, srv.Requests(), "the 503 is retried")
+	assert.Len(t, ticks, 3, "a replayed body does not report files twice")
+
+	again, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, res.SubmissionID, again.SubmissionID)
+	assert.Len(t, srv.Submissions(), 1)
+}
+
+func TestAPISubmitBatch_ValidationBlocksBeforeSend(t *testing.T) {
+	p, srv := newAPITestProvider(t, ProviderApexAPI)
+
+	b := rtsBatch()
+	b.Invoices[0].PONumber = " "
+	_, err := p.SubmitBatch(context.Background(), b, nil)
+	require.Error(t, err)
+	assert.True(t, IsBatchValidationError(err))
+	assert.Zero(t, srv.Requests())
+}
+
+func TestAPISubmitBatch_RejectedKeyIsAuthError(t *testing.T) {
+	p, _ := newAPITestProvider(t, ProviderDenimAPI)
+	p.accessKey = "wrong"
+
+	err := p.TestConnection(context.Background())
+	require.Error(t, err)
+	assert.True(t, IsAuthError(err))
+
+	_, err = p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	assert.True(t, IsAuthError(err))
+}
+
+func TestAPISubmitResponse_UnreportedAndUnknownArePending(t *testing.T) {
+	var resp apiSubmitResponse
+	resp.Invoices = append(resp.Invoices, struct {
+		InvoiceNumber string `json:"invoice_number"`
+		Status        string `json:"status"`
+		Reference     string `json:"reference"`
+		Reason        string `json:"reason"`
+	}{InvoiceNumber: "A", Status: "FUNDED"})
+
+	got := resp.statuses([]InvoiceLine{{InvoiceNumber: "A"}, {InvoiceNumber: "B"}})
+	assert.Equal(t, []InvoiceStatus{
+		{InvoiceNumber: "A", State: InvoicePending, Reason: `unrecognised status "FUNDED"`},
+		{InvoiceNumber: "B", State: InvoicePending, Reason: "not reported by factor"},
+	}, got)
+}
+
+func TestAPIProvider_IgnoresURLOverrideInProd(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv(envOTRAPIURL, "https://intake.example.com/v1/")
+	t.Setenv(envTestOTRAPIURL, "http://localhost:1")
+	p := NewOTRAPI(Credential{ProviderType: ProviderOTRAPI, AccessKey: "k"})
+	assert.Equal(t, "https://intake.example.com/v1", p.baseURL)
+}
+
+func TestAPIProvider_DisabledUntilConfigured(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv(envApexAPIURL, "")
+	assert.False(t, ProviderApexAPI.IsValid())
+	assert.NotContains(t, EnabledProviderTypes(), ProviderApexAPI)
+	_, err := NewProviderFromCredential(Credential{ProviderType: ProviderApexAPI, AccessKey: "k"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not enabled")
+
+	t.Setenv(envApexAPIURL, "https://intake.example.com")
+	assert.True(t, ProviderApexAPI.IsValid())
+	assert.Contains(t, EnabledProviderTypes(), ProviderApexAPI)
+	assert.NotContains(t, AllProviderTypes, ProviderApexAPI)
+}
+
+func TestAPISubmitBatch_RequiresSubmittedAt(t *testing.T) {
+	p, srv := newAPITestProvider(t, ProviderOTRAPI)
+	b := rtsBatch()
+	b.SubmittedAt = time.Time{}
+	_, err := p.SubmitBatch(context.Background(), b, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SubmittedAt")
+	assert.Zero(t, srv.Requests())
+}
+
+func TestNewProviderFromCredential_APIProviders(t *testing.T) {
+	t.Setenv(envOTRAPIURL, "https://intake.example.com")
+	t.Setenv(envApexAPIURL, "https://intake.example.com")
+	t.Setenv(envDenimAPIURL, "https://intake.example.com")
+	_, err := NewProviderFromCredential(Credential{ProviderType: ProviderOTRAPI, Username: "u", Password: "p"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing access_key")
+
+	_, err = NewProviderFromCredential(Credential{ProviderType: ProviderDenimAPI, AccessKey: "k"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing username")
+
+	p, err := NewProviderFromCredential(Credential{ProviderType: ProviderApexAPI, AccessKey: "k"})
+	require.NoError(t, err)
+	assert.IsType(t, &APIProvider{}, p)
+
+	assert.True(t, IsAPI(ProviderApexAPI))
+	assert.False(t, IsAPI(ProviderTriumphSFTP))
+	assert.True(t, RulesFor(ProviderDenimAPI).ReportsInvoiceStatus)
+	assert.False(t, RulesFor(ProviderRTSSFTP).ReportsInvoiceStatus)
+}
+
+var _ Provider = (*APIProvider)(nil)
@@ -113,3 +113,52 @@ func BuildRTSCSV(clientNumber string, invoices []InvoiceLine) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// apiManifestHeader is the manifest part of an API submission. Unlike the
+// SFTP layouts it names the document of each row, because the factor matches
+// PDFs to rows by that column rather than by a folder listing.
+var apiManifestHeader = []string{"invoice_number", "debtor_name", "po_number", "invoice_date", "amount", "document"}
+
+// BuildAPIManifest renders the manifest shipped as the last part of an
+// APIProvider submission. Spec:
+//   - header row + one row per invoice, CRLF, UTF-8 no BOM
+//   - invoice_date as YYYY-MM-DD, amount as %.2f with no currency symbol
+//   - document is the file name of the invoice's PDF part (<INVOICE#>.pdf,
+//     sanitized exactly as the part is named)
+func BuildAPIManifest(invoices []InvoiceLine) ([]byte, error) {
+	if len(invoices) == 0 {
+		return nil, fmt.Errorf("factoring: cannot build CSV with zero invoices")
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = true
+
+	if err := w.Write(apiManifestHeader); err != nil {
+		return nil, fmt.Errorf("factoring: write csv header: %w", err)
+	}
+
+	for i, inv := range invoices {
+		if inv.InvoiceNumber == "" {
+			return nil, fmt.Errorf("factoring: invoice[%d] missing invoice number", i)
+		}
+		row := []string{
+			inv.InvoiceNumber,
+			inv.DebtorName,
+			inv.PONumber,
+			inv.InvoiceDate.Format("2006-01-02"),
+			strconv.FormatFloat(inv.AmountUSD, 'f', 2, 64),
+			sanitizePDFName(inv.InvoiceNumber),
+		}
+		if err := w.Write(row); err != nil {
+			return nil, fmt.Errorf("factoring: write csv row %d: %w", i, err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("factoring: flush csv: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
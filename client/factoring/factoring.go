@@ -8,10 +8,18 @@
 // interface that hides the transport so callers (backend-accounting) write the
 // submission flow once.
 //
-// Implementations: TriumphSFTPProvider (triumph_sftp) and RTSSFTPProvider
-// (rts_sftp / rts_test_sftp — same adapter, different host). Add more
-// (TriumphAPI, EcapitalAPI...) by writing a new file and registering it in
-// registry.go.
+// Implementations: TriumphSFTPProvider (triumph_sftp), RTSSFTPProvider
+// (rts_sftp / rts_test_sftp — same adapter, different host) and APIProvider
+// (otr_api / apex_api / denim_api — factors with a REST intake that answer
+// with per-invoice acceptance). Add more by writing a new file and
+// registering it in registry.go.
+//
+// The SFTP providers also implement RemittanceReader: the factor's purchase
+// schedules are pulled back from its outbox, parsed into RemittanceLines and
+// matched to the submitted invoices with MatchRemittance.
+//
+// Submissions over SFTP are resumable with WithJournal (see BatchJournal), and
+// WithDryRun renders any provider's submission into a local directory.
 //
 // Credentials are per-company and stored in tms360-backend's `settings` table
 // under one universal key — `factoring_credentials` — mirrored to Redis at
@@ -28,6 +36,8 @@ import (
 	"io"
 	"strconv"
 	"time"
+
+	"github.com/TMS360/backend-pkg/secrets"
 )
 
 // ProviderType identifies which factoring backend a Batch should be routed to.
@@ -40,16 +50,42 @@ const (
 	ProviderTriumphSFTP ProviderType = "triumph_sftp"
 	ProviderRTSSFTP     ProviderType = "rts_sftp"
 	ProviderRTSTestSFTP ProviderType = "rts_test_sftp"
+	ProviderOTRAPI      ProviderType = "otr_api"
+	ProviderApexAPI     ProviderType = "apex_api"
+	ProviderDenimAPI    ProviderType = "denim_api"
 )
 
 // AllProviderTypes is the canonical list of supported provider types — used by
 // tms360-backend's credential validator and by gqlgen for the GraphQL enum.
+// The API factors are not in it: they are enabled per deployment (see
+// APIProviderTypes and EnabledProviderTypes).
 var AllProviderTypes = []ProviderType{
 	ProviderTriumphSFTP,
 	ProviderRTSSFTP,
 	ProviderRTSTestSFTP,
 }
 
+// APIProviderTypes are the HTTPS API factors. Each is valid only while its
+// base URL is configured (FACTORING_<FACTOR>_API_URL); their endpoints are
+// not yet verified against the factors' contracts.
+var APIProviderTypes = []ProviderType{
+	ProviderOTRAPI,
+	ProviderApexAPI,
+	ProviderDenimAPI,
+}
+
+// EnabledProviderTypes is AllProviderTypes plus the API factors configured in
+// this deployment — the list to offer in a credential form.
+func EnabledProviderTypes() []ProviderType {
+	out := append([]ProviderType(nil), AllProviderTypes...)
+	for _, pt := range APIProviderTypes {
+		if apiEnabled(pt) {
+			out = append(out, pt)
+		}
+	}
+	return out
+}
+
 // IsRTS reports whether pt is an RTS Financial SFTP provider (prod or test).
 // Use this for rules, invoice PDF profile, and trigger-and-clear behaviour —
 // not for picking the dial host.
@@ -57,15 +93,24 @@ func IsRTS(pt ProviderType) bool {
 	return pt == ProviderRTSSFTP || pt == ProviderRTSTestSFTP
 }
 
-// IsValid reports whether p is a known ProviderType. Useful for validating
-// incoming JSON before persisting credentials.
+// IsAPI reports whether pt submits over a factor's HTTPS API (APIProvider)
+// rather than an SFTP drop. API providers authenticate with AccessKey, not
+// Username + Password.
+func IsAPI(pt ProviderType) bool {
+	_, ok := apiSpecs[pt]
+	return ok
+}
+
+// IsValid reports whether p is a known ProviderType — one of
+// AllProviderTypes, or an API factor enabled in this deployment. Useful for
+// validating incoming JSON before persisting credentials.
 func (p ProviderType) IsValid() bool {
 	for _, x := range AllProviderTypes {
 		if p == x {
 			return true
 		}
 	}
-	return false
+	return apiEnabled(p)
 }
 
 // String returns the wire form ("triumph_sftp"); satisfies fmt.Stringer.
@@ -102,7 +147,12 @@ func (p *ProviderType) UnmarshalGQL(v any) error {
 // future invoice rendering but transport implementations may ignore them.
 //
 // Transport-specific config (SFTP host/port, inbound directory, API base URL)
-// is NOT here — it's hardcoded as constants inside each Provider impl.
+// is NOT here — SFTP endpoints are constants inside each Provider impl, API
+// base URLs come from the deployment's FACTORING_<FACTOR>_API_URL env vars.
+//
+// AccessKey and Password may hold secrets references (secret://…) instead of
+// plaintext; provider.JSONClientProvider resolves them on read, and callers
+// holding a Credential from elsewhere use Resolve.
 type Credential struct {
 	ProviderType         ProviderType `json:"provider_type"`
 	FactoringCompanyName string       `json:"factoring_company_name,omitempty"`
@@ -113,6 +163,24 @@ type Credential struct {
 	RemitNotice          string       `json:"remit_notice,omitempty"`
 }
 
+// Resolve returns a copy with every secrets reference replaced by its value,
+// read as companyID's through r (secrets.ResolveTenantStruct when nil).
+// Credentials are tenant-edited settings, so they never reach the operator
+// resolver: a reference outside the company's own prefix fails. Plaintext
+// fields pass through.
+func (c Credential) Resolve(ctx context.Context, companyID string, r *secrets.TenantResolver) (Credential, error) {
+	var err error
+	if r == nil {
+		err = secrets.ResolveTenantStruct(ctx, companyID, &c)
+	} else {
+		err = r.ResolveStruct(ctx, companyID, &c)
+	}
+	if err != nil {
+		return Credential{}, fmt.Errorf("factoring: resolve %s credential: %w", c.ProviderType, err)
+	}
+	return c, nil
+}
+
 // Progress is one upload-progress tick reported by a Provider during
 // SubmitBatch. Provider-neutral: SFTP, API, or any future factor reports the
 // same shape so backend-accounting can surface a live progress bar regardless
@@ -185,9 +253,37 @@ type InvoicePDF struct {
 
 // SubmitResult reports what was actually uploaded. Stored alongside the
 // FactoringSubmission row for audit.
+//
+// SubmissionID and Invoices are filled only by providers whose factor answers
+// the upload (RulesFor(pt).ReportsInvoiceStatus). An SFTP drop is one-way:
+// both stay empty and acceptance is learned from the factor's own reports.
 type SubmitResult struct {
-	CSVFileName string   // e.g. "invoices_20260111_143052.csv"
-	Uploaded    []string // remote paths in the order they were uploaded
+	CSVFileName  string          // e.g. "invoices_20260111_143052.csv"
+	Uploaded     []string        // remote paths in the order they were uploaded
+	SubmissionID string          // the factor's id for the submission, when it returns one
+	Invoices     []InvoiceStatus // one per invoice of the batch, in batch order
+}
+
+// InvoiceState is a factor's verdict on one invoice of a submission.
+type InvoiceState string
+
+const (
+	// InvoiceAccepted means the factor took the invoice for purchase.
+	InvoiceAccepted InvoiceState = "accepted"
+	// InvoiceRejected means the factor refused it; Reason says why. The
+	// invoice can be corrected and submitted again in a later batch.
+	InvoiceRejected InvoiceState = "rejected"
+	// InvoicePending means the factor received it but has not decided yet
+	// (manual verification, or the answer did not mention it).
+	InvoicePending InvoiceState = "pending"
+)
+
+// InvoiceStatus is the per-invoice outcome reported by an API factor.
+type InvoiceStatus struct {
+	InvoiceNumber string
+	State         InvoiceState
+	Reference     string // the factor's id for the invoice, for support tickets
+	Reason        string // rejection or pending reason, as the factor worded it
 }
 
 // AuthError is returned when a provider rejects credentials (SFTP login
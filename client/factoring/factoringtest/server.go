@@ -0,0 +1,260 @@
+// Package factoringtest is a local stand-in for the REST intake of the API
+// factors (factoring.APIProvider): it speaks the same multipart submission
+// protocol, records what it received and answers with per-invoice verdicts
+// the test scripts up front.
+//
+// Unit tests point a provider at Server.URL; on dev / stage / local the
+// TEST_<FACTOR>_API_URL env vars do the same for a whole deployment.
+//
+// The package deliberately does not import factoring, so factoring's own
+// tests can use it.
+package factoringtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Submission is one batch as the server received it.
+type Submission struct {
+	ID             string
+	IdempotencyKey string
+	ManifestName   string
+	Manifest       [][]string        // parsed manifest, header row first
+	Documents      map[string][]byte // PDF parts by file name
+	DocumentOrder  []string          // PDF file names in the order they arrived
+}
+
+// Server is the fake factor. The zero value is not usable; call NewServer.
+type Server struct {
+	*httptest.Server
+
+	accessKey string
+
+	mu          sync.Mutex
+	rejects     map[string]string
+	pending     map[string]bool
+	failures    []int
+	submissions []Submission
+	answers     map[string][]byte // response by Idempotency-Key
+	requests    int
+}
+
+// Option scripts the server's behaviour.
+type Option func(*Server)
+
+// Reject makes the server refuse invoiceNumber with reason.
+func Reject(invoiceNumber, reason string) Option {
+	return func(s *Server) { s.rejects[invoiceNumber] = reason }
+}
+
+// Pending makes the server leave invoiceNumber undecided.
+func Pending(invoiceNumber string) Option {
+	return func(s *Server) { s.pending[invoiceNumber] = true }
+}
+
+// FailNext makes the next len(statuses) submissions answer with those HTTP
+// statuses, in order, before anything is recorded — e.g. a 503 to exercise
+// the client's retry.
+func FailNext(statuses ...int) Option {
+	return func(s *Server) { s.failures = append(s.failures, statuses...) }
+}
+
+// NewServer starts a fake factor that accepts accessKey in any of the forms
+// the API factors use: X-API-Key, a bearer token, or the basic-auth
+// password. Any path ending in /account or /me answers a connection test;
+// any other POST is a submission. Close it with Server.Close.
+func NewServer(accessKey string, opts ...Option) *Server {
+	s := &Server{
+		accessKey: accessKey,
+		rejects:   map[string]string{},
+		pending:   map[string]bool{},
+		answers:   map[string][]byte{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Submissions returns the batches received so far, oldest first. A request
+// replayed with the same Idempotency-Key is not recorded twice.
+func (s *Server) Submissions() []Submission {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Submission(nil), s.submissions...)
+}
+
+// Requests counts every submission request, replays and failures included.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && (strings.HasSuffix(r.URL.Path, "/account") || strings.HasSuffix(r.URL.Path, "/me")):
+		writeJSON(w, http.StatusOK, map[string]string{"status": "active"})
+	case r.Method == http.MethodPost:
+		s.submit(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if r.Header.Get("X-API-Key") == s.accessKey {
+		return true
+	}
+	if r.Header.Get("Authorization") == "Bearer "+s.accessKey {
+		return true
+	}
+	_, pass, ok := r.BasicAuth()
+	return ok && pass == s.accessKey
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mu.Unlock()
+		http.Error(w, `{"error":"scripted failure"}`, status)
+		return
+	}
+	key := r.Header.Get("Idempotency-Key")
+	if answer, ok := s.answers[key]; ok && key != "" {
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(answer)
+		return
+	}
+	s.mu.Unlock()
+
+	sub, err := readSubmission(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	sub.IdempotencyKey = key
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub.ID = fmt.Sprintf("sub-%d", len(s.submissions)+1)
+	answer, err := json.Marshal(s.verdicts(sub))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.submissions = append(s.submissions, sub)
+	if key != "" {
+		s.answers[key] = answer
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(answer)
+}
+
+type verdict struct {
+	InvoiceNumber string `json:"invoice_number"`
+	Status        string `json:"status"`
+	Reference     string `json:"reference,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// verdicts decides every manifest row: scripted rejections and pendings
+// first, then a row whose document is missing is rejected, the rest
+// accepted.
+func (s *Server) verdicts(sub Submission) map[string]any {
+	out := make([]verdict, 0, len(sub.Manifest))
+	for i, row := range sub.Manifest {
+		if i == 0 || len(row) == 0 {
+			continue
+		}
+		v := verdict{InvoiceNumber: row[0]}
+		doc := ""
+		if len(row) > 5 {
+			doc = row[5]
+		}
+		switch reason, rejected := s.rejects[row[0]]; {
+		case rejected:
+			v.Status, v.Reason = "rejected", reason
+		case s.pending[row[0]]:
+			v.Status, v.Reason = "pending", "manual verification"
+		case sub.Documents[doc] == nil:
+			v.Status, v.Reason = "rejected", "missing document "+doc
+		default:
+			v.Status = "accepted"
+			v.Reference = fmt.Sprintf("%s-%d", sub.ID, i)
+		}
+		out = append(out, v)
+	}
+	return map[string]any{"submission_id": sub.ID, "invoices": out}
+}
+
+// readSubmission parses the multipart body. The manifest must be the last
+// part: a document after it is a protocol error, as a streaming factor would
+// already have started ingesting.
+func readSubmission(r *http.Request) (Submission, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return Submission{}, fmt.Errorf("expected multipart/form-data, got %q", r.Header.Get("Content-Type"))
+	}
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	sub := Submission{Documents: map[string][]byte{}}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Submission{}, fmt.Errorf("read part: %w", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return Submission{}, fmt.Errorf("read part %s: %w", part.FileName(), err)
+		}
+		switch part.FormName() {
+		case "documents":
+			if sub.Manifest != nil {
+				return Submission{}, fmt.Errorf("document %s arrived after the manifest", part.FileName())
+			}
+			sub.Documents[part.FileName()] = content
+			sub.DocumentOrder = append(sub.DocumentOrder, part.FileName())
+		case "manifest":
+			rows, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+			if err != nil {
+				return Submission{}, fmt.Errorf("parse manifest: %w", err)
+			}
+			sub.ManifestName = part.FileName()
+			sub.Manifest = rows
+		default:
+			return Submission{}, fmt.Errorf("unexpected part %q", part.FormName())
+		}
+	}
+	if sub.Manifest == nil {
+		return Submission{}, fmt.Errorf("no manifest part")
+	}
+	return sub, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,292 @@
+package factoring
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrManifestInDoubt is returned by SubmitBatch when the journal shows a
+// manifest upload that started but was never confirmed, for a provider whose
+// manifest triggers ingestion (ProviderRules.TriggerAndClear). The factor may
+// already hold the batch, so it is not shipped again: quarantine the row for
+// manual review.
+var ErrManifestInDoubt = errors.New("factoring: manifest upload was interrupted; the factor may already have the batch")
+
+// JournalFile is one file the factor is confirmed to hold: uploaded, then its
+// remote size checked against the local bytes.
+type JournalFile struct {
+	Name       string    `json:"name"`
+	RemotePath string    `json:"remote_path"`
+	SHA256     string    `json:"sha256"`
+	Size       int64     `json:"size"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// BatchJournal is the state record of one submission. SubmitBatch saves it
+// after every confirmed file, so a retry after a dropped connection resumes
+// from the last confirmed file instead of re-uploading the batch, and the
+// record shows exactly which files the factor has.
+type BatchJournal struct {
+	Key          string        `json:"key"`
+	ProviderType ProviderType  `json:"provider_type"`
+	BatchNumber  string        `json:"batch_number,omitempty"`
+	CSVFileName  string        `json:"csv_file_name"`
+	Files        []JournalFile `json:"files"`
+	// ManifestStartedAt is set, and saved, just before the manifest upload;
+	// Manifest once it is confirmed. Started without Manifest means the
+	// outcome of the trigger is unknown.
+	ManifestStartedAt *time.Time   `json:"manifest_started_at,omitempty"`
+	Manifest          *JournalFile `json:"manifest,omitempty"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// Delivered reports whether the manifest is confirmed — the batch is with the
+// factor and SubmitBatch will not upload anything for it again.
+func (j BatchJournal) Delivered() bool { return j.Manifest != nil }
+
+func (j BatchJournal) file(name string) (JournalFile, bool) {
+	for _, f := range j.Files {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return JournalFile{}, false
+}
+
+func (j *BatchJournal) record(f JournalFile) {
+	for i := range j.Files {
+		if j.Files[i].Name == f.Name {
+			j.Files[i] = f
+			return
+		}
+	}
+	j.Files = append(j.Files, f)
+}
+
+// JournalKey identifies a submission. It is built from the same inputs as the
+// deterministic manifest name (DEV-840), so a reclaimed retry of a batch
+// finds the journal of the first attempt.
+func JournalKey(pt ProviderType, batchNumber, csvFileName string) string {
+	return fmt.Sprintf("%s:%s:%s", pt, batchNumber, csvFileName)
+}
+
+// JournalStore persists BatchJournals. MemoryJournal suits tests and a single
+// replica; RedisJournal survives restarts and lets another replica resume a
+// batch — the usual case, as the retry runs wherever the job is reclaimed.
+type JournalStore interface {
+	Load(ctx context.Context, key string) (BatchJournal, bool, error)
+	Save(ctx context.Context, j BatchJournal) error
+}
+
+// MemoryJournal keeps journals in-process.
+type MemoryJournal struct {
+	mu       sync.Mutex
+	journals map[string]BatchJournal
+}
+
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{journals: map[string]BatchJournal{}}
+}
+
+func (m *MemoryJournal) Load(_ context.Context, key string) (BatchJournal, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.journals[key]
+	if ok {
+		j.Files = append([]JournalFile(nil), j.Files...)
+	}
+	return j, ok, nil
+}
+
+func (m *MemoryJournal) Save(_ context.Context, j BatchJournal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j.Files = append([]JournalFile(nil), j.Files...)
+	m.journals[j.Key] = j
+	return nil
+}
+
+// journalTTL outlives any retry schedule by far; the journal is an audit
+// record of the upload, not the submission of record (that is the
+// FactoringSubmission row).
+const journalTTL = 30 * 24 * time.Hour
+
+// RedisJournal keeps journals in Redis under <prefix>factoring:journal:<key>.
+// Pass a company prefix such as "{company_id}:" to keep tenants apart, as the
+// settings keys do.
+type RedisJournal struct {
+	rdb    *redis.Client
+	prefix string
+}
+
+func NewRedisJournal(rdb *redis.Client, prefix string) *RedisJournal {
+	return &RedisJournal{rdb: rdb, prefix: prefix}
+}
+
+func (r *RedisJournal) Load(ctx context.Context, key string) (BatchJournal, bool, error) {
+	var j BatchJournal
+	data, err := r.rdb.Get(ctx, r.prefix+"factoring:journal:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return j, false, nil
+	}
+	if err != nil {
+		return j, false, err
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return j, false, err
+	}
+	return j, true, nil
+}
+
+func (r *RedisJournal) Save(ctx context.Context, j BatchJournal) error {
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return r.rdb.Set(ctx, r.prefix+"factoring:journal:"+j.Key, data, journalTTL).Err()
+}
+
+// sftpSubmission is the resumable upload shared by the SFTP providers: it
+// skips documents the journal confirms, verifies remote sizes before the
+// manifest goes up, and records every step. With a nil store it still
+// verifies, and simply remembers nothing between attempts.
+type sftpSubmission struct {
+	store JournalStore
+	state BatchJournal
+	now   func() time.Time
+}
+
+func beginSFTPSubmission(ctx context.Context, store JournalStore, pt ProviderType, batch Batch, csvFileName string, now func() time.Time) (*sftpSubmission, error) {
+	if store != nil && batch.SubmittedAt.IsZero() {
+		// The manifest name would fall back to the clock, so every retry
+		// would journal under a fresh key and never resume.
+		return nil, fmt.Errorf("factoring: %s: batch %s has no SubmittedAt to key the journal on", pt, batch.BatchNumber)
+	}
+	key := JournalKey(pt, batch.BatchNumber, csvFileName)
+	s := &sftpSubmission{
+		store: store,
+		state: BatchJournal{Key: key, ProviderType: pt, BatchNumber: batch.BatchNumber, CSVFileName: csvFileName},
+		now:   now,
+	}
+	if store == nil {
+		return s, nil
+	}
+	j, ok, err := store.Load(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("factoring: load journal %s: %w", key, err)
+	}
+	if ok {
+		s.state = j
+	}
+	if s.state.ManifestStartedAt != nil && !s.state.Delivered() && RulesFor(pt).TriggerAndClear {
+		return nil, ErrManifestInDoubt
+	}
+	return s, nil
+}
+
+// result is the SubmitResult of a delivered journal.
+func (s *sftpSubmission) result() SubmitResult {
+	uploaded := make([]string, 0, len(s.state.Files)+1)
+	for _, f := range s.state.Files {
+		uploaded = append(uploaded, f.RemotePath)
+	}
+	if s.state.Manifest != nil {
+		uploaded = append(uploaded, s.state.Manifest.RemotePath)
+	}
+	return SubmitResult{CSVFileName: s.state.CSVFileName, Uploaded: uploaded}
+}
+
+func (s *sftpSubmission) save(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	s.state.UpdatedAt = s.now()
+	if err := s.store.Save(ctx, s.state); err != nil {
+		return fmt.Errorf("factoring: save journal %s: %w", s.state.Key, err)
+	}
+	return nil
+}
+
+// uploadDocuments ships every PDF the journal does not already confirm, then
+// checks the remote size of every PDF — skipped ones included, a factor may
+// have cleaned up since — re-uploading any that do not match. Returns the
+// remote paths in batch order.
+func (s *sftpSubmission) uploadDocuments(ctx context.Context, client sftpUploader, dir string, pdfs []InvoicePDF, total int, onProgress ProgressFunc) ([]string, error) {
+	uploaded := make([]string, 0, len(pdfs)+1)
+	for i, pdf := range pdfs {
+		if err := ctx.Err(); err != nil {
+			return uploaded, err
+		}
+		fileName := sanitizePDFName(pdf.InvoiceNumber)
+		remote, err := s.ensure(ctx, client, dir, fileName, pdf.Bytes)
+		if err != nil {
+			return uploaded, fmt.Errorf("upload pdf[%d] %s: %w", i, pdf.InvoiceNumber, err)
+		}
+		uploaded = append(uploaded, remote)
+		if onProgress != nil {
+			onProgress(Progress{Phase: "uploading", Done: i + 1, Total: total, Detail: fileName})
+		}
+	}
+	return uploaded, nil
+}
+
+// ensure makes remote dir/name hold content and journals it. A journaled file
+// with the same checksum and the right remote size is left alone.
+func (s *sftpSubmission) ensure(ctx context.Context, client sftpUploader, dir, name string, content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	size := int64(len(content))
+
+	if f, ok := s.state.file(name); ok && f.SHA256 == digest {
+		if remote, err := client.Stat(dir, name); err == nil && remote == size {
+			return f.RemotePath, nil
+		}
+	}
+	remotePath, err := client.Upload(dir, name, content)
+	if err != nil {
+		return "", err
+	}
+	if err := verifySize(client, dir, name, size); err != nil {
+		return "", err
+	}
+	s.state.record(JournalFile{Name: name, RemotePath: remotePath, SHA256: digest, Size: size, UploadedAt: s.now()})
+	return remotePath, s.save(ctx)
+}
+
+// startManifest saves the in-flight marker. It must be durable before the
+// manifest upload begins, or an interrupted trigger would look unstarted.
+func (s *sftpSubmission) startManifest(ctx context.Context) error {
+	at := s.now()
+	s.state.ManifestStartedAt = &at
+	return s.save(ctx)
+}
+
+func (s *sftpSubmission) confirmManifest(ctx context.Context, name, remotePath string, content []byte) error {
+	sum := sha256.Sum256(content)
+	s.state.Manifest = &JournalFile{
+		Name: name, RemotePath: remotePath, SHA256: hex.EncodeToString(sum[:]),
+		Size: int64(len(content)), UploadedAt: s.now(),
+	}
+	return s.save(ctx)
+}
+
+// verifySize compares the remote size with what was sent — a cheap check that
+// the upload was not cut short without the server reporting it.
+func verifySize(client sftpUploader, dir, name string, want int64) error {
+	got, err := client.Stat(dir, name)
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", name, err)
+	}
+	if got != want {
+		return fmt.Errorf("verify %s: remote size %d, sent %d", name, got, want)
+	}
+	return nil
+}
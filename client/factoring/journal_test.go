@@ -0,0 +1,222 @@
+package factoring
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyUploader drops the connection on the first upload of one file.
+type flakyUploader struct {
+	*fakeUploader
+	failOn string
+}
+
+func (f *flakyUploader) Upload(dir, name string, content []byte) (string, error) {
+	if name == f.failOn {
+		f.failOn = ""
+		return "", errors.New("connection lost")
+	}
+	return f.fakeUploader.Upload(dir, name, content)
+}
+
+func journaledTriumph(t *testing.T, up sftpUploader, store JournalStore, dials *int) *TriumphSFTPProvider {
+	t.Helper()
+	p := NewTriumphSFTP(Credential{ProviderType: ProviderTriumphSFTP, Username: "u", Password: "p"}, WithJournal(store))
+	p.dialFn = func(context.Context, sftpDialer) (sftpUploader, error) {
+		*dials++
+		return up, nil
+	}
+	p.now = func() time.Time { return time.Date(2026, 1, 11, 14, 31, 0, 0, time.UTC) }
+	return p
+}
+
+func uploadedNames(f *fakeUploader) []string {
+	var out []string
+	for _, u := range f.uploads {
+		out = append(out, u.filename)
+	}
+	return out
+}
+
+func TestSubmitBatch_ResumesFromJournal(t *testing.T) {
+	fake := &fakeUploader{}
+	store := NewMemoryJournal()
+	var dials int
+	p := journaledTriumph(t, &flakyUploader{fakeUploader: fake, failOn: "IN-000001.pdf"}, store, &dials)
+
+	_, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.Error(t, err)
+	j, ok, _ := store.Load(context.Background(), JournalKey(ProviderTriumphSFTP, "IB-000001", "invoices_20260111_143052.csv"))
+	require.True(t, ok)
+	require.Len(t, j.Files, 1)
+	assert.Equal(t, "IN-000000.pdf", j.Files[0].Name)
+	assert.Equal(t, int64(4), j.Files[0].Size)
+	assert.Len(t, j.Files[0].SHA256, 64)
+	assert.False(t, j.Delivered())
+
+	var ticks []Progress
+	res, err := p.SubmitBatch(context.Background(), rtsBatch(), func(pr Progress) { ticks = append(ticks, pr) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"IN-000000.pdf", "IN-000001.pdf", "invoices_20260111_143052.csv"}, uploadedNames(fake),
+		"the confirmed PDF is not uploaded again")
+	assert.Len(t, res.Uploaded, 3)
+	assert.Len(t, ticks, 3, "skipped files still count toward progress")
+
+	again, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dials, "a delivered batch is answered from the journal")
+	assert.Equal(t, res.Uploaded, again.Uploaded)
+}
+
+func TestSubmitBatch_ShortUploadIsCaughtBeforeManifest(t *testing.T) {
+	fake := &fakeUploader{short: "IN-000001.pdf"}
+	store := NewMemoryJournal()
+	var dials int
+	p := journaledTriumph(t, fake, store, &dials)
+
+	_, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "remote size 3, sent 4")
+	assert.NotContains(t, uploadedNames(fake), "invoices_20260111_143052.csv")
+
+	_, err = p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"IN-000000.pdf", "IN-000001.pdf", "IN-000001.pdf", "invoices_20260111_143052.csv"}, uploadedNames(fake))
+}
+
+func TestSubmitBatch_JournalRequiresSubmittedAt(t *testing.T) {
+	fake := &fakeUploader{}
+	var dials int
+	p := journaledTriumph(t, fake, NewMemoryJournal(), &dials)
+
+	batch := rtsBatch()
+	batch.SubmittedAt = time.Time{}
+	_, err := p.SubmitBatch(context.Background(), batch, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no SubmittedAt")
+	assert.Zero(t, dials)
+}
+
+// A journaled PDF the factor no longer holds (or holds truncated) is sent
+// again rather than trusted.
+func TestSubmitBatch_JournalIsCheckedAgainstRemote(t *testing.T) {
+	fake := &fakeUploader{}
+	store := NewMemoryJournal()
+	var dials int
+	p := journaledTriumph(t, &flakyUploader{fakeUploader: fake, failOn: "invoices_20260111_143052.csv"}, store, &dials)
+
+	_, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.Error(t, err)
+	delete(fake.sizes, "TMS_INPUT/IN-000000.pdf")
+
+	_, err = p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"IN-000000.pdf", "IN-000001.pdf", "IN-000000.pdf", "invoices_20260111_143052.csv"}, uploadedNames(fake))
+}
+
+func TestRTSSubmitBatch_InterruptedTriggerIsInDoubt(t *testing.T) {
+	fake := &fakeUploader{renameErr: errors.New("connection lost")}
+	store := NewMemoryJournal()
+	p := newRTSProvider(t, fake)
+	p.journal = store
+
+	_, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.Error(t, err)
+
+	fake.renameErr = nil
+	dialed := false
+	p.dialFn = func(context.Context, sftpDialer) (sftpUploader, error) { dialed = true; return fake, nil }
+	_, err = p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.ErrorIs(t, err, ErrManifestInDoubt)
+	assert.False(t, dialed, "RTS may already have the batch; nothing is re-shipped")
+}
+
+func TestDryRun_WritesTheDropLocally(t *testing.T) {
+	dir := t.TempDir()
+	p, err := NewProviderFromCredential(Credential{ProviderType: ProviderRTSSFTP, Username: "truckco1", Password: "p"}, WithDryRun(dir))
+	require.NoError(t, err)
+	require.NoError(t, p.TestConnection(context.Background()))
+
+	res, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "invoices_20260111_143052.csv", res.CSVFileName)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"IN-000000.pdf", "IN-000001.pdf", "invoices_20260111_143052.csv"}, names,
+		"the temp manifest was renamed into place")
+
+	dir = t.TempDir()
+	tp := NewTriumphSFTP(Credential{ProviderType: ProviderTriumphSFTP, Username: "u", Password: "p"}, WithDryRun(dir))
+	_, err = tp.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	pdf, err := os.ReadFile(filepath.Join(dir, "TMS_INPUT", "IN-000001.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pdf2"), pdf)
+}
+
+func TestDryRun_DoesNotJournalTheRealSubmission(t *testing.T) {
+	store := NewMemoryJournal()
+	dry := NewTriumphSFTP(Credential{ProviderType: ProviderTriumphSFTP, Username: "u", Password: "p"},
+		WithJournal(store), WithDryRun(t.TempDir()))
+	_, err := dry.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	_, ok, _ := store.Load(context.Background(), JournalKey(ProviderTriumphSFTP, "IB-000001", "invoices_20260111_143052.csv"))
+	assert.False(t, ok, "a dry run leaves no journal")
+
+	fake := &fakeUploader{}
+	var dials int
+	p := journaledTriumph(t, fake, store, &dials)
+	_, err = p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dials)
+	assert.Equal(t, []string{"IN-000000.pdf", "IN-000001.pdf", "invoices_20260111_143052.csv"}, uploadedNames(fake))
+}
+
+func TestDryRun_APIProvider(t *testing.T) {
+	dir := t.TempDir()
+	p := NewApexAPI(Credential{ProviderType: ProviderApexAPI, AccessKey: "k"}, WithDryRun(dir))
+	p.baseURL = "http://127.0.0.1:1" // would fail if contacted
+
+	res, err := p.SubmitBatch(context.Background(), rtsBatch(), nil)
+	require.NoError(t, err)
+	require.Len(t, res.Invoices, 2)
+	assert.Equal(t, InvoicePending, res.Invoices[0].State)
+	manifest, err := os.ReadFile(filepath.Join(dir, res.CSVFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "IN-000001,XYZ Brokers")
+}
+
+func TestRedisJournal_RoundTrip(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRedisJournal(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "42:")
+
+	_, ok, err := store.Load(context.Background(), "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	at := time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC)
+	want := BatchJournal{Key: "k", ProviderType: ProviderRTSSFTP, CSVFileName: "x.csv",
+		Files: []JournalFile{{Name: "a.pdf", SHA256: "00", Size: 1, UploadedAt: at}}, ManifestStartedAt: &at, UpdatedAt: at}
+	require.NoError(t, store.Save(context.Background(), want))
+	assert.True(t, mr.Exists("42:factoring:journal:k"))
+
+	got, ok, err := store.Load(context.Background(), "k")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, want.Files, got.Files)
+	assert.True(t, got.ManifestStartedAt.Equal(at))
+}
@@ -0,0 +1,103 @@
+package factoring
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// ProviderOption configures a Provider at construction. Every constructor
+// and NewProviderFromCredential accept them; options a provider has no use
+// for are ignored.
+type ProviderOption func(*providerOptions)
+
+type providerOptions struct {
+	journal   JournalStore
+	dryRunDir string
+}
+
+func applyProviderOptions(opts []ProviderOption) providerOptions {
+	var o providerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// A dry run never journals: its "delivered" manifest would make the next
+	// real SubmitBatch of the same batch return without uploading anything.
+	if o.dryRunDir != "" {
+		o.journal = nil
+	}
+	return o
+}
+
+// WithJournal makes SubmitBatch record every confirmed file in store and
+// resume from it on retry (see BatchJournal). SFTP providers only: an
+// APIProvider submission is a single request made idempotent by its
+// Idempotency-Key, so there is nothing to resume. Batches submitted with a
+// journal must carry SubmittedAt: it names the manifest the journal is keyed
+// on.
+func WithJournal(store JournalStore) ProviderOption {
+	return func(o *providerOptions) { o.journal = store }
+}
+
+// WithDryRun renders the submission into the local directory dir instead of
+// contacting the factor: the same files, names and subfolders the factor
+// would receive, written in the same order. Use it to inspect a batch (or let
+// support inspect one) without funding anything. An APIProvider writes its
+// parts there and reports every invoice pending. A dry run ignores
+// WithJournal and leaves the journal untouched.
+func WithDryRun(dir string) ProviderOption {
+	return func(o *providerOptions) { o.dryRunDir = dir }
+}
+
+// localDial returns a dial function that "connects" to a local directory.
+func localDial(root string) func(context.Context, sftpDialer) (sftpUploader, error) {
+	return func(context.Context, sftpDialer) (sftpUploader, error) {
+		if err := os.MkdirAll(root, 0o755); err != nil {
+			return nil, fmt.Errorf("factoring/dryrun: create %s: %w", root, err)
+		}
+		return &localUploader{root: root}, nil
+	}
+}
+
+// localUploader implements sftpUploader on the local filesystem for dry
+// runs. Remote paths are confined to root.
+type localUploader struct {
+	root string
+}
+
+func (l *localUploader) local(remoteDir, filename string) string {
+	return filepath.Join(l.root, filepath.FromSlash(path.Clean("/"+path.Join(remoteDir, filename))))
+}
+
+func (l *localUploader) EnsureDir(remoteDir string) error {
+	if err := os.MkdirAll(l.local(remoteDir, ""), 0o755); err != nil {
+		return fmt.Errorf("factoring/dryrun: mkdir %s: %w", remoteDir, err)
+	}
+	return nil
+}
+
+func (l *localUploader) Upload(remoteDir, filename string, content []byte) (string, error) {
+	if err := os.WriteFile(l.local(remoteDir, filename), content, 0o644); err != nil {
+		return "", fmt.Errorf("factoring/dryrun: write %s: %w", filename, err)
+	}
+	return path.Join(remoteDir, filename), nil
+}
+
+func (l *localUploader) Rename(remoteDir, from, to string) error {
+	if err := os.Rename(l.local(remoteDir, from), l.local(remoteDir, to)); err != nil {
+		return fmt.Errorf("factoring/dryrun: rename %s -> %s: %w", from, to, err)
+	}
+	return nil
+}
+
+func (l *localUploader) Stat(remoteDir, filename string) (int64, error) {
+	fi, err := os.Stat(l.local(remoteDir, filename))
+	if err != nil {
+		return 0, fmt.Errorf("factoring/dryrun: stat %s: %w", filename, err)
+	}
+	return fi.Size(), nil
+}
+
+func (l *localUploader) Close() error { return nil }
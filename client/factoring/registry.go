@@ -8,24 +8,41 @@ import (
 // NewProviderFromCredential returns the concrete Provider implementation that
 // matches cred.ProviderType. This is the only entry point callers need: parse
 // the company's stored credential JSON, pass it in, get a ready-to-use
-// Provider.
+// Provider. opts (WithJournal, WithDryRun) are passed to the constructor.
 //
 // Adding a new factoring backend: declare the constant in factoring.go, add
-// the impl file (e.g. apex.go), then add a case here.
-func NewProviderFromCredential(cred Credential) (Provider, error) {
+// the impl file (e.g. ecapital.go), then add a case here.
+func NewProviderFromCredential(cred Credential, opts ...ProviderOption) (Provider, error) {
+	if IsAPI(cred.ProviderType) && !apiEnabled(cred.ProviderType) {
+		return nil, fmt.Errorf("factoring: %s is not enabled: %s is not set",
+			cred.ProviderType, apiSpecs[cred.ProviderType].configURL)
+	}
 	if !cred.ProviderType.IsValid() {
 		return nil, fmt.Errorf("factoring: unknown provider_type %q", cred.ProviderType)
 	}
-	if cred.Username == "" || cred.Password == "" {
+	if spec, ok := apiSpecs[cred.ProviderType]; ok {
+		if cred.AccessKey == "" {
+			return nil, fmt.Errorf("factoring: %s credential missing access_key", cred.ProviderType)
+		}
+		if spec.auth == apiAuthBasic && cred.Username == "" {
+			return nil, fmt.Errorf("factoring: %s credential missing username", cred.ProviderType)
+		}
+	} else if cred.Username == "" || cred.Password == "" {
 		return nil, fmt.Errorf("factoring: %s credential missing username/password", cred.ProviderType)
 	}
 	switch cred.ProviderType {
 	case ProviderTriumphSFTP:
-		return NewTriumphSFTP(cred), nil
+		return NewTriumphSFTP(cred, opts...), nil
 	case ProviderRTSSFTP:
-		return NewRTSSFTP(cred), nil
+		return NewRTSSFTP(cred, opts...), nil
 	case ProviderRTSTestSFTP:
-		return NewRTSTestSFTP(cred), nil
+		return NewRTSTestSFTP(cred, opts...), nil
+	case ProviderOTRAPI:
+		return NewOTRAPI(cred, opts...), nil
+	case ProviderApexAPI:
+		return NewApexAPI(cred, opts...), nil
+	case ProviderDenimAPI:
+		return NewDenimAPI(cred, opts...), nil
 	default:
 		return nil, fmt.Errorf("factoring: provider %q has no implementation yet", cred.ProviderType)
 	}
@@ -35,7 +52,7 @@ func NewProviderFromCredential(cred Credential) (Provider, error) {
 // Redis/DB into Credential and dispatches. tms360-backend already validates
 // shape on save, so unmarshal errors here would mean tampering or a stale
 // schema.
-func NewProviderFromJSON(credentialJSON []byte) (Provider, error) {
+func NewProviderFromJSON(credentialJSON []byte, opts ...ProviderOption) (Provider, error) {
 	if len(credentialJSON) == 0 {
 		return nil, fmt.Errorf("factoring: empty credentials")
 	}
@@ -43,5 +60,5 @@ func NewProviderFromJSON(credentialJSON []byte) (Provider, error) {
 	if err := json.Unmarshal(credentialJSON, &cred); err != nil {
 		return nil, fmt.Errorf("factoring: parse credentials: %w", err)
 	}
-	return NewProviderFromCredential(cred)
+	return NewProviderFromCredential(cred, opts...)
 }
@@ -0,0 +1,439 @@
+package factoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRemittanceFileSize caps one remittance download. Schedules are a few
+// hundred rows; anything near this is not a remittance file.
+const maxRemittanceFileSize = 16 << 20
+
+// RemittanceReader is the reverse channel of a Provider: the factor drops
+// remittance / schedule files into an outbox, and the reader lists, fetches
+// and parses them so backend-accounting learns what was funded, short-paid,
+// rejected or charged back. It is an optional capability — check with a type
+// assertion:
+//
+//	if rr, ok := provider.(factoring.RemittanceReader); ok { ... }
+//
+// Like Provider, it hides the transport; ParseRemittance is pure (no network)
+// so a file archived earlier can be re-parsed.
+type RemittanceReader interface {
+	// ListRemittances returns the remittance files currently in the outbox,
+	// newest first. Nothing is moved or deleted — callers dedupe by name.
+	ListRemittances(ctx context.Context) ([]RemittanceFile, error)
+	// FetchRemittance downloads one file named by ListRemittances.
+	FetchRemittance(ctx context.Context, name string) ([]byte, error)
+	// ParseRemittance turns a fetched file into typed lines. Malformed rows
+	// are collected in Remittance.Errors rather than failing the file; an
+	// error means the file is not this factor's remittance at all.
+	ParseRemittance(name string, content []byte) (Remittance, error)
+}
+
+// RemittanceFile is one entry of a factor's outbox.
+type RemittanceFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// RemittanceKind classifies a remittance line.
+type RemittanceKind string
+
+const (
+	RemittancePurchased      RemittanceKind = "purchased"       // invoice bought; Advance paid out
+	RemittanceRejected       RemittanceKind = "rejected"        // factor declined to buy the invoice
+	RemittanceChargeback     RemittanceKind = "chargeback"      // earlier purchase taken back (recourse)
+	RemittanceReserveRelease RemittanceKind = "reserve_release" // held reserve paid out after the debtor paid
+	RemittanceOther          RemittanceKind = "other"
+)
+
+// RemittanceLine is one row of a remittance file. Amounts are USD and keep
+// the factor's sign: a negative Advance is a recourse debit, a negative Fees
+// a fee reversal. Only columns the factor's format defines as unsigned — such
+// as a chargeback column printed in parentheses — are read as magnitudes.
+type RemittanceLine struct {
+	RowNumber      int // 1-based line in the file, for support tickets
+	InvoiceNumber  string
+	DebtorName     string
+	Kind           RemittanceKind
+	Status         string // the factor's own wording, kept for audit
+	ScheduleNumber string
+	Date           time.Time // purchase / posting date; zero when the file has none
+	InvoiceAmount  float64   // face value the factor bought
+	Advance        float64
+	Reserve        float64
+	Fees           float64
+	Chargeback     float64
+}
+
+// Remittance is a parsed remittance file.
+type Remittance struct {
+	FileName string
+	Lines    []RemittanceLine
+	Errors   []RemittanceRowError
+	Skipped  int // blank and total lines
+}
+
+// RemittanceRowError is a row ParseRemittance could not read.
+type RemittanceRowError struct {
+	RowNumber int
+	Reason    string
+}
+
+func (e RemittanceRowError) Error() string {
+	return fmt.Sprintf("factoring: remittance row %d: %s", e.RowNumber, e.Reason)
+}
+
+// remittanceLayout names the columns of a factor's remittance CSV. Each field
+// lists the header spellings seen for it; matching ignores case and spacing.
+// Only invoice is required. unsigned lists, by name, the money fields the
+// format prints as unsigned magnitudes, whatever their notation.
+type remittanceLayout struct {
+	invoice, debtor, amount, advance, reserve, fees, chargeback []string
+	status, schedule, date                                      []string
+	dateFormats                                                 []string
+	unsigned                                                    map[string]bool
+}
+
+var remittanceLayouts = map[ProviderType]remittanceLayout{
+	ProviderTriumphSFTP: {
+		invoice:     []string{"INVOICE#", "INVOICE", "INV#"},
+		debtor:      []string{"DTR_NAME", "DEBTOR"},
+		amount:      []string{"INVAMT", "INVOICE AMOUNT"},
+		advance:     []string{"ADVANCE", "ADV_AMT"},
+		reserve:     []string{"RESERVE", "RSV_AMT"},
+		fees:        []string{"FEE", "FEES", "DISCOUNT"},
+		chargeback:  []string{"CHARGEBACK", "CHGBACK"},
+		status:      []string{"STATUS"},
+		schedule:    []string{"SCHEDULE#", "SCHEDULE"},
+		date:        []string{"PURCHASE_DATE", "DATE"},
+		dateFormats: []string{"01/02/2006", "2006-01-02"},
+		unsigned:    map[string]bool{"chargeback": true},
+	},
+	ProviderRTSSFTP:     rtsRemittanceLayout,
+	ProviderRTSTestSFTP: rtsRemittanceLayout,
+}
+
+var rtsRemittanceLayout = remittanceLayout{
+	invoice:     []string{"Invoice#", "Invoice Number"},
+	debtor:      []string{"Debtor Name"},
+	amount:      []string{"InvAmt", "Invoice Amount"},
+	advance:     []string{"Advance", "Advance Amount"},
+	reserve:     []string{"Reserve", "Escrow"},
+	fees:        []string{"Fee", "Fees", "Factoring Fee"},
+	chargeback:  []string{"Chargeback", "Recourse"},
+	status:      []string{"Status"},
+	schedule:    []string{"Schedule", "Schedule #"},
+	date:        []string{"Date", "Purchase Date"},
+	dateFormats: []string{"01/02/2006", "1/2/2006", "2006-01-02"},
+	unsigned:    map[string]bool{"chargeback": true},
+}
+
+// parseRemittanceCSV is the shared ParseRemittance: find the header row (a
+// preamble of account lines is allowed), then read every row by column name.
+func parseRemittanceCSV(pt ProviderType, name string, content []byte) (Remittance, error) {
+	layout, ok := remittanceLayouts[pt]
+	if !ok {
+		return Remittance{}, fmt.Errorf("factoring: %s has no remittance layout", pt)
+	}
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return Remittance{}, fmt.Errorf("factoring: %s: read %s: %w", pt, name, err)
+	}
+
+	headerAt, cols := -1, map[string]int{}
+	for i, rec := range records {
+		if i >= 20 {
+			break
+		}
+		idx := make(map[string]int, len(rec))
+		for j, cell := range rec {
+			idx[headerKey(cell)] = j
+		}
+		if findColumn(idx, layout.invoice) >= 0 {
+			headerAt, cols = i, idx
+			break
+		}
+	}
+	if headerAt < 0 {
+		return Remittance{}, fmt.Errorf("factoring: %s: %s has no remittance table", pt, name)
+	}
+
+	col := func(aliases []string) int { return findColumn(cols, aliases) }
+	invCol, debtorCol, statusCol, schedCol, dateCol := col(layout.invoice), col(layout.debtor),
+		col(layout.status), col(layout.schedule), col(layout.date)
+	money := []struct {
+		col int
+		dst func(*RemittanceLine) *float64
+		nm  string
+	}{
+		{col(layout.amount), func(l *RemittanceLine) *float64 { return &l.InvoiceAmount }, "invoice amount"},
+		{col(layout.advance), func(l *RemittanceLine) *float64 { return &l.Advance }, "advance"},
+		{col(layout.reserve), func(l *RemittanceLine) *float64 { return &l.Reserve }, "reserve"},
+		{col(layout.fees), func(l *RemittanceLine) *float64 { return &l.Fees }, "fees"},
+		{col(layout.chargeback), func(l *RemittanceLine) *float64 { return &l.Chargeback }, "chargeback"},
+	}
+
+	out := Remittance{FileName: name}
+	for i := headerAt + 1; i < len(records); i++ {
+		rec := records[i]
+		cell := func(c int) string {
+			if c < 0 || c >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[c])
+		}
+		inv := cell(invCol)
+		if inv == "" || strings.HasPrefix(strings.ToLower(inv), "total") {
+			out.Skipped++
+			continue
+		}
+
+		line := RemittanceLine{
+			RowNumber:      i + 1,
+			InvoiceNumber:  inv,
+			DebtorName:     cell(debtorCol),
+			Status:         cell(statusCol),
+			ScheduleNumber: cell(schedCol),
+		}
+		var bad string
+		for _, m := range money {
+			v, err := parseMoney(cell(m.col))
+			if err != nil {
+				bad = fmt.Sprintf("%s %q is not an amount", m.nm, cell(m.col))
+				break
+			}
+			if layout.unsigned[m.nm] {
+				v = math.Abs(v)
+			}
+			*m.dst(&line) = v
+		}
+		if bad == "" {
+			if raw := cell(dateCol); raw != "" {
+				if line.Date, ok = parseDate(raw, layout.dateFormats); !ok {
+					bad = fmt.Sprintf("date %q is not a date", raw)
+				}
+			}
+		}
+		if bad != "" {
+			out.Errors = append(out.Errors, RemittanceRowError{RowNumber: i + 1, Reason: bad})
+			continue
+		}
+		line.Kind = remittanceKind(line)
+		out.Lines = append(out.Lines, line)
+	}
+	return out, nil
+}
+
+// remittanceKind reads the factor's status wording first and falls back to
+// the amounts for files without a status column.
+func remittanceKind(l RemittanceLine) RemittanceKind {
+	s := strings.ToLower(l.Status)
+	switch {
+	case strings.Contains(s, "chargeback"), strings.Contains(s, "recourse"):
+		return RemittanceChargeback
+	case strings.Contains(s, "reject"), strings.Contains(s, "declin"), strings.Contains(s, "non-purchase"):
+		return RemittanceRejected
+	case strings.Contains(s, "reserve"), strings.Contains(s, "rebate"):
+		return RemittanceReserveRelease
+	case strings.Contains(s, "purchas"), strings.Contains(s, "fund"), strings.Contains(s, "approv"), strings.Contains(s, "paid"):
+		return RemittancePurchased
+	case s != "":
+		return RemittanceOther
+	case l.Chargeback > 0:
+		return RemittanceChargeback
+	case l.Advance > 0:
+		return RemittancePurchased
+	case l.Advance < 0:
+		return RemittanceChargeback
+	default:
+		return RemittanceOther
+	}
+}
+
+func headerKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), ""))
+}
+
+func findColumn(idx map[string]int, aliases []string) int {
+	for _, a := range aliases {
+		if c, ok := idx[headerKey(a)]; ok {
+			return c
+		}
+	}
+	return -1
+}
+
+// parseMoney reads "$1,234.50", "(12.00)" and "-12.00"; blank is zero.
+func parseMoney(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+	s = strings.Trim(s, "()")
+	s = strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+	if s == "" || s == "-" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+func parseDate(s string, formats []string) (time.Time, bool) {
+	for _, f := range formats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// RemittanceMatch is the outcome of MatchRemittance.
+type RemittanceMatch struct {
+	Matched     []MatchedInvoice
+	Unmatched   []RemittanceLine // lines for invoices outside the given set (e.g. an older batch's chargeback)
+	Outstanding []InvoiceLine    // submitted invoices no line mentions yet
+}
+
+// MatchedInvoice is a submitted invoice with every remittance line naming it
+// and the totals across them.
+type MatchedInvoice struct {
+	Invoice    InvoiceLine
+	Lines      []RemittanceLine
+	State      InvoiceState // accepted once purchased, rejected if declined, else pending
+	Advance    float64
+	Reserve    float64
+	Fees       float64
+	Chargeback float64
+	// ShortPaid is how much less than the invoice total the factor bought
+	// across all its purchase lines (0 when it bought the full amount or has
+	// not bought yet).
+	ShortPaid float64
+}
+
+// MatchRemittance matches remittance lines back to submitted invoices by
+// invoice number (case and spacing ignored). Lines may come from several
+// files; order is preserved within each invoice. Matched follows the order of
+// submitted.
+func MatchRemittance(lines []RemittanceLine, submitted []InvoiceLine) RemittanceMatch {
+	byNumber := make(map[string]int, len(submitted))
+	for i, inv := range submitted {
+		byNumber[headerKey(inv.InvoiceNumber)] = i
+	}
+	found := make([]*MatchedInvoice, len(submitted))
+	purchased := make([]float64, len(submitted))
+	var out RemittanceMatch
+	for _, l := range lines {
+		i, ok := byNumber[headerKey(l.InvoiceNumber)]
+		if !ok {
+			out.Unmatched = append(out.Unmatched, l)
+			continue
+		}
+		m := found[i]
+		if m == nil {
+			m = &MatchedInvoice{Invoice: submitted[i], State: InvoicePending}
+			found[i] = m
+		}
+		m.Lines = append(m.Lines, l)
+		m.Advance += l.Advance
+		m.Reserve += l.Reserve
+		m.Fees += l.Fees
+		m.Chargeback += l.Chargeback
+		switch l.Kind {
+		case RemittancePurchased:
+			m.State = InvoiceAccepted
+			purchased[i] += l.InvoiceAmount
+		case RemittanceRejected:
+			if m.State != InvoiceAccepted {
+				m.State = InvoiceRejected
+			}
+		}
+	}
+	for i, m := range found {
+		if m == nil {
+			out.Outstanding = append(out.Outstanding, submitted[i])
+			continue
+		}
+		if p := purchased[i]; p > 0 && p < m.Invoice.AmountUSD {
+			m.ShortPaid = math.Round((m.Invoice.AmountUSD-p)*100) / 100
+		}
+		out.Matched = append(out.Matched, *m)
+	}
+	return out
+}
+
+// sftpReader is the read half of *sftpClient, used by the remittance side of
+// the SFTP providers. Separate from sftpUploader so each fake implements only
+// the direction its tests exercise.
+type sftpReader interface {
+	ReadDir(remoteDir string) ([]os.FileInfo, error)
+	ReadFile(remoteDir, filename string, limit int64) ([]byte, error)
+	Close() error
+}
+
+// defaultSFTPReadDial is the production dialer for the remittance side.
+func defaultSFTPReadDial(ctx context.Context, d sftpDialer) (sftpReader, error) {
+	return dialSFTP(ctx, d)
+}
+
+// listRemittanceFiles lists the CSV files in an SFTP outbox, newest first.
+func listRemittanceFiles(ctx context.Context, dial func(context.Context, sftpDialer) (sftpReader, error), d sftpDialer, dir string) ([]RemittanceFile, error) {
+	if dial == nil {
+		dial = defaultSFTPReadDial
+	}
+	c, err := dial(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	entries, err := c.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RemittanceFile, 0, len(entries))
+	for _, e := range entries {
+		if !strings.EqualFold(path.Ext(e.Name()), ".csv") {
+			continue
+		}
+		out = append(out, RemittanceFile{Name: e.Name(), Size: e.Size(), ModTime: e.ModTime()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModTime.After(out[j].ModTime) })
+	return out, nil
+}
+
+// fetchRemittanceFile downloads one outbox file. The name is reduced to its
+// base so a crafted listing entry cannot read outside the outbox.
+func fetchRemittanceFile(ctx context.Context, dial func(context.Context, sftpDialer) (sftpReader, error), d sftpDialer, dir, name string) ([]byte, error) {
+	base := path.Base(strings.TrimSpace(name))
+	if base == "" || base == "." || base == "/" || base == ".." {
+		return nil, fmt.Errorf("factoring: invalid remittance file name %q", name)
+	}
+	if dial == nil {
+		dial = defaultSFTPReadDial
+	}
+	c, err := dial(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.ReadFile(dir, base, maxRemittanceFileSize)
+}
@@ -0,0 +1,194 @@
+package factoring
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbox is an in-memory sftpReader over one directory.
+type fakeOutbox struct {
+	dir    string
+	files  map[string]fakeFile
+	read   []string
+	closed int
+}
+
+type fakeFile struct {
+	content []byte
+	mod     time.Time
+}
+
+type fakeFileInfo struct {
+	os.FileInfo
+	name string
+	size int64
+	mod  time.Time
+}
+
+func (f fakeFileInfo) Name() string       { return f.name }
+func (f fakeFileInfo) Size() int64        { return f.size }
+func (f fakeFileInfo) ModTime() time.Time { return f.mod }
+
+func (o *fakeOutbox) ReadDir(dir string) ([]os.FileInfo, error) {
+	o.dir = dir
+	var out []os.FileInfo
+	for name, f := range o.files {
+		out = append(out, fakeFileInfo{name: name, size: int64(len(f.content)), mod: f.mod})
+	}
+	return out, nil
+}
+
+func (o *fakeOutbox) ReadFile(dir, name string, _ int64) ([]byte, error) {
+	o.dir = dir
+	o.read = append(o.read, name)
+	f, ok := o.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return f.content, nil
+}
+
+func (o *fakeOutbox) Close() error { o.closed++; return nil }
+
+const triumphSchedule = "Client: TRUCKCO\r\n" +
+	"SCHEDULE#,PURCHASE_DATE,INVOICE#,DTR_NAME,INVAMT,ADVANCE,RESERVE,FEE,CHARGEBACK,STATUS\r\n" +
+	"S-100,01/13/2026,IN-000000,ABC Logistics,\"$1,000.00\",900.00,70.00,30.00,,Purchased\r\n" +
+	"S-100,01/13/2026,IN-000001,XYZ Brokers,450.00,405.00,31.50,13.50,,Purchased\r\n" +
+	"S-100,01/13/2026,in-000002,Slow Pay Inc,0,0,0,0,,Rejected - debtor over limit\r\n" +
+	"S-100,01/13/2026,IN-099999,Old Debtor,0,0,0,0,(250.00),Chargeback\r\n" +
+	"S-100,01/13/2026,IN-000003,Bad Row,12abc,0,0,0,,Purchased\r\n" +
+	"Total,,,,\"$1,450.00\",,,,,\r\n"
+
+func TestParseRemittance_Triumph(t *testing.T) {
+	p := NewTriumphSFTP(Credential{ProviderType: ProviderTriumphSFTP, Username: "u", Password: "p"})
+
+	rem, err := p.ParseRemittance("schedule_S-100.csv", []byte(triumphSchedule))
+	require.NoError(t, err)
+	assert.Equal(t, 1, rem.Skipped, "the total line")
+	require.Len(t, rem.Errors, 1)
+	assert.Equal(t, 7, rem.Errors[0].RowNumber)
+	require.Len(t, rem.Lines, 4)
+
+	first := rem.Lines[0]
+	assert.Equal(t, RemittanceLine{
+		RowNumber: 3, InvoiceNumber: "IN-000000", DebtorName: "ABC Logistics", Kind: RemittancePurchased,
+		Status: "Purchased", ScheduleNumber: "S-100", Date: time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC),
+		InvoiceAmount: 1000, Advance: 900, Reserve: 70, Fees: 30,
+	}, first)
+	assert.Equal(t, RemittanceRejected, rem.Lines[2].Kind)
+	assert.Equal(t, RemittanceChargeback, rem.Lines[3].Kind)
+	assert.Equal(t, 250.0, rem.Lines[3].Chargeback, "the chargeback column is unsigned")
+}
+
+func TestParseRemittance_KeepsSignedAmounts(t *testing.T) {
+	p := NewTriumphSFTP(Credential{ProviderType: ProviderTriumphSFTP, Username: "u", Password: "p"})
+	csv := "INVOICE#,INVAMT,ADVANCE,RESERVE,FEE,CHARGEBACK\n" +
+		"IN-7,0,(900.00),0,0,\n" +
+		"IN-8,0,0,0,-13.50,\n" +
+		"IN-9,0,0,0,0,-40.00\n"
+
+	rem, err := p.ParseRemittance("s.csv", []byte(csv))
+	require.NoError(t, err)
+	require.Len(t, rem.Lines, 3)
+	assert.Equal(t, -900.0, rem.Lines[0].Advance, "a recourse debit stays a debit")
+	assert.Equal(t, RemittanceChargeback, rem.Lines[0].Kind)
+	assert.Equal(t, -13.5, rem.Lines[1].Fees, "a fee reversal stays a credit")
+	assert.Equal(t, 40.0, rem.Lines[2].Chargeback)
+}
+
+func TestParseRemittance_RTSWithoutStatusColumn(t *testing.T) {
+	p := NewRTSSFTP(Credential{ProviderType: ProviderRTSSFTP, Username: "u", Password: "p"})
+	csv := "Schedule #,Purchase Date,Invoice Number,Debtor Name,Invoice Amount,Advance Amount,Escrow,Factoring Fee,Recourse\n" +
+		"77,1/9/2026,IN-1,ABC,500.00,485.00,0,15.00,0\n" +
+		"77,1/9/2026,IN-0,ABC,0,0,0,0,120.00\n"
+
+	rem, err := p.ParseRemittance("r.csv", []byte(csv))
+	require.NoError(t, err)
+	require.Len(t, rem.Lines, 2)
+	assert.Equal(t, RemittancePurchased, rem.Lines[0].Kind, "an advance without a status is a purchase")
+	assert.Equal(t, RemittanceChargeback, rem.Lines[1].Kind)
+	assert.Equal(t, "77", rem.Lines[0].ScheduleNumber)
+
+	_, err = p.ParseRemittance("invoices.csv", []byte("Client,Load #\nx,y\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no remittance table")
+}
+
+func TestMatchRemittance(t *testing.T) {
+	p := NewTriumphSFTP(Credential{ProviderType: ProviderTriumphSFTP, Username: "u", Password: "p"})
+	rem, err := p.ParseRemittance("s.csv", []byte(triumphSchedule))
+	require.NoError(t, err)
+
+	submitted := []InvoiceLine{
+		{InvoiceNumber: "IN-000000", AmountUSD: 1000},
+		{InvoiceNumber: "IN-000001", AmountUSD: 500},
+		{InvoiceNumber: "IN-000002", AmountUSD: 800},
+		{InvoiceNumber: "IN-000004", AmountUSD: 100},
+	}
+	m := MatchRemittance(rem.Lines, submitted)
+
+	require.Len(t, m.Matched, 3)
+	assert.Equal(t, InvoiceAccepted, m.Matched[0].State)
+	assert.Equal(t, 900.0, m.Matched[0].Advance)
+	assert.Zero(t, m.Matched[0].ShortPaid)
+	assert.Equal(t, 50.0, m.Matched[1].ShortPaid, "bought 450 of a 500 invoice")
+	assert.Equal(t, InvoiceRejected, m.Matched[2].State, "matched despite the lower-case number")
+	require.Len(t, m.Unmatched, 1)
+	assert.Equal(t, "IN-099999", m.Unmatched[0].InvoiceNumber, "an older batch's chargeback")
+	assert.Equal(t, []InvoiceLine{submitted[3]}, m.Outstanding)
+
+	// A purchase split over two lines is short-paid only by what neither bought.
+	split := []RemittanceLine{
+		{InvoiceNumber: "IN-5", Kind: RemittancePurchased, InvoiceAmount: 600},
+		{InvoiceNumber: "IN-5", Kind: RemittancePurchased, InvoiceAmount: 300},
+	}
+	m = MatchRemittance(split, []InvoiceLine{{InvoiceNumber: "IN-5", AmountUSD: 1000}})
+	require.Len(t, m.Matched, 1)
+	assert.Equal(t, 100.0, m.Matched[0].ShortPaid)
+}
+
+func TestRemittanceReader_ListsAndFetchesOutbox(t *testing.T) {
+	outbox := &fakeOutbox{files: map[string]fakeFile{
+		"schedule_1.csv": {content: []byte("a"), mod: time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)},
+		"schedule_2.CSV": {content: []byte("b"), mod: time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)},
+		"readme.pdf":     {content: []byte("c"), mod: time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC)},
+	}}
+	p := NewTriumphSFTP(Credential{ProviderType: ProviderTriumphSFTP, Username: "u", Password: "p"})
+	p.readDialFn = func(context.Context, sftpDialer) (sftpReader, error) { return outbox, nil }
+
+	var rr RemittanceReader = p
+	files, err := rr.ListRemittances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "schedule_2.CSV", files[0].Name, "newest first")
+	assert.Equal(t, "TMS_OUTPUT", outbox.dir)
+
+	got, err := rr.FetchRemittance(context.Background(), "../TMS_INPUT/schedule_1.csv")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), got)
+	assert.Equal(t, []string{"schedule_1.csv"}, outbox.read, "the name is reduced to its base")
+	assert.Equal(t, 2, outbox.closed)
+
+	_, err = rr.FetchRemittance(context.Background(), "..")
+	assert.Error(t, err)
+}
+
+func TestRTSRemittanceOutboxOverride(t *testing.T) {
+	t.Setenv("APP_ENV", "stage")
+	t.Setenv(envTestRTSSFTPOutboxDir, "remit")
+	p := NewRTSTestSFTP(Credential{ProviderType: ProviderRTSTestSFTP, Username: "u", Password: "p"})
+	assert.Equal(t, "remit", p.outboxDir)
+
+	t.Setenv("APP_ENV", "")
+	assert.Equal(t, rtsSFTPOutboxDir, NewRTSSFTP(Credential{}).outboxDir)
+}
+
+var (
+	_ RemittanceReader = (*TriumphSFTPProvider)(nil)
+	_ RemittanceReader = (*RTSSFTPProvider)(nil)
+)
@@ -24,10 +24,14 @@ const (
 	rtsTestSFTPHost   = "ftps.rtsfinancial.com"
 	rtsSFTPPort       = 22
 	rtsSFTPInboundDir = "" // chrooted home dir; EnsureDir("") is a documented no-op
+	// Remittance / schedule CSVs come back in a subfolder, out of reach of the
+	// grab-and-clear trigger on the home dir.
+	rtsSFTPOutboxDir = "outbox"
 
 	envTestRTSSFTPHost       = "TEST_RTS_SFTP_HOST"
 	envTestRTSSFTPPort       = "TEST_RTS_SFTP_PORT"
 	envTestRTSSFTPInboundDir = "TEST_RTS_SFTP_INBOUND_DIR"
+	envTestRTSSFTPOutboxDir  = "TEST_RTS_SFTP_OUTBOX_DIR"
 )
 
 // RTSSFTPProvider implements Provider for RTS Financial's FTPS/SFTP invoice
@@ -43,8 +47,11 @@ type RTSSFTPProvider struct {
 	host         string
 	port         int
 	inboundDir   string
+	outboxDir    string
 	providerType ProviderType
 	dialFn       func(ctx context.Context, d sftpDialer) (sftpUploader, error)
+	readDialFn   func(ctx context.Context, d sftpDialer) (sftpReader, error)
+	journal      JournalStore
 	now          func() time.Time
 }
 
@@ -53,20 +60,22 @@ type RTSSFTPProvider struct {
 // and closed inside each SubmitBatch. Reads only Username + Password from
 // the universal Credential — the username doubles as the RTS "Client Number"
 // (first CSV column). AccessKey is ignored (it is not a host).
-func NewRTSSFTP(cred Credential) *RTSSFTPProvider {
-	return newRTSSFTP(cred, rtsSFTPHost, ProviderRTSSFTP)
+func NewRTSSFTP(cred Credential, opts ...ProviderOption) *RTSSFTPProvider {
+	return newRTSSFTP(cred, rtsSFTPHost, ProviderRTSSFTP, opts)
 }
 
 // NewRTSTestSFTP is the same adapter as NewRTSSFTP, pointed at the RTS test
 // host. On non-prod (APP_ENV allowlist) both constructors honour
 // TEST_RTS_SFTP_* so golden-tenant / our dev never dial real RTS.
-func NewRTSTestSFTP(cred Credential) *RTSSFTPProvider {
-	return newRTSSFTP(cred, rtsTestSFTPHost, ProviderRTSTestSFTP)
+func NewRTSTestSFTP(cred Credential, opts ...ProviderOption) *RTSSFTPProvider {
+	return newRTSSFTP(cred, rtsTestSFTPHost, ProviderRTSTestSFTP, opts)
 }
 
-func newRTSSFTP(cred Credential, host string, pt ProviderType) *RTSSFTPProvider {
+func newRTSSFTP(cred Credential, host string, pt ProviderType, opts []ProviderOption) *RTSSFTPProvider {
+	o := applyProviderOptions(opts)
 	port := rtsSFTPPort
 	inboundDir := rtsSFTPInboundDir
+	outboxDir := rtsSFTPOutboxDir
 
 	if isNonProdAppEnv() {
 		if h := strings.TrimSpace(os.Getenv(envTestRTSSFTPHost)); h != "" {
@@ -80,17 +89,27 @@ func newRTSSFTP(cred Credential, host string, pt ProviderType) *RTSSFTPProvider
 		if d := strings.TrimSpace(os.Getenv(envTestRTSSFTPInboundDir)); d != "" {
 			inboundDir = d
 		}
+		if d := strings.TrimSpace(os.Getenv(envTestRTSSFTPOutboxDir)); d != "" {
+			outboxDir = d
+		}
 	}
 
-	return &RTSSFTPProvider{
+	p := &RTSSFTPProvider{
 		username:     cred.Username,
 		password:     cred.Password,
 		host:         host,
 		port:         port,
 		inboundDir:   inboundDir,
+		outboxDir:    outboxDir,
 		providerType: pt,
 		dialFn:       defaultSFTPDial,
+		readDialFn:   defaultSFTPReadDial,
+		journal:      o.journal,
+	}
+	if o.dryRunDir != "" {
+		p.dialFn = localDial(o.dryRunDir)
 	}
+	return p
 }
 
 // BuildManifest renders the RTS 7-column CSV — the same bytes SubmitBatch
@@ -99,27 +118,30 @@ func (p *RTSSFTPProvider) BuildManifest(invoices []InvoiceLine) ([]byte, error)
 	return BuildRTSCSV(p.username, invoices)
 }
 
+func (p *RTSSFTPProvider) TestConnection(ctx context.Context) error {
+	return testSFTPConnection(ctx, p.dialFn, p.dialer())
+}
+
 // SubmitBatch uploads every invoice PDF first, then the CSV manifest last.
 // Order is load-bearing for RTS: the spreadsheet upload triggers the transfer
 // and the folder is cleared, so any file landing after the CSV is lost.
 // Provider rules are validated BEFORE dialing — a non-compliant batch never
 // reaches RTS at all (structured *BatchValidationError names the offender).
+// Every PDF's remote size, and the temp manifest's, is checked before the
+// rename that fires the trigger.
+//
+// With WithJournal a retry skips the PDFs already confirmed and a delivered
+// batch is not touched again. A retry that finds the manifest started but
+// unconfirmed returns ErrManifestInDoubt instead of re-shipping: RTS may
+// already have ingested it, and a second drop would be a second funding.
+// The journal is keyed on batch.SubmittedAt, so a journaled batch without
+// one is rejected.
 //
 // File naming:
 //   - PDFs: <INVOICE#>.pdf (InvoiceNumber sanitized for filesystem safety)
 //   - CSV:  invoices_YYYYMMDD_HHMMSS.csv (UTC timestamp from batch.SubmittedAt
 //     — deterministic, so a reclaimed retry overwrites the same file instead
 //     of producing a second manifest; DEV-840)
-func (p *RTSSFTPProvider) TestConnection(ctx context.Context) error {
-	return testSFTPConnection(ctx, p.dialFn, sftpDialer{
-		Host:         p.host,
-		Port:         p.port,
-		Username:     p.username,
-		Password:     p.password,
-		ProviderType: p.providerType,
-	})
-}
-
 func (p *RTSSFTPProvider) SubmitBatch(ctx context.Context, batch Batch, onProgress ProgressFunc) (SubmitResult, error) {
 	if err := batch.validate(); err != nil {
 		return SubmitResult{}, err
@@ -139,17 +161,19 @@ func (p *RTSSFTPProvider) SubmitBatch(ctx context.Context, batch Batch, onProgre
 	}
 	csvFileName := fmt.Sprintf("invoices_%s.csv", timestamp.UTC().Format("20060102_150405"))
 
+	sub, err := beginSFTPSubmission(ctx, p.journal, p.providerType, batch, csvFileName, p.clock)
+	if err != nil {
+		return SubmitResult{}, err
+	}
+	if sub.state.Delivered() {
+		return sub.result(), nil
+	}
+
 	dial := p.dialFn
 	if dial == nil {
 		dial = defaultSFTPDial
 	}
-	client, err := dial(ctx, sftpDialer{
-		Host:         p.host,
-		Port:         p.port,
-		Username:     p.username,
-		Password:     p.password,
-		ProviderType: p.providerType,
-	})
+	client, err := dial(ctx, p.dialer())
 	if err != nil {
 		return SubmitResult{}, err
 	}
@@ -161,21 +185,9 @@ func (p *RTSSFTPProvider) SubmitBatch(ctx context.Context, batch Batch, onProgre
 
 	// total = every PDF plus the CSV manifest (uploaded last).
 	total := len(batch.PDFs) + 1
-	uploaded := make([]string, 0, total)
-	for i, pdf := range batch.PDFs {
-		if err := ctx.Err(); err != nil {
-			return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded}, err
-		}
-		fileName := sanitizePDFName(pdf.InvoiceNumber)
-		remote, uerr := client.Upload(p.inboundDir, fileName, pdf.Bytes)
-		if uerr != nil {
-			return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded},
-				fmt.Errorf("upload pdf[%d] %s: %w", i, pdf.InvoiceNumber, uerr)
-		}
-		uploaded = append(uploaded, remote)
-		if onProgress != nil {
-			onProgress(Progress{Phase: "uploading", Done: i + 1, Total: total, Detail: fileName})
-		}
+	uploaded, err := sub.uploadDocuments(ctx, client, p.inboundDir, batch.PDFs, total, onProgress)
+	if err != nil {
+		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded}, err
 	}
 
 	// Trigger-safe manifest drop: RTS ingests on ANY *.csv/*.xlsx the moment it
@@ -189,11 +201,23 @@ func (p *RTSSFTPProvider) SubmitBatch(ctx context.Context, batch Batch, onProgre
 		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded},
 			fmt.Errorf("upload csv manifest (temp): %w", err)
 	}
+	if err := verifySize(client, p.inboundDir, tmpName, int64(len(csvBytes))); err != nil {
+		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded},
+			fmt.Errorf("upload csv manifest (temp): %w", err)
+	}
+	// Durable before the rename: from here on a retry must not re-ship.
+	if err := sub.startManifest(ctx); err != nil {
+		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded}, err
+	}
 	if err := client.Rename(p.inboundDir, tmpName, csvFileName); err != nil {
 		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded},
 			fmt.Errorf("activate csv manifest: %w", err)
 	}
-	uploaded = append(uploaded, path.Join(p.inboundDir, csvFileName))
+	csvPath := path.Join(p.inboundDir, csvFileName)
+	uploaded = append(uploaded, csvPath)
+	// Delivered regardless of the journal; a failed save leaves the batch
+	// "in doubt", which only matters if someone retries a successful batch.
+	_ = sub.confirmManifest(ctx, csvFileName, csvPath, csvBytes)
 	if onProgress != nil {
 		onProgress(Progress{Phase: "uploading", Done: total, Total: total, Detail: csvFileName})
 	}
@@ -207,3 +231,30 @@ func (p *RTSSFTPProvider) clock() time.Time {
 	}
 	return time.Now()
 }
+
+func (p *RTSSFTPProvider) dialer() sftpDialer {
+	return sftpDialer{
+		Host:         p.host,
+		Port:         p.port,
+		Username:     p.username,
+		Password:     p.password,
+		ProviderType: p.providerType,
+	}
+}
+
+// ListRemittances lists the schedule / remittance CSVs RTS left in the
+// outbox subfolder, newest first. Implements RemittanceReader.
+func (p *RTSSFTPProvider) ListRemittances(ctx context.Context) ([]RemittanceFile, error) {
+	return listRemittanceFiles(ctx, p.readDialFn, p.dialer(), p.outboxDir)
+}
+
+// FetchRemittance downloads one file named by ListRemittances.
+func (p *RTSSFTPProvider) FetchRemittance(ctx context.Context, name string) ([]byte, error) {
+	return fetchRemittanceFile(ctx, p.readDialFn, p.dialer(), p.outboxDir, name)
+}
+
+// ParseRemittance reads an RTS schedule CSV (Invoice# / Debtor Name / InvAmt
+// / Advance / Reserve / Fee / Chargeback / Status ...).
+func (p *RTSSFTPProvider) ParseRemittance(name string, content []byte) (Remittance, error) {
+	return parseRemittanceCSV(p.providerType, name, content)
+}
@@ -37,6 +37,11 @@ type ProviderRules struct {
 	// upload: quarantine for manual review instead (DEV-840 does not protect
 	// these providers).
 	TriggerAndClear bool
+	// ReportsInvoiceStatus marks factors that answer a submission with a
+	// verdict per invoice (SubmitResult.Invoices). Callers can settle each
+	// invoice from the result instead of waiting for the factor's reports,
+	// and resubmit only the rejected ones.
+	ReportsInvoiceStatus bool
 }
 
 // RulesFor returns the submission constraints of a provider. Unknown types get
@@ -52,6 +57,27 @@ func RulesFor(pt ProviderType) ProviderRules {
 			RequireAllFields:            true,
 			TriggerAndClear:             true,
 		}
+	case pt == ProviderOTRAPI:
+		return ProviderRules{
+			MaxRecords:                  100,
+			RequireUniqueInvoiceNumbers: true,
+			RequirePositiveAmounts:      true,
+			ReportsInvoiceStatus:        true,
+		}
+	case pt == ProviderApexAPI:
+		return ProviderRules{
+			MaxRecords:                  250,
+			RequireUniqueInvoiceNumbers: true,
+			RequirePositiveAmounts:      true,
+			RequireAllFields:            true,
+			ReportsInvoiceStatus:        true,
+		}
+	case pt == ProviderDenimAPI:
+		return ProviderRules{
+			RequireUniqueInvoiceNumbers: true,
+			RequirePositiveAmounts:      true,
+			ReportsInvoiceStatus:        true,
+		}
 	default:
 		return ProviderRules{}
 	}
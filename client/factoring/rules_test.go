@@ -1,11 +1,13 @@
 package factoring
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/TMS360/backend-pkg/secrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -127,3 +129,19 @@ func TestIsBatchValidationError_FalseForOtherErrors(t *testing.T) {
 	assert.False(t, IsBatchValidationError(errors.New("boom")))
 	assert.False(t, IsBatchValidationError(nil))
 }
+
+// Credentials are tenant-edited; an env reference must not reach the operator
+// environment, with or without an explicit resolver.
+func TestCredential_ResolveRejectsOperatorReferences(t *testing.T) {
+	t.Setenv("JWT_PRIVATE_KEY", "operator-only")
+	ctx := context.Background()
+	vault, err := secrets.NewLocalVault("")
+	require.NoError(t, err)
+	tr := secrets.NewTenantResolver("vault", vault, nil)
+
+	c := Credential{ProviderType: ProviderOTRAPI, AccessKey: "secret://env/JWT_PRIVATE_KEY"}
+	_, err = c.Resolve(ctx, "acme", tr)
+	require.Error(t, err)
+	_, err = c.Resolve(ctx, "acme", nil)
+	require.Error(t, err)
+}
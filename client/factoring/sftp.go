@@ -179,6 +179,55 @@ func (c *sftpClient) Rename(remoteDir, from, to string) error {
 	return nil
 }
 
+// Stat returns the size of remoteDir/filename, used to confirm an upload
+// landed whole before the manifest is written.
+func (c *sftpClient) Stat(remoteDir, filename string) (int64, error) {
+	remotePath := path.Join(remoteDir, filename)
+	fi, err := c.sftp.Stat(remotePath)
+	if err != nil {
+		return 0, fmt.Errorf("factoring/sftp: stat %s: %w", remotePath, err)
+	}
+	return fi.Size(), nil
+}
+
+// ReadDir lists remoteDir. Only regular files are returned; callers filter
+// by name.
+func (c *sftpClient) ReadDir(remoteDir string) ([]os.FileInfo, error) {
+	if remoteDir == "" {
+		remoteDir = "."
+	}
+	entries, err := c.sftp.ReadDir(remoteDir)
+	if err != nil {
+		return nil, fmt.Errorf("factoring/sftp: list %s: %w", remoteDir, err)
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		if e.Mode().IsRegular() {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// ReadFile downloads remoteDir/filename, refusing anything over limit bytes
+// so a misplaced archive cannot exhaust memory.
+func (c *sftpClient) ReadFile(remoteDir, filename string, limit int64) ([]byte, error) {
+	remotePath := path.Join(remoteDir, filename)
+	f, err := c.sftp.Open(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("factoring/sftp: open %s: %w", remotePath, err)
+	}
+	defer func() { _ = f.Close() }()
+	content, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("factoring/sftp: read %s: %w", remotePath, err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("factoring/sftp: %s exceeds %d bytes", remotePath, limit)
+	}
+	return content, nil
+}
+
 // Close releases the SFTP subsystem and the underlying SSH connection. Safe
 // to call on a partially-constructed client (nil-safe).
 func (c *sftpClient) Close() error {
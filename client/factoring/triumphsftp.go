@@ -24,10 +24,14 @@ const (
 	triumphSFTPHost       = "files.triumphbcap.com"
 	triumphSFTPPort       = 22
 	triumphSFTPInboundDir = "TMS_INPUT"
+	// Triumph drops purchase schedules / remittance CSVs for the carrier into
+	// the sibling TMS_OUTPUT folder.
+	triumphSFTPOutboxDir = "TMS_OUTPUT"
 
 	envTestTriumphSFTPHost       = "TEST_TRIUMPH_SFTP_HOST"
 	envTestTriumphSFTPPort       = "TEST_TRIUMPH_SFTP_PORT"
 	envTestTriumphSFTPInboundDir = "TEST_TRIUMPH_SFTP_INBOUND_DIR"
+	envTestTriumphSFTPOutboxDir  = "TEST_TRIUMPH_SFTP_OUTBOX_DIR"
 
 	// Legacy names from the single-provider era ("SFTP" meant Triumph back
 	// then). Still honored as a fallback so existing dev/stage environments
@@ -46,13 +50,17 @@ type sftpUploader interface {
 	// Rename moves remoteDir/from to remoteDir/to (replacing the target) —
 	// the second half of a trigger-safe two-step manifest drop.
 	Rename(remoteDir, from, to string) error
+	// Stat returns the remote size of remoteDir/filename.
+	Stat(remoteDir, filename string) (int64, error)
 	Close() error
 }
 
 // TriumphSFTPProvider implements Provider for Triumph Business Capital's SFTP
 // drop. The drop is one-way: Triumph polls the inbound folder every ~5
 // minutes, picks up the manifest + PDFs together, and reports status via
-// MyTriumph reports — there is no ACK file by protocol.
+// MyTriumph reports — there is no ACK file by protocol. Purchase schedules
+// do come back as CSVs in TMS_OUTPUT; the provider is also a
+// RemittanceReader over that folder.
 //
 // The provider takes the universal Credential (the same shape used by every
 // future factor) and pulls only the fields it needs (Username + Password).
@@ -65,8 +73,11 @@ type TriumphSFTPProvider struct {
 	host         string
 	port         int
 	inboundDir   string
+	outboxDir    string
 	providerType ProviderType
 	dialFn       func(ctx context.Context, d sftpDialer) (sftpUploader, error)
+	readDialFn   func(ctx context.Context, d sftpDialer) (sftpReader, error)
+	journal      JournalStore
 	now          func() time.Time
 }
 
@@ -82,10 +93,15 @@ type TriumphSFTPProvider struct {
 // while a self-hosted sftpgo instance can stand in for Triumph. In
 // production (or when APP_ENV is unset) the env vars are ignored and the
 // real Triumph endpoint is always used.
-func NewTriumphSFTP(cred Credential) *TriumphSFTPProvider {
+//
+// Options: WithJournal makes submissions resumable, WithDryRun writes them to
+// a local directory instead.
+func NewTriumphSFTP(cred Credential, opts ...ProviderOption) *TriumphSFTPProvider {
+	o := applyProviderOptions(opts)
 	host := triumphSFTPHost
 	port := triumphSFTPPort
 	inboundDir := triumphSFTPInboundDir
+	outboxDir := triumphSFTPOutboxDir
 
 	if isNonProdAppEnv() {
 		if h := firstNonEmptyEnv(envTestTriumphSFTPHost, envTestSFTPHostLegacy); h != "" {
@@ -99,17 +115,27 @@ func NewTriumphSFTP(cred Credential) *TriumphSFTPProvider {
 		if d := firstNonEmptyEnv(envTestTriumphSFTPInboundDir, envTestSFTPInboundDirLegacy); d != "" {
 			inboundDir = d
 		}
+		if d := firstNonEmptyEnv(envTestTriumphSFTPOutboxDir); d != "" {
+			outboxDir = d
+		}
 	}
 
-	return &TriumphSFTPProvider{
+	p := &TriumphSFTPProvider{
 		username:     cred.Username,
 		password:     cred.Password,
 		host:         host,
 		port:         port,
 		inboundDir:   inboundDir,
+		outboxDir:    outboxDir,
 		providerType: ProviderTriumphSFTP,
 		dialFn:       defaultSFTPDial,
+		readDialFn:   defaultSFTPReadDial,
+		journal:      o.journal,
+	}
+	if o.dryRunDir != "" {
+		p.dialFn = localDial(o.dryRunDir)
 	}
+	return p
 }
 
 // firstNonEmptyEnv returns the first of the named env vars whose trimmed
@@ -144,23 +170,23 @@ func defaultSFTPDial(ctx context.Context, d sftpDialer) (sftpUploader, error) {
 	return dialSFTP(ctx, d)
 }
 
+func (p *TriumphSFTPProvider) TestConnection(ctx context.Context) error {
+	return testSFTPConnection(ctx, p.dialFn, p.dialer())
+}
+
 // SubmitBatch uploads every invoice PDF first, then the CSV manifest last.
 // Order matters: Triumph's poller starts processing the moment it sees the
-// CSV, so any PDF uploaded after the CSV is missed.
+// CSV, so any PDF uploaded after the CSV is missed. Every PDF's remote size is
+// checked before the CSV goes up.
+//
+// With WithJournal a retry skips the PDFs already confirmed, and a batch
+// whose CSV is confirmed is not touched again — the journal's result is
+// returned without dialing. The journal is keyed on batch.SubmittedAt, so a
+// journaled batch without one is rejected.
 //
 // File naming:
 //   - PDFs: <INVOICE#>.pdf (InvoiceNumber sanitized for filesystem safety)
 //   - CSV:  invoices_YYYYMMDD_HHMMSS.csv (UTC timestamp from batch.SubmittedAt)
-func (p *TriumphSFTPProvider) TestConnection(ctx context.Context) error {
-	return testSFTPConnection(ctx, p.dialFn, sftpDialer{
-		Host:         p.host,
-		Port:         p.port,
-		Username:     p.username,
-		Password:     p.password,
-		ProviderType: p.providerType,
-	})
-}
-
 func (p *TriumphSFTPProvider) SubmitBatch(ctx context.Context, batch Batch, onProgress ProgressFunc) (SubmitResult, error) {
 	if err := batch.validate(); err != nil {
 		return SubmitResult{}, err
@@ -177,17 +203,19 @@ func (p *TriumphSFTPProvider) SubmitBatch(ctx context.Context, batch Batch, onPr
 	}
 	csvFileName := fmt.Sprintf("invoices_%s.csv", timestamp.UTC().Format("20060102_150405"))
 
+	sub, err := beginSFTPSubmission(ctx, p.journal, p.providerType, batch, csvFileName, p.clock)
+	if err != nil {
+		return SubmitResult{}, err
+	}
+	if sub.state.Delivered() {
+		return sub.result(), nil
+	}
+
 	dial := p.dialFn
 	if dial == nil {
 		dial = defaultSFTPDial
 	}
-	client, err := dial(ctx, sftpDialer{
-		Host:         p.host,
-		Port:         p.port,
-		Username:     p.username,
-		Password:     p.password,
-		ProviderType: p.providerType,
-	})
+	client, err := dial(ctx, p.dialer())
 	if err != nil {
 		return SubmitResult{}, err
 	}
@@ -199,29 +227,26 @@ func (p *TriumphSFTPProvider) SubmitBatch(ctx context.Context, batch Batch, onPr
 
 	// total = every PDF plus the CSV manifest (uploaded last).
 	total := len(batch.PDFs) + 1
-	uploaded := make([]string, 0, total)
-	for i, pdf := range batch.PDFs {
-		if err := ctx.Err(); err != nil {
-			return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded}, err
-		}
-		fileName := sanitizePDFName(pdf.InvoiceNumber)
-		remote, uerr := client.Upload(p.inboundDir, fileName, pdf.Bytes)
-		if uerr != nil {
-			return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded},
-				fmt.Errorf("upload pdf[%d] %s: %w", i, pdf.InvoiceNumber, uerr)
-		}
-		uploaded = append(uploaded, remote)
-		if onProgress != nil {
-			onProgress(Progress{Phase: "uploading", Done: i + 1, Total: total, Detail: fileName})
-		}
+	uploaded, err := sub.uploadDocuments(ctx, client, p.inboundDir, batch.PDFs, total, onProgress)
+	if err != nil {
+		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded}, err
 	}
 
+	if err := sub.startManifest(ctx); err != nil {
+		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded}, err
+	}
 	csvPath, err := client.Upload(p.inboundDir, csvFileName, csvBytes)
+	if err == nil {
+		err = verifySize(client, p.inboundDir, csvFileName, int64(len(csvBytes)))
+	}
 	if err != nil {
 		return SubmitResult{CSVFileName: csvFileName, Uploaded: uploaded},
 			fmt.Errorf("upload csv manifest: %w", err)
 	}
 	uploaded = append(uploaded, csvPath)
+	// The batch is delivered whatever the journal says now; a failed save only
+	// means a later retry of this batch overwrites the same CSV.
+	_ = sub.confirmManifest(ctx, csvFileName, csvPath, csvBytes)
 	if onProgress != nil {
 		onProgress(Progress{Phase: "uploading", Done: total, Total: total, Detail: csvFileName})
 	}
@@ -264,3 +289,30 @@ func sanitizePDFName(invoiceNumber string) string {
 	b.WriteString(".pdf")
 	return b.String()
 }
+
+func (p *TriumphSFTPProvider) dialer() sftpDialer {
+	return sftpDialer{
+		Host:         p.host,
+		Port:         p.port,
+		Username:     p.username,
+		Password:     p.password,
+		ProviderType: p.providerType,
+	}
+}
+
+// ListRemittances lists the purchase schedules Triumph left in TMS_OUTPUT,
+// newest first. Implements RemittanceReader.
+func (p *TriumphSFTPProvider) ListRemittances(ctx context.Context) ([]RemittanceFile, error) {
+	return listRemittanceFiles(ctx, p.readDialFn, p.dialer(), p.outboxDir)
+}
+
+// FetchRemittance downloads one file named by ListRemittances.
+func (p *TriumphSFTPProvider) FetchRemittance(ctx context.Context, name string) ([]byte, error) {
+	return fetchRemittanceFile(ctx, p.readDialFn, p.dialer(), p.outboxDir, name)
+}
+
+// ParseRemittance reads a Triumph schedule CSV (INVOICE# / DTR_NAME / INVAMT
+// / ADVANCE / RESERVE / FEE / CHARGEBACK / STATUS ...).
+func (p *TriumphSFTPProvider) ParseRemittance(name string, content []byte) (Remittance, error) {
+	return parseRemittanceCSV(p.providerType, name, content)
+}
@@ -3,6 +3,7 @@ package factoring
 import (
 	"context"
 	"errors"
+	"os"
 	"strings"
 	"sync"
 	"testing"
@@ -22,6 +23,10 @@ type fakeUploader struct {
 	closed    bool
 	uploadErr error
 	renameErr error
+	// sizes is what the fake server holds, by dir/name; short names a file
+	// that lands one byte short, once.
+	sizes map[string]int64
+	short string
 }
 
 type recordedUpload struct {
@@ -49,9 +54,27 @@ func (f *fakeUploader) Upload(dir, filename string, content []byte) (string, err
 		return "", f.uploadErr
 	}
 	f.uploads = append(f.uploads, recordedUpload{dir: dir, filename: filename, content: append([]byte(nil), content...)})
+	if f.sizes == nil {
+		f.sizes = map[string]int64{}
+	}
+	f.sizes[dir+"/"+filename] = int64(len(content))
+	if filename == f.short {
+		f.sizes[dir+"/"+filename]--
+		f.short = ""
+	}
 	return dir + "/" + filename, nil
 }
 
+func (f *fakeUploader) Stat(dir, filename string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	size, ok := f.sizes[dir+"/"+filename]
+	if !ok {
+		return 0, os.ErrNotExist
+	}
+	return size, nil
+}
+
 func (f *fakeUploader) Rename(dir, from, to string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -59,6 +82,10 @@ func (f *fakeUploader) Rename(dir, from, to string) error {
 		return f.renameErr
 	}
 	f.renames = append(f.renames, recordedRename{dir: dir, from: from, to: to})
+	if size, ok := f.sizes[dir+"/"+from]; ok {
+		delete(f.sizes, dir+"/"+from)
+		f.sizes[dir+"/"+to] = size
+	}
 	return nil
 }
 
@@ -259,7 +286,7 @@ func TestProviderType_UnmarshalGQL(t *testing.T) {
 	require.NoError(t, p.UnmarshalGQL("rts_test_sftp"))
 	assert.Equal(t, ProviderRTSTestSFTP, p)
 
-	require.Error(t, p.UnmarshalGQL("apex_api"), "unknown type must reject")
+	require.Error(t, p.UnmarshalGQL("ecapital_api"), "unknown type must reject")
 	require.Error(t, p.UnmarshalGQL(123), "non-string must reject")
 }
 
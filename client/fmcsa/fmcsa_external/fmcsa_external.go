@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/resilience"
 )
 
 type FmcsaExternalApi interface {
@@ -23,17 +27,22 @@ type clientExternal struct {
 // NewClientExternal creates a clientExternal with a 10-second timeout
 func NewClientExternal(apiKey string) FmcsaExternalApi {
 	return &clientExternal{
-		apiKey:  apiKey,
-		baseURL: "https://mobile.fmcsa.dot.gov/qc/services/carriers/",
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		apiKey:     apiKey,
+		baseURL:    "https://mobile.fmcsa.dot.gov/qc/services/carriers/",
+		httpClient: transport.HTTPClient(),
 	}
 }
 
+// transport is the QCMobile policy: resilience.Standard. Searches are GETs,
+// so 429/5xx and transport errors are retried.
+var transport = resilience.ClientOptions{
+	Base:     metrics.Transport(metrics.ProviderFMCSA, nil, nil),
+	Defaults: resilience.Standard(resilience.NewBreakerSet(metrics.ProviderFMCSA, resilience.BreakerConfig{})),
+	Timeout:  60 * time.Second,
+}
+
 // SearchCompaniesByName calls the FMCSA API
 func (c *clientExternal) SearchCompaniesByName(ctx context.Context, name string) ([]Carrier, error) {
-	fmt.Println("Searching FMCSA for company name: ", url.PathEscape(name))
 	req, err := c.prepareReq(ctx, "name/"+url.PathEscape(name))
 	if err != nil {
 		return nil, err
@@ -50,9 +59,7 @@ func (c *clientExternal) SearchCompaniesByName(ctx context.Context, name string)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// --- LOGGING ---
-	fmt.Printf("Fmcsa Status: %s\n", resp.Status)
-	fmt.Printf("Fmcsa Body: %s\n", string(bodyBytes))
+	slog.DebugContext(ctx, "fmcsa: name search response", "status", resp.StatusCode, "bytes", len(bodyBytes))
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("fmcsa returned non-200 status: %d", resp.StatusCode)
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -29,9 +30,7 @@ func (c *client) executeSearch(ctx context.Context, entityType string, params Se
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// --- LOGGING ---
-	fmt.Printf("Fmcsa Status: %s\n", resp.Status)
-	fmt.Printf("Fmcsa Body: %s\n", string(bodyBytes))
+	slog.DebugContext(ctx, "fmcsa: search response", "entity", entityType, "status", resp.StatusCode, "bytes", len(bodyBytes))
 
 	if resp.StatusCode > 300 {
 		return nil, c.handleAPIError(resp.StatusCode, bodyBytes)
@@ -45,7 +44,6 @@ func (c *client) executeSearch(ctx context.Context, entityType string, params Se
 }
 
 func (c *client) prepareReq(ctx context.Context, entityType string, params SearchParams) (*http.Request, error) {
-	fmt.Println("Searching FMCSA for company: ", url.PathEscape(params.Query))
 	reqURL, err := url.Parse(fmt.Sprintf("%s/api/v1/%s", c.baseURL, entityType))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/TMS360/backend-pkg/client/fmcsa/fmcsa_errors"
+	"github.com/TMS360/backend-pkg/resilience"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -45,12 +47,17 @@ func NewClient(baseURL string, systemAPIKey string) FmcsaAPI {
 	return &client{
 		baseURL:      baseURL,
 		systemAPIKey: systemAPIKey,
-		httpClient: &http.Client{
-			Timeout: 90 * time.Second,
-		},
+		httpClient:   transport.HTTPClient(),
 	}
 }
 
+// transport is the company-service policy: resilience.Standard with no
+// metrics base, as the service is internal. Every lookup is a GET.
+var transport = resilience.ClientOptions{
+	Defaults: resilience.Standard(resilience.NewBreakerSet("fmcsa_internal", resilience.BreakerConfig{})),
+	Timeout:  90 * time.Second,
+}
+
 // 1. Define an unexported type for the context key to prevent collisions
 type authModeKey struct{}
 
@@ -127,9 +134,7 @@ func (c *client) GetCompany(ctx context.Context, dotNumber string) (*Result, err
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// --- LOGGING ---
-	fmt.Printf("Fmcsa Status: %s\n", resp.Status)
-	fmt.Printf("Fmcsa Body: %s\n", string(bodyBytes))
+	slog.DebugContext(ctx, "fmcsa: company response", "status", resp.StatusCode, "bytes", len(bodyBytes))
 
 	if resp.StatusCode > 300 {
 		return nil, c.handleAPIError(resp.StatusCode, bodyBytes)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"log/slog"
 	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
 )
 
 // Google Maps Platform bills per request, so every outbound call is money.
@@ -16,14 +18,17 @@ import (
 // many call paths. Google is fallback-only and reached from a handful of sites,
 // so the op alone identifies the path and the stack walk would be noise.
 
-// logCall records one outbound Google transaction. It never receives the request
+// logCall records one outbound Google transaction, as a log line and in the
+// tms_external_* metrics. It never receives the request
 // URL: the Geocoding and Places endpoints take the credential as a `key` query
 // parameter, so the URL is a secret and must not reach the logs. op is passed in
 // by the caller instead.
 func logCall(ctx context.Context, op string, status int, started time.Time, err error) {
+	outcome := outcomeOf(ctx, status, err)
+	metrics.ObserveExternal(metrics.ProviderGoogleMaps, op, outcome, time.Since(started))
 	slog.LogAttrs(ctx, slog.LevelInfo, "google_call",
 		slog.String("op", op),
-		slog.String("outcome", outcomeOf(ctx, status, err)),
+		slog.String("outcome", outcome),
 		slog.Int("status", status),
 		slog.Int64("dur_ms", time.Since(started).Milliseconds()),
 	)
@@ -35,22 +40,8 @@ func logCall(ctx context.Context, op string, status int, started time.Time, err
 //
 // The auth check comes before the status check on purpose: Google reports a
 // rejected key on the Geocoding and Places endpoints as HTTP 200 with
-// REQUEST_DENIED in the body, so status alone would file it under "ok".
+// REQUEST_DENIED in the body, so status alone would file it under "ok". The
+// switch itself is metrics.Outcome, shared with here.
 func outcomeOf(ctx context.Context, status int, err error) string {
-	switch {
-	case err == nil:
-		return "ok"
-	case ctx != nil && ctx.Err() != nil:
-		return "ctx_canceled"
-	case IsAuthError(err):
-		return "auth"
-	case status >= 500:
-		return "http_5xx"
-	case status >= 400:
-		return "http_4xx"
-	case status > 0:
-		return "http_other"
-	default:
-		return "transport"
-	}
+	return metrics.Outcome(ctx, status, err, IsAuthError(err))
 }
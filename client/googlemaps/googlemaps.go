@@ -28,6 +28,8 @@ import (
 	"time"
 
 	"github.com/TMS360/backend-pkg/config"
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/quota"
 )
 
 // ErrInvalidCredentials is returned by TestConnection when Google rejects the
@@ -41,6 +43,7 @@ const (
 	opGeocode      = "geocode"
 	opAutocomplete = "places_autocomplete"
 	opComputeRoute = "compute_routes"
+	opRouteMatrix  = "compute_route_matrix"
 	// opTestConn is the credential probe behind the Settings "Test connection"
 	// button. It bills like any other geocode, so it is counted separately rather
 	// than folded into opGeocode, which would misattribute it to stop resolution.
@@ -84,17 +87,29 @@ func IsAuthError(err error) bool {
 	return errors.As(err, &ae)
 }
 
+// StatusError is returned for a non-2xx response that is not a credential
+// failure, so callers can tell a Google outage (5xx) from a bad request.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-2xx status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
 // Client talks to the Google Maps Platform APIs for one company's key.
 type Client struct {
 	httpClient  *http.Client
 	geocodeHost string
 	routesHost  string
 	apiKey      string
+	meter       *quota.Meter
 }
 
 // NewClient builds a client with overridable hosts, so tests can point it at a
 // local stub. Empty hosts fall back to the public endpoints.
-func NewClient(cfg config.GoogleMapsConfig, apiKey string) (*Client, error) {
+func NewClient(cfg config.GoogleMapsConfig, apiKey string, opts ...Option) (*Client, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("API key cannot be empty")
 	}
@@ -109,12 +124,16 @@ func NewClient(cfg config.GoogleMapsConfig, apiKey string) (*Client, error) {
 		routesHost = "https://routes.googleapis.com"
 	}
 
-	return &Client{
-		httpClient:  &http.Client{Timeout: 30 * time.Second},
+	c := &Client{
+		httpClient:  transport.HTTPClient(),
 		geocodeHost: geocodeHost,
 		routesHost:  routesHost,
 		apiKey:      apiKey,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewClientWithToken builds a client against the public endpoints. This is the
@@ -303,7 +322,7 @@ func (c *Client) ComputeRouteDistance(ctx context.Context, waypoints []Coordinat
 		return nil, fmt.Errorf("googlemaps: failed to encode routes request: %w", err)
 	}
 
-	body, err := c.doPost(ctx, opComputeRoute, c.routesHost+"/directions/v2:computeRoutes", payload)
+	body, err := c.doPost(ctx, opComputeRoute, c.routesHost+"/directions/v2:computeRoutes", "routes.distanceMeters,routes.duration", payload, 1)
 	if err != nil {
 		return nil, err
 	}
@@ -325,6 +344,98 @@ func (c *Client) ComputeRouteDistance(ctx context.Context, waypoints []Coordinat
 	}, nil
 }
 
+type routeMatrixWaypoint struct {
+	Waypoint routesWaypoint `json:"waypoint"`
+}
+
+type computeRouteMatrixRequest struct {
+	Origins      []routeMatrixWaypoint `json:"origins"`
+	Destinations []routeMatrixWaypoint `json:"destinations"`
+	TravelMode   string                `json:"travelMode"`
+}
+
+// MatrixElement is one origin/destination pair of ComputeRouteMatrix.
+type MatrixElement struct {
+	OriginIndex      int
+	DestinationIndex int
+	DistanceMeters   int
+	DurationSeconds  int
+	// RouteExists is false when Google found no route for the pair; the
+	// distance and duration are then zero.
+	RouteExists bool
+}
+
+type routeMatrixElement struct {
+	OriginIndex      int    `json:"originIndex"`
+	DestinationIndex int    `json:"destinationIndex"`
+	DistanceMeters   int    `json:"distanceMeters"`
+	Duration         string `json:"duration"`
+	Condition        string `json:"condition"`
+	Status           *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+// MaxMatrixElements is the Routes API cap on origins × destinations for a
+// traffic-unaware matrix; MaxMatrixWaypoints caps origins + destinations.
+const (
+	MaxMatrixElements  = 625
+	MaxMatrixWaypoints = 50
+)
+
+// ComputeRouteMatrix returns driving distance and duration for every origin
+// × destination pair in one billed call (billed per element). Requests over
+// MaxMatrixElements or MaxMatrixWaypoints are rejected up front; split them
+// at the call site.
+func (c *Client) ComputeRouteMatrix(ctx context.Context, origins, destinations []Coordinates) ([]MatrixElement, error) {
+	if len(origins) == 0 || len(destinations) == 0 {
+		return nil, fmt.Errorf("googlemaps: a matrix needs origins and destinations")
+	}
+	if n := len(origins) * len(destinations); n > MaxMatrixElements {
+		return nil, fmt.Errorf("googlemaps: %d matrix elements exceeds the Routes API limit of %d", n, MaxMatrixElements)
+	}
+	if n := len(origins) + len(destinations); n > MaxMatrixWaypoints {
+		return nil, fmt.Errorf("googlemaps: %d matrix waypoints exceeds the Routes API limit of %d", n, MaxMatrixWaypoints)
+	}
+
+	req := computeRouteMatrixRequest{TravelMode: "DRIVE"}
+	for _, o := range origins {
+		req.Origins = append(req.Origins, routeMatrixWaypoint{Waypoint: toWaypoint(o)})
+	}
+	for _, d := range destinations {
+		req.Destinations = append(req.Destinations, routeMatrixWaypoint{Waypoint: toWaypoint(d)})
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("googlemaps: failed to encode route matrix request: %w", err)
+	}
+
+	// Billed per element, so metered per element against the tenant's budget.
+	body, err := c.doPost(ctx, opRouteMatrix, c.routesHost+"/distanceMatrix/v2:computeRouteMatrix",
+		"originIndex,destinationIndex,distanceMeters,duration,condition,status", payload,
+		int64(len(origins)*len(destinations)))
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []routeMatrixElement
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("googlemaps: failed to decode route matrix response: %w", err)
+	}
+	out := make([]MatrixElement, 0, len(raw))
+	for _, e := range raw {
+		ok := e.Condition == "ROUTE_EXISTS" && (e.Status == nil || e.Status.Code == 0)
+		el := MatrixElement{OriginIndex: e.OriginIndex, DestinationIndex: e.DestinationIndex, RouteExists: ok}
+		if ok {
+			el.DistanceMeters = e.DistanceMeters
+			el.DurationSeconds = parseDurationSeconds(e.Duration)
+		}
+		out = append(out, el)
+	}
+	return out, nil
+}
+
 // TestConnection probes the credential for the Settings "Test connection"
 // button. It deliberately does not surface *AuthError: callers match on
 // ErrInvalidCredentials (tms-auth's integration classify), mirroring
@@ -361,6 +472,9 @@ func (c *Client) TestConnection(ctx context.Context) error {
 // out of fullURL, which carries the key and must never be logged.
 func (c *Client) doGet(ctx context.Context, op, fullURL string) ([]byte, error) {
 	started := time.Now()
+	if err := c.quota().Check(ctx, metrics.ProviderGoogleMaps, op); err != nil {
+		return nil, err
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
 	if err != nil {
@@ -368,13 +482,18 @@ func (c *Client) doGet(ctx context.Context, op, fullURL string) ([]byte, error)
 	}
 	req.Header.Set("Accept", "application/json")
 
-	return c.finish(ctx, op, started, req)
+	return c.finish(ctx, op, started, req, 1)
 }
 
-// doPost performs the Routes v2 call. Routes takes the credential in a header
+// doPost performs a Routes v2 call. Routes takes the credential in a header
 // rather than the query string, and answers a bad key with a real 403.
-func (c *Client) doPost(ctx context.Context, op, fullURL string, payload []byte) ([]byte, error) {
+// fieldMask lists the response fields wanted; Routes bills by them. units is
+// what the call is billed as — 1, or a matrix's element count.
+func (c *Client) doPost(ctx context.Context, op, fullURL, fieldMask string, payload []byte, units int64) ([]byte, error) {
 	started := time.Now()
+	if err := c.quota().CheckN(ctx, metrics.ProviderGoogleMaps, op, units); err != nil {
+		return nil, err
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, bytes.NewReader(payload))
 	if err != nil {
@@ -383,20 +502,21 @@ func (c *Client) doPost(ctx context.Context, op, fullURL string, payload []byte)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Goog-Api-Key", c.apiKey)
-	// Ask only for what we use; Routes bills by the fields requested.
-	req.Header.Set("X-Goog-FieldMask", "routes.distanceMeters,routes.duration")
+	req.Header.Set("X-Goog-FieldMask", fieldMask)
 
-	return c.finish(ctx, op, started, req)
+	return c.finish(ctx, op, started, req, units)
 }
 
-func (c *Client) finish(ctx context.Context, op string, started time.Time, req *http.Request) ([]byte, error) {
+func (c *Client) finish(ctx context.Context, op string, started time.Time, req *http.Request, units int64) ([]byte, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		// Never reached Google, so nothing was billed — logged anyway so a
 		// network-level outage is visible in the same sample.
 		logCall(ctx, op, 0, started, err)
+		c.quota().RecordN(ctx, metrics.ProviderGoogleMaps, op, 0, units)
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
+	c.quota().RecordN(ctx, metrics.ProviderGoogleMaps, op, resp.StatusCode, units)
 	defer func() { _ = resp.Body.Close() }()
 
 	body, readErr := io.ReadAll(resp.Body)
@@ -408,7 +528,7 @@ func (c *Client) finish(ctx context.Context, op string, started time.Time, req *
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		statusErr := fmt.Errorf("received non-2xx status code: %d, body: %s", resp.StatusCode, string(body))
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 		logCall(ctx, op, resp.StatusCode, started, statusErr)
 		return nil, statusErr
 	}
@@ -11,6 +11,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/TMS360/backend-pkg/config"
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/quota"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
 )
 
 // newTestClient points a Client at a stub that always answers with body/status,
@@ -262,3 +268,134 @@ func TestNewClientWithToken_RejectsEmptyKey(t *testing.T) {
 		t.Fatal("an empty key must be rejected, so the provider reports the tenant as unconfigured")
 	}
 }
+
+// A tenant past its hard budget is stopped before the request goes out, so the
+// blocked call costs nothing; billed calls are counted against the budget.
+func TestComputeRouteDistance_HardBudgetBlocksBeforeCalling(t *testing.T) {
+	captureLogs(t)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{"routes":[{"distanceMeters":1,"duration":"1s"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(config.GoogleMapsConfig{RoutesHost: srv.URL}, "key",
+		WithMeter(quota.NewMeter(quota.WithRedis(rdb), quota.WithBudgets(quota.StaticBudgets{
+			{Provider: metrics.ProviderGoogleMaps, Op: opComputeRoute, Period: quota.Daily, Hard: 1},
+		}))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := quota.WithCompany(context.Background(), "acme")
+	points := []Coordinates{{Latitude: 1, Longitude: 2}, {Latitude: 3, Longitude: 4}}
+
+	if _, err := c.ComputeRouteDistance(ctx, points); err != nil {
+		t.Fatalf("first call within budget: %v", err)
+	}
+	_, err = c.ComputeRouteDistance(ctx, points)
+	if !errors.Is(err, quota.ErrBudgetExceeded) {
+		t.Fatalf("second call must hit the hard budget, got %v", err)
+	}
+	if hits != 1 {
+		t.Fatalf("blocked call reached Google: %d hits", hits)
+	}
+}
+
+func TestComputeRouteMatrix_MapsElementsAndMissingRoutes(t *testing.T) {
+	captureLogs(t)
+
+	var (
+		body      []byte
+		fieldMask string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		fieldMask = r.Header.Get("X-Goog-FieldMask")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"originIndex":0,"destinationIndex":0,"distanceMeters":1200,"duration":"90s","condition":"ROUTE_EXISTS","status":{}},
+			{"originIndex":1,"destinationIndex":0,"condition":"ROUTE_NOT_FOUND","status":{}}]`))
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{httpClient: srv.Client(), geocodeHost: srv.URL, routesHost: srv.URL, apiKey: "k"}
+
+	got, err := c.ComputeRouteMatrix(context.Background(),
+		[]Coordinates{{Latitude: 1, Longitude: 1}, {Latitude: 2, Longitude: 2}},
+		[]Coordinates{{Latitude: 3, Longitude: 3}})
+	if err != nil {
+		t.Fatalf("ComputeRouteMatrix: %v", err)
+	}
+
+	var req computeRouteMatrixRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if len(req.Origins) != 2 || req.Origins[1].Waypoint.Location.LatLng.Latitude != 2 {
+		t.Errorf("origins = %+v", req.Origins)
+	}
+	if !strings.Contains(fieldMask, "condition") {
+		t.Errorf("field mask %q must ask for condition, or every pair looks routeless", fieldMask)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d elements, want 2", len(got))
+	}
+	if !got[0].RouteExists || got[0].DistanceMeters != 1200 || got[0].DurationSeconds != 90 {
+		t.Errorf("element 0 = %+v", got[0])
+	}
+	if got[1].RouteExists || got[1].OriginIndex != 1 {
+		t.Errorf("element 1 = %+v, want no route from origin 1", got[1])
+	}
+}
+
+// A matrix is billed per element, so it spends the budget per element: a
+// 2×3 block uses 6 units and the next block no longer fits a 10-unit budget.
+func TestComputeRouteMatrix_MetersElements(t *testing.T) {
+	captureLogs(t)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(config.GoogleMapsConfig{RoutesHost: srv.URL}, "key",
+		WithMeter(quota.NewMeter(quota.WithRedis(rdb), quota.WithBudgets(quota.StaticBudgets{
+			{Provider: metrics.ProviderGoogleMaps, Period: quota.Daily, Hard: 10},
+		}))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := quota.WithCompany(context.Background(), "acme")
+	origins, destinations := make([]Coordinates, 2), make([]Coordinates, 3)
+
+	if _, err := c.ComputeRouteMatrix(ctx, origins, destinations); err != nil {
+		t.Fatalf("first block within budget: %v", err)
+	}
+	_, err = c.ComputeRouteMatrix(ctx, origins, destinations)
+	if !errors.Is(err, quota.ErrBudgetExceeded) {
+		t.Fatalf("6 + 6 elements must exceed a 10-element budget, got %v", err)
+	}
+	if hits != 1 {
+		t.Fatalf("blocked block reached Google: %d hits", hits)
+	}
+}
+
+func TestComputeRouteMatrix_RejectsOversizedMatrix(t *testing.T) {
+	captureLogs(t)
+	c := newTestClient(t, http.StatusOK, `[]`)
+
+	_, err := c.ComputeRouteMatrix(context.Background(), make([]Coordinates, 26), make([]Coordinates, 25))
+	if err == nil || !strings.Contains(err.Error(), "exceeds the Routes API limit") {
+		t.Errorf("26×25 matrix err = %v, want the limit named", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
 )
 
 // HERE bills per transaction, so every outbound call is money. logCall emits one
@@ -126,14 +128,17 @@ func resolveCaller(ctx context.Context) string {
 	return callerChain()
 }
 
-// logCall records one outbound HERE transaction. It never receives the request
+// logCall records one outbound HERE transaction, as a log line and in the
+// tms_external_* metrics (op and outcome only — caller is unbounded). It never receives the request
 // URL: HERE takes its credential as an `apiKey` query parameter, so the URL is a
 // secret and must not reach the logs. op is passed in by the caller instead.
 func logCall(ctx context.Context, op string, status int, started time.Time, err error) {
+	outcome := outcomeOf(ctx, status, err)
+	metrics.ObserveExternal(metrics.ProviderHERE, op, outcome, time.Since(started))
 	slog.LogAttrs(ctx, slog.LevelInfo, "here_call",
 		slog.String("op", op),
 		slog.String("caller", resolveCaller(ctx)),
-		slog.String("outcome", outcomeOf(ctx, status, err)),
+		slog.String("outcome", outcome),
 		slog.Int("status", status),
 		slog.Int64("dur_ms", time.Since(started).Milliseconds()),
 	)
@@ -143,22 +148,8 @@ func logCall(ctx context.Context, op string, status int, started time.Time, err
 // retryable failures from permanent ones. Ranking callers without it is
 // misleading: a retried path (backend-load retries route calls three times)
 // inflates on a HERE outage, so the loudest caller in a sample may be the
-// unluckiest rather than the most frequent.
+// unluckiest rather than the most frequent. The switch itself is
+// metrics.Outcome, shared so the log line and the metric agree.
 func outcomeOf(ctx context.Context, status int, err error) string {
-	switch {
-	case err == nil:
-		return "ok"
-	case ctx != nil && ctx.Err() != nil:
-		return "ctx_canceled"
-	case IsAuthError(err):
-		return "auth"
-	case status >= 500:
-		return "http_5xx"
-	case status >= 400:
-		return "http_4xx"
-	case status > 0:
-		return "http_other"
-	default:
-		return "transport"
-	}
+	return metrics.Outcome(ctx, status, err, IsAuthError(err))
 }
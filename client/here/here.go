@@ -1,6 +1,7 @@
 package here
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -12,6 +13,8 @@ import (
 	"time"
 
 	"github.com/TMS360/backend-pkg/config"
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/quota"
 )
 
 // ErrInvalidCredentials is returned by TestConnection when the HERE API
@@ -20,10 +23,12 @@ var ErrInvalidCredentials = errors.New("here: invalid credentials")
 
 // Billed HERE endpoints, as reported in the "op" attribute of here_call.
 const (
-	opRoutes     = "routes"
-	opGeocode    = "geocode"
-	opRevgeocode = "revgeocode"
-	opLookup     = "lookup"
+	opRoutes       = "routes"
+	opGeocode      = "geocode"
+	opRevgeocode   = "revgeocode"
+	opLookup       = "lookup"
+	opAutocomplete = "autocomplete"
+	opMatrix       = "matrix"
 	// opTestConn is the credential probe behind the Settings "Test connection"
 	// button. It bills like any other revgeocode, so it is counted separately
 	// rather than folded into opRevgeocode, which would misattribute it to
@@ -48,6 +53,17 @@ func IsAuthError(err error) bool {
 	return errors.As(err, &ae)
 }
 
+// StatusError is returned by doRequest for any other non-2xx response, so
+// callers can tell a HERE outage (5xx) from a bad request.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-2xx status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
 // Route API models
 type RouteRequest struct {
 	Origin        Coordinates
@@ -93,6 +109,7 @@ type RouteSection struct {
 	Actions  []RouteAction `json:"actions,omitempty"`
 	Spans    []RouteSpan   `json:"spans,omitempty"`
 	Notices  []RouteNotice `json:"notices,omitempty"`
+	Tolls    []RouteToll   `json:"tolls,omitempty"`
 	Language string        `json:"language,omitempty"`
 }
 
@@ -189,15 +206,21 @@ type Scoring struct {
 
 // Client struct
 type Client struct {
-	httpClient  *http.Client
-	routerHost  string
-	geocodeHost string
-	lookupHost  string
-	apiKey      string
+	httpClient       *http.Client
+	routerHost       string
+	geocodeHost      string
+	lookupHost       string
+	autocompleteHost string
+	matrixHost       string
+	apiKey           string
+	meter            *quota.Meter
+	// tolls adds `return=tolls` to the truck routes behind Service (see
+	// WithTollCosts).
+	tolls bool
 }
 
 // NewClient creates a new HERE API client
-func NewClient(cfg config.HereConfig, apiKey string) (*Client, error) {
+func NewClient(cfg config.HereConfig, apiKey string, opts ...Option) (*Client, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("API key cannot be empty")
 	}
@@ -217,28 +240,34 @@ func NewClient(cfg config.HereConfig, apiKey string) (*Client, error) {
 		lookupHost = "https://lookup.search.hereapi.com"
 	}
 
-	return &Client{
-		httpClient:  &http.Client{Timeout: 30 * time.Second},
-		routerHost:  routerHost,
-		geocodeHost: geocodeHost,
-		lookupHost:  lookupHost,
-		apiKey:      apiKey,
-	}, nil
+	autocompleteHost := cfg.AutocompleteHost
+	if autocompleteHost == "" {
+		autocompleteHost = "https://autocomplete.search.hereapi.com"
+	}
+
+	matrixHost := cfg.MatrixHost
+	if matrixHost == "" {
+		matrixHost = "https://matrix.router.hereapi.com"
+	}
+
+	c := &Client{
+		httpClient:       transport.HTTPClient(),
+		routerHost:       routerHost,
+		geocodeHost:      geocodeHost,
+		lookupHost:       lookupHost,
+		autocompleteHost: autocompleteHost,
+		matrixHost:       matrixHost,
+		apiKey:           apiKey,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewClientWithToken creates a new HERE API client with just an API key
 func NewClientWithToken(apiKey string) (*Client, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("API key cannot be empty")
-	}
-
-	return &Client{
-		httpClient:  &http.Client{Timeout: 30 * time.Second},
-		routerHost:  "https://router.hereapi.com",
-		geocodeHost: "https://geocode.search.hereapi.com",
-		lookupHost:  "https://lookup.search.hereapi.com",
-		apiKey:      apiKey,
-	}, nil
+	return NewClient(config.HereConfig{}, apiKey)
 }
 
 // doRequest performs HTTP request.
@@ -247,22 +276,41 @@ func NewClientWithToken(apiKey string) (*Client, error) {
 // and is passed in rather than parsed back out of fullURL, which carries the
 // apiKey and must never be logged.
 func (c *Client) doRequest(ctx context.Context, method, op, fullURL string) (*http.Response, error) {
+	return c.doRequestJSON(ctx, method, op, fullURL, nil, 1)
+}
+
+// doRequestJSON is doRequest with a JSON request body (nil for none). units
+// is what HERE bills the call as — 1, or a matrix's element count — and is
+// what the quota meter counts.
+func (c *Client) doRequestJSON(ctx context.Context, method, op, fullURL string, payload []byte, units int64) (*http.Response, error) {
 	started := time.Now()
+	if err := c.quota().CheckN(ctx, metrics.ProviderHERE, op, units); err != nil {
+		return nil, err
+	}
 
-	req, err := http.NewRequestWithContext(ctx, method, fullURL, nil)
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		// Never reached HERE, so nothing was billed — logged anyway so a
 		// network-level outage is visible in the same sample.
 		logCall(ctx, op, 0, started, err)
+		c.quota().RecordN(ctx, metrics.ProviderHERE, op, 0, units)
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
+	c.quota().RecordN(ctx, metrics.ProviderHERE, op, resp.StatusCode, units)
 
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
 		defer func() { _ = resp.Body.Close() }()
@@ -280,7 +328,7 @@ func (c *Client) doRequest(ctx context.Context, method, op, fullURL string) (*ht
 			logCall(ctx, op, resp.StatusCode, started, statusErr)
 			return nil, statusErr
 		}
-		statusErr := fmt.Errorf("received non-2xx status code: %d, body: %s", resp.StatusCode, string(body))
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 		logCall(ctx, op, resp.StatusCode, started, statusErr)
 		return nil, statusErr
 	}
@@ -377,12 +425,20 @@ func (c *Client) GetTruckRoute(ctx context.Context, origin, destination Coordina
 		DepartureTime: departureTime,
 		TransportMode: "truck",
 		Currency:      "USD",
-		ReturnOptions: []string{"summary", "polyline"},
+		ReturnOptions: c.truckReturnOptions(),
 	}
 
 	return c.GetRoute(ctx, req)
 }
 
+// truckReturnOptions is the `return=` of the truck routes behind Service.
+func (c *Client) truckReturnOptions() []string {
+	if c.tolls {
+		return []string{"summary", "polyline", "tolls"}
+	}
+	return []string{"summary", "polyline"}
+}
+
 // Geocode converts an address to coordinates
 func (c *Client) Geocode(ctx context.Context, req GeocodeRequest) (*GeocodeResponse, error) {
 	params := url.Values{}
@@ -498,6 +554,54 @@ func (c *Client) CalculateDistance(ctx context.Context, origin, destination Coor
 	return summary.Length, summary.Duration, nil
 }
 
+// AutocompleteRequest contains parameters for the Autocomplete API.
+type AutocompleteRequest struct {
+	Query    string       // partial address as typed
+	At       *Coordinates // proximity bias
+	Limit    int
+	Language string
+}
+
+// Autocomplete returns address suggestions for a partial query. Items carry
+// an ID and address but no Position; resolve the chosen one with LookupByID.
+// Like Geocode it searches the US unless told otherwise — there is no `in`
+// override yet because no caller needs one.
+func (c *Client) Autocomplete(ctx context.Context, req AutocompleteRequest) (*GeocodeResponse, error) {
+	if req.Query == "" {
+		return nil, fmt.Errorf("query cannot be empty")
+	}
+
+	params := url.Values{}
+	params.Set("q", req.Query)
+	params.Set("apiKey", c.apiKey)
+	params.Set("in", "countryCode:USA")
+
+	if req.At != nil {
+		params.Set("at", fmt.Sprintf("%f,%f", req.At.Latitude, req.At.Longitude))
+	}
+	if req.Limit > 0 {
+		params.Set("limit", strconv.Itoa(req.Limit))
+	}
+	if req.Language != "" {
+		params.Set("lang", req.Language)
+	}
+
+	fullURL := fmt.Sprintf("%s/v1/autocomplete?%s", c.autocompleteHost, params.Encode())
+
+	resp, err := c.doRequest(ctx, http.MethodGet, opAutocomplete, fullURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to autocomplete: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out GeocodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode autocomplete response: %w", err)
+	}
+
+	return &out, nil
+}
+
 // LookupRequest contains parameters for the Lookup API
 type LookupRequest struct {
 	ID       string // Required: HERE ID of the place to look up
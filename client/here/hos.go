@@ -0,0 +1,191 @@
+package here
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoLegs is returned by HOSAwareETA for a route without legs.
+var ErrNoLegs = errors.New("here: route has no legs")
+
+// HOSRules are the hours-of-service limits the ETA calculator plans with.
+type HOSRules struct {
+	MaxDriving        time.Duration // driving allowed between resets
+	DutyWindow        time.Duration // on-duty window that opens after a reset
+	DrivingUntilBreak time.Duration // cumulative driving before a break is due
+	Break             time.Duration
+	Reset             time.Duration // off duty that restores every clock
+}
+
+// PropertyCarrierRules are the FMCSA limits for property-carrying drivers
+// (49 CFR 395.3): 11 hours driving within a 14-hour window, a 30-minute break
+// after 8 hours of driving, and 10 consecutive hours off duty to reset. The
+// 60/70-hour cycle is not planned: a multi-stop route rarely spans it, and
+// the remaining cycle belongs to dispatch's driver pick, not to the ETA.
+var PropertyCarrierRules = HOSRules{
+	MaxDriving:        11 * time.Hour,
+	DutyWindow:        14 * time.Hour,
+	DrivingUntilBreak: 8 * time.Hour,
+	Break:             30 * time.Minute,
+	Reset:             10 * time.Hour,
+}
+
+// DriverClock is what is left of a driver's clocks at departure, as the ELD
+// reports it.
+type DriverClock struct {
+	Driving    time.Duration // left of MaxDriving
+	Window     time.Duration // left of DutyWindow
+	UntilBreak time.Duration // driving left before the break is due
+}
+
+// FreshClock is the clock of a driver coming off a reset.
+func (r HOSRules) FreshClock() DriverClock {
+	return DriverClock{Driving: r.MaxDriving, Window: r.DutyWindow, UntilBreak: r.DrivingUntilBreak}
+}
+
+// HOSStopKind tells a break from a reset.
+type HOSStopKind string
+
+const (
+	HOSBreak HOSStopKind = "break"
+	HOSReset HOSStopKind = "reset"
+)
+
+// HOSStop is a mandatory stop the calculator inserted.
+type HOSStop struct {
+	Kind     HOSStopKind
+	LegIndex int
+	Start    time.Time
+	Duration time.Duration
+	// DrivenInLeg is the driving done on the leg before the stop, and
+	// DistanceIntoLegMeters its share of the leg's distance — roughly where
+	// the driver has to find parking.
+	DrivenInLeg           time.Duration
+	DistanceIntoLegMeters int
+}
+
+// HOSLegETA is one leg of a route with HOS stops applied.
+type HOSLegETA struct {
+	Index     int
+	Departure time.Time
+	Arrival   time.Time
+	// Driving is the leg's drive time the plan used (traffic-aware when the
+	// route has it).
+	Driving time.Duration
+	Stops   []HOSStop
+}
+
+// HOSPlan is the HOS-aware schedule of a route.
+type HOSPlan struct {
+	Legs    []HOSLegETA
+	Arrival time.Time // at the last stop
+	Breaks  int
+	Resets  int
+	// Clock is what the driver has left on arrival at the last stop.
+	Clock DriverClock
+}
+
+// HOSOption configures HOSAwareETA.
+type HOSOption func(*hosConfig)
+
+type hosConfig struct {
+	rules   HOSRules
+	service time.Duration
+}
+
+// WithHOSRules plans with rules instead of PropertyCarrierRules.
+func WithHOSRules(rules HOSRules) HOSOption {
+	return func(c *hosConfig) { c.rules = rules }
+}
+
+// WithStopServiceTime spends d on duty, not driving, at every intermediate
+// stop (loading, unloading, paperwork). It runs down the duty window, and a
+// stop of at least the break length satisfies a due break, as on-duty time
+// does under the 2020 rule.
+func WithStopServiceTime(d time.Duration) HOSOption {
+	return func(c *hosConfig) { c.service = d }
+}
+
+// HOSAwareETA schedules route, as returned by CalculateMultiStopRoute, for a
+// driver leaving at departure with clock left, inserting a break whenever the
+// driving since the last break runs out and a reset whenever the driving
+// limit or duty window does. A due break taken too late in the window to
+// drive after it becomes a reset.
+//
+// Legs drive for DurationWithTrafficSeconds when set, DurationSeconds
+// otherwise. Stops are placed exactly where a clock runs out; a driver will
+// stop somewhat earlier, at parking, so the plan is a lower bound.
+func HOSAwareETA(route *MultiStopRouteInfo, departure time.Time, clock DriverClock, opts ...HOSOption) (*HOSPlan, error) {
+	if route == nil || len(route.Legs) == 0 {
+		return nil, ErrNoLegs
+	}
+	cfg := hosConfig{rules: PropertyCarrierRules}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	rules := cfg.rules
+	if rules.MaxDriving <= 0 || rules.DutyWindow <= 0 || rules.DrivingUntilBreak <= 0 || rules.Reset <= 0 {
+		return nil, fmt.Errorf("here: HOS rules need positive driving, window, break interval and reset")
+	}
+	if clock.Driving < 0 || clock.Window < 0 || clock.UntilBreak < 0 {
+		return nil, fmt.Errorf("here: negative driver clock %+v", clock)
+	}
+
+	plan := &HOSPlan{Legs: make([]HOSLegETA, 0, len(route.Legs))}
+	now := departure
+
+	for i, leg := range route.Legs {
+		if i > 0 && cfg.service > 0 {
+			now = now.Add(cfg.service)
+			clock.Window = max(clock.Window-cfg.service, 0)
+			if cfg.service >= rules.Break {
+				clock.UntilBreak = rules.DrivingUntilBreak
+			}
+		}
+
+		seconds := leg.DurationWithTrafficSeconds
+		if seconds <= 0 {
+			seconds = leg.DurationSeconds
+		}
+		driving := time.Duration(seconds) * time.Second
+		eta := HOSLegETA{Index: leg.Index, Departure: now, Driving: driving}
+
+		var driven time.Duration
+		for driven < driving {
+			avail := min(clock.Driving, clock.Window, clock.UntilBreak)
+			if avail <= 0 {
+				stop := HOSStop{LegIndex: leg.Index, Start: now, DrivenInLeg: driven}
+				if driving > 0 {
+					stop.DistanceIntoLegMeters = int(float64(leg.DistanceMeters) * float64(driven) / float64(driving))
+				}
+				if clock.Driving <= 0 || clock.Window <= rules.Break {
+					stop.Kind, stop.Duration = HOSReset, rules.Reset
+					clock = rules.FreshClock()
+					plan.Resets++
+				} else {
+					stop.Kind, stop.Duration = HOSBreak, rules.Break
+					clock.Window -= rules.Break
+					clock.UntilBreak = rules.DrivingUntilBreak
+					plan.Breaks++
+				}
+				now = now.Add(stop.Duration)
+				eta.Stops = append(eta.Stops, stop)
+				continue
+			}
+			step := min(avail, driving-driven)
+			now = now.Add(step)
+			driven += step
+			clock.Driving -= step
+			clock.Window -= step
+			clock.UntilBreak -= step
+		}
+
+		eta.Arrival = now
+		plan.Legs = append(plan.Legs, eta)
+	}
+
+	plan.Arrival = now
+	plan.Clock = clock
+	return plan, nil
+}
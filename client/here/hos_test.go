@@ -0,0 +1,140 @@
+package here
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func hosRoute(legHours ...float64) *MultiStopRouteInfo {
+	r := &MultiStopRouteInfo{}
+	for i, h := range legHours {
+		r.Legs = append(r.Legs, RouteLegInfo{
+			Index:           i,
+			DistanceMeters:  int(h * 100000), // 100 km/h
+			DurationSeconds: int(h * 3600),
+		})
+	}
+	return r
+}
+
+var hosDepart = time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+
+func TestHOSAwareETA_FitsWithoutStops(t *testing.T) {
+	plan, err := HOSAwareETA(hosRoute(3, 4), hosDepart, PropertyCarrierRules.FreshClock())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if plan.Breaks != 0 || plan.Resets != 0 {
+		t.Errorf("breaks=%d resets=%d, want none", plan.Breaks, plan.Resets)
+	}
+	if want := hosDepart.Add(7 * time.Hour); !plan.Arrival.Equal(want) {
+		t.Errorf("arrival=%v, want %v", plan.Arrival, want)
+	}
+	if plan.Clock.Driving != 4*time.Hour || plan.Clock.UntilBreak != time.Hour {
+		t.Errorf("clock left=%+v", plan.Clock)
+	}
+}
+
+// 20 hours of driving on a fresh clock: break at 8h, reset at 11h, then a
+// fresh 9 hours, which needs another break at 8h.
+func TestHOSAwareETA_BreaksAndResets(t *testing.T) {
+	plan, err := HOSAwareETA(hosRoute(20), hosDepart, PropertyCarrierRules.FreshClock())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	stops := plan.Legs[0].Stops
+	if len(stops) != 3 {
+		t.Fatalf("stops=%+v, want break, reset, break", stops)
+	}
+	want := []struct {
+		kind   HOSStopKind
+		start  time.Duration
+		driven time.Duration
+	}{
+		{HOSBreak, 8 * time.Hour, 8 * time.Hour},
+		{HOSReset, 11*time.Hour + 30*time.Minute, 11 * time.Hour},
+		{HOSBreak, 29*time.Hour + 30*time.Minute, 19 * time.Hour},
+	}
+	for i, w := range want {
+		if stops[i].Kind != w.kind || !stops[i].Start.Equal(hosDepart.Add(w.start)) || stops[i].DrivenInLeg != w.driven {
+			t.Errorf("stop %d=%+v, want %s at +%v after %v driving", i, stops[i], w.kind, w.start, w.driven)
+		}
+	}
+	if stops[1].DistanceIntoLegMeters != 1100000 {
+		t.Errorf("reset at %d m, want 1100000", stops[1].DistanceIntoLegMeters)
+	}
+	if want := hosDepart.Add(20*time.Hour + 30*time.Minute + 10*time.Hour + 30*time.Minute); !plan.Arrival.Equal(want) {
+		t.Errorf("arrival=%v, want %v", plan.Arrival, want)
+	}
+}
+
+// A driver with 2 hours left resets before the second hour of a 3-hour leg;
+// the reset lands mid-leg, not at the next stop.
+func TestHOSAwareETA_UsesRemainingClock(t *testing.T) {
+	clock := DriverClock{Driving: 2 * time.Hour, Window: 5 * time.Hour, UntilBreak: 6 * time.Hour}
+	plan, err := HOSAwareETA(hosRoute(1, 3), hosDepart, clock)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if plan.Resets != 1 || len(plan.Legs[1].Stops) != 1 || plan.Legs[1].Stops[0].DrivenInLeg != time.Hour {
+		t.Fatalf("legs=%+v", plan.Legs)
+	}
+	if !plan.Legs[0].Arrival.Equal(hosDepart.Add(time.Hour)) {
+		t.Errorf("leg 0 arrival=%v", plan.Legs[0].Arrival)
+	}
+	if want := hosDepart.Add(14 * time.Hour); !plan.Arrival.Equal(want) {
+		t.Errorf("arrival=%v, want %v", plan.Arrival, want)
+	}
+}
+
+// A due break with no window left to drive after it is a reset.
+func TestHOSAwareETA_LateBreakBecomesReset(t *testing.T) {
+	clock := DriverClock{Driving: 5 * time.Hour, Window: 90 * time.Minute, UntilBreak: 70 * time.Minute}
+	plan, err := HOSAwareETA(hosRoute(2), hosDepart, clock)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if plan.Breaks != 0 || plan.Resets != 1 {
+		t.Errorf("breaks=%d resets=%d, want 0/1", plan.Breaks, plan.Resets)
+	}
+}
+
+func TestHOSAwareETA_ServiceTimeAndTraffic(t *testing.T) {
+	route := hosRoute(7, 2)
+	route.Legs[0].DurationWithTrafficSeconds = int((7*time.Hour + 30*time.Minute).Seconds())
+	clock := PropertyCarrierRules.FreshClock()
+
+	// Without service the 8-hour break falls in leg 1; an hour at the
+	// stop satisfies it.
+	plan, err := HOSAwareETA(route, hosDepart, clock, WithStopServiceTime(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if plan.Breaks != 0 {
+		t.Errorf("breaks=%d, want 0", plan.Breaks)
+	}
+	if !plan.Legs[1].Departure.Equal(hosDepart.Add(8*time.Hour + 30*time.Minute)) {
+		t.Errorf("leg 1 departs %v", plan.Legs[1].Departure)
+	}
+
+	plan, err = HOSAwareETA(route, hosDepart, clock)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if plan.Breaks != 1 || plan.Legs[1].Stops[0].DrivenInLeg != 30*time.Minute {
+		t.Errorf("legs=%+v", plan.Legs)
+	}
+}
+
+func TestHOSAwareETA_Errors(t *testing.T) {
+	if _, err := HOSAwareETA(&MultiStopRouteInfo{}, hosDepart, DriverClock{}); !errors.Is(err, ErrNoLegs) {
+		t.Errorf("err=%v, want ErrNoLegs", err)
+	}
+	if _, err := HOSAwareETA(hosRoute(1), hosDepart, DriverClock{Driving: -time.Hour}); err == nil {
+		t.Error("negative clock accepted")
+	}
+	if _, err := HOSAwareETA(hosRoute(1), hosDepart, DriverClock{}, WithHOSRules(HOSRules{})); err == nil {
+		t.Error("zero rules accepted")
+	}
+}
@@ -0,0 +1,129 @@
+package here
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Synchronous Matrix Routing v8 limits for a `world` region: larger matrices
+// need the async flow, which we do not use. Split at the call site.
+const (
+	MaxMatrixOrigins      = 15
+	MaxMatrixDestinations = 100
+)
+
+// MatrixRequest asks for travel times and distances from every origin to
+// every destination.
+type MatrixRequest struct {
+	Origins      []Coordinates
+	Destinations []Coordinates
+	// TransportMode defaults to truck.
+	TransportMode string
+	// DepartureTime nil asks for a time-agnostic matrix (departureTime=any):
+	// no traffic, and cheaper to compute.
+	DepartureTime *time.Time
+}
+
+// MatrixResponse is the computed matrix. The slices are row-major: the entry
+// for origin i and destination j is at i*NumDestinations+j.
+type MatrixResponse struct {
+	NumOrigins      int   `json:"numOrigins"`
+	NumDestinations int   `json:"numDestinations"`
+	TravelTimes     []int `json:"travelTimes"` // seconds
+	Distances       []int `json:"distances"`   // meters
+	// ErrorCodes is present only when some entry failed; non-zero means no
+	// route for that pair.
+	ErrorCodes []int `json:"errorCodes,omitempty"`
+}
+
+// At returns the distance and travel time from origin i to destination j;
+// ok is false when HERE found no route for the pair.
+func (m *MatrixResponse) At(i, j int) (distanceMeters, durationSeconds int, ok bool) {
+	k := i*m.NumDestinations + j
+	if k < len(m.ErrorCodes) && m.ErrorCodes[k] != 0 {
+		return 0, 0, false
+	}
+	if k >= len(m.Distances) || k >= len(m.TravelTimes) {
+		return 0, 0, false
+	}
+	return m.Distances[k], m.TravelTimes[k], true
+}
+
+type matrixPoint struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type matrixRequestBody struct {
+	Origins          []matrixPoint     `json:"origins"`
+	Destinations     []matrixPoint     `json:"destinations"`
+	RegionDefinition map[string]string `json:"regionDefinition"`
+	TransportMode    string            `json:"transportMode"`
+	MatrixAttributes []string          `json:"matrixAttributes"`
+	DepartureTime    string            `json:"departureTime"`
+}
+
+// CalculateMatrix computes a distance matrix in one synchronous call, billed
+// per origin × destination element — still far cheaper and faster than one
+// GetRoute per pair.
+func (c *Client) CalculateMatrix(ctx context.Context, req MatrixRequest) (*MatrixResponse, error) {
+	if len(req.Origins) == 0 || len(req.Destinations) == 0 {
+		return nil, fmt.Errorf("matrix needs origins and destinations")
+	}
+	if len(req.Origins) > MaxMatrixOrigins || len(req.Destinations) > MaxMatrixDestinations {
+		return nil, fmt.Errorf("matrix of %d×%d exceeds the synchronous limit of %d×%d",
+			len(req.Origins), len(req.Destinations), MaxMatrixOrigins, MaxMatrixDestinations)
+	}
+
+	body := matrixRequestBody{
+		RegionDefinition: map[string]string{"type": "world"},
+		TransportMode:    req.TransportMode,
+		MatrixAttributes: []string{"travelTimes", "distances"},
+		DepartureTime:    "any",
+	}
+	if body.TransportMode == "" {
+		body.TransportMode = "truck"
+	}
+	if req.DepartureTime != nil {
+		body.DepartureTime = req.DepartureTime.Format(time.RFC3339)
+	}
+	for _, o := range req.Origins {
+		body.Origins = append(body.Origins, matrixPoint{Lat: o.Latitude, Lng: o.Longitude})
+	}
+	for _, d := range req.Destinations {
+		body.Destinations = append(body.Destinations, matrixPoint{Lat: d.Latitude, Lng: d.Longitude})
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode matrix request: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("async", "false")
+	params.Set("apiKey", c.apiKey)
+	fullURL := fmt.Sprintf("%s/v8/matrix?%s", c.matrixHost, params.Encode())
+
+	// HERE bills a matrix per element, and the tenant's budget counts the same.
+	units := int64(len(req.Origins) * len(req.Destinations))
+	resp, err := c.doRequestJSON(ctx, http.MethodPost, opMatrix, fullURL, payload, units)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate matrix: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Matrix MatrixResponse `json:"matrix"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode matrix response: %w", err)
+	}
+	if out.Matrix.NumOrigins != len(req.Origins) || out.Matrix.NumDestinations != len(req.Destinations) {
+		return nil, fmt.Errorf("matrix response is %d×%d, asked for %d×%d",
+			out.Matrix.NumOrigins, out.Matrix.NumDestinations, len(req.Origins), len(req.Destinations))
+	}
+	return &out.Matrix, nil
+}
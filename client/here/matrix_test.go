@@ -0,0 +1,104 @@
+package here
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalculateMatrix_OneCallForAllPairs(t *testing.T) {
+	var body []byte
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"matrix":{"numOrigins":2,"numDestinations":2,
+			"travelTimes":[0,600,700,0],"distances":[0,9000,9500,0],"errorCodes":[0,0,3,0]}}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{httpClient: srv.Client(), matrixHost: srv.URL, apiKey: "test-key"}
+
+	departure := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	got, err := c.CalculateMatrix(context.Background(), MatrixRequest{
+		Origins:       []Coordinates{{Latitude: 41, Longitude: -87}, {Latitude: 42, Longitude: -88}},
+		Destinations:  []Coordinates{{Latitude: 41, Longitude: -87}, {Latitude: 42, Longitude: -88}},
+		DepartureTime: &departure,
+	})
+	if err != nil {
+		t.Fatalf("CalculateMatrix: %v", err)
+	}
+
+	if !strings.Contains(query, "async=false") {
+		t.Errorf("query=%q, want the synchronous flow", query)
+	}
+	var sent matrixRequestBody
+	if err := json.Unmarshal(body, &sent); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if sent.TransportMode != "truck" {
+		t.Errorf("transportMode=%q, want truck", sent.TransportMode)
+	}
+	if sent.DepartureTime != "2026-03-02T08:00:00Z" {
+		t.Errorf("departureTime=%q", sent.DepartureTime)
+	}
+	if sent.RegionDefinition["type"] != "world" {
+		t.Errorf("regionDefinition=%v, want world", sent.RegionDefinition)
+	}
+	if len(sent.Origins) != 2 || sent.Origins[1].Lat != 42 {
+		t.Errorf("origins=%+v", sent.Origins)
+	}
+
+	if d, s, ok := got.At(0, 1); !ok || d != 9000 || s != 600 {
+		t.Errorf("At(0,1)=%d,%d,%v want 9000,600,true", d, s, ok)
+	}
+	if _, _, ok := got.At(1, 0); ok {
+		t.Error("At(1,0) should report the error code as no route")
+	}
+}
+
+func TestCalculateMatrix_DefaultsToTimeAgnostic(t *testing.T) {
+	svc, _, _ := newCountingService(t, `{"matrix":{"numOrigins":1,"numDestinations":1,"travelTimes":[60],"distances":[1000]}}`)
+	c := svc.client
+
+	got, err := c.CalculateMatrix(context.Background(), MatrixRequest{
+		Origins:      []Coordinates{{Latitude: 41, Longitude: -87}},
+		Destinations: []Coordinates{{Latitude: 42, Longitude: -88}},
+	})
+	if err != nil {
+		t.Fatalf("CalculateMatrix: %v", err)
+	}
+	if d, s, ok := got.At(0, 0); !ok || d != 1000 || s != 60 {
+		t.Errorf("At(0,0)=%d,%d,%v want 1000,60,true", d, s, ok)
+	}
+}
+
+func TestCalculateMatrix_RejectsOversizedAndMismatched(t *testing.T) {
+	svc, calls, _ := newCountingService(t, `{"matrix":{"numOrigins":1,"numDestinations":1,"travelTimes":[60],"distances":[1000]}}`)
+	c := svc.client
+
+	_, err := c.CalculateMatrix(context.Background(), MatrixRequest{
+		Origins:      make([]Coordinates, MaxMatrixOrigins+1),
+		Destinations: make([]Coordinates, 1),
+	})
+	if err == nil || !strings.Contains(err.Error(), "synchronous limit") {
+		t.Errorf("oversized matrix err=%v, want the limit named", err)
+	}
+	if calls() != 0 {
+		t.Errorf("oversized matrix made %d calls, want 0", calls())
+	}
+
+	// A matrix of the wrong shape would index the wrong pairs.
+	_, err = c.CalculateMatrix(context.Background(), MatrixRequest{
+		Origins:      make([]Coordinates, 2),
+		Destinations: make([]Coordinates, 1),
+	})
+	if err == nil {
+		t.Error("expected an error for a 1×1 answer to a 2×1 request")
+	}
+}
@@ -21,6 +21,9 @@ type TruckNavRequest struct {
 	Lang          string // default "en-US"
 	Currency      string // default "USD"
 	Alternatives  int
+	// IncludeTolls requests toll costs (TruckNavResult.Tolls), priced in
+	// Currency. HERE bills a route with tolls at a higher rate.
+	IncludeTolls bool
 }
 
 // TruckNavMultiStopRequest is the multi-stop variant; all stops in one HERE call.
@@ -32,6 +35,7 @@ type TruckNavMultiStopRequest struct {
 	Lang          string
 	Currency      string
 	Alternatives  int
+	IncludeTolls  bool
 }
 
 // TruckNavResult is a parsed, navigation-ready route.
@@ -45,6 +49,7 @@ type TruckNavResult struct {
 	PolylineDecoded     []DecodedCoordinate
 	Steps               []NavStep
 	Notices             []RouteNotice
+	Tolls               *TollCost         // nil unless IncludeTolls and the route has tolls
 	Alternatives        []*TruckNavResult `json:",omitempty"`
 }
 
@@ -95,10 +100,21 @@ var navReturnOptions = []string{
 	"travelSummary",
 }
 
+// navReturn is navReturnOptions, plus tolls when asked for.
+func navReturn(tolls bool) []string {
+	if !tolls {
+		return navReturnOptions
+	}
+	return append(append([]string(nil), navReturnOptions...), "tolls")
+}
+
 func (s *navigationService) BuildTruckRoute(ctx context.Context, req TruckNavRequest) (*TruckNavResult, error) {
 	if s.client == nil {
 		return nil, fmt.Errorf("here: navigation client is not configured")
 	}
+	if err := req.Truck.Validate(); err != nil {
+		return nil, err
+	}
 
 	currency := req.Currency
 	if currency == "" {
@@ -115,7 +131,7 @@ func (s *navigationService) BuildTruckRoute(ctx context.Context, req TruckNavReq
 		DepartureTime: req.DepartureTime,
 		TransportMode: "truck",
 		Currency:      currency,
-		ReturnOptions: navReturnOptions,
+		ReturnOptions: navReturn(req.IncludeTolls),
 		TruckAttrs:    cloneTruck(req.Truck),
 		Avoid:         req.Avoid,
 		Alternatives:  req.Alternatives,
@@ -145,6 +161,9 @@ func (s *navigationService) BuildTruckRouteMultiStop(ctx context.Context, req Tr
 	if len(req.Stops) < 2 {
 		return nil, fmt.Errorf("here: multi-stop requires at least 2 stops")
 	}
+	if err := req.Truck.Validate(); err != nil {
+		return nil, err
+	}
 
 	via := []Coordinates{}
 	if len(req.Stops) > 2 {
@@ -178,7 +197,7 @@ func (s *navigationService) BuildTruckRouteMultiStop(ctx context.Context, req Tr
 		DepartureTime: rr.DepartureTime,
 		TransportMode: "truck",
 		Currency:      currency,
-		ReturnOptions: navReturnOptions,
+		ReturnOptions: navReturn(req.IncludeTolls),
 		Via:           via,
 		TruckAttrs:    cloneTruck(rr.Truck),
 		Avoid:         rr.Avoid,
@@ -281,6 +300,8 @@ func buildResultFromRoute(r Route, departureTime *time.Time) *TruckNavResult {
 		}
 	}
 
+	out.Tolls = summarizeTolls(r.Sections)
+
 	// Fill PolyEnd by chaining adjacent steps; last step ends at last point.
 	for i := range out.Steps {
 		if i+1 < len(out.Steps) {
@@ -2,6 +2,7 @@ package here
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -41,7 +42,7 @@ func TestBuildTruckRoute_URLContainsTruckParams(t *testing.T) {
 		Truck: TruckAttributes{
 			GrossWeightKg:         36000,
 			HeightCm:              400,
-			ShippedHazardousGoods: []string{"flammable"},
+			ShippedHazardousGoods: []string{string(HazmatFlammable)},
 		},
 	})
 	if err != nil {
@@ -168,3 +169,84 @@ func TestSanitizeOffset(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildTruckRoute_RejectsUnknownHazmatBeforeCalling(t *testing.T) {
+	var got *http.Request
+	svc := newTestNavService(t, `{"routes":[]}`, &got)
+
+	for _, truck := range []TruckAttributes{
+		{ShippedHazardousGoods: []string{string(HazmatCorrosive), "radioActive"}},
+		{TunnelCategory: "A"},
+	} {
+		_, err := svc.BuildTruckRoute(context.Background(), TruckNavRequest{Truck: truck})
+		if !errors.Is(err, ErrInvalidTruck) {
+			t.Errorf("truck %+v: err=%v, want ErrInvalidTruck", truck, err)
+		}
+	}
+	if got != nil {
+		t.Error("an invalid truck must not cost a HERE call")
+	}
+}
+
+func TestBuildTruckRoute_HazmatAndTunnelParams(t *testing.T) {
+	body := `{"routes":[{"id":"r1","sections":[{"id":"s1","summary":{"length":1,"duration":1,"baseDuration":1}}]}]}`
+	var got *http.Request
+	svc := newTestNavService(t, body, &got)
+
+	_, err := svc.BuildTruckRoute(context.Background(), TruckNavRequest{
+		Truck: TruckAttributes{
+			TunnelCategory:        string(TunnelCategoryD),
+			ShippedHazardousGoods: []string{string(HazmatFlammable), string(HazmatPoisonousInhalation)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	q := got.URL.Query()
+	if q.Get("vehicle[tunnelCategory]") != "D" {
+		t.Errorf("tunnelCategory=%q, want D", q.Get("vehicle[tunnelCategory]"))
+	}
+	if q.Get("vehicle[shippedHazardousGoods]") != "flammable,poisonousInhalation" {
+		t.Errorf("hazmat=%q", q.Get("vehicle[shippedHazardousGoods]"))
+	}
+	if strings.Contains(q.Get("return"), "tolls") {
+		t.Errorf("return=%q requests tolls without IncludeTolls", q.Get("return"))
+	}
+}
+
+func TestBuildTruckRoute_TollCost(t *testing.T) {
+	body := `{"routes":[{"id":"r1","sections":[
+		{"id":"s1","summary":{"length":1000,"duration":120,"baseDuration":100},"tolls":[
+			{"countryCode":"USA","tollSystem":"Illinois Tollway","fares":[
+				{"id":"f1","price":{"type":"value","currency":"USD","value":8.5},"paymentMethods":["cash"]},
+				{"id":"f2","price":{"type":"value","currency":"USD","value":4.25},"paymentMethods":["transponder"]}
+			],"tollCollectionLocations":[{"name":"Plaza 9","location":{"lat":41.8,"lng":-88.1}}]}
+		]},
+		{"id":"s2","summary":{"length":1000,"duration":120,"baseDuration":100},"tolls":[
+			{"countryCode":"CAN","tollSystem":"407 ETR","fares":[
+				{"id":"f3","price":{"type":"range","currency":"CAD","minimum":10,"maximum":14},
+				 "convertedPrice":{"type":"range","currency":"USD","minimum":7.3,"maximum":10.22}}
+			]}
+		]}
+	]}]}`
+	var got *http.Request
+	svc := newTestNavService(t, body, &got)
+
+	res, err := svc.BuildTruckRoute(context.Background(), TruckNavRequest{IncludeTolls: true})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !strings.Contains(got.URL.Query().Get("return"), "tolls") {
+		t.Errorf("return=%q missing tolls", got.URL.Query().Get("return"))
+	}
+	if res.Tolls == nil {
+		t.Fatal("no toll cost")
+	}
+	if res.Tolls.Currency != "USD" || res.Tolls.Total != 14.47 {
+		t.Errorf("total=%v %s, want 14.47 USD (cheapest fare, top of the converted range)", res.Tolls.Total, res.Tolls.Currency)
+	}
+	if len(res.Tolls.Charges) != 2 || res.Tolls.Charges[0].Location != "Plaza 9" ||
+		res.Tolls.Charges[0].PaymentMethods[0] != "transponder" {
+		t.Errorf("charges=%+v", res.Tolls.Charges)
+	}
+}
@@ -0,0 +1,55 @@
+package here
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/quota"
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// Option customises a Client built by NewClient.
+type Option func(*Client)
+
+// transport is the HERE policy: the breaker alone. HERE bills each router
+// and search transaction, and backend callers retry through the shared
+// mileage wrapper documented in caller.go, so a retry here would double-bill.
+// Metering happens in doRequest, so there is no metrics base.
+var transport = resilience.ClientOptions{
+	Defaults: []resilience.Middleware{resilience.HostCircuitBreaker(resilience.NewBreakerSet(metrics.ProviderHERE, resilience.BreakerConfig{}), nil)},
+	Timeout:  30 * time.Second,
+}
+
+// WithResilience replaces the default resilience stack; with no arguments
+// the client makes exactly one attempt per call. Metering is unaffected.
+func WithResilience(mws ...resilience.Middleware) Option {
+	return func(c *Client) { c.httpClient = transport.Replace(c.httpClient, mws...) }
+}
+
+// WithHTTPClient makes the client use hc as is — no metering or resilience
+// is added. For tests and callers with their own transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithMeter meters and budgets this client's calls with m instead of
+// quota.Default().
+func WithMeter(m *quota.Meter) Option {
+	return func(c *Client) { c.meter = m }
+}
+
+// WithTollCosts makes the truck routes behind Service (CalculateTruckRoute,
+// CalculateMultiStopRoute, GetETA) return toll costs in RouteInfo.Tolls and
+// MultiStopRouteInfo.Tolls. Off by default: HERE bills a route with tolls
+// as a separate, pricier transaction.
+func WithTollCosts() Option {
+	return func(c *Client) { c.tolls = true }
+}
+
+func (c *Client) quota() *quota.Meter {
+	if c.meter != nil {
+		return c.meter
+	}
+	return quota.Default()
+}
@@ -42,6 +42,9 @@ type RouteInfo struct {
 	EstimatedArrival time.Time
 	// Encoded polyline geometry for the route
 	Polyline string
+	// Toll cost, when the client was built WithTollCosts and the route has
+	// tolls; nil otherwise
+	Tolls *TollCost
 }
 
 // MultiStopRouteInfo represents route information for a multi-stop trip
@@ -52,6 +55,8 @@ type MultiStopRouteInfo struct {
 	TotalDurationSeconds int
 	// Per-leg information
 	Legs []RouteLegInfo
+	// Toll cost of the whole route (see RouteInfo.Tolls)
+	Tolls *TollCost
 }
 
 // RouteLegInfo represents one leg of a multi-stop route
@@ -66,10 +71,14 @@ type RouteLegInfo struct {
 	DistanceMeters int
 	// Duration in seconds
 	DurationSeconds int
+	// Duration with traffic in seconds
+	DurationWithTrafficSeconds int
 	// Estimated arrival at this stop
 	EstimatedArrival time.Time
 	// Encoded polyline geometry for this leg
 	Polyline string
+	// Toll cost of this leg (see RouteInfo.Tolls)
+	Tolls *TollCost
 }
 
 // Service provides HERE Maps API operations
@@ -291,7 +300,7 @@ func (s *service) CalculateMultiStopRoute(ctx context.Context, waypoints []Coord
 		DepartureTime: departure,
 		TransportMode: "truck",
 		Currency:      "USD",
-		ReturnOptions: []string{"summary", "polyline"},
+		ReturnOptions: s.client.truckReturnOptions(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate multi-stop route: %w", err)
@@ -325,15 +334,19 @@ func (s *service) CalculateMultiStopRoute(ctx context.Context, waypoints []Coord
 		}
 		arrival = arrival.Add(time.Duration(trafficDuration) * time.Second)
 
+		legTolls := summarizeTolls(sections[i : i+1])
 		result.Legs = append(result.Legs, RouteLegInfo{
-			Index:            i,
-			Origin:           waypoints[i],
-			Destination:      waypoints[i+1],
-			DistanceMeters:   distance,
-			DurationSeconds:  baseDuration,
-			EstimatedArrival: arrival,
-			Polyline:         section.Polyline,
+			Index:                      i,
+			Origin:                     waypoints[i],
+			Destination:                waypoints[i+1],
+			DistanceMeters:             distance,
+			DurationSeconds:            baseDuration,
+			DurationWithTrafficSeconds: trafficDuration,
+			EstimatedArrival:           arrival,
+			Polyline:                   section.Polyline,
+			Tolls:                      legTolls,
 		})
+		result.Tolls = mergeTolls(result.Tolls, legTolls)
 		result.TotalDistanceMeters += distance
 		result.TotalDurationSeconds += baseDuration
 	}
@@ -361,16 +374,19 @@ func (s *service) calculateMultiStopPerLeg(ctx context.Context, waypoints []Coor
 		}
 
 		leg := RouteLegInfo{
-			Index:            i,
-			Origin:           origin,
-			Destination:      dest,
-			DistanceMeters:   routeInfo.DistanceMeters,
-			DurationSeconds:  routeInfo.DurationSeconds,
-			EstimatedArrival: routeInfo.EstimatedArrival,
-			Polyline:         routeInfo.Polyline,
+			Index:                      i,
+			Origin:                     origin,
+			Destination:                dest,
+			DistanceMeters:             routeInfo.DistanceMeters,
+			DurationSeconds:            routeInfo.DurationSeconds,
+			DurationWithTrafficSeconds: routeInfo.DurationWithTrafficSeconds,
+			EstimatedArrival:           routeInfo.EstimatedArrival,
+			Polyline:                   routeInfo.Polyline,
+			Tolls:                      routeInfo.Tolls,
 		}
 
 		result.Legs = append(result.Legs, leg)
+		result.Tolls = mergeTolls(result.Tolls, routeInfo.Tolls)
 		result.TotalDistanceMeters += routeInfo.DistanceMeters
 		result.TotalDurationSeconds += routeInfo.DurationSeconds
 
@@ -394,6 +410,7 @@ func (s *service) parseRouteResponse(resp *RouteResponse, departureTime *time.Ti
 	}
 
 	info.Polyline = section.Polyline
+	info.Tolls = summarizeTolls(resp.Routes[0].Sections)
 
 	// Calculate ETA
 	departure := time.Now()
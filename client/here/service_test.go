@@ -39,11 +39,13 @@ func newCountingService(t *testing.T, bodies ...string) (*service, func() int, f
 	t.Cleanup(srv.Close)
 
 	c := &Client{
-		httpClient:  srv.Client(),
-		routerHost:  srv.URL,
-		geocodeHost: srv.URL,
-		lookupHost:  srv.URL,
-		apiKey:      "test-key",
+		httpClient:       srv.Client(),
+		routerHost:       srv.URL,
+		geocodeHost:      srv.URL,
+		lookupHost:       srv.URL,
+		apiKey:           "test-key",
+		autocompleteHost: srv.URL,
+		matrixHost:       srv.URL,
 	}
 
 	countFn := func() int {
@@ -271,3 +273,52 @@ func TestCalculateMultiStopRoute_NoRoutes(t *testing.T) {
 		t.Fatal("expected error when HERE returns no routes")
 	}
 }
+
+func TestCalculateMultiStopRoute_TollsPerLeg(t *testing.T) {
+	toll := func(v float64) string {
+		return fmt.Sprintf(`"tolls":[{"countryCode":"USA","fares":[{"price":{"type":"value","currency":"USD","value":%g}}]}]`, v)
+	}
+	body := `{"routes":[{"id":"r1","sections":[
+		{"id":"s0","summary":{"length":100,"duration":11,"baseDuration":10},` + toll(2.5) + `},
+		{"id":"s1","summary":{"length":200,"duration":22,"baseDuration":20}},
+		{"id":"s2","summary":{"length":300,"duration":33,"baseDuration":30},` + toll(7.75) + `}
+	]}]}`
+	svc, _, reqs := newCountingService(t, body)
+	svc.client.tolls = true
+
+	got, err := svc.CalculateMultiStopRoute(context.Background(), fourStops(), nil)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if q := reqs()[0].URL.Query().Get("return"); q != "summary,polyline,tolls" {
+		t.Errorf("return=%q, want summary,polyline,tolls", q)
+	}
+	if got.Tolls == nil || got.Tolls.Total != 10.25 {
+		t.Fatalf("route tolls=%+v, want 10.25", got.Tolls)
+	}
+	if got.Legs[0].Tolls.Total != 2.5 || got.Legs[1].Tolls != nil || got.Legs[2].Tolls.Total != 7.75 {
+		t.Errorf("leg tolls=%+v %+v %+v", got.Legs[0].Tolls, got.Legs[1].Tolls, got.Legs[2].Tolls)
+	}
+	if got.Legs[1].DurationWithTrafficSeconds != 22 {
+		t.Errorf("leg 1 DurationWithTrafficSeconds=%d, want 22", got.Legs[1].DurationWithTrafficSeconds)
+	}
+}
+
+func TestAutocomplete_QueriesTheAutocompleteEndpoint(t *testing.T) {
+	svc, calls, reqs := newCountingService(t, `{"items":[{"title":"Niles, IL, United States","id":"here:cm:1","resultType":"locality"}]}`)
+
+	resp, err := svc.client.Autocomplete(context.Background(), AutocompleteRequest{Query: "Nil", Limit: 3})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if calls() != 1 || len(resp.Items) != 1 || resp.Items[0].ID != "here:cm:1" {
+		t.Fatalf("calls=%d items=%+v", calls(), resp.Items)
+	}
+	r := reqs()[0]
+	if r.URL.Path != "/v1/autocomplete" || r.URL.Query().Get("q") != "Nil" || r.URL.Query().Get("limit") != "3" {
+		t.Errorf("request=%s", r.URL)
+	}
+	if _, err := svc.client.Autocomplete(context.Background(), AutocompleteRequest{}); err == nil {
+		t.Error("empty query accepted")
+	}
+}
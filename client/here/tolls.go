@@ -0,0 +1,143 @@
+package here
+
+import "math"
+
+// RouteToll is one toll on a section (HERE `return=tolls`). Prices come in
+// the currency of the toll system; ConvertedPrice is the same amount in the
+// request's `currency`, when that differs.
+type RouteToll struct {
+	CountryCode             string                   `json:"countryCode,omitempty"`
+	TollSystem              string                   `json:"tollSystem,omitempty"`
+	Fares                   []TollFare               `json:"fares,omitempty"`
+	TollCollectionLocations []TollCollectionLocation `json:"tollCollectionLocations,omitempty"`
+}
+
+// TollFare is one way of paying a toll. A toll lists a fare per payment
+// option (cash, transponder, pass...); the driver pays one of them.
+type TollFare struct {
+	ID             string     `json:"id,omitempty"`
+	Name           string     `json:"name,omitempty"`
+	Price          TollPrice  `json:"price"`
+	ConvertedPrice *TollPrice `json:"convertedPrice,omitempty"`
+	Reason         string     `json:"reason,omitempty"`         // toll, ferry, vignette, ...
+	PaymentMethods []string   `json:"paymentMethods,omitempty"` // cash, transponder, creditCard, ...
+}
+
+// TollPrice is either an exact value (type "value") or a range (type
+// "range") when the fare depends on something HERE does not know, such as
+// the time the booth is passed.
+type TollPrice struct {
+	Type     string  `json:"type,omitempty"`
+	Currency string  `json:"currency"`
+	Value    float64 `json:"value,omitempty"`
+	Minimum  float64 `json:"minimum,omitempty"`
+	Maximum  float64 `json:"maximum,omitempty"`
+}
+
+// amount is the price to plan with: the top of a range, so a quote never
+// comes in under what the driver pays.
+func (p TollPrice) amount() float64 {
+	if p.Type == "range" {
+		return p.Maximum
+	}
+	return p.Value
+}
+
+type TollCollectionLocation struct {
+	Name     string   `json:"name,omitempty"`
+	Location Location `json:"location"`
+}
+
+// TollCost is the toll bill of a route or leg.
+type TollCost struct {
+	// Total of the charges in Currency. A charge in another currency (only
+	// possible when HERE could not convert it) is listed but not added.
+	Total    float64
+	Currency string
+	Charges  []TollCharge
+}
+
+// TollCharge is one toll at the price of its cheapest fare.
+type TollCharge struct {
+	CountryCode    string
+	System         string
+	Location       string // first collection point, when HERE names one
+	Amount         float64
+	Currency       string
+	PaymentMethods []string
+}
+
+// summarizeTolls totals the tolls of sections, or returns nil when there are
+// none (tolls not requested, or a toll-free route).
+func summarizeTolls(sections []RouteSection) *TollCost {
+	var cost *TollCost
+	for _, section := range sections {
+		for _, toll := range section.Tolls {
+			charge, ok := cheapestFare(toll)
+			if !ok {
+				continue
+			}
+			if cost == nil {
+				cost = &TollCost{Currency: charge.Currency}
+			}
+			cost.Charges = append(cost.Charges, charge)
+			if charge.Currency == cost.Currency {
+				cost.Total += charge.Amount
+			}
+		}
+	}
+	if cost != nil {
+		cost.Total = roundCents(cost.Total)
+	}
+	return cost
+}
+
+// mergeTolls adds b's charges to a, either of which may be nil.
+func mergeTolls(a, b *TollCost) *TollCost {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		a = &TollCost{Currency: b.Currency}
+	}
+	for _, c := range b.Charges {
+		a.Charges = append(a.Charges, c)
+		if c.Currency == a.Currency {
+			a.Total += c.Amount
+		}
+	}
+	a.Total = roundCents(a.Total)
+	return a
+}
+
+func cheapestFare(toll RouteToll) (TollCharge, bool) {
+	var (
+		best  TollCharge
+		found bool
+	)
+	for _, fare := range toll.Fares {
+		price := fare.Price
+		if fare.ConvertedPrice != nil {
+			price = *fare.ConvertedPrice
+		}
+		if found && price.amount() >= best.Amount {
+			continue
+		}
+		best = TollCharge{
+			CountryCode:    toll.CountryCode,
+			System:         toll.TollSystem,
+			Amount:         price.amount(),
+			Currency:       price.Currency,
+			PaymentMethods: fare.PaymentMethods,
+		}
+		found = true
+	}
+	if found && len(toll.TollCollectionLocations) > 0 {
+		best.Location = toll.TollCollectionLocations[0].Name
+	}
+	return best, found
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
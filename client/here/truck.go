@@ -1,11 +1,78 @@
 package here
 
 import (
+	"errors"
+	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
 )
 
+// ErrInvalidTruck is returned when TruckAttributes carry a hazmat class or
+// tunnel category HERE does not accept. HERE answers those with a 400 that
+// reads like "no route", so they are rejected before the billed call.
+var ErrInvalidTruck = errors.New("here: invalid truck attributes")
+
+// HazmatClass is a shipped hazardous goods class as HERE names it
+// (vehicle[shippedHazardousGoods]). Roughly the US DOT / ADR hazard classes;
+// HERE restricts roads and tunnels per class. TruckAttributes keeps plain
+// strings; these are the values Validate accepts.
+type HazmatClass string
+
+const (
+	HazmatExplosive           HazmatClass = "explosive"
+	HazmatGas                 HazmatClass = "gas"
+	HazmatFlammable           HazmatClass = "flammable"
+	HazmatCombustible         HazmatClass = "combustible"
+	HazmatOrganic             HazmatClass = "organic"
+	HazmatPoison              HazmatClass = "poison"
+	HazmatRadioactive         HazmatClass = "radioactive"
+	HazmatCorrosive           HazmatClass = "corrosive"
+	HazmatPoisonousInhalation HazmatClass = "poisonousInhalation"
+	HazmatHarmfulToWater      HazmatClass = "harmfulToWater"
+	HazmatOther               HazmatClass = "other"
+)
+
+// AllHazmatClasses lists every class HERE accepts.
+var AllHazmatClasses = []HazmatClass{
+	HazmatExplosive, HazmatGas, HazmatFlammable, HazmatCombustible, HazmatOrganic, HazmatPoison,
+	HazmatRadioactive, HazmatCorrosive, HazmatPoisonousInhalation, HazmatHarmfulToWater, HazmatOther,
+}
+
+// Valid reports whether HERE accepts the class.
+func (h HazmatClass) Valid() bool {
+	for _, c := range AllHazmatClasses {
+		if h == c {
+			return true
+		}
+	}
+	return false
+}
+
+// TunnelCategory is the ADR tunnel restriction code of the load
+// (vehicle[tunnelCategory]). The route only uses tunnels whose category is
+// less strict than the load's: a category C load avoids C, D and E tunnels.
+// It comes from the shipping papers — it depends on the UN number, not just
+// the hazmat class — so it is never derived from ShippedHazardousGoods. Like
+// HazmatClass, it names the values TruckAttributes.TunnelCategory accepts.
+type TunnelCategory string
+
+const (
+	TunnelCategoryB TunnelCategory = "B"
+	TunnelCategoryC TunnelCategory = "C"
+	TunnelCategoryD TunnelCategory = "D"
+	TunnelCategoryE TunnelCategory = "E"
+)
+
+// Valid reports whether c is one of B, C, D or E.
+func (c TunnelCategory) Valid() bool {
+	switch c {
+	case TunnelCategoryB, TunnelCategoryC, TunnelCategoryD, TunnelCategoryE:
+		return true
+	}
+	return false
+}
+
 // TruckAttributes describes the vehicle for HERE Routing v8 truck mode.
 // All fields are optional; zero values are omitted from the request.
 // Units: weights in kilograms, dimensions in centimeters.
@@ -17,8 +84,25 @@ type TruckAttributes struct {
 	LengthCm              int      // truck[length]
 	AxleCount             int      // truck[axleCount]
 	TrailerCount          int      // truck[trailerCount]
-	TunnelCategory        string   // vehicle[tunnelCategory] — B|C|D|E
-	ShippedHazardousGoods []string // vehicle[shippedHazardousGoods]
+	TunnelCategory        string   // vehicle[tunnelCategory] — B|C|D|E, see TunnelCategory
+	ShippedHazardousGoods []string // vehicle[shippedHazardousGoods], see HazmatClass
+}
+
+// Validate rejects hazmat classes and tunnel categories HERE does not know.
+// A nil receiver is valid.
+func (t *TruckAttributes) Validate() error {
+	if t == nil {
+		return nil
+	}
+	if t.TunnelCategory != "" && !TunnelCategory(t.TunnelCategory).Valid() {
+		return fmt.Errorf("%w: tunnel category %q (want B, C, D or E)", ErrInvalidTruck, t.TunnelCategory)
+	}
+	for _, h := range t.ShippedHazardousGoods {
+		if !HazmatClass(h).Valid() {
+			return fmt.Errorf("%w: hazmat class %q", ErrInvalidTruck, h)
+		}
+	}
+	return nil
 }
 
 func (t *TruckAttributes) applyTo(params url.Values) {
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"time"
 
 	"github.com/TMS360/backend-pkg/config"
+	"github.com/TMS360/backend-pkg/observability/telemetry"
 	"github.com/TMS360/backend-pkg/response"
 	"github.com/TMS360/backend-pkg/tmsdb"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -44,9 +46,9 @@ func EnsureDatabase(cfg config.PostgresSQLConfig) error {
 		if err := db.Exec(fmt.Sprintf("CREATE DATABASE \"%s\"", cfg.DBName)).Error; err != nil {
 			return fmt.Errorf("failed to create database %s: %w", cfg.DBName, err)
 		}
-		log.Printf("Database %s created successfully", cfg.DBName)
+		slog.Info("database created", "db", cfg.DBName)
 	} else {
-		log.Printf("Database %s already exists", cfg.DBName)
+		slog.Info("database already exists", "db", cfg.DBName)
 	}
 
 	if sqlDB, err := db.DB(); err == nil {
@@ -72,6 +74,10 @@ func NewClient(cfg config.PostgresSQLConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to register tenant scope plugin: %w", err)
 	}
 
+	if err := db.Use(telemetry.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register telemetry plugin: %w", err)
+	}
+
 	if sqlDB, err := db.DB(); err == nil {
 		maxOpen := cfg.MaxOpenConns
 		if maxOpen <= 0 {
@@ -88,7 +94,7 @@ func NewClient(cfg config.PostgresSQLConfig) (*gorm.DB, error) {
 		sqlDB.SetMaxIdleConns(maxIdle)
 		sqlDB.SetConnMaxIdleTime(5 * time.Minute)
 		sqlDB.SetConnMaxLifetime(30 * time.Minute)
-		log.Printf("postgres pool: max_open=%d max_idle=%d", maxOpen, maxIdle)
+		slog.Info("postgres pool configured", "max_open", maxOpen, "max_idle", maxIdle)
 	}
 
 	return db, nil
@@ -100,7 +106,6 @@ func NewClient(cfg config.PostgresSQLConfig) (*gorm.DB, error) {
 // из протухшего плана на дренящемся поде. Держим это здесь, чтобы контракт был
 // тестируемым (см. postgresql_test.go).
 func openGorm(dsn string) (*gorm.DB, error) {
-	fmt.Println("dsn: ", dsn)
 	return gorm.Open(
 		postgres.New(postgres.Config{
 			DSN:                  dsn,
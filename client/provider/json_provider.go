@@ -7,6 +7,7 @@ import (
 
 	"github.com/TMS360/backend-pkg/cache"
 	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/secrets"
 )
 
 // JSONClientProvider is the multi-field analogue of ClientProvider: instead of
@@ -113,7 +114,7 @@ func (p *JSONClientProvider[Cred, T]) fetchCredential(ctx context.Context, compa
 	// JSON), then fall back to the double-decode that matches tms360-backend's
 	// current behavior.
 	if err := json.Unmarshal(data, &cred); err == nil {
-		return cred, nil
+		return p.resolve(ctx, companyID, cred)
 	}
 
 	var jsonStr string
@@ -125,5 +126,17 @@ func (p *JSONClientProvider[Cred, T]) fetchCredential(ctx context.Context, compa
 		var zero Cred
 		return zero, fmt.Errorf("provider: failed to parse %s json for company %s: %w", p.settingKey, companyID, err)
 	}
+	return p.resolve(ctx, companyID, cred)
+}
+
+// resolve replaces secrets references (secret://…) in the credential's string
+// fields with their values, so a company's password can live in a secrets
+// backend while the rest of the blob stays in settings. Only the company's own
+// tenant-scoped secrets are reachable.
+func (p *JSONClientProvider[Cred, T]) resolve(ctx context.Context, companyID string, cred Cred) (Cred, error) {
+	if err := secrets.ResolveTenantStruct(ctx, companyID, &cred); err != nil {
+		var zero Cred
+		return zero, fmt.Errorf("provider: resolve %s secrets for company %s: %w", p.settingKey, companyID, err)
+	}
 	return cred, nil
 }
@@ -8,10 +8,13 @@ import (
 
 	"github.com/TMS360/backend-pkg/cache"
 	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/secrets"
 )
 
 // ClientProvider builds a per-company client on demand by reading the tenant's
-// API key from Redis at pattern {company_id}:setting:{settingKey}.
+// API key from Redis at pattern {company_id}:setting:{settingKey}. The value
+// may be a secrets reference (secret://…), resolved through the company-scoped
+// secrets.ResolveTenant.
 //
 // No in-memory client cache: each call fetches the API key from Redis and
 // builds the client via the factory. This ensures the provider always reflects
@@ -125,5 +128,10 @@ func (p *ClientProvider[T]) fetchAPIKey(ctx context.Context, companyID string) (
 		return "", fmt.Errorf("provider: %s is empty for company %s", p.settingKey, companyID)
 	}
 
+	// The setting may hold a secrets reference instead of the key itself.
+	apiKey, err = secrets.ResolveTenant(ctx, companyID, apiKey)
+	if err != nil {
+		return "", fmt.Errorf("provider: resolve %s for company %s: %w", p.settingKey, companyID, err)
+	}
 	return apiKey, nil
 }
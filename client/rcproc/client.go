@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
 	"time"
 
 	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/resilience"
 )
 
 type client struct {
@@ -20,13 +22,19 @@ type client struct {
 	client   *http.Client
 }
 
+// transport is the RC processor policy: resilience.Standard with no metrics
+// base — the processor is an internal service, not a billed vendor. Status
+// polls are retried; the processing POSTs each start a job and are sent once.
+var transport = resilience.ClientOptions{
+	Defaults: resilience.Standard(resilience.NewBreakerSet("rcproc", resilience.BreakerConfig{})),
+	Timeout:  60 * time.Second,
+}
+
 func NewClient(baseURL, provider string) Client {
 	return &client{
 		baseURL:  baseURL,
 		provider: provider,
-		client: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+		client:   transport.HTTPClient(),
 	}
 }
 
@@ -78,9 +86,7 @@ func (c *client) Process(ctx context.Context, fileUrl string) (*RCProcessingResp
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// --- LOGGING ---
-	fmt.Printf("RC Processor (async) Status: %s\n", resp.Status)
-	fmt.Printf("RC Processor (async) Body: %s\n", string(bodyBytes))
+	slog.DebugContext(ctx, "rcproc: process response", "status", resp.StatusCode, "bytes", len(bodyBytes))
 
 	if resp.StatusCode > 300 {
 		return nil, c.handleAPIError(resp.StatusCode, bodyBytes)
@@ -117,9 +123,7 @@ func (c *client) GetStatus(ctx context.Context, requestID string) (*RCProcessing
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// --- LOGGING ---
-	fmt.Printf("RC Processor get-status Status: %s\n", resp.Status)
-	fmt.Printf("RC Processor get-status Body: %s\n", string(bodyBytes))
+	slog.DebugContext(ctx, "rcproc: status response", "request_id", requestID, "status", resp.StatusCode, "bytes", len(bodyBytes))
 
 	if resp.StatusCode > 300 {
 		return nil, c.handleAPIError(resp.StatusCode, bodyBytes)
@@ -130,8 +134,6 @@ func (c *client) GetStatus(ctx context.Context, requestID string) (*RCProcessing
 		return nil, fmt.Errorf("failed to decode rc response: %w", err)
 	}
 
-	fmt.Println("rcResp", rcResp)
-
 	return &rcResp, nil
 }
 
@@ -181,9 +183,7 @@ func (c *client) ProcessSync(ctx context.Context, file io.Reader, filename, cont
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// --- LOGGING ---
-	fmt.Printf("RC Processor (sync) Status: %s\n", resp.Status)
-	fmt.Printf("RC Processor (sync) Body: %s\n", string(bodyBytes))
+	slog.DebugContext(ctx, "rcproc: process sync response", "status", resp.StatusCode, "bytes", len(bodyBytes))
 
 	if resp.StatusCode > 300 {
 		return nil, c.handleAPIError(resp.StatusCode, bodyBytes)
@@ -293,11 +293,6 @@ func (c *client) handleAPIError(status int, bodyBytes []byte) error {
 //		return nil, fmt.Errorf("failed to read response body: %w", err)
 //	}
 //
-//	// --- LOGGING ---
-//	fmt.Printf("RC Processor Status: %s\n", resp.Status)
-//	fmt.Printf("RC Processor Body: %s\n", string(bodyBytes))
-//	// ----------------
-//
 //	if resp.StatusCode != http.StatusOK {
 //		return nil, fmt.Errorf("rc processor returned status: %d", resp.StatusCode)
 //	}
@@ -0,0 +1,33 @@
+package relaypayments
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// Option customises a Client built by NewClient.
+type Option func(*Client)
+
+// transport is the Relay policy: resilience.Standard. The creating POSTs
+// (drivers, fuel codes, policy assignments) carry no Idempotency-Key and are
+// sent once; reads, PUTs and DELETEs are retried.
+var transport = resilience.ClientOptions{
+	Base:     metrics.Transport(metrics.ProviderRelay, nil, nil),
+	Defaults: resilience.Standard(resilience.NewBreakerSet(metrics.ProviderRelay, resilience.BreakerConfig{})),
+	Timeout:  30 * time.Second,
+}
+
+// WithResilience replaces the default resilience stack; with no arguments
+// the client makes exactly one attempt per call. Metering is unaffected.
+func WithResilience(mws ...resilience.Middleware) Option {
+	return func(c *Client) { c.httpClient = transport.Replace(c.httpClient, mws...) }
+}
+
+// WithHTTPClient makes the client use hc as is — no metering or resilience
+// is added. For tests and callers with their own transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
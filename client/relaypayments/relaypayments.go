@@ -69,7 +69,7 @@ type Client struct {
 //
 // transactionsHost is derived by stripping the "/integrations" suffix so
 // ListTransactions targets ".../api/fuel/transactions/" as Relay requires.
-func NewClient(cfg config.RelayConfig, apiKey string) (*Client, error) {
+func NewClient(cfg config.RelayConfig, apiKey string, opts ...Option) (*Client, error) {
 	if apiKey == "" {
 		return nil, errors.New("relaypayments: apiKey is empty")
 	}
@@ -77,12 +77,16 @@ func NewClient(cfg config.RelayConfig, apiKey string) (*Client, error) {
 	if host == "" {
 		host = defaultProductionHost
 	}
-	return &Client{
-		httpClient:       &http.Client{Timeout: 30 * time.Second},
+	c := &Client{
+		httpClient:       transport.HTTPClient(),
 		host:             host,
 		transactionsHost: strings.TrimSuffix(host, "/integrations"),
 		apiKey:           apiKey,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewClientWithToken is a convenience constructor used with the per-company
@@ -10,6 +10,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/resilience"
 )
 
 const (
@@ -321,6 +324,10 @@ func (c *Client) fetchRecordingAt(ctx context.Context, contentURI string) (io.Re
 
 	client := &http.Client{
 		Timeout: recordingTimeout,
+		Transport: resilience.Chain(
+			metrics.Transport(metrics.ProviderRingCentral, nil, func(*http.Request) string { return "fetch_recording" }),
+			transport.Defaults...,
+		),
 		// Do not follow redirects: a redirect to another host would either drop
 		// the Authorization header or carry it somewhere we never vetted.
 		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
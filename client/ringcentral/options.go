@@ -0,0 +1,32 @@
+package ringcentral
+
+import (
+	"net/http"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// Option customises a Client built by NewClient.
+type Option func(*Client)
+
+// transport is the RingCentral policy: resilience.Standard, which retries
+// the idempotent call-log and recording reads on 429/5xx — RingCentral
+// throttles by rate group and answers with Retry-After.
+var transport = resilience.ClientOptions{
+	Base:     metrics.Transport(metrics.ProviderRingCentral, nil, nil),
+	Defaults: resilience.Standard(resilience.NewBreakerSet(metrics.ProviderRingCentral, resilience.BreakerConfig{})),
+	Timeout:  defaultTimeout,
+}
+
+// WithResilience replaces the default resilience stack; with no arguments
+// the client makes exactly one attempt per call. Metering is unaffected.
+func WithResilience(mws ...resilience.Middleware) Option {
+	return func(c *Client) { c.httpClient = transport.Replace(c.httpClient, mws...) }
+}
+
+// WithHTTPClient makes the client use hc as is — no metering or resilience
+// is added. For tests and callers with their own transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
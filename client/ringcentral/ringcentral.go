@@ -133,6 +133,11 @@ type Client struct {
 // credential is validated up front so a half-filled integration fails fast
 // instead of producing a confusing RingCentral error.
 func NewClientWithCred(cred Cred) (*Client, error) {
+	return NewClient(cred)
+}
+
+// NewClient is NewClientWithCred with options.
+func NewClient(cred Cred, opts ...Option) (*Client, error) {
 	if err := cred.Validate(); err != nil {
 		return nil, err
 	}
@@ -140,11 +145,15 @@ func NewClientWithCred(cred Cred) (*Client, error) {
 	if server == "" {
 		server = DefaultServerURL
 	}
-	return &Client{
-		httpClient: &http.Client{Timeout: defaultTimeout},
+	c := &Client{
+		httpClient: transport.HTTPClient(),
 		serverURL:  strings.TrimRight(server, "/"),
 		cred:       cred,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // AccessToken exchanges the stored credentials for a short-lived bearer token.
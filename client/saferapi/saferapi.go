@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/resilience"
 )
 
 type SaferApi interface {
@@ -21,13 +25,19 @@ type saferAPIService struct {
 	client  *http.Client
 }
 
+// transport is the SaferWebAPI policy: resilience.Standard. Snapshots are
+// GETs, so 429/5xx and transport errors are retried.
+var transport = resilience.ClientOptions{
+	Base:     metrics.Transport(metrics.ProviderSaferAPI, nil, nil),
+	Defaults: resilience.Standard(resilience.NewBreakerSet(metrics.ProviderSaferAPI, resilience.BreakerConfig{})),
+	Timeout:  30 * time.Second,
+}
+
 func NewSaferAPIService(apiKey string) SaferApi {
 	return &saferAPIService{
 		baseURL: "https://saferwebapi.com/v2",
 		apiKey:  apiKey,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:  transport.HTTPClient(),
 	}
 }
 
@@ -62,10 +72,7 @@ func (s *saferAPIService) executeRequest(ctx context.Context, url, entityType st
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// --- LOGGING ---
-	fmt.Printf("SaferApi Status: %s\n", resp.Status)
-	fmt.Printf("SaferApi Body: %s\n", string(bodyBytes))
-	// ----------------
+	slog.DebugContext(ctx, "saferapi: response", "entity", entityType, "status", resp.StatusCode, "bytes", len(bodyBytes))
 
 	// Handle Error Status Codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -0,0 +1,32 @@
+package samsara
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// Option customises a Client built by NewClient.
+type Option func(*Client)
+
+// transport is the Samsara policy: resilience.Standard. The fleet endpoints
+// used are reads, so 429/5xx and transport errors are retried.
+var transport = resilience.ClientOptions{
+	Base:     metrics.Transport(metrics.ProviderSamsara, nil, nil),
+	Defaults: resilience.Standard(resilience.NewBreakerSet(metrics.ProviderSamsara, resilience.BreakerConfig{})),
+	Timeout:  30 * time.Second,
+}
+
+// WithResilience replaces the default resilience stack; with no arguments
+// the client makes exactly one attempt per call. Metering is unaffected.
+func WithResilience(mws ...resilience.Middleware) Option {
+	return func(c *Client) { c.httpClient = transport.Replace(c.httpClient, mws...) }
+}
+
+// WithHTTPClient makes the client use hc as is — no metering or resilience
+// is added. For tests and callers with their own transport.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
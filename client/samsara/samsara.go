@@ -579,21 +579,21 @@ type Client struct {
 }
 
 // NewClient создаёт новый клиент Samsara с конфигурацией
-func NewClient(cfg config.SamsaraConfig, apiKey string) (*Client, error) {
-	return &Client{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+func NewClient(cfg config.SamsaraConfig, apiKey string, opts ...Option) (*Client, error) {
+	c := &Client{
+		httpClient: transport.HTTPClient(),
 		host:       cfg.Host,
 		apiKey:     apiKey,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewClientWithToken создаёт клиент только с API ключом (использует дефолтный хост)
 func NewClientWithToken(apiKey string) (*Client, error) {
-	return &Client{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-		host:       "https://api.samsara.com",
-		apiKey:     apiKey,
-	}, nil
+	return NewClient(config.SamsaraConfig{Host: "https://api.samsara.com"}, apiKey)
 }
 
 // doRequest - вспомогательный метод для выполнения HTTP запросов
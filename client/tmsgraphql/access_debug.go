@@ -0,0 +1,64 @@
+package tmsgraphql
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/TMS360/backend-pkg/middleware"
+)
+
+// AccessDebugHeader opts a request into the access-decision debug extension.
+const AccessDebugHeader = "X-Debug-Access"
+
+// AccessDecisionsExtensionKey is the response "extensions" key the decisions
+// are reported under.
+const AccessDecisionsExtensionKey = "accessDecisions"
+
+// AccessDecisionExtension reports every @hasPerm decision taken while serving
+// an operation — required codes, matched grant or deny entry, reason, perm
+// resolution — under extensions.accessDecisions, so an admin reproducing a
+// user's "access denied" sees why without reading logs.
+//
+// It only activates for admins and super-admins that send AccessDebugHeader;
+// everyone else pays one header lookup. The decisions include the caller's own
+// perm list, nothing about other users.
+type AccessDecisionExtension struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = AccessDecisionExtension{}
+
+func (AccessDecisionExtension) ExtensionName() string { return "AccessDecisionDebug" }
+
+func (AccessDecisionExtension) Validate(graphql.ExecutableSchema) error { return nil }
+
+func (AccessDecisionExtension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if !accessDebugRequested(ctx) {
+		return next(ctx)
+	}
+	ctx, trace := middleware.WithAccessTrace(ctx)
+	handler := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		if resp == nil {
+			return nil
+		}
+		if resp.Extensions == nil {
+			resp.Extensions = map[string]interface{}{}
+		}
+		resp.Extensions[AccessDecisionsExtensionKey] = trace.Decisions()
+		return resp
+	}
+}
+
+func accessDebugRequested(ctx context.Context) bool {
+	if !graphql.HasOperationContext(ctx) {
+		return false
+	}
+	if graphql.GetOperationContext(ctx).Headers.Get(AccessDebugHeader) == "" {
+		return false
+	}
+	actor, err := middleware.GetActor(ctx)
+	return err == nil && actor != nil && (actor.IsAdmin() || actor.IsSuperAdmin())
+}
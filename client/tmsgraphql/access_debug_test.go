@@ -0,0 +1,56 @@
+package tmsgraphql
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/enums"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/google/uuid"
+)
+
+// runWithAccessDebug drives the extension around an operation whose single
+// field is gated by @hasPerm(perms: ["accounting"]).
+func runWithAccessDebug(t *testing.T, roles []string, header bool) *graphql.Response {
+	t.Helper()
+	uid := uuid.New()
+	ctx := consts.WithActor(context.Background(), &consts.Actor{
+		ID:     uid,
+		Claims: &consts.UserClaims{UserID: uid, Roles: roles},
+	})
+	ctx = consts.WithUserPerms(ctx, []string{"loads"})
+	headers := http.Header{}
+	if header {
+		headers.Set(AccessDebugHeader, "1")
+	}
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{Headers: headers})
+
+	handler := AccessDecisionExtension{}.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		_, _ = HasPermDirective(ctx, nil, func(context.Context) (interface{}, error) { return nil, nil }, []string{"accounting"})
+		return func(context.Context) *graphql.Response { return &graphql.Response{} }
+	})
+	return handler(ctx)
+}
+
+func TestAccessDecisionExtension_ReportsToAdmins(t *testing.T) {
+	resp := runWithAccessDebug(t, []string{enums.UserRoleAdmin.String()}, true)
+	ds, ok := resp.Extensions[AccessDecisionsExtensionKey].([]middleware.AccessDecision)
+	if !ok || len(ds) != 1 {
+		t.Fatalf("extensions = %#v", resp.Extensions)
+	}
+	if ds[0].Allowed || ds[0].Reason != middleware.AccessMissingPermission || ds[0].Gate != "graphql" {
+		t.Fatalf("decision = %+v", ds[0])
+	}
+}
+
+func TestAccessDecisionExtension_HiddenWithoutHeaderOrAdmin(t *testing.T) {
+	if resp := runWithAccessDebug(t, []string{enums.UserRoleAdmin.String()}, false); resp.Extensions != nil {
+		t.Fatalf("no header: extensions = %#v", resp.Extensions)
+	}
+	if resp := runWithAccessDebug(t, []string{enums.UserRoleDispatcher.String()}, true); resp.Extensions != nil {
+		t.Fatalf("non-admin: extensions = %#v", resp.Extensions)
+	}
+}
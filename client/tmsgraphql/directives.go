@@ -61,9 +61,10 @@ func HasRoleDirective(ctx context.Context, obj interface{}, next graphql.Resolve
 // from the request context (stashed by auth.IdentifyUserPerms middleware on
 // every service); the JWT no longer carries perms.
 //
-// Matching is hierarchical: holding "accounting" grants every key under it.
-// Any one of `perms` being granted is sufficient (OR semantics, preserving
-// the prior directive's contract).
+// Matching is hierarchical: holding "accounting" grants every key under it,
+// unless a deny entry ("-accounting.invoices.delete") covers the code. Any one
+// of `perms` being granted is sufficient (OR semantics, preserving the prior
+// directive's contract).
 //
 // Guests bypass the perm check. Guest access is granted per-field by
 // `@authGuest`, which verifies the share-link token's resource scope; a guest
@@ -73,19 +74,16 @@ func HasRoleDirective(ctx context.Context, obj interface{}, next graphql.Resolve
 // would otherwise lock super-admins out of every gated endpoint — this gives
 // ops a permanent recovery path that doesn't depend on the catalog state.
 func HasPermDirective(ctx context.Context, obj interface{}, next graphql.Resolver, perms []string) (interface{}, error) {
-	actor, err := middleware.GetActor(ctx)
-	if err != nil {
-		return nil, consts.ErrUnauthorized
-	}
-	if actor.IsGuest {
+	// A conditional grant ("…?own") passes here — the resolver checks the
+	// loaded record with middleware.CanAccess. A deny entry always wins.
+	d := middleware.CheckAccess(ctx, "graphql", perms...)
+	switch {
+	case d.Allowed:
 		return next(ctx)
-	}
-	if actor.IsSuperAdmin() {
-		return next(ctx)
-	}
-
-	// DEV-1555: upstream perm lookup failed — not a real RBAC denial.
-	if middleware.PermsUnresolved(ctx) {
+	case d.Reason == middleware.AccessUnauthenticated:
+		return nil, consts.ErrUnauthorized
+	case d.Unresolved():
+		// DEV-1555: upstream perm lookup failed — not a real RBAC denial.
 		return nil, response.NewCodedError(
 			"PERMS_UNRESOLVED",
 			"perms unresolved: upstream auth lookup failed",
@@ -94,13 +92,5 @@ func HasPermDirective(ctx context.Context, obj interface{}, next graphql.Resolve
 			nil,
 		)
 	}
-
-	userPerms := middleware.GetUserPermsFromContext(ctx)
-	for _, required := range perms {
-		if middleware.HasPermission(userPerms, required) {
-			return next(ctx)
-		}
-	}
-
 	return nil, response.NewForbidden("access denied: missing permission", "access denied: missing permission")
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 
 	"github.com/99designs/gqlgen/graphql"
 )
@@ -12,7 +13,7 @@ func MarshalJSONObject(val interface{}) graphql.Marshaler {
 	return graphql.WriterFunc(func(w io.Writer) {
 		err := json.NewEncoder(w).Encode(val)
 		if err != nil {
-			fmt.Printf("error marshaling JSONObject: %v", err)
+			slog.Error("tmsgraphql: marshal JSONObject", "err", err)
 		}
 	})
 }
@@ -35,6 +35,8 @@ func NewHandler(es graphql.ExecutableSchema, isDebug bool) *handler.Server {
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
+	// Admin-only, opt-in via the X-Debug-Access header.
+	srv.Use(AccessDecisionExtension{})
 
 	// If you have APM (DataDog/Sentry/Prometheus), add it here too!
 	// srv.Use(extension.FixedComplexityLimit(1000))
@@ -3,6 +3,7 @@ package tmskafka
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net"
 	"strconv"
 
@@ -87,6 +88,6 @@ func EnsureTopicExists(addr, topic string, partitions, replications int) error {
 		return fmt.Errorf("failed to create topic: %w", err)
 	}
 
-	fmt.Printf("Auto-created Kafka topic: %s\n", topic)
+	slog.Info("auto-created Kafka topic", "topic", topic)
 	return nil
 }
@@ -3,12 +3,13 @@ package tmsredis
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 
 	"github.com/TMS360/backend-pkg/config"
+	"github.com/TMS360/backend-pkg/observability/telemetry"
 )
 
 func NewClient(cfg config.RedisConfig) (*redis.Client, error) {
@@ -16,6 +17,7 @@ func NewClient(cfg config.RedisConfig) (*redis.Client, error) {
 		Addr:     fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
 		Password: cfg.Password,
 	})
+	rdb.AddHook(telemetry.RedisHook{})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -24,7 +26,7 @@ func NewClient(cfg config.RedisConfig) (*redis.Client, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	log.Printf("Successfully connected to Redis at %s:%s", cfg.Host, cfg.Port)
+	slog.Info("connected to Redis", "addr", fmt.Sprintf("%s:%s", cfg.Host, cfg.Port))
 
 	return rdb, nil
 }
@@ -0,0 +1,189 @@
+package toll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// apiTimeout bounds one attempt of an API call, download included. Statement
+// exports are a few megabytes at most.
+const apiTimeout = 60 * time.Second
+
+// apiPolicy retries transient failures — every call here is a GET — and gives
+// each attempt its own apiTimeout, so a download that stalls is retried
+// rather than ending the call.
+var apiPolicy = func() resilience.Policy {
+	p := resilience.DefaultPolicy()
+	p.AttemptTimeout = apiTimeout
+	return p
+}()
+
+// apiFile is one entry of an aggregator's statement listing.
+type apiFile struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// apiSource is the transport half shared by providers that serve their
+// exports over HTTPS instead of a folder: a listing endpoint returning
+// {"files": [{"name", "size", "created_at"}]} and a download endpoint per
+// file name. Providers embed it and add their own Parse, exactly as the
+// folder providers embed sftpSource, so Parse stays network-free.
+type apiSource struct {
+	providerType ProviderType
+	baseURL      string
+	// baseErr is why baseURL must not be called — set when the credential's
+	// URL is not https, since the call carries the tenant's secret.
+	baseErr error
+	// username, when set, makes the call use basic auth (key id + secret);
+	// otherwise the secret is sent as a bearer token.
+	username string
+	secret   string
+
+	listPath     string
+	downloadPath string // joined with the escaped file name
+
+	httpClient *http.Client
+}
+
+// newAPISource reads the transport settings from cred. On non-production
+// deployments the TEST_* env var named by urlEnv overrides the base URL, for
+// the same reason newSFTPSource overrides the folder; only that override may
+// be plain http (a local fake server). The credential's own URL must be https.
+func newAPISource(pt ProviderType, cred Credential, urlEnv, listPath, downloadPath string) apiSource {
+	base := strings.TrimSpace(cred.BaseURL)
+	baseErr := checkAPIBaseURL(pt, base)
+	if isNonProdAppEnv() {
+		if v := firstNonEmptyEnv(urlEnv); v != "" {
+			base, baseErr = v, nil
+		}
+	}
+	return apiSource{
+		providerType: pt,
+		baseURL:      strings.TrimRight(base, "/"),
+		baseErr:      baseErr,
+		username:     strings.TrimSpace(cred.Username),
+		secret:       cred.Secret,
+		listPath:     listPath,
+		downloadPath: downloadPath,
+		httpClient: &http.Client{
+			Timeout:   apiPolicy.TotalTimeout(),
+			Transport: resilience.Chain(nil, resilience.Retry(apiPolicy)),
+		},
+	}
+}
+
+// checkAPIBaseURL rejects a credential base URL the secret must not be sent
+// to: anything but an absolute https URL. Empty is left to the caller.
+func checkAPIBaseURL(pt ProviderType, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("toll: %s base url must be an https url", pt)
+	}
+	return nil
+}
+
+// List returns the exports the aggregator currently offers, newest first.
+func (s *apiSource) List(ctx context.Context) ([]RemoteFile, error) {
+	body, err := s.get(ctx, s.listPath, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Files []apiFile `json:"files"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("toll/api: %s: decode listing: %w", s.providerType, err)
+	}
+	exts := RulesFor(s.providerType).FileExtensions
+	out := make([]RemoteFile, 0, len(resp.Files))
+	for _, f := range resp.Files {
+		if f.Name == "" || !hasAcceptedExt(f.Name, exts) {
+			continue
+		}
+		out = append(out, RemoteFile{Name: f.Name, Size: f.Size, ModTime: f.CreatedAt})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModTime.After(out[j].ModTime) })
+	return out, nil
+}
+
+// Fetch downloads one export named by List. The name is reduced to its base
+// and path-escaped, so a crafted listing entry cannot reach another endpoint.
+func (s *apiSource) Fetch(ctx context.Context, name string) ([]byte, error) {
+	base := path.Base(strings.TrimSpace(name))
+	if base == "" || base == "." || base == "/" || base == ".." {
+		return nil, fmt.Errorf("toll/api: invalid file name %q", name)
+	}
+	return s.get(ctx, s.downloadPath+"/"+url.PathEscape(base), maxRemoteFileSize)
+}
+
+// TestConnection calls the listing endpoint and discards the result — the
+// cheapest authenticated call the API offers.
+func (s *apiSource) TestConnection(ctx context.Context) error {
+	_, err := s.get(ctx, s.listPath, 1<<20)
+	return err
+}
+
+// get performs one authenticated GET and returns the body, capped at limit.
+func (s *apiSource) get(ctx context.Context, p string, limit int64) ([]byte, error) {
+	if s.baseErr != nil {
+		return nil, s.baseErr
+	}
+	if s.baseURL == "" {
+		return nil, fmt.Errorf("toll/api: %s: base url is empty", s.providerType)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+p, nil)
+	if err != nil {
+		return nil, fmt.Errorf("toll/api: %s: build request: %w", s.providerType, err)
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.secret)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+s.secret)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("toll/api: %s: GET %s: %w", s.providerType, p, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// One byte over the cap so an oversized export is detected rather than
+	// silently truncated.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("toll/api: %s: read %s: %w", s.providerType, p, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, &AuthError{ProviderType: s.providerType, Cause: fmt.Errorf("HTTP %d", resp.StatusCode)}
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, fmt.Errorf("toll/api: %s: GET %s: HTTP %d: %s",
+			s.providerType, p, resp.StatusCode, truncate(string(body), 200))
+	case int64(len(body)) > limit:
+		return nil, fmt.Errorf("toll/api: %s: %s exceeds %d bytes", s.providerType, p, limit)
+	}
+	return body, nil
+}
+
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,136 @@
+package toll
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBestpass points the provider at a local server through the non-prod
+// TEST_* override — the only way a plain-http URL is accepted.
+func newTestBestpass(t *testing.T, h http.HandlerFunc) *BestpassAPI {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	t.Setenv("APP_ENV", "local")
+	t.Setenv(envTestBestpassURL, srv.URL+"/v1/")
+	return NewBestpassAPI(Credential{
+		ProviderType: ProviderBestpassAPI,
+		Secret:       "key",
+		BaseURL:      "https://api.example.test",
+	})
+}
+
+func TestAPI_ListSortsNewestFirstAndSendsBearer(t *testing.T) {
+	p := newTestBestpass(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/exports", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"files":[
+			{"name":"week-35.csv","size":10,"created_at":"2024-08-26T06:00:00Z"},
+			{"name":"","size":1,"created_at":"2024-09-09T06:00:00Z"},
+			{"name":"week-36.xlsx","size":20,"created_at":"2024-09-02T06:00:00Z"}
+		]}`))
+	})
+
+	files, err := p.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, files, 2, "entries without a name are dropped")
+	assert.Equal(t, "week-36.xlsx", files[0].Name)
+	assert.Equal(t, int64(20), files[0].Size)
+}
+
+func TestAPI_FetchEscapesTheName(t *testing.T) {
+	p := newTestBestpass(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/exports/week 36.csv", r.URL.Path)
+		_, _ = w.Write([]byte("payload"))
+	})
+
+	got, err := p.Fetch(context.Background(), "../../admin/week 36.csv")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payload"), got)
+
+	_, err = p.Fetch(context.Background(), "..")
+	assert.Error(t, err)
+}
+
+func TestAPI_BasicAuthWhenUsernameIsSet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "id", user)
+		assert.Equal(t, "key", pass)
+		_, _ = w.Write([]byte(`{"files":[]}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("APP_ENV", "local")
+	t.Setenv(envTestBestpassURL, srv.URL)
+	p := NewBestpassAPI(Credential{ProviderType: ProviderBestpassAPI, Username: "id", Secret: "key"})
+	require.NoError(t, p.TestConnection(context.Background()))
+}
+
+func TestAPI_RefusesPlainHTTPCredentialURL(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{"files":[]}`))
+	}))
+	defer srv.Close()
+
+	cred := Credential{ProviderType: ProviderBestpassAPI, Secret: "key", BaseURL: srv.URL}
+	err := cred.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "https")
+
+	err = NewBestpassAPI(cred).TestConnection(context.Background())
+	require.Error(t, err)
+	assert.Zero(t, hits, "the secret must not be sent over plain http")
+}
+
+func TestAPI_RejectedKeyIsAnAuthError(t *testing.T) {
+	p := newTestBestpass(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	err := p.TestConnection(context.Background())
+	require.Error(t, err)
+	assert.True(t, IsAuthError(err))
+
+	p = newTestBestpass(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("no such account"))
+	})
+	err = p.TestConnection(context.Background())
+	require.Error(t, err)
+	assert.False(t, IsAuthError(err), "a missing endpoint is not a credential problem")
+	assert.Contains(t, err.Error(), "no such account")
+}
+
+func TestNewProviderFromCredential_NewProviders(t *testing.T) {
+	p, err := NewProviderFromCredential(Credential{
+		ProviderType: ProviderBestpassAPI, Secret: "key", BaseURL: "https://api.example.test",
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &BestpassAPI{}, p)
+
+	_, err = NewProviderFromCredential(Credential{ProviderType: ProviderBestpassAPI, Secret: "key"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing base url")
+
+	p, err = NewProviderFromCredential(Credential{
+		ProviderType: ProviderEZPassCSV, Host: "sftp.example.test", Username: "u", Secret: "p",
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &EZPassCSV{}, p)
+
+	assert.Equal(t, TransportAPI, RulesFor(ProviderBestpassAPI).Transport)
+	assert.False(t, RulesFor(ProviderBestpassAPI).RequiresUsername, "Bestpass authenticates with a key alone")
+}
+
+var (
+	_ Provider = (*BestpassAPI)(nil)
+	_ Provider = (*EZPassCSV)(nil)
+)
@@ -0,0 +1,157 @@
+package toll
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Bestpass export column names, as printed in the transaction export. Like
+// PrePass they are addressed by name: the portal lets a user reorder and hide
+// columns, and the API serves whatever layout the account last saved.
+const (
+	bpColAccount     = "Account"
+	bpColPostedDate  = "Posted Date"
+	bpColTxnDate     = "Transaction Date"
+	bpColUnit        = "Unit"
+	bpColTransponder = "Transponder"
+	bpColPlate       = "Plate"
+	bpColPlateState  = "Plate State"
+	bpColNetwork     = "Network"
+	bpColAgency      = "Agency"
+	bpColEntryPlaza  = "Entry Plaza"
+	bpColEntryAt     = "Entry Date/Time"
+	bpColExitPlaza   = "Exit Plaza"
+	bpColExitAt      = "Exit Date/Time"
+	bpColClass       = "Class"
+	bpColAmount      = "Amount"
+)
+
+// Bestpass API paths, relative to the credential's BaseURL.
+const (
+	bestpassListPath     = "/exports"
+	bestpassDownloadPath = "/exports"
+
+	envTestBestpassURL = "TEST_BESTPASS_API_URL"
+)
+
+// BestpassAPI pulls transaction exports from Bestpass over HTTPS. The export
+// is the same file the portal's "Export" button produces, so a hand-uploaded
+// portal download and an API pull parse identically.
+//
+// List, Fetch and TestConnection come from the embedded apiSource.
+type BestpassAPI struct {
+	apiSource
+	accountName string
+}
+
+// NewBestpassAPI builds the provider from a stored credential.
+func NewBestpassAPI(cred Credential) *BestpassAPI {
+	return &BestpassAPI{
+		apiSource: newAPISource(ProviderBestpassAPI, cred, envTestBestpassURL,
+			bestpassListPath, bestpassDownloadPath),
+		accountName: strings.TrimSpace(cred.AccountName),
+	}
+}
+
+// Parse reads a Bestpass export (CSV or XLSX) into normalised rows. Pure, and
+// tolerant of bad rows in the same way as PrePassSFTP.Parse.
+func (p *BestpassAPI) Parse(name string, content []byte) (ParseResult, error) {
+	sheets, err := readSheets(name, content)
+	if err != nil {
+		return ParseResult{}, err
+	}
+	t, ok := findTable(sheets, bpColPostedDate, bpColAmount)
+	if !ok {
+		return ParseResult{}, fmt.Errorf(
+			"toll: %q has no Bestpass transaction table (no header row with %q and %q)",
+			name, bpColPostedDate, bpColAmount)
+	}
+
+	var res ParseResult
+	res.Rows = make([]Row, 0, len(t.rows))
+	for i, raw := range t.rows {
+		rowNum := t.headerRow + 1 + i
+		if isBlank(raw) {
+			res.Skipped++
+			continue
+		}
+		// Every row carries the account, so the first one names the file.
+		if res.Account == "" {
+			res.Account = t.cell(raw, bpColAccount)
+		}
+		row, err := parseBestpassRow(t, rowNum, raw)
+		if err != nil {
+			var re RowError
+			if !errors.As(err, &re) {
+				re = RowError{RowNumber: rowNum, Err: err}
+			}
+			res.Errors = append(res.Errors, re)
+			continue
+		}
+		res.Rows = append(res.Rows, row)
+	}
+
+	assignHashes(ProviderBestpassAPI, res.Rows)
+	return res, nil
+}
+
+// parseBestpassRow maps one export row onto Row. Unlike PrePass, Bestpass
+// splits transponder and plate into separate columns and has no read-type
+// column: a row with a transponder was a tag read, a row with only a plate was
+// a video toll.
+func parseBestpassRow(t table, rowNum int, raw []string) (Row, error) {
+	postRaw := t.cell(raw, bpColPostedDate)
+	post, ok := ParseFileTime(postRaw)
+	if !ok {
+		return Row{}, RowError{
+			RowNumber: rowNum, Column: bpColPostedDate, Value: postRaw,
+			Err: fmt.Errorf("unreadable date"),
+		}
+	}
+	amountRaw := t.cell(raw, bpColAmount)
+	amount, err := ParseMoney(amountRaw)
+	if err != nil {
+		return Row{}, RowError{RowNumber: rowNum, Column: bpColAmount, Value: amountRaw, Err: err}
+	}
+
+	row := Row{
+		RowNumber:  rowNum,
+		PostDate:   post,
+		Source:     t.cell(raw, bpColNetwork),
+		Agency:     t.cell(raw, bpColAgency),
+		TruckRef:   NormalizeTruckRef(t.cell(raw, bpColUnit)),
+		EntryPlaza: t.cell(raw, bpColEntryPlaza),
+		ExitPlaza:  t.cell(raw, bpColExitPlaza),
+		Class:      t.cell(raw, bpColClass),
+		Amount:     amount,
+		Raw:        t.rowMap(raw),
+	}
+
+	transponder := NormalizeDeviceID(t.cell(raw, bpColTransponder))
+	plate := t.cell(raw, bpColPlate)
+	switch {
+	case transponder != "":
+		row.ReadType = ReadTransponder
+		row.DeviceID = transponder
+		row.AgencyRef = transponder
+	case plate != "":
+		row.ReadType = ReadPlate
+		row.Plate = NormalizePlate(plate)
+		row.AgencyRef = plate
+		if st := t.cell(raw, bpColPlateState); st != "" {
+			row.AgencyRef = st + "-" + plate
+		}
+	}
+
+	if ts, ok := ParseFileTime(t.cell(raw, bpColEntryAt)); ok {
+		row.EntryAt = &ts
+	}
+	if ts, ok := ParseFileTime(t.cell(raw, bpColExitAt)); ok {
+		row.ExitAt = &ts
+	} else if ts, ok := ParseFileTime(t.cell(raw, bpColTxnDate)); ok {
+		// Single-point plazas print only the transaction time.
+		row.ExitAt = &ts
+	}
+	return row, nil
+}
@@ -0,0 +1,72 @@
+package toll
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// disputeHeader is the layout of the dispute file. Aggregators' dispute desks
+// work from the identifiers on their own statement, so the file leads with
+// those and keeps our internal ids (truck, trip) to the end.
+var disputeHeader = []string{
+	"Post Date", "Crossing Time", "Agency", "Device ID", "Plate",
+	"Entry Plaza", "Exit Plaza", "Class", "Amount",
+	"Reasons", "Details", "Truck", "Trip", "Row",
+}
+
+// WriteDisputeCSV writes the findings as a CSV the carrier can attach to a
+// dispute with the aggregator, one line per charge, with a total line at the
+// end. Times are printed as the file printed them (wall clock, no zone).
+func (r Report) WriteDisputeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(disputeHeader); err != nil {
+		return fmt.Errorf("toll: write dispute header: %w", err)
+	}
+	for _, f := range r.Findings {
+		crossing := f.Row.ExitAt
+		if crossing == nil {
+			crossing = f.Row.EntryAt
+		}
+		reasons := make([]string, len(f.Flags))
+		for i, fl := range f.Flags {
+			reasons[i] = string(fl)
+		}
+		rec := []string{
+			f.Row.PostDate.Format("2006-01-02"),
+			formatWallClock(crossing),
+			f.Row.Agency,
+			f.Row.DeviceID,
+			f.Row.Plate,
+			f.Row.EntryPlaza,
+			f.Row.ExitPlaza,
+			f.Row.Class,
+			f.Row.Amount.StringFixed(2),
+			strings.Join(reasons, ";"),
+			strings.Join(f.Evidence, "; "),
+			f.TruckID,
+			f.TripID,
+			fmt.Sprint(f.Row.RowNumber),
+		}
+		if err := cw.Write(rec); err != nil {
+			return fmt.Errorf("toll: write dispute row %d: %w", f.Row.RowNumber, err)
+		}
+	}
+	total := make([]string, len(disputeHeader))
+	total[0] = "Total"
+	total[8] = r.Disputed.StringFixed(2)
+	if err := cw.Write(total); err != nil {
+		return fmt.Errorf("toll: write dispute total: %w", err)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatWallClock(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
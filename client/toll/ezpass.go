@@ -0,0 +1,189 @@
+package toll
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// E-ZPass statement column names. The customer service centers of the
+// E-ZPass member agencies share one commercial-account statement export; the
+// columns below are the ones every center prints.
+const (
+	ezColPostingDate = "Posting Date"
+	ezColTxnDate     = "Transaction Date"
+	ezColTag         = "Tag/Plate Number"
+	ezColAgency      = "Agency"
+	ezColDescription = "Description"
+	ezColEntryTime   = "Entry Time"
+	ezColEntryPlaza  = "Entry Plaza"
+	ezColExitTime    = "Exit Time"
+	ezColExitPlaza   = "Exit Plaza"
+	ezColClass       = "Vehicle Class"
+	ezColAmount      = "Amount"
+)
+
+const (
+	envTestEZPassHost = "TEST_EZPASS_SFTP_HOST"
+	envTestEZPassPort = "TEST_EZPASS_SFTP_PORT"
+	envTestEZPassDir  = "TEST_EZPASS_SFTP_DIR"
+)
+
+// ezAccountLabels are the labels the statement preamble puts before the
+// account number, compared case-insensitively with any trailing colon removed.
+var ezAccountLabels = []string{"account", "account number", "account no", "account #"}
+
+// EZPassCSV reads the statement export of a carrier's own E-ZPass commercial
+// account. Unlike an aggregator file the statement is a ledger: tolls sit
+// beside replenishments, fees and payments, and charges are printed as
+// debits. Parse keeps only the toll lines and flips the sign so Row.Amount
+// means "toll charged", as it does for every other provider.
+//
+// List, Fetch and TestConnection come from the embedded sftpSource.
+type EZPassCSV struct {
+	sftpSource
+	accountName string
+}
+
+// NewEZPassCSV builds the provider from a stored credential.
+func NewEZPassCSV(cred Credential) *EZPassCSV {
+	return &EZPassCSV{
+		sftpSource: newSFTPSource(ProviderEZPassCSV, cred,
+			envTestEZPassHost, envTestEZPassPort, envTestEZPassDir),
+		accountName: strings.TrimSpace(cred.AccountName),
+	}
+}
+
+// Parse reads an E-ZPass statement into normalised rows. Non-toll ledger lines
+// are counted in Skipped, not reported as errors.
+func (p *EZPassCSV) Parse(name string, content []byte) (ParseResult, error) {
+	sheets, err := readSheets(name, content)
+	if err != nil {
+		return ParseResult{}, err
+	}
+	t, ok := findTable(sheets, ezColPostingDate, ezColAmount)
+	if !ok {
+		return ParseResult{}, fmt.Errorf(
+			"toll: %q is not an E-ZPass statement (no header row with %q and %q)",
+			name, ezColPostingDate, ezColAmount)
+	}
+
+	res := ParseResult{Account: ezPassAccount(sheets, t)}
+	res.Rows = make([]Row, 0, len(t.rows))
+	for i, raw := range t.rows {
+		rowNum := t.headerRow + 1 + i
+		if isBlank(raw) || !isTollLine(t.cell(raw, ezColDescription)) {
+			res.Skipped++
+			continue
+		}
+		row, err := parseEZPassRow(t, rowNum, raw)
+		if err != nil {
+			var re RowError
+			if !errors.As(err, &re) {
+				re = RowError{RowNumber: rowNum, Err: err}
+			}
+			res.Errors = append(res.Errors, re)
+			continue
+		}
+		res.Rows = append(res.Rows, row)
+	}
+
+	assignHashes(ProviderEZPassCSV, res.Rows)
+	return res, nil
+}
+
+// isTollLine reports whether a ledger line is a toll (or a toll adjustment).
+// A file without a Description column is taken to be tolls only.
+func isTollLine(desc string) bool {
+	return desc == "" || strings.Contains(strings.ToLower(desc), "toll")
+}
+
+func parseEZPassRow(t table, rowNum int, raw []string) (Row, error) {
+	postRaw := t.cell(raw, ezColPostingDate)
+	post, ok := ParseFileTime(postRaw)
+	if !ok {
+		return Row{}, RowError{
+			RowNumber: rowNum, Column: ezColPostingDate, Value: postRaw,
+			Err: fmt.Errorf("unreadable date"),
+		}
+	}
+	amountRaw := t.cell(raw, ezColAmount)
+	amount, err := ParseMoney(amountRaw)
+	if err != nil {
+		return Row{}, RowError{RowNumber: rowNum, Column: ezColAmount, Value: amountRaw, Err: err}
+	}
+
+	tag := t.cell(raw, ezColTag)
+	row := Row{
+		RowNumber:  rowNum,
+		PostDate:   post,
+		Source:     "E-ZPass",
+		Agency:     t.cell(raw, ezColAgency),
+		AgencyRef:  tag,
+		EntryPlaza: t.cell(raw, ezColEntryPlaza),
+		ExitPlaza:  t.cell(raw, ezColExitPlaza),
+		Class:      t.cell(raw, ezColClass),
+		// Debits are printed negative; a toll reversal comes out negative here.
+		Amount: amount.Neg(),
+		Raw:    t.rowMap(raw),
+	}
+
+	// The same column holds the tag number or, for a video toll, the plate;
+	// only the description tells them apart.
+	desc := strings.ToLower(t.cell(raw, ezColDescription))
+	if strings.Contains(desc, "video") || strings.Contains(desc, "plate") {
+		row.ReadType = ReadPlate
+		row.Plate = NormalizePlate(tag)
+	} else if tag != "" {
+		row.ReadType = ReadTransponder
+		row.DeviceID = NormalizeDeviceID(tag)
+	}
+
+	if ts, ok := ParseFileTime(t.cell(raw, ezColEntryTime)); ok {
+		row.EntryAt = &ts
+	}
+	if ts, ok := ParseFileTime(t.cell(raw, ezColExitTime)); ok {
+		row.ExitAt = &ts
+	} else if ts, ok := ParseFileTime(t.cell(raw, ezColTxnDate)); ok {
+		row.ExitAt = &ts
+	}
+	return row, nil
+}
+
+// ezPassAccount reads the account number from the statement preamble above
+// the header row: either "Account Number: 123" in one cell or the label and
+// the value in neighbouring cells.
+func ezPassAccount(sheets []sheet, t table) string {
+	for _, s := range sheets {
+		if s.name != t.sheet {
+			continue
+		}
+		for _, row := range s.rows[:t.headerRow-1] {
+			for c, cell := range row {
+				label, value, _ := strings.Cut(normalizeHeader(cell), ":")
+				if !isAccountLabel(label) {
+					continue
+				}
+				if v := strings.TrimSpace(value); v != "" {
+					return v
+				}
+				for _, v := range row[c+1:] {
+					if v = strings.TrimSpace(v); v != "" {
+						return v
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func isAccountLabel(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, l := range ezAccountLabels {
+		if s == l {
+			return true
+		}
+	}
+	return false
+}
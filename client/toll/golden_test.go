@@ -0,0 +1,145 @@
+package toll
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Golden-file tests for the statement parsers. The inputs under testdata/ are
+// synthetic — made-up accounts, transponders and plates in the layouts the
+// providers export — for the same reason the PrePass fixtures are built in
+// code: real exports carry live carrier data.
+//
+// After an intentional parser change, regenerate the expectations with
+//
+//	go test ./client/toll -run TestParseGolden -update
+//
+// and review the diff of the .golden.json files like any other code change.
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden.json from the current parsers")
+
+// goldenRow is the stable, reviewable projection of a Row: dates as text,
+// amounts fixed to cents, Raw left out (it mirrors the input).
+type goldenRow struct {
+	RowNumber  int      `json:"row"`
+	PostDate   string   `json:"post_date"`
+	EntryAt    string   `json:"entry_at,omitempty"`
+	ExitAt     string   `json:"exit_at,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	Agency     string   `json:"agency,omitempty"`
+	ReadType   ReadType `json:"read_type,omitempty"`
+	DeviceID   string   `json:"device_id,omitempty"`
+	AgencyRef  string   `json:"agency_ref,omitempty"`
+	Plate      string   `json:"plate,omitempty"`
+	TruckRef   string   `json:"truck_ref,omitempty"`
+	EntryPlaza string   `json:"entry_plaza,omitempty"`
+	ExitPlaza  string   `json:"exit_plaza,omitempty"`
+	Class      string   `json:"class,omitempty"`
+	Amount     string   `json:"amount"`
+	Hash       string   `json:"hash"`
+}
+
+type goldenResult struct {
+	Account string      `json:"account,omitempty"`
+	Rows    []goldenRow `json:"rows"`
+	Errors  []string    `json:"errors,omitempty"`
+	Skipped int         `json:"skipped"`
+}
+
+func goldenTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func toGolden(res ParseResult) goldenResult {
+	out := goldenResult{Account: res.Account, Skipped: res.Skipped, Rows: []goldenRow{}}
+	for _, r := range res.Rows {
+		out.Rows = append(out.Rows, goldenRow{
+			RowNumber:  r.RowNumber,
+			PostDate:   r.PostDate.Format("2006-01-02"),
+			EntryAt:    goldenTime(r.EntryAt),
+			ExitAt:     goldenTime(r.ExitAt),
+			Source:     r.Source,
+			Agency:     r.Agency,
+			ReadType:   r.ReadType,
+			DeviceID:   r.DeviceID,
+			AgencyRef:  r.AgencyRef,
+			Plate:      r.Plate,
+			TruckRef:   r.TruckRef,
+			EntryPlaza: r.EntryPlaza,
+			ExitPlaza:  r.ExitPlaza,
+			Class:      r.Class,
+			Amount:     r.Amount.StringFixed(2),
+			Hash:       r.Hash,
+		})
+	}
+	for _, e := range res.Errors {
+		out.Errors = append(out.Errors, e.Error())
+	}
+	return out
+}
+
+func TestParseGolden(t *testing.T) {
+	cases := []struct {
+		provider Provider
+		file     string
+	}{
+		{NewBestpassAPI(Credential{ProviderType: ProviderBestpassAPI}), "bestpass/export.csv"},
+		{NewBestpassAPI(Credential{ProviderType: ProviderBestpassAPI}), "bestpass/export.xlsx"},
+		{NewEZPassCSV(Credential{ProviderType: ProviderEZPassCSV}), "ezpass/statement.csv"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			input := filepath.Join("testdata", tc.file)
+			content, err := os.ReadFile(input)
+			require.NoError(t, err)
+
+			res, err := tc.provider.Parse(filepath.Base(input), content)
+			require.NoError(t, err)
+			got, err := json.MarshalIndent(toGolden(res), "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			golden := input + ".golden.json"
+			if *update {
+				require.NoError(t, os.WriteFile(golden, got, 0o644))
+				return
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "missing golden file; run with -update")
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestParse_BestpassRejectsForeignFile(t *testing.T) {
+	p := NewBestpassAPI(Credential{ProviderType: ProviderBestpassAPI})
+	_, err := p.Parse("prepass.csv", []byte("Post Date,Toll $\n2024-09-02,7.70\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Bestpass transaction table")
+}
+
+func TestParse_EZPassSkipsLedgerLinesAndFlipsSign(t *testing.T) {
+	p := NewEZPassCSV(Credential{ProviderType: ProviderEZPassCSV})
+	csv := "Account: 555\nPosting Date,Tag/Plate Number,Description,Amount\n" +
+		"09/02/2024,006111,Toll,($1.50)\n" +
+		"09/02/2024,,Replenishment,$100.00\n" +
+		"09/03/2024,006111,Toll Reversal,$1.50\n"
+
+	res, err := p.Parse("s.csv", []byte(csv))
+	require.NoError(t, err)
+	assert.Equal(t, "555", res.Account)
+	require.Len(t, res.Rows, 2)
+	assert.Equal(t, "1.50", res.Rows[0].Amount.StringFixed(2), "a debit is a charge")
+	assert.Equal(t, "-1.50", res.Rows[1].Amount.StringFixed(2), "a reversal is a credit")
+	assert.Equal(t, 1, res.Skipped, "a replenishment is not a toll")
+}
@@ -1,7 +1,6 @@
 package toll
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -44,123 +43,31 @@ const colAccount = "Account"
 // from the company's stored credential and these apply when it leaves them
 // blank.
 const (
-	prePassSFTPPort = 22
-
 	envTestPrePassHost = "TEST_PREPASS_SFTP_HOST"
 	envTestPrePassPort = "TEST_PREPASS_SFTP_PORT"
 	envTestPrePassDir  = "TEST_PREPASS_SFTP_DIR"
 )
 
-// sftpFetcher is the read-side seam that lets tests replace the network.
-// Mirrors factoring's sftpUploader, inverted.
-type sftpFetcher interface {
-	List(remoteDir string, exts []string) ([]RemoteFile, error)
-	Fetch(remoteDir, name string) ([]byte, error)
-	Close() error
-}
-
-type dialFunc func(context.Context, sftpDialer) (sftpFetcher, error)
-
-func defaultSFTPDial(ctx context.Context, d sftpDialer) (sftpFetcher, error) {
-	return dialSFTP(ctx, d)
-}
-
 // PrePassSFTP pulls the weekly toll spreadsheet PrePass drops into a carrier's
 // SFTP folder. PrePass publishes no API — the file is the entire interface,
 // and it carries no transaction id, which is why rows are deduplicated by
 // content hash rather than by key.
+//
+// List, Fetch and TestConnection come from the embedded sftpSource; the
+// remote file is deliberately left in place after a Fetch.
 type PrePassSFTP struct {
-	host        string
-	port        int
-	directory   string
-	username    string
-	password    string
-	hostKey     string
+	sftpSource
 	accountName string
-
-	dialFn dialFunc
 }
 
 // NewPrePassSFTP builds the provider from a stored credential, applying the
 // non-production folder override when one is configured.
 func NewPrePassSFTP(cred Credential) *PrePassSFTP {
-	host := strings.TrimSpace(cred.Host)
-	port := cred.Port
-	dir := strings.TrimSpace(cred.Directory)
-
-	// On non-production deployments point every carrier at our own catcher
-	// folder regardless of what the database says. Staging databases are
-	// restored from production, so without this a stage run would reach into
-	// the real PrePass account.
-	if isNonProdAppEnv() {
-		if v := firstNonEmptyEnv(envTestPrePassHost); v != "" {
-			host = v
-		}
-		if v := firstNonEmptyEnv(envTestPrePassPort); v != "" {
-			if n, err := strconv.Atoi(v); err == nil && n > 0 {
-				port = n
-			}
-		}
-		if v := firstNonEmptyEnv(envTestPrePassDir); v != "" {
-			dir = v
-		}
-	}
-	if port == 0 {
-		port = prePassSFTPPort
-	}
-
 	return &PrePassSFTP{
-		host:        host,
-		port:        port,
-		directory:   dir,
-		username:    cred.Username,
-		password:    cred.Secret,
-		hostKey:     cred.HostKey,
+		sftpSource: newSFTPSource(ProviderPrePassSFTP, cred,
+			envTestPrePassHost, envTestPrePassPort, envTestPrePassDir),
 		accountName: strings.TrimSpace(cred.AccountName),
-		dialFn:      defaultSFTPDial,
-	}
-}
-
-func (p *PrePassSFTP) dialer() sftpDialer {
-	return sftpDialer{
-		Host:         p.host,
-		Port:         p.port,
-		Username:     p.username,
-		Password:     p.password,
-		ProviderType: ProviderPrePassSFTP,
-		HostKey:      p.hostKey,
-	}
-}
-
-// List returns the spreadsheets waiting in the carrier's folder, newest first.
-func (p *PrePassSFTP) List(ctx context.Context) ([]RemoteFile, error) {
-	c, err := p.dialFn(ctx, p.dialer())
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = c.Close() }()
-	return c.List(p.directory, RulesFor(ProviderPrePassSFTP).FileExtensions)
-}
-
-// Fetch downloads one spreadsheet. The remote file is deliberately left in
-// place: it is the carrier's own record with PrePass, and re-reading it is
-// made harmless by row hashing.
-func (p *PrePassSFTP) Fetch(ctx context.Context, name string) ([]byte, error) {
-	c, err := p.dialFn(ctx, p.dialer())
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = c.Close() }()
-	return c.Fetch(p.directory, name)
-}
-
-// TestConnection dials and closes without reading anything.
-func (p *PrePassSFTP) TestConnection(ctx context.Context) error {
-	c, err := p.dialFn(ctx, p.dialer())
-	if err != nil {
-		return err
 	}
-	return c.Close()
 }
 
 // Parse reads a PrePass export into normalised rows.
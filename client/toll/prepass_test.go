@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/TMS360/backend-pkg/secrets"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xuri/excelize/v2"
@@ -556,7 +557,7 @@ func TestNewProviderFromCredential(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, p)
 
-	_, err = NewProviderFromCredential(Credential{ProviderType: "bestpass_api"})
+	_, err = NewProviderFromCredential(Credential{ProviderType: "ipass_api"})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unknown provider_type")
 
@@ -612,6 +613,29 @@ func TestCredential_RedactedHidesOnlyTheSecret(t *testing.T) {
 	assert.Empty(t, Credential{}.Redacted().Secret)
 }
 
+// A tenant controls both the credential and, for API providers, the URL the
+// secret is sent to — so a reference to the process environment must fail
+// instead of resolving to an operator secret.
+func TestCredential_ResolveStaysInsideTheCompany(t *testing.T) {
+	t.Setenv("JWT_PRIVATE_KEY", "operator-only")
+	ctx := context.Background()
+	vault, err := secrets.NewLocalVault("")
+	require.NoError(t, err)
+	require.NoError(t, vault.Put(ctx, "acme/bestpass", "acme-key"))
+	tr := secrets.NewTenantResolver("vault", vault, nil)
+
+	c := Credential{ProviderType: ProviderBestpassAPI, Secret: "secret://env/JWT_PRIVATE_KEY", BaseURL: "https://attacker.example"}
+	_, err = c.Resolve(ctx, "acme", tr)
+	require.Error(t, err)
+	_, err = c.Resolve(ctx, "acme", nil)
+	require.Error(t, err, "the default path is the tenant resolver, not the operator one")
+
+	c.Secret = "secret://vault/acme/bestpass"
+	got, err := c.Resolve(ctx, "acme", tr)
+	require.NoError(t, err)
+	assert.Equal(t, "acme-key", got.Secret)
+}
+
 // The credential is persisted as one opaque blob, so a provider on a transport
 // we have not written yet must not need a schema change. Only the fields a
 // transport actually uses may appear.
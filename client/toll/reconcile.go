@@ -0,0 +1,510 @@
+package toll
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TMS360/backend-pkg/client/here"
+	"github.com/shopspring/decimal"
+)
+
+// Reconciliation answers "should the carrier pay this toll?" for rows that
+// loads.MatchTollRows has already attributed to a truck (and usually a trip).
+// Matching says WHOSE crossing a row is; reconciliation checks whether that
+// crossing is plausible against what the truck actually did:
+//
+//   - outside_trip   — the truck had no active trip at the crossing time,
+//   - off_route      — the plaza is nowhere near the GPS breadcrumbs at that
+//     time, or (without breadcrumbs) nowhere near the planned route,
+//   - duplicate      — the same device or plate was charged twice at the same
+//     plaza within a few minutes,
+//   - class_mismatch — the billed class implies a different axle count than
+//     the truck has.
+//
+// A charge the aggregator has already reversed in full — a credit with the
+// same identity, plaza and amount, negated — is dropped before any check:
+// disputing it would ask for money that was already returned.
+//
+// Like Parse it is pure: the caller loads trips, routes and breadcrumbs and
+// passes them in, so a report can be regenerated and diffed deterministically.
+
+// Flag is one reason a charge is disputed.
+type Flag string
+
+const (
+	FlagOutsideTrip   Flag = "outside_trip"
+	FlagOffRoute      Flag = "off_route"
+	FlagDuplicate     Flag = "duplicate"
+	FlagClassMismatch Flag = "class_mismatch"
+)
+
+// LatLng is a WGS84 position in degrees.
+type LatLng struct {
+	Lat float64
+	Lng float64
+}
+
+// Breadcrumb is one GPS fix of a truck.
+type Breadcrumb struct {
+	At time.Time
+	LatLng
+}
+
+// Trip is what the fleet knows about one trip of one truck. Times are
+// instants.
+type Trip struct {
+	ID      string
+	TruckID string
+	Start   time.Time
+	End     time.Time
+
+	// Axles is the truck's axle count (tractor plus trailer). Zero skips the
+	// class check.
+	Axles int
+
+	// RoutePolyline is the planned route as HERE returns it; Route is the same
+	// already decoded. Either may be set; both empty skips the route check.
+	RoutePolyline string
+	Route         []LatLng
+
+	// Breadcrumbs are the truck's GPS fixes, in any order. When they cover the
+	// crossing they take precedence over the planned route: a truck may
+	// legitimately leave the plan, and the toll follows the truck.
+	Breadcrumbs []Breadcrumb
+}
+
+// MatchedRow is a parsed row with the attribution MatchTollRows returned.
+type MatchedRow struct {
+	Row
+	// TruckID is empty when the row could not be attributed; such rows are
+	// counted but not judged.
+	TruckID string
+	// TripID is the trip MatchTollRows found, if any. When empty the
+	// reconciler looks for one itself among the truck's trips.
+	TripID string
+}
+
+// PlazaLocator places a plaza on the map. Toll files name plazas, never
+// coordinates, so the off-route check needs a lookup the caller maintains.
+type PlazaLocator interface {
+	LocatePlaza(agency, plaza string) (LatLng, bool)
+}
+
+// PlazaTable is a static PlazaLocator keyed by PlazaKey(agency, plaza).
+type PlazaTable map[string]LatLng
+
+// PlazaKey is the PlazaTable key: agency and plaza, case- and
+// whitespace-insensitive.
+func PlazaKey(agency, plaza string) string {
+	return strings.ToLower(normalizeHeader(agency)) + "|" + strings.ToLower(normalizeHeader(plaza))
+}
+
+// LocatePlaza implements PlazaLocator.
+func (t PlazaTable) LocatePlaza(agency, plaza string) (LatLng, bool) {
+	p, ok := t[PlazaKey(agency, plaza)]
+	return p, ok
+}
+
+// ReconcileOption customizes a Reconciler.
+type ReconcileOption func(*Reconciler)
+
+// WithLocation sets the zone the file's wall-clock times are read in — the
+// company's zone. See the TIME ZONES note on Row. Defaults to UTC.
+func WithLocation(loc *time.Location) ReconcileOption {
+	return func(r *Reconciler) {
+		if loc != nil {
+			r.loc = loc
+		}
+	}
+}
+
+// WithPlazas enables the off-route check.
+func WithPlazas(l PlazaLocator) ReconcileOption {
+	return func(r *Reconciler) { r.plazas = l }
+}
+
+// WithTripGrace widens every trip window on both sides. Trip start and end
+// are recorded by people and apps, not by the plaza, so a crossing a few
+// minutes before dispatch is not a dispute. Default 30 minutes.
+func WithTripGrace(d time.Duration) ReconcileOption {
+	return func(r *Reconciler) { r.tripGrace = d }
+}
+
+// WithOffRouteDistance sets how far, in meters, a plaza may sit from the
+// truck's path before it is flagged. Default 3 km — plazas are geocoded to
+// the booth, routes to the road centre line, and breadcrumbs are minutes
+// apart at highway speed.
+func WithOffRouteDistance(meters float64) ReconcileOption {
+	return func(r *Reconciler) { r.offRouteMeters = meters }
+}
+
+// WithDuplicateWindow sets how close two charges at one plaza must be to
+// count as a double charge. Default 10 minutes.
+func WithDuplicateWindow(d time.Duration) ReconcileOption {
+	return func(r *Reconciler) { r.dupWindow = d }
+}
+
+// WithClassAxles overrides how a billed class maps to an axle count. The
+// default reads the class as the axle count, which is what E-ZPass agencies
+// and most others bill trucks by; agencies with their own class tables plug
+// in here. ok=false skips the check for that row.
+func WithClassAxles(fn func(agency, class string) (axles int, ok bool)) ReconcileOption {
+	return func(r *Reconciler) { r.classAxles = fn }
+}
+
+// Reconciler checks matched rows against trips.
+type Reconciler struct {
+	loc            *time.Location
+	plazas         PlazaLocator
+	tripGrace      time.Duration
+	offRouteMeters float64
+	dupWindow      time.Duration
+	classAxles     func(agency, class string) (int, bool)
+}
+
+// NewReconciler builds a Reconciler with the defaults documented on the
+// options.
+func NewReconciler(opts ...ReconcileOption) *Reconciler {
+	r := &Reconciler{
+		loc:            time.UTC,
+		tripGrace:      30 * time.Minute,
+		offRouteMeters: 3000,
+		dupWindow:      10 * time.Minute,
+		classAxles:     defaultClassAxles,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func defaultClassAxles(_, class string) (int, bool) {
+	var n int
+	if _, err := fmt.Sscanf(strings.TrimSpace(class), "%d", &n); err != nil || n < 2 || n > 9 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Finding is one disputed charge.
+type Finding struct {
+	Row     Row
+	TruckID string
+	TripID  string
+	Flags   []Flag
+	// Evidence is one human-readable line per flag, in Flags order, written
+	// for the aggregator's dispute desk.
+	Evidence []string
+	// DuplicateOf is the Hash of the charge this one duplicates.
+	DuplicateOf string
+}
+
+// Report is the outcome of one reconciliation run.
+type Report struct {
+	// Findings are the disputed charges, in row order.
+	Findings []Finding
+	// Checked counts the rows judged; Unattributed the rows skipped because
+	// no truck was known; Credits the reversals, refunds and zero-amount rows,
+	// which are never disputed; Reversed the charges a credit cancelled in
+	// full, which are not judged either.
+	Checked      int
+	Unattributed int
+	Credits      int
+	Reversed     int
+	// Disputed is the sum of the disputed charges' amounts.
+	Disputed decimal.Decimal
+}
+
+// preparedTrip is a Trip with its route decoded and breadcrumbs sorted.
+type preparedTrip struct {
+	Trip
+	route []LatLng
+}
+
+// Reconcile judges every charge that was not reversed in full. The only
+// error is an undecodable route polyline; everything about
+// the rows themselves ends up in the report.
+func (r *Reconciler) Reconcile(rows []MatchedRow, trips []Trip) (Report, error) {
+	byID := make(map[string]*preparedTrip, len(trips))
+	byTruck := make(map[string][]*preparedTrip)
+	for _, t := range trips {
+		pt, err := prepareTrip(t)
+		if err != nil {
+			return Report{}, err
+		}
+		byID[t.ID] = pt
+		byTruck[t.TruckID] = append(byTruck[t.TruckID], pt)
+	}
+
+	rep := Report{Disputed: decimal.Zero}
+	reversed := r.reversed(rows)
+	dups := r.duplicates(rows, reversed)
+	for i, m := range rows {
+		if !m.Amount.IsPositive() {
+			rep.Credits++
+			continue
+		}
+		if reversed[i] {
+			rep.Reversed++
+			continue
+		}
+		if m.TruckID == "" {
+			rep.Unattributed++
+			continue
+		}
+		rep.Checked++
+		f := Finding{Row: m.Row, TruckID: m.TruckID, TripID: m.TripID}
+		at, hasTime := r.crossingTime(m.Row)
+
+		trip := byID[m.TripID]
+		if trip == nil && hasTime {
+			trip = r.tripAt(byTruck[m.TruckID], at)
+			if trip == nil {
+				f.add(FlagOutsideTrip, fmt.Sprintf(
+					"no trip of truck %s was active at %s", m.TruckID, at.Format(time.RFC3339)))
+			}
+		}
+		if trip != nil {
+			f.TripID = trip.ID
+			if hasTime {
+				r.checkRoute(&f, trip, at)
+			}
+			r.checkClass(&f, trip)
+		}
+		if orig, ok := dups[i]; ok {
+			f.DuplicateOf = rows[orig].Hash
+			f.add(FlagDuplicate, fmt.Sprintf("same %s at %s charged on row %d",
+				identity(m.Row), m.ExitPlaza, rows[orig].RowNumber))
+		}
+
+		if len(f.Flags) > 0 {
+			rep.Findings = append(rep.Findings, f)
+			rep.Disputed = rep.Disputed.Add(m.Amount)
+		}
+	}
+	return rep, nil
+}
+
+func (f *Finding) add(flag Flag, evidence string) {
+	f.Flags = append(f.Flags, flag)
+	f.Evidence = append(f.Evidence, evidence)
+}
+
+func prepareTrip(t Trip) (*preparedTrip, error) {
+	route := t.Route
+	if len(route) == 0 && t.RoutePolyline != "" {
+		coords, err := here.DecodeFlexiblePolyline(t.RoutePolyline)
+		if err != nil {
+			return nil, fmt.Errorf("toll: trip %s route: %w", t.ID, err)
+		}
+		route = make([]LatLng, len(coords))
+		for i, c := range coords {
+			route[i] = LatLng{Lat: c.Lat, Lng: c.Lng}
+		}
+	}
+	crumbs := append([]Breadcrumb(nil), t.Breadcrumbs...)
+	sort.Slice(crumbs, func(i, j int) bool { return crumbs[i].At.Before(crumbs[j].At) })
+	t.Breadcrumbs = crumbs
+	return &preparedTrip{Trip: t, route: route}, nil
+}
+
+// crossingTime is the exit (else entry) time re-read in the company's zone.
+// Post Date is not used: it lags the crossing by days.
+func (r *Reconciler) crossingTime(row Row) (time.Time, bool) {
+	ts := row.ExitAt
+	if ts == nil {
+		ts = row.EntryAt
+	}
+	if ts == nil {
+		return time.Time{}, false
+	}
+	return time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, r.loc), true
+}
+
+func (r *Reconciler) tripAt(trips []*preparedTrip, at time.Time) *preparedTrip {
+	for _, t := range trips {
+		if !at.Before(t.Start.Add(-r.tripGrace)) && !at.After(t.End.Add(r.tripGrace)) {
+			return t
+		}
+	}
+	return nil
+}
+
+// breadcrumbSpan is how far from the crossing a GPS fix may be to count as
+// evidence of where the truck was.
+const breadcrumbSpan = 20 * time.Minute
+
+func (r *Reconciler) checkRoute(f *Finding, t *preparedTrip, at time.Time) {
+	if r.plazas == nil {
+		return
+	}
+	plaza := f.Row.ExitPlaza
+	if plaza == "" {
+		plaza = f.Row.EntryPlaza
+	}
+	pos, ok := r.plazas.LocatePlaza(f.Row.Agency, plaza)
+	if !ok {
+		return
+	}
+
+	lo := sort.Search(len(t.Breadcrumbs), func(i int) bool {
+		return !t.Breadcrumbs[i].At.Before(at.Add(-breadcrumbSpan))
+	})
+	best, seen := math.Inf(1), false
+	for _, b := range t.Breadcrumbs[lo:] {
+		if b.At.After(at.Add(breadcrumbSpan)) {
+			break
+		}
+		seen = true
+		best = math.Min(best, haversine(pos, b.LatLng))
+	}
+	if seen {
+		if best > r.offRouteMeters {
+			f.add(FlagOffRoute, fmt.Sprintf("%s (%s) is %.1f km from the truck's GPS position around %s",
+				plaza, f.Row.Agency, best/1000, at.Format("15:04")))
+		}
+		return
+	}
+	if len(t.route) == 0 {
+		return
+	}
+	if d := distanceToPath(pos, t.route); d > r.offRouteMeters {
+		f.add(FlagOffRoute, fmt.Sprintf("%s (%s) is %.1f km from the planned route of trip %s",
+			plaza, f.Row.Agency, d/1000, t.ID))
+	}
+}
+
+func (r *Reconciler) checkClass(f *Finding, t *preparedTrip) {
+	if t.Axles == 0 || f.Row.Class == "" {
+		return
+	}
+	billed, ok := r.classAxles(f.Row.Agency, f.Row.Class)
+	if !ok || billed == t.Axles {
+		return
+	}
+	f.add(FlagClassMismatch, fmt.Sprintf("billed class %s (%d axles), truck %s runs %d axles",
+		f.Row.Class, billed, f.TruckID, t.Axles))
+}
+
+// chargeKey is what pairs a charge with its repeat or its reversal.
+type chargeKey struct{ id, agency, plaza, amount string }
+
+func keyOf(row Row, amount decimal.Decimal) chargeKey {
+	return chargeKey{identity(row), row.Agency, row.ExitPlaza, amount.StringFixed(2)}
+}
+
+// reversed marks the charges a credit cancels in full: each reversal (same
+// identity, plaza and amount, negated) takes the latest charge of its key
+// not yet taken — a double charge is refunded on the repeat, not the
+// original. Rows without an identity are never paired.
+func (r *Reconciler) reversed(rows []MatchedRow) map[int]bool {
+	charges := make(map[chargeKey][]int)
+	for i, m := range rows {
+		if m.Amount.IsPositive() && identity(m.Row) != "" {
+			k := keyOf(m.Row, m.Amount)
+			charges[k] = append(charges[k], i)
+		}
+	}
+	for _, idx := range charges {
+		sort.SliceStable(idx, func(a, b int) bool {
+			ta, _ := r.crossingTime(rows[idx[a]].Row)
+			tb, _ := r.crossingTime(rows[idx[b]].Row)
+			return ta.Before(tb)
+		})
+	}
+
+	out := make(map[int]bool)
+	for _, m := range rows {
+		if !m.Amount.IsNegative() || identity(m.Row) == "" {
+			continue
+		}
+		k := keyOf(m.Row, m.Amount.Neg())
+		if idx := charges[k]; len(idx) > 0 {
+			out[idx[len(idx)-1]] = true
+			charges[k] = idx[:len(idx)-1]
+		}
+	}
+	return out
+}
+
+// duplicates maps the index of every duplicate charge to the index of the
+// charge it repeats. Reversed charges take no part: a refunded repeat is not
+// a duplicate, and a refunded original is not what a repeat duplicates.
+func (r *Reconciler) duplicates(rows []MatchedRow, reversed map[int]bool) map[int]int {
+	type charge struct {
+		idx int
+		at  time.Time
+	}
+	groups := make(map[chargeKey][]charge)
+	for i, m := range rows {
+		if !m.Amount.IsPositive() || identity(m.Row) == "" || reversed[i] {
+			continue
+		}
+		at, ok := r.crossingTime(m.Row)
+		if !ok {
+			continue
+		}
+		k := keyOf(m.Row, m.Amount)
+		groups[k] = append(groups[k], charge{i, at})
+	}
+
+	out := make(map[int]int)
+	for _, cs := range groups {
+		sort.Slice(cs, func(i, j int) bool { return cs[i].at.Before(cs[j].at) })
+		for i := 1; i < len(cs); i++ {
+			prev := cs[i-1]
+			if cs[i].at.Sub(prev.at) > r.dupWindow {
+				continue
+			}
+			out[cs[i].idx] = prev.idx
+		}
+	}
+	return out
+}
+
+// identity is what the plaza read: the transponder, else the plate.
+func identity(row Row) string {
+	if row.DeviceID != "" {
+		return row.DeviceID
+	}
+	if row.Plate != "" {
+		return "plate " + row.Plate
+	}
+	return ""
+}
+
+const earthRadiusMeters = 6371008.8
+
+func haversine(a, b LatLng) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// distanceToPath is the distance in meters from p to the nearest segment of
+// path, on a local equirectangular projection — accurate to well under a
+// percent over the few kilometres that matter here.
+func distanceToPath(p LatLng, path []LatLng) float64 {
+	if len(path) == 1 {
+		return haversine(p, path[0])
+	}
+	kx := earthRadiusMeters * math.Pi / 180 * math.Cos(p.Lat*math.Pi/180)
+	ky := earthRadiusMeters * math.Pi / 180
+	best := math.Inf(1)
+	for i := 1; i < len(path); i++ {
+		ax, ay := (path[i-1].Lng-p.Lng)*kx, (path[i-1].Lat-p.Lat)*ky
+		bx, by := (path[i].Lng-p.Lng)*kx, (path[i].Lat-p.Lat)*ky
+		dx, dy := bx-ax, by-ay
+		t := 0.0
+		if l := dx*dx + dy*dy; l > 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/l))
+		}
+		best = math.Min(best, math.Hypot(ax+t*dx, ay+t*dy))
+	}
+	return best
+}
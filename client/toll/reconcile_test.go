@@ -0,0 +1,201 @@
+package toll
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var chicago = func() *time.Location {
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}()
+
+// I-90 between Elgin and Rockford, roughly west-north-west.
+var i90 = []LatLng{{42.0354, -88.2826}, {42.1200, -88.6500}, {42.2600, -89.0000}}
+
+var plazas = PlazaTable{
+	PlazaKey("ILTOLL", "Belvidere"): {42.2000, -88.8600}, // on I-90
+	PlazaKey("ILTOLL", "82nd St."):  {41.7450, -87.5600}, // Chicago Skyway, far off
+	PlazaKey("ILTOLL", "Elgin"):     {42.0400, -88.2900}, // on I-90
+}
+
+// wall builds a file time: the company's wall clock stamped UTC.
+func wall(day, hour, min int) *time.Time {
+	t := time.Date(2024, 9, day, hour, min, 0, 0, time.UTC)
+	return &t
+}
+
+func charge(n int, truck, device, plaza string, exit *time.Time, class string, amount string) MatchedRow {
+	return MatchedRow{
+		Row: Row{
+			RowNumber: n, PostDate: time.Date(2024, 9, 4, 0, 0, 0, 0, time.UTC),
+			Agency: "ILTOLL", DeviceID: device, ExitPlaza: plaza, ExitAt: exit,
+			Class: class, Amount: decimal.RequireFromString(amount), Hash: string(rune('a' + n)),
+		},
+		TruckID: truck,
+	}
+}
+
+func testTrip() Trip {
+	return Trip{
+		ID: "trip-1", TruckID: "truck-A", Axles: 5, Route: i90,
+		Start: time.Date(2024, 9, 1, 8, 0, 0, 0, chicago),
+		End:   time.Date(2024, 9, 1, 18, 0, 0, 0, chicago),
+	}
+}
+
+func TestReconcile_FlagsEachKindOfDispute(t *testing.T) {
+	rows := []MatchedRow{
+		charge(1, "truck-A", "D1", "Belvidere", wall(1, 10, 0), "5", "4.20"),
+		charge(2, "truck-A", "D1", "Elgin", wall(1, 22, 30), "5", "2.10"),
+		charge(3, "truck-A", "D1", "82nd St.", wall(1, 12, 0), "5", "7.70"),
+		charge(4, "truck-A", "D1", "Belvidere", wall(1, 10, 3), "5", "4.20"),
+		charge(5, "truck-A", "D1", "Elgin", wall(1, 9, 0), "6", "3.30"),
+		charge(6, "", "D9", "Elgin", wall(1, 9, 0), "5", "3.30"),
+	}
+
+	rep, err := NewReconciler(WithLocation(chicago), WithPlazas(plazas)).Reconcile(rows, []Trip{testTrip()})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, rep.Checked)
+	assert.Equal(t, 1, rep.Unattributed)
+	flags := map[int][]Flag{}
+	for _, f := range rep.Findings {
+		flags[f.Row.RowNumber] = f.Flags
+		assert.Len(t, f.Evidence, len(f.Flags))
+	}
+	assert.Equal(t, map[int][]Flag{
+		2: {FlagOutsideTrip},
+		3: {FlagOffRoute},
+		4: {FlagDuplicate},
+		5: {FlagClassMismatch},
+	}, flags)
+	assert.Equal(t, "17.30", rep.Disputed.StringFixed(2))
+	assert.Equal(t, "trip-1", rep.Findings[1].TripID, "the trip is found by time when MatchTollRows gave none")
+	assert.Equal(t, rows[0].Hash, rep.Findings[2].DuplicateOf)
+}
+
+// The file prints 22:30 Chicago time. Read as UTC it would be 17:30 in
+// Chicago, before an evening trip that starts at 19:00 — the reconciler must
+// use the company zone.
+func TestReconcile_WallClockIsReadInCompanyZone(t *testing.T) {
+	trip := testTrip()
+	trip.Start = time.Date(2024, 9, 1, 19, 0, 0, 0, chicago)
+	trip.End = time.Date(2024, 9, 1, 23, 0, 0, 0, chicago)
+	rows := []MatchedRow{charge(1, "truck-A", "D1", "Elgin", wall(1, 22, 30), "5", "2.10")}
+
+	rep, err := NewReconciler(WithLocation(chicago)).Reconcile(rows, []Trip{trip})
+	require.NoError(t, err)
+	assert.Empty(t, rep.Findings)
+
+	rep, err = NewReconciler().Reconcile(rows, []Trip{trip})
+	require.NoError(t, err)
+	require.Len(t, rep.Findings, 1, "read as UTC the same crossing falls outside the trip")
+}
+
+func TestReconcile_BreadcrumbsTakePrecedenceOverRoute(t *testing.T) {
+	trip := testTrip()
+	// The truck detoured through Chicago; GPS puts it at the Skyway.
+	trip.Breadcrumbs = []Breadcrumb{
+		{At: time.Date(2024, 9, 1, 12, 5, 0, 0, chicago), LatLng: LatLng{41.7460, -87.5610}},
+		{At: time.Date(2024, 9, 1, 11, 55, 0, 0, chicago), LatLng: LatLng{41.7300, -87.5500}},
+	}
+	rows := []MatchedRow{
+		charge(1, "truck-A", "D1", "82nd St.", wall(1, 12, 0), "5", "7.70"),
+		charge(2, "truck-A", "D1", "Belvidere", wall(1, 12, 1), "5", "4.20"),
+	}
+
+	rep, err := NewReconciler(WithLocation(chicago), WithPlazas(plazas)).Reconcile(rows, []Trip{trip})
+	require.NoError(t, err)
+	require.Len(t, rep.Findings, 1)
+	assert.Equal(t, 2, rep.Findings[0].Row.RowNumber, "the plaza on the plan is the one the truck was not at")
+	assert.Contains(t, rep.Findings[0].Evidence[0], "GPS")
+}
+
+func TestReconcile_ReversalCancelsDuplicate(t *testing.T) {
+	rows := []MatchedRow{
+		charge(1, "truck-A", "D1", "Belvidere", wall(1, 10, 0), "5", "4.20"),
+		charge(2, "truck-A", "D1", "Belvidere", wall(1, 10, 2), "5", "4.20"),
+		charge(3, "truck-A", "D1", "Belvidere", wall(1, 10, 2), "5", "-4.20"),
+	}
+	rep, err := NewReconciler(WithLocation(chicago)).Reconcile(rows, []Trip{testTrip()})
+	require.NoError(t, err)
+	assert.Empty(t, rep.Findings, "the aggregator already refunded the double charge")
+	assert.Equal(t, 1, rep.Checked)
+	assert.Equal(t, 1, rep.Credits)
+	assert.Equal(t, 1, rep.Reversed)
+}
+
+func TestReconcile_ReversedChargeIsNeverDisputed(t *testing.T) {
+	rows := []MatchedRow{
+		// Outside the trip and off route, but refunded in full on row 2.
+		charge(1, "truck-A", "D1", "82nd St.", wall(1, 22, 30), "5", "7.70"),
+		charge(2, "truck-A", "D1", "82nd St.", wall(2, 9, 0), "5", "-7.70"),
+		// A partial credit cancels nothing.
+		charge(3, "truck-A", "D1", "Elgin", wall(1, 22, 40), "5", "2.10"),
+		charge(4, "truck-A", "D1", "Elgin", wall(2, 9, 0), "5", "-1.00"),
+	}
+	rep, err := NewReconciler(WithLocation(chicago), WithPlazas(plazas)).Reconcile(rows, []Trip{testTrip()})
+	require.NoError(t, err)
+	require.Len(t, rep.Findings, 1, "only the charge that was not refunded")
+	assert.Equal(t, 3, rep.Findings[0].Row.RowNumber)
+	assert.Equal(t, "2.10", rep.Disputed.StringFixed(2), "the refunded charge is not in the disputed total")
+	assert.Equal(t, 2, rep.Credits)
+	assert.Equal(t, 1, rep.Reversed)
+	assert.Equal(t, 1, rep.Checked)
+}
+
+func TestReconcile_DecodesHEREPolyline(t *testing.T) {
+	// The flexible-polyline reference sample: a short path through Frankfurt.
+	trip := Trip{
+		ID: "trip-fra", TruckID: "truck-B", RoutePolyline: "BFoz5xJ67i1B1B7PzIhaxL7Y",
+		Start: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC),
+	}
+	near := PlazaTable{
+		PlazaKey("A5", "Frankfurt"): {50.1010, 8.6930},
+		PlazaKey("A5", "Kassel"):    {51.3127, 9.4797},
+	}
+	rows := []MatchedRow{
+		{Row: Row{RowNumber: 1, Agency: "A5", ExitPlaza: "Frankfurt", ExitAt: wall(1, 12, 0), Amount: decimal.NewFromInt(5)}, TruckID: "truck-B"},
+		{Row: Row{RowNumber: 2, Agency: "A5", ExitPlaza: "Kassel", ExitAt: wall(1, 13, 0), Amount: decimal.NewFromInt(5)}, TruckID: "truck-B"},
+	}
+
+	rep, err := NewReconciler(WithPlazas(near)).Reconcile(rows, []Trip{trip})
+	require.NoError(t, err)
+	require.Len(t, rep.Findings, 1)
+	assert.Equal(t, []Flag{FlagOffRoute}, rep.Findings[0].Flags)
+
+	trip.RoutePolyline = "!!"
+	_, err = NewReconciler().Reconcile(rows, []Trip{trip})
+	assert.Error(t, err)
+}
+
+func TestReport_WriteDisputeCSV(t *testing.T) {
+	rows := []MatchedRow{charge(2, "truck-A", "D1", "Elgin", wall(1, 22, 30), "5", "2.10")}
+	rep, err := NewReconciler(WithLocation(chicago)).Reconcile(rows, []Trip{testTrip()})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, rep.WriteDisputeCSV(&buf))
+	recs, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+	assert.Equal(t, disputeHeader, recs[0])
+	assert.Equal(t, []string{
+		"2024-09-04", "2024-09-01 22:30:00", "ILTOLL", "D1", "", "", "Elgin", "5", "2.10",
+		"outside_trip", "no trip of truck truck-A was active at 2024-09-01T22:30:00-05:00",
+		"truck-A", "", "2",
+	}, recs[1])
+	assert.Equal(t, "Total", recs[2][0])
+	assert.Equal(t, "2.10", recs[2][8])
+}
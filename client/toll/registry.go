@@ -14,7 +14,8 @@ import (
 // Required-field validation is driven by RulesFor rather than hardcoded.
 // factoring's registry demands a username and a password from every provider,
 // which would reject an API-key-only vendor outright; toll asks each type what
-// it actually needs, so the first HTTP provider does not require surgery here.
+// it actually needs, so an HTTP provider like Bestpass (key only, no login)
+// needed no surgery here.
 func NewProviderFromCredential(cred Credential) (Provider, error) {
 	if err := cred.Validate(); err != nil {
 		return nil, err
@@ -22,6 +23,10 @@ func NewProviderFromCredential(cred Credential) (Provider, error) {
 	switch cred.ProviderType {
 	case ProviderPrePassSFTP:
 		return NewPrePassSFTP(cred), nil
+	case ProviderBestpassAPI:
+		return NewBestpassAPI(cred), nil
+	case ProviderEZPassCSV:
+		return NewEZPassCSV(cred), nil
 	default:
 		return nil, fmt.Errorf("toll: provider %q has no implementation yet", cred.ProviderType)
 	}
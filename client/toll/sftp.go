@@ -214,3 +214,114 @@ func hasAcceptedExt(name string, exts []string) bool {
 	}
 	return false
 }
+
+// sftpFetcher is the read-side seam that lets tests replace the network.
+// Mirrors factoring's sftpUploader, inverted.
+type sftpFetcher interface {
+	List(remoteDir string, exts []string) ([]RemoteFile, error)
+	Fetch(remoteDir, name string) ([]byte, error)
+	Close() error
+}
+
+type dialFunc func(context.Context, sftpDialer) (sftpFetcher, error)
+
+func defaultSFTPDial(ctx context.Context, d sftpDialer) (sftpFetcher, error) {
+	return dialSFTP(ctx, d)
+}
+
+// sftpSource is the transport half shared by every provider that reads a
+// carrier's SFTP folder. Providers embed it and add their own Parse.
+type sftpSource struct {
+	providerType ProviderType
+	host         string
+	port         int
+	directory    string
+	username     string
+	password     string
+	hostKey      string
+
+	dialFn dialFunc
+}
+
+// newSFTPSource reads the transport settings from cred.
+//
+// On non-production deployments the TEST_* env vars named by hostEnv, portEnv
+// and dirEnv point every carrier at our own catcher folder regardless of what
+// the database says. Staging databases are restored from production, so
+// without this a stage run would reach into the real aggregator account.
+func newSFTPSource(pt ProviderType, cred Credential, hostEnv, portEnv, dirEnv string) sftpSource {
+	host := strings.TrimSpace(cred.Host)
+	port := cred.Port
+	dir := strings.TrimSpace(cred.Directory)
+
+	if isNonProdAppEnv() {
+		if v := firstNonEmptyEnv(hostEnv); v != "" {
+			host = v
+		}
+		if v := firstNonEmptyEnv(portEnv); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				port = n
+			}
+		}
+		if v := firstNonEmptyEnv(dirEnv); v != "" {
+			dir = v
+		}
+	}
+	if port == 0 {
+		port = 22
+	}
+
+	return sftpSource{
+		providerType: pt,
+		host:         host,
+		port:         port,
+		directory:    dir,
+		username:     cred.Username,
+		password:     cred.Secret,
+		hostKey:      cred.HostKey,
+		dialFn:       defaultSFTPDial,
+	}
+}
+
+func (s *sftpSource) dialer() sftpDialer {
+	return sftpDialer{
+		Host:         s.host,
+		Port:         s.port,
+		Username:     s.username,
+		Password:     s.password,
+		ProviderType: s.providerType,
+		HostKey:      s.hostKey,
+	}
+}
+
+// List returns the files waiting in the carrier's folder, newest first,
+// filtered to the provider's accepted extensions.
+func (s *sftpSource) List(ctx context.Context) ([]RemoteFile, error) {
+	c, err := s.dialFn(ctx, s.dialer())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Close() }()
+	return c.List(s.directory, RulesFor(s.providerType).FileExtensions)
+}
+
+// Fetch downloads one file. The remote file is deliberately left in place: it
+// is the carrier's own record with the aggregator, and re-reading it is made
+// harmless by row hashing.
+func (s *sftpSource) Fetch(ctx context.Context, name string) ([]byte, error) {
+	c, err := s.dialFn(ctx, s.dialer())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.Close() }()
+	return c.Fetch(s.directory, name)
+}
+
+// TestConnection dials and closes without reading anything.
+func (s *sftpSource) TestConnection(ctx context.Context) error {
+	c, err := s.dialFn(ctx, s.dialer())
+	if err != nil {
+		return err
+	}
+	return c.Close()
+}
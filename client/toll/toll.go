@@ -1,5 +1,5 @@
 // Package toll is the abstraction for pulling toll-road transactions from
-// toll aggregators (PrePass today; EZPass, Bestpass and others later).
+// toll aggregators (PrePass, Bestpass and direct E-ZPass accounts).
 //
 // A carrier bolts a transponder to each truck; the aggregator pays the plazas
 // and bills the carrier weekly. For lease/owner-operator drivers those tolls
@@ -10,8 +10,8 @@
 // batch to a vendor, toll PULLS a file from one. Two axes vary independently
 // and are deliberately kept apart:
 //
-//   - transport — how the bytes arrive (SFTP today; FTPS/FTP/HTTP API later),
-//   - format    — how the bytes are read (PrePass column layout; others later).
+//   - transport — how the bytes arrive (SFTP or an HTTPS API; FTPS/FTP later),
+//   - format    — how the bytes are read (PrePass, Bestpass, E-ZPass layouts).
 //
 // They are separate because a human uploading a spreadsheet by hand uses the
 // format with no transport at all. Parse is therefore part of Provider and
@@ -24,8 +24,14 @@
 // (host/port/directory) IS part of Credential: every carrier gets its own
 // folder on the aggregator, so it cannot be a package constant.
 //
-// Implementations: PrePassSFTP (prepass_sftp). Add more by writing a new file
-// and registering it in registry.go.
+// Implementations: PrePassSFTP (prepass_sftp), BestpassAPI (bestpass_api) and
+// EZPassCSV (ezpass_csv). Add more by writing a new file and registering it in
+// registry.go.
+//
+// After loads.MatchTollRows has attributed rows to trucks and trips, a
+// Reconciler (reconcile.go) checks them against trip windows, routes and GPS
+// breadcrumbs and produces the dispute report the carrier sends back to the
+// aggregator.
 package toll
 
 import (
@@ -37,6 +43,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/TMS360/backend-pkg/secrets"
 	"github.com/shopspring/decimal"
 )
 
@@ -50,12 +57,22 @@ const (
 	// ProviderPrePassSFTP is PrePass delivering a weekly spreadsheet to an
 	// SFTP folder. PrePass exposes no API — the file is the whole interface.
 	ProviderPrePassSFTP ProviderType = "prepass_sftp"
+	// ProviderBestpassAPI is Bestpass serving its transaction exports over
+	// an HTTPS API. The export is the same CSV/XLSX a user downloads from the
+	// Bestpass portal, so manual uploads go through the same parser.
+	ProviderBestpassAPI ProviderType = "bestpass_api"
+	// ProviderEZPassCSV is a carrier's own E-ZPass commercial account: the
+	// monthly statement export (CSV) dropped into an SFTP folder, or uploaded
+	// by hand from the customer service center's site.
+	ProviderEZPassCSV ProviderType = "ezpass_csv"
 )
 
 // AllProviderTypes is the canonical list of supported provider types — used by
 // credential validation and by gqlgen for the GraphQL enum.
 var AllProviderTypes = []ProviderType{
 	ProviderPrePassSFTP,
+	ProviderBestpassAPI,
+	ProviderEZPassCSV,
 }
 
 // IsValid reports whether p is a known ProviderType. Use it to validate
@@ -132,9 +149,10 @@ const (
 // migration, no new column and no change to the persistence layer. Each field
 // is documented with the transports that read it; everything else is ignored.
 //
-// Secret is stored plaintext at rest, matching the existing convention for
-// vendor credentials in this project. It is masked on read via Redacted() and
-// is never logged here.
+// Secret may be stored plaintext, matching the older convention for vendor
+// credentials in this project, or as a secrets reference (secret://…) that
+// Resolve swaps for the value just before use. It is masked on read via
+// Redacted() and is never logged here.
 type Credential struct {
 	ProviderType ProviderType `json:"provider_type,omitempty"`
 
@@ -169,9 +187,10 @@ type Credential struct {
 	// never survives a container network.
 	Passive *bool `json:"passive,omitempty"`
 
-	// BaseURL is read by HTTP API transports. Usually left empty so the
-	// provider's own production endpoint is used; set it to point a company at
-	// a sandbox.
+	// BaseURL is read by HTTP API transports and required by them: no
+	// provider has a built-in endpoint, so this is where the secret is sent.
+	// It is tenant-edited like the rest of the credential, which is why it
+	// must be https and why Resolve only reads the company's own secrets.
 	BaseURL string `json:"base_url,omitempty"`
 }
 
@@ -186,6 +205,24 @@ func (c Credential) Redacted() Credential {
 	return c
 }
 
+// Resolve returns a copy with every secrets reference replaced by its value,
+// read as companyID's through r (secrets.ResolveTenantStruct when nil).
+// Credentials are tenant-edited settings — the tenant also picks BaseURL —
+// so they never reach the operator resolver: a reference outside the
+// company's own prefix fails. Plaintext fields pass through.
+func (c Credential) Resolve(ctx context.Context, companyID string, r *secrets.TenantResolver) (Credential, error) {
+	var err error
+	if r == nil {
+		err = secrets.ResolveTenantStruct(ctx, companyID, &c)
+	} else {
+		err = r.ResolveStruct(ctx, companyID, &c)
+	}
+	if err != nil {
+		return Credential{}, fmt.Errorf("toll: resolve %s credential: %w", c.ProviderType, err)
+	}
+	return c, nil
+}
+
 // Validate checks the credential against its provider's declared rules.
 func (c Credential) Validate() error {
 	if !c.ProviderType.IsValid() {
@@ -201,6 +238,9 @@ func (c Credential) Validate() error {
 	if r.RequiresBaseURL && strings.TrimSpace(c.BaseURL) == "" {
 		return fmt.Errorf("toll: %s credential missing base url", c.ProviderType)
 	}
+	if err := checkAPIBaseURL(c.ProviderType, strings.TrimSpace(c.BaseURL)); err != nil {
+		return err
+	}
 	// The host requirement is waived on non-production deployments, where the
 	// TEST_* override supplies our catcher folder instead of the real one.
 	if r.RequiresHost && strings.TrimSpace(c.Host) == "" && !isNonProdAppEnv() {
@@ -246,6 +286,22 @@ func RulesFor(pt ProviderType) Rules {
 			RequiresHost:     true,
 			FileExtensions:   []string{".xlsx", ".xls", ".csv"},
 		}
+	case ProviderBestpassAPI:
+		// Bestpass authenticates with an API key alone, and every account is
+		// served from an endpoint Bestpass hands out, so there is no default.
+		return Rules{
+			Transport:       TransportAPI,
+			RequiresSecret:  true,
+			RequiresBaseURL: true,
+		}
+	case ProviderEZPassCSV:
+		return Rules{
+			Transport:        TransportSFTP,
+			RequiresUsername: true,
+			RequiresSecret:   true,
+			RequiresHost:     true,
+			FileExtensions:   []string{".csv", ".txt"},
+		}
 	default:
 		return Rules{}
 	}
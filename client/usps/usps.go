@@ -27,6 +27,8 @@ import (
 	"time"
 
 	"github.com/TMS360/backend-pkg/config"
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/quota"
 )
 
 const (
@@ -126,10 +128,11 @@ type Client struct {
 	baseURL    string
 	oauthURL   string
 	cred       Cred
+	meter      *quota.Meter
 }
 
 // NewClient builds a Client from config defaults + per-company credentials.
-func NewClient(cfg config.UspsConfig, cred Cred) (*Client, error) {
+func NewClient(cfg config.UspsConfig, cred Cred, opts ...Option) (*Client, error) {
 	base := cfg.BaseURL
 	if base == "" {
 		base = defaultBaseURL
@@ -138,12 +141,16 @@ func NewClient(cfg config.UspsConfig, cred Cred) (*Client, error) {
 	if oauth == "" {
 		oauth = base
 	}
-	return &Client{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+	c := &Client{
+		httpClient: transport.HTTPClient(),
 		baseURL:    strings.TrimRight(base, "/"),
 		oauthURL:   strings.TrimRight(oauth, "/"),
 		cred:       cred,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewClientWithCred builds a Client with the production hosts and the given
@@ -216,10 +223,17 @@ func (c *Client) doRequest(ctx context.Context, method, path string, query url.V
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/json")
 
+	// Metered under the same op label as tms_external_* so the two agree.
+	op := metrics.PathOp(req)
+	if err := c.quota().Check(ctx, metrics.ProviderUSPS, op); err != nil {
+		return nil, err
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.quota().Record(ctx, metrics.ProviderUSPS, op, 0)
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
+	c.quota().Record(ctx, metrics.ProviderUSPS, op, resp.StatusCode)
 
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
 		body, _ := io.ReadAll(resp.Body)
@@ -1,38 +1,58 @@
 package config
 
 import (
+	"context"
 	"strings"
 	"time"
 
+	"github.com/TMS360/backend-pkg/secrets"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	AppName           string `mapstructure:"APP_NAME"`
-	AppEnv            string `mapstructure:"APP_ENV"`
-	AppDebug          bool   `mapstructure:"APP_DEBUG"`
-	AppPort           string `mapstructure:"APP_PORT"`
-	AppURL            string `mapstructure:"APP_URL"`
-	FrontendURL       string `mapstructure:"FRONTEND_URL"`
-	MobileAppURL      string `mapstructure:"MOBILE_APP_URL"`
-	SigningKey        string `mapstructure:"SIGNING_KEY"`
-	CleanupPassword   string `mapstructure:"CLEANUP_PASSWORD"`
-	GRPCPort          string `mapstructure:"GRPC_PORT"`
-	HTTPServer        `mapstructure:"HTTP"`
-	PostgresSQLConfig `mapstructure:"DB"`
-	KafkaConfig       `mapstructure:"KAFKA"`
-	RedisConfig       `mapstructure:"REDIS"`
-	JWTConfig         `mapstructure:"JWT"`
-	MailConfig        `mapstructure:"MAIL"`
-	SamsaraConfig     `mapstructure:"SAMSARA"`
-	HereConfig        `mapstructure:"HERE"`
-	GoogleMapsConfig  `mapstructure:"GOOGLE_MAPS"`
-	RelayConfig       `mapstructure:"RELAY"`
-	UspsConfig        `mapstructure:"USPS"`
-	FactoringConfig   `mapstructure:"FACTORING"`
-	ClickHouseConfig  `mapstructure:"CLICKHOUSE"`
-	AwsConfig         `mapstructure:"AWS"`
-	ServiceURLs       `mapstructure:"SERVICES"`
+	AppName         string `mapstructure:"APP_NAME" validate:"required"`
+	AppEnv          string `mapstructure:"APP_ENV"`
+	AppDebug        bool   `mapstructure:"APP_DEBUG"`
+	AppPort         string `mapstructure:"APP_PORT" validate:"omitempty,numeric"`
+	AppURL          string `mapstructure:"APP_URL" validate:"omitempty,url"`
+	FrontendURL     string `mapstructure:"FRONTEND_URL" validate:"omitempty,url"`
+	MobileAppURL    string `mapstructure:"MOBILE_APP_URL" validate:"omitempty,url"`
+	SigningKey      string `mapstructure:"SIGNING_KEY" redact:"true"`
+	CleanupPassword string `mapstructure:"CLEANUP_PASSWORD" redact:"true"`
+	GRPCPort        string `mapstructure:"GRPC_PORT" validate:"omitempty,numeric"`
+	// Features lists the feature flags switched on for this deployment
+	// (FEATURES=geo_v2,new_router). Hot-reloadable, see Runtime.
+	Features []string `mapstructure:"FEATURES"`
+
+	// Sections are validated one by one (see Validate), never through Config,
+	// so a service is only held to the sections it asks for.
+	HTTPServer        `mapstructure:"HTTP" validate:"-"`
+	PostgresSQLConfig `mapstructure:"DB" validate:"-"`
+	KafkaConfig       `mapstructure:"KAFKA" validate:"-"`
+	RedisConfig       `mapstructure:"REDIS" validate:"-"`
+	JWTConfig         `mapstructure:"JWT" validate:"-"`
+	MailConfig        `mapstructure:"MAIL" validate:"-"`
+	SamsaraConfig     `mapstructure:"SAMSARA" validate:"-"`
+	HereConfig        `mapstructure:"HERE" validate:"-"`
+	GoogleMapsConfig  `mapstructure:"GOOGLE_MAPS" validate:"-"`
+	RelayConfig       `mapstructure:"RELAY" validate:"-"`
+	UspsConfig        `mapstructure:"USPS" validate:"-"`
+	FactoringConfig   `mapstructure:"FACTORING" validate:"-"`
+	ClickHouseConfig  `mapstructure:"CLICKHOUSE" validate:"-"`
+	AwsConfig         `mapstructure:"AWS" validate:"-"`
+	ServiceURLs       `mapstructure:"SERVICES" validate:"-"`
+	RateLimitConfig   `mapstructure:"RATE_LIMIT" validate:"-"`
+}
+
+// ResolveSecrets replaces every secrets reference (secret://env/…,
+// secret://file/…) in cfg with its value. Call it after unmarshalling, so a
+// deployment can point DB_PASSWORD or JWT_PRIVATE_KEY at a mounted secret
+// instead of putting the value in the environment.
+func (c *Config) ResolveSecrets(ctx context.Context, r *secrets.Resolver) error {
+	if r == nil {
+		r = secrets.Default()
+	}
+	return r.ResolveStruct(ctx, c)
 }
 
 // IsProduction reports whether the app is running in production. The codebase
@@ -47,55 +67,56 @@ func (c *Config) IsProduction() bool {
 // (RouterURL) as the acting user. Set via env vars SERVICES_AUTH_URL,
 // SERVICES_BROKERS_URL, SERVICES_ROUTER_URL, etc.
 type ServiceURLs struct {
-	AuthURL     string `mapstructure:"AUTH_URL"`
-	BrokersURL  string `mapstructure:"BROKERS_URL"`
-	LoadsURL    string `mapstructure:"LOADS_URL"`
-	TeamsURL    string `mapstructure:"TEAMS_URL"`
-	FilesURL    string `mapstructure:"FILES_URL"`
-	MediatorURL string `mapstructure:"MEDIATOR_URL"`
-	RouterURL   string `mapstructure:"ROUTER_URL"`
+	AuthURL     string `mapstructure:"AUTH_URL" validate:"omitempty,url"`
+	BrokersURL  string `mapstructure:"BROKERS_URL" validate:"omitempty,url"`
+	LoadsURL    string `mapstructure:"LOADS_URL" validate:"omitempty,url"`
+	TeamsURL    string `mapstructure:"TEAMS_URL" validate:"omitempty,url"`
+	FilesURL    string `mapstructure:"FILES_URL" validate:"omitempty,url"`
+	MediatorURL string `mapstructure:"MEDIATOR_URL" validate:"omitempty,url"`
+	RouterURL   string `mapstructure:"ROUTER_URL" validate:"omitempty,url"`
 }
 
 type HTTPServer struct {
-	Timeout        time.Duration `mapstructure:"TIMEOUT"`
-	IdleTimeout    time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	Timeout        time.Duration `mapstructure:"TIMEOUT" validate:"gte=0"`
+	IdleTimeout    time.Duration `mapstructure:"IDLE_TIMEOUT" validate:"gte=0"`
 	AllowedOrigins []string      `mapstructure:"ALLOWED_ORIGINS"`
 }
 
 type PostgresSQLConfig struct {
-	Host         string `mapstructure:"HOST"`
-	Port         string `mapstructure:"PORT"`
-	DBName       string `mapstructure:"DATABASE"`
+	Host         string `mapstructure:"HOST" validate:"required"`
+	Port         string `mapstructure:"PORT" validate:"required,numeric"`
+	DBName       string `mapstructure:"DATABASE" validate:"required"`
 	DBNameTest   string `mapstructure:"DATABASE_TEST"`
-	User         string `mapstructure:"USERNAME"`
-	Password     string `mapstructure:"PASSWORD"`
-	SSLMode      string `mapstructure:"SSLMODE"`
+	User         string `mapstructure:"USERNAME" validate:"required"`
+	Password     string `mapstructure:"PASSWORD" redact:"true"`
+	SSLMode      string `mapstructure:"SSLMODE" validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full"`
 	TimeZone     string `mapstructure:"TIMEZONE"`
-	MaxOpenConns int    `mapstructure:"MAX_OPEN_CONNS"`
-	MaxIdleConns int    `mapstructure:"MAX_IDLE_CONNS"`
+	MaxOpenConns int    `mapstructure:"MAX_OPEN_CONNS" validate:"gte=0"`
+	MaxIdleConns int    `mapstructure:"MAX_IDLE_CONNS" validate:"gte=0"`
 }
 
+// KafkaConfig accepts either a single HOST/PORT or a BROKERS list.
 type KafkaConfig struct {
-	Host    string   `mapstructure:"HOST"`
-	Port    string   `mapstructure:"PORT"`
-	Brokers []string `mapstructure:"BROKERS"`
+	Host    string   `mapstructure:"HOST" validate:"required_without=Brokers"`
+	Port    string   `mapstructure:"PORT" validate:"omitempty,numeric"`
+	Brokers []string `mapstructure:"BROKERS" validate:"required_without=Host,dive,hostname_port"`
 	GroupID string   `mapstructure:"GROUP_ID"`
 	Topics  []string `mapstructure:"TOPICS"`
 }
 
 type RedisConfig struct {
-	Host     string `mapstructure:"HOST"`
-	Port     string `mapstructure:"PORT"`
-	Password string `mapstructure:"PASSWORD"`
+	Host     string `mapstructure:"HOST" validate:"required"`
+	Port     string `mapstructure:"PORT" validate:"omitempty,numeric"`
+	Password string `mapstructure:"PASSWORD" redact:"true"`
 }
 
 type JWTConfig struct {
-	PrivateKey     string        `mapstructure:"PRIVATE_KEY"`
+	PrivateKey     string        `mapstructure:"PRIVATE_KEY" redact:"true"`
 	PrivateKeyPath string        `mapstructure:"PRIVATE_KEY_PATH"`
-	PublicKey      string        `mapstructure:"PUBLIC_KEY"`
-	PublicKeyPath  string        `mapstructure:"PUBLIC_KEY_PATH"`
-	AccessTTL      time.Duration `mapstructure:"ACCESS_TTL"`
-	RefreshTTL     time.Duration `mapstructure:"REFRESH_TTL"`
+	PublicKey      string        `mapstructure:"PUBLIC_KEY" validate:"required_without=PublicKeyPath"`
+	PublicKeyPath  string        `mapstructure:"PUBLIC_KEY_PATH" validate:"required_without=PublicKey"`
+	AccessTTL      time.Duration `mapstructure:"ACCESS_TTL" validate:"gte=0"`
+	RefreshTTL     time.Duration `mapstructure:"REFRESH_TTL" validate:"gte=0"`
 	CookieDomain   string        `mapstructure:"COOKIE_DOMAIN"`
 	CookieSecure   bool          `mapstructure:"COOKIE_SECURE"`
 	CookieLaxMode  int           `mapstructure:"COOKIE_LAX_MODE"`
@@ -103,24 +124,28 @@ type JWTConfig struct {
 
 type MailConfig struct {
 	// Provider selects the delivery backend: "smtp" (default) or "resend".
-	Provider string `mapstructure:"PROVIDER"`
+	Provider string `mapstructure:"PROVIDER" validate:"omitempty,oneof=smtp resend"`
 	// APIKey is used by HTTP-based providers (e.g. Resend).
-	APIKey   string `mapstructure:"API_KEY"`
+	APIKey   string `mapstructure:"API_KEY" redact:"true"`
 	Host     string `mapstructure:"HOST"`
-	Port     string `mapstructure:"PORT"`
+	Port     string `mapstructure:"PORT" validate:"omitempty,numeric"`
 	Username string `mapstructure:"USERNAME"`
-	Password string `mapstructure:"PASSWORD"`
-	From     string `mapstructure:"FROM"`
+	Password string `mapstructure:"PASSWORD" redact:"true"`
+	From     string `mapstructure:"FROM" validate:"required"`
 }
 
 type SamsaraConfig struct {
-	Host string `mapstructure:"HOST"`
+	Host string `mapstructure:"HOST" validate:"omitempty,url"`
 }
 
 type HereConfig struct {
-	RouterHost  string `mapstructure:"ROUTER_HOST"`
-	GeocodeHost string `mapstructure:"GEOCODE_HOST"`
-	LookupHost  string `mapstructure:"LOOKUP_HOST"`
+	RouterHost  string `mapstructure:"ROUTER_HOST" validate:"omitempty,url"`
+	GeocodeHost string `mapstructure:"GEOCODE_HOST" validate:"omitempty,url"`
+	LookupHost  string `mapstructure:"LOOKUP_HOST" validate:"omitempty,url"`
+	// AutocompleteHost serves address suggestions (/v1/autocomplete).
+	AutocompleteHost string `mapstructure:"AUTOCOMPLETE_HOST" validate:"omitempty,url"`
+	// MatrixHost serves Matrix Routing v8 (/v8/matrix).
+	MatrixHost string `mapstructure:"MATRIX_HOST" validate:"omitempty,url"`
 }
 
 // GoogleMapsConfig holds the non-secret Google Maps Platform hosts. The API key
@@ -128,12 +153,12 @@ type HereConfig struct {
 // same as the HERE key. Geocoding and Places share one host; Routes v2 is a
 // separate service.
 type GoogleMapsConfig struct {
-	GeocodeHost string `mapstructure:"GEOCODE_HOST"`
-	RoutesHost  string `mapstructure:"ROUTES_HOST"`
+	GeocodeHost string `mapstructure:"GEOCODE_HOST" validate:"omitempty,url"`
+	RoutesHost  string `mapstructure:"ROUTES_HOST" validate:"omitempty,url"`
 }
 
 type RelayConfig struct {
-	Host string `mapstructure:"HOST"`
+	Host string `mapstructure:"HOST" validate:"omitempty,url"`
 }
 
 // UspsConfig holds non-secret USPS API hosts. The OAuth2 Consumer Key/Secret
@@ -142,8 +167,8 @@ type RelayConfig struct {
 // Hosts default in the client (apis.usps.com); override via USPS_BASE_URL /
 // USPS_OAUTH_HOST (e.g. the CAT/TEM sandbox apis-tem.usps.com).
 type UspsConfig struct {
-	BaseURL   string `mapstructure:"BASE_URL"`
-	OAuthHost string `mapstructure:"OAUTH_HOST"`
+	BaseURL   string `mapstructure:"BASE_URL" validate:"omitempty,url"`
+	OAuthHost string `mapstructure:"OAUTH_HOST" validate:"omitempty,url"`
 }
 
 // FactoringConfig holds per-provider defaults that callers can override via env.
@@ -152,35 +177,51 @@ type UspsConfig struct {
 // tms360-backend.
 type FactoringConfig struct {
 	TriumphSFTPHost string `mapstructure:"TRIUMPH_SFTP_HOST"`
-	TriumphSFTPPort int    `mapstructure:"TRIUMPH_SFTP_PORT"`
+	TriumphSFTPPort int    `mapstructure:"TRIUMPH_SFTP_PORT" validate:"omitempty,min=1,max=65535"`
 }
 
 type ClickHouseConfig struct {
-	Host     string `mapstructure:"HOST"`
-	Port     string `mapstructure:"PORT"`
+	Host     string `mapstructure:"HOST" validate:"required"`
+	Port     string `mapstructure:"PORT" validate:"omitempty,numeric"`
 	DBName   string `mapstructure:"DATABASE"`
 	User     string `mapstructure:"USERNAME"`
-	Password string `mapstructure:"PASSWORD"`
+	Password string `mapstructure:"PASSWORD" redact:"true"`
 }
 
 type AwsConfig struct {
-	AccessKeyID     string `mapstructure:"ACCESS_KEY_ID"`
-	SecretAccessKey string `mapstructure:"SECRET_ACCESS_KEY"`
-	Region          string `mapstructure:"REGION"`
-	BucketName      string `mapstructure:"BUCKET_NAME"`
-	EndpointURL     string `mapstructure:"ENDPOINT_URL"`
+	AccessKeyID     string `mapstructure:"ACCESS_KEY_ID" redact:"true"`
+	SecretAccessKey string `mapstructure:"SECRET_ACCESS_KEY" redact:"true"`
+	Region          string `mapstructure:"REGION" validate:"required"`
+	BucketName      string `mapstructure:"BUCKET_NAME" validate:"required"`
+	EndpointURL     string `mapstructure:"ENDPOINT_URL" validate:"omitempty,url"`
 }
 
-var Prefixes = []string{"http", "db", "kafka", "redis", "jwt", "redis", "mail", "samsara", "here", "relay", "usps", "factoring", "clickhouse", "aws", "services"}
+// RateLimitConfig mirrors the env vars middleware.RateLimitAuthenticated reads
+// (RATE_LIMIT_AUTH_MAX, RATE_LIMIT_AUTH_WINDOW). Zero means "use the
+// middleware default". Hot-reloadable, see Runtime.
+type RateLimitConfig struct {
+	AuthMax    int           `mapstructure:"AUTH_MAX" validate:"gte=0"`
+	AuthWindow time.Duration `mapstructure:"AUTH_WINDOW" validate:"gte=0"`
+}
 
+// Prefixes are the env prefixes MapConfig folds into nested keys
+// (DB_HOST → db.host). One per Section.
+var Prefixes = []string{"http", "db", "kafka", "redis", "jwt", "mail", "samsara", "here", "google_maps", "relay", "usps", "factoring", "clickhouse", "aws", "services", "rate_limit"}
+
+// MapConfig folds the flat env-style keys of the global viper instance into
+// the nested keys Unmarshal expects. Load does this itself.
 func MapConfig() {
-	for _, key := range viper.AllKeys() {
+	mapKeys(viper.GetViper())
+}
+
+func mapKeys(v *viper.Viper) {
+	for _, key := range v.AllKeys() {
 		for _, prefix := range Prefixes {
 			target := prefix + "_"
 
 			if strings.HasPrefix(key, target) {
 				newKey := strings.Replace(key, target, prefix+".", 1)
-				viper.Set(newKey, viper.Get(key))
+				v.Set(newKey, v.Get(key))
 				break
 			}
 		}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeEnv(t *testing.T, path, body string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+}
+
+func TestPrefixesAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, p := range Prefixes {
+		assert.False(t, seen[p], "duplicate prefix %q", p)
+		seen[p] = true
+	}
+	assert.True(t, seen["google_maps"])
+}
+
+func TestValidateAggregatesProblems(t *testing.T) {
+	cfg := &Config{
+		AppName:           "loads",
+		AppURL:            "not a url",
+		PostgresSQLConfig: PostgresSQLConfig{Host: "db", Port: "five"},
+		MailConfig:        MailConfig{Provider: "pigeon"},
+	}
+
+	err := cfg.Validate(SectionDB, SectionKafka, SectionJWT)
+	var ve *ValidationError
+	require.True(t, errors.As(err, &ve))
+	assert.ElementsMatch(t, []string{
+		`APP_URL: must be an absolute URL, got "not a url"`,
+		`DB_PORT: must be numeric, got "five"`,
+		"DB_DATABASE: is required",
+		"DB_USERNAME: is required",
+		"KAFKA_HOST: is required when Brokers is not set",
+		"KAFKA_BROKERS: is required when Host is not set",
+		"JWT_PUBLIC_KEY: is required when PublicKeyPath is not set",
+		"JWT_PUBLIC_KEY_PATH: is required when PublicKey is not set",
+		// MAIL is not required by this service, but a bad value is still wrong.
+		`MAIL_PROVIDER: must be one of [smtp resend], got "pigeon"`,
+	}, ve.Problems)
+}
+
+func TestValidateOnlyRequiresListedSections(t *testing.T) {
+	cfg := &Config{AppName: "files", RedisConfig: RedisConfig{Host: "redis"}}
+	assert.NoError(t, cfg.Validate(SectionRedis))
+	assert.Error(t, cfg.Validate(SectionRedis, SectionAws))
+	assert.ErrorContains(t, cfg.Validate("nope"), "unknown section")
+}
+
+func TestLoadFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	writeEnv(t, path, "APP_NAME=loads\nDB_HOST=filehost\nDB_PORT=5432\nDB_DATABASE=loads\nDB_USERNAME=app\nHTTP_TIMEOUT=15s\n")
+	t.Setenv("DB_HOST", "envhost")
+	t.Setenv("KAFKA_BROKERS", "k1:9092,k2:9092")
+	t.Setenv("GOOGLE_MAPS_ROUTES_HOST", "https://routes.example.com")
+	t.Setenv("DB_PASSWORD", "secret://env/TEST_DB_PASSWORD")
+	t.Setenv("TEST_DB_PASSWORD", "hunter2")
+
+	cfg, err := Load(context.Background(), WithFile(path), WithSections(SectionDB, SectionKafka))
+	require.NoError(t, err)
+	assert.Equal(t, "envhost", cfg.PostgresSQLConfig.Host, "env wins over the file")
+	assert.Equal(t, "loads", cfg.PostgresSQLConfig.DBName)
+	assert.Equal(t, 15*time.Second, cfg.HTTPServer.Timeout)
+	assert.Equal(t, []string{"k1:9092", "k2:9092"}, cfg.Brokers)
+	assert.Equal(t, "https://routes.example.com", cfg.RoutesHost)
+	assert.Equal(t, "hunter2", cfg.PostgresSQLConfig.Password)
+
+	_, err = Load(context.Background(), WithFile(path), WithSections(SectionJWT))
+	assert.ErrorContains(t, err, "JWT_PUBLIC_KEY")
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := &Config{
+		AppName:           "auth",
+		PostgresSQLConfig: PostgresSQLConfig{Host: "db", Password: "hunter2"},
+		JWTConfig:         JWTConfig{AccessTTL: time.Minute},
+	}
+	dump := cfg.Redacted()
+	assert.Equal(t, "auth", dump["APP_NAME"])
+	assert.Equal(t, "db", dump["DB_HOST"])
+	assert.Equal(t, redactedValue, dump["DB_PASSWORD"])
+	assert.Equal(t, "", dump["REDIS_PASSWORD"], "unset secrets stay visibly unset")
+	assert.Equal(t, time.Minute, dump["JWT_ACCESS_TTL"])
+	assert.NotContains(t, cfg.LogValue().String(), "hunter2")
+}
+
+func TestRuntimeApplyNotifies(t *testing.T) {
+	rt := NewRuntime(Dynamic{Features: []string{"a"}})
+	var got []Dynamic
+	unsubscribe := rt.Subscribe(func(_, cur Dynamic) { got = append(got, cur) })
+
+	changed, err := rt.Apply(Dynamic{Features: []string{"a"}})
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	changed, err = rt.Apply(Dynamic{RateLimit: RateLimitConfig{AuthMax: 100}, Features: []string{"b"}})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, rt.Enabled("b"))
+	max, window := rt.AuthRateLimit()
+	assert.Equal(t, 100, max)
+	assert.Zero(t, window)
+
+	_, err = rt.Apply(Dynamic{RateLimit: RateLimitConfig{AuthMax: -1}})
+	assert.Error(t, err)
+	assert.True(t, rt.Enabled("b"), "invalid values are rejected")
+
+	unsubscribe()
+	_, _ = rt.Apply(Dynamic{})
+	assert.Len(t, got, 1)
+}
+
+func TestWatcherAppliesOnlyDynamicFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	writeEnv(t, path, "APP_NAME=loads\nREDIS_HOST=redis\nRATE_LIMIT_AUTH_MAX=600\n")
+	cfg, err := Load(context.Background(), WithFile(path), WithSections(SectionRedis))
+	require.NoError(t, err)
+
+	rt := NewRuntime(cfg.Dynamic())
+	w := NewWatcher(cfg, rt, WatchFile(path))
+	assert.Equal(t, []string(nil), w.restartKeys(cfg))
+
+	updates := make(chan Dynamic, 1)
+	rt.Subscribe(func(_, cur Dynamic) { updates <- cur })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond) // let Run register the watch
+	writeEnv(t, path, "APP_NAME=loads\nREDIS_HOST=elsewhere\nRATE_LIMIT_AUTH_MAX=50\nRATE_LIMIT_AUTH_WINDOW=30s\nFEATURES=geo_v2\n")
+
+	select {
+	case cur := <-updates:
+		assert.Equal(t, RateLimitConfig{AuthMax: 50, AuthWindow: 30 * time.Second}, cur.RateLimit)
+		assert.True(t, cur.Enabled("geo_v2"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after the file changed")
+	}
+	cancel()
+	require.NoError(t, <-done)
+
+	next, err := w.loader.read()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"REDIS_HOST"}, w.restartKeys(next), "restart-only change is reported, not applied")
+}
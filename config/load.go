@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/TMS360/backend-pkg/secrets"
+	"github.com/spf13/viper"
+)
+
+// LoadOption customizes Load.
+type LoadOption func(*loader)
+
+type loader struct {
+	file     string
+	sections []Section
+	resolver *secrets.Resolver
+}
+
+// WithFile reads path (typically ".env") under the environment. Env vars win
+// over the file, as they do with viper.AutomaticEnv.
+func WithFile(path string) LoadOption {
+	return func(l *loader) { l.file = path }
+}
+
+// WithSections declares the sections this service uses; Validate requires
+// only those.
+func WithSections(sections ...Section) LoadOption {
+	return func(l *loader) { l.sections = append(l.sections, sections...) }
+}
+
+// WithResolver resolves secret:// references with r instead of
+// secrets.Default().
+func WithResolver(r *secrets.Resolver) LoadOption {
+	return func(l *loader) { l.resolver = r }
+}
+
+// Load reads the environment (and the optional file), resolves secrets and
+// validates the result, so a missing JWT key or Kafka broker stops the
+// service at startup with every problem listed, not at first use.
+//
+//	cfg, err := config.Load(ctx,
+//		config.WithFile(".env"),
+//		config.WithSections(config.SectionDB, config.SectionRedis, config.SectionJWT),
+//	)
+func Load(ctx context.Context, opts ...LoadOption) (*Config, error) {
+	l := &loader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	cfg, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ResolveSecrets(ctx, l.resolver); err != nil {
+		return nil, fmt.Errorf("config: resolve secrets: %w", err)
+	}
+	if err := cfg.Validate(l.sections...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// read decodes a fresh viper instance, so it can run again on reload without
+// touching the global one services may still use.
+func (l *loader) read() (*Config, error) {
+	v := viper.New()
+	if l.file != "" {
+		v.SetConfigFile(l.file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("config: read %s: %w", l.file, err)
+		}
+	}
+	// AutomaticEnv only answers for keys viper already knows; bind every key
+	// of Config so env-only deployments (no file) decode too.
+	for _, key := range envKeys() {
+		_ = v.BindEnv(strings.ToLower(key), key)
+	}
+	mapKeys(v)
+
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("config: decode: %w", err)
+	}
+	return cfg, nil
+}
+
+// envKeys lists the env var name of every leaf field of Config.
+func envKeys() []string {
+	var keys []string
+	walk(reflect.ValueOf(&Config{}).Elem(), "", func(key string, _ reflect.StructField, _ reflect.Value) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// walk calls fn for every leaf field of the struct v with its env var name,
+// descending into the embedded sections.
+func walk(v reflect.Value, prefix string, fn func(key string, f reflect.StructField, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), prefix+name+"_", fn)
+			continue
+		}
+		fn(prefix+name, f, v.Field(i))
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"sort"
+)
+
+// redactedValue replaces a set secret in dumps. An unset secret is shown as
+// "" so the dump still tells "missing" from "present".
+const redactedValue = "[REDACTED]"
+
+// Redacted flattens c into env-keyed values with every `redact:"true"` field
+// masked — safe to log or serve from a debug endpoint.
+func (c *Config) Redacted() map[string]any {
+	out := make(map[string]any)
+	walk(reflect.ValueOf(c).Elem(), "", func(key string, f reflect.StructField, v reflect.Value) {
+		if f.Tag.Get("redact") == "true" && !v.IsZero() {
+			out[key] = redactedValue
+			return
+		}
+		out[key] = v.Interface()
+	})
+	return out
+}
+
+// LogValue makes slog print the redacted dump, so logging a *Config never
+// leaks a password.
+func (c *Config) LogValue() slog.Value {
+	dump := c.Redacted()
+	keys := make([]string, 0, len(dump))
+	for k := range dump {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, dump[k]))
+	}
+	return slog.GroupValue(attrs...)
+}
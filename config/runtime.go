@@ -0,0 +1,103 @@
+package config
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dynamic is the part of Config that is safe to change while the service
+// runs. Everything else (hosts, credentials, pools) is read once at startup
+// and needs a restart.
+type Dynamic struct {
+	RateLimit RateLimitConfig
+	Features  []string
+}
+
+// Dynamic returns the hot-reloadable fields of c.
+func (c *Config) Dynamic() Dynamic {
+	return Dynamic{RateLimit: c.RateLimitConfig, Features: slices.Clone(c.Features)}
+}
+
+// Enabled reports whether the feature flag is on.
+func (d Dynamic) Enabled(flag string) bool {
+	return slices.Contains(d.Features, flag)
+}
+
+func (d Dynamic) equal(o Dynamic) bool {
+	return d.RateLimit == o.RateLimit && slices.Equal(d.Features, o.Features)
+}
+
+// Runtime holds the current Dynamic values. Readers call Current (lock-free)
+// on every use instead of caching; a Watcher swaps in new values and notifies
+// subscribers.
+type Runtime struct {
+	cur atomic.Pointer[Dynamic]
+
+	mu   sync.Mutex
+	subs map[int]func(old, cur Dynamic)
+	next int
+}
+
+// NewRuntime starts a Runtime at d, usually cfg.Dynamic().
+func NewRuntime(d Dynamic) *Runtime {
+	r := &Runtime{subs: make(map[int]func(old, cur Dynamic))}
+	r.cur.Store(&d)
+	return r
+}
+
+// Current returns the values in effect.
+func (r *Runtime) Current() Dynamic {
+	return *r.cur.Load()
+}
+
+// Enabled reports whether the feature flag is currently on.
+func (r *Runtime) Enabled(flag string) bool {
+	return r.Current().Enabled(flag)
+}
+
+// AuthRateLimit returns the current auth ceiling and window, zero when unset.
+// Its signature fits middleware.WithAuthRateLimitSource.
+func (r *Runtime) AuthRateLimit() (int, time.Duration) {
+	rl := r.Current().RateLimit
+	return rl.AuthMax, rl.AuthWindow
+}
+
+// Subscribe registers fn to run after every change, with the previous and
+// new values. It returns a func that removes the subscription.
+func (r *Runtime) Subscribe(fn func(old, cur Dynamic)) (unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.next
+	r.next++
+	r.subs[id] = fn
+	return func() {
+		r.mu.Lock()
+		delete(r.subs, id)
+		r.mu.Unlock()
+	}
+}
+
+// Apply validates d and, when it differs from the current values, swaps it in
+// and notifies subscribers synchronously, in no particular order. Subscribers
+// must not call Subscribe or Apply. Invalid values are rejected and the current
+// ones kept.
+func (r *Runtime) Apply(d Dynamic) (changed bool, err error) {
+	if problems := check(&d.RateLimit, "RATE_LIMIT_", true); len(problems) > 0 {
+		return false, &ValidationError{Problems: problems}
+	}
+	d.Features = slices.Clone(d.Features)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.Current()
+	if old.equal(d) {
+		return false, nil
+	}
+	r.cur.Store(&d)
+	for _, fn := range r.subs {
+		fn(old, d)
+	}
+	return true, nil
+}
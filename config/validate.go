@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Section names one block of Config by its env prefix. A service declares the
+// sections it actually uses and Validate only requires those, so a consumer
+// without a mailer does not fail on a missing MAIL_FROM.
+type Section string
+
+const (
+	SectionHTTP       Section = "http"
+	SectionDB         Section = "db"
+	SectionKafka      Section = "kafka"
+	SectionRedis      Section = "redis"
+	SectionJWT        Section = "jwt"
+	SectionMail       Section = "mail"
+	SectionSamsara    Section = "samsara"
+	SectionHere       Section = "here"
+	SectionGoogleMaps Section = "google_maps"
+	SectionRelay      Section = "relay"
+	SectionUsps       Section = "usps"
+	SectionFactoring  Section = "factoring"
+	SectionClickHouse Section = "clickhouse"
+	SectionAws        Section = "aws"
+	SectionServices   Section = "services"
+	SectionRateLimit  Section = "rate_limit"
+)
+
+// Sections lists every Section, in Prefixes order.
+func Sections() []Section {
+	out := make([]Section, len(Prefixes))
+	for i, p := range Prefixes {
+		out[i] = Section(p)
+	}
+	return out
+}
+
+// section returns a pointer to the block of c that s names, nil for an
+// unknown section.
+func (c *Config) section(s Section) any {
+	switch s {
+	case SectionHTTP:
+		return &c.HTTPServer
+	case SectionDB:
+		return &c.PostgresSQLConfig
+	case SectionKafka:
+		return &c.KafkaConfig
+	case SectionRedis:
+		return &c.RedisConfig
+	case SectionJWT:
+		return &c.JWTConfig
+	case SectionMail:
+		return &c.MailConfig
+	case SectionSamsara:
+		return &c.SamsaraConfig
+	case SectionHere:
+		return &c.HereConfig
+	case SectionGoogleMaps:
+		return &c.GoogleMapsConfig
+	case SectionRelay:
+		return &c.RelayConfig
+	case SectionUsps:
+		return &c.UspsConfig
+	case SectionFactoring:
+		return &c.FactoringConfig
+	case SectionClickHouse:
+		return &c.ClickHouseConfig
+	case SectionAws:
+		return &c.AwsConfig
+	case SectionServices:
+		return &c.ServiceURLs
+	case SectionRateLimit:
+		return &c.RateLimitConfig
+	}
+	return nil
+}
+
+// ValidationError aggregates every problem found in one pass, so a broken
+// deployment is fixed in one round instead of one restart per variable.
+type ValidationError struct {
+	// Problems are "ENV_KEY: reason" lines, in field order.
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "config: invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+func validatorInstance() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+			if name := f.Tag.Get("mapstructure"); name != "" {
+				return name
+			}
+			return f.Name
+		})
+	})
+	return validate
+}
+
+// Validate checks c against its struct tags. The app-level fields and the
+// listed sections are validated in full; every other section is still
+// format-checked (a malformed URL is wrong whether or not it is used), but its
+// required fields are not enforced. All problems are returned together as a
+// *ValidationError.
+func (c *Config) Validate(required ...Section) error {
+	want := make(map[Section]bool, len(required))
+	for _, s := range required {
+		if c.section(s) == nil {
+			return fmt.Errorf("config: unknown section %q", s)
+		}
+		want[s] = true
+	}
+
+	var problems []string
+	problems = append(problems, check(c, "", true)...)
+	for _, s := range Sections() {
+		problems = append(problems, check(c.section(s), strings.ToUpper(string(s))+"_", want[s])...)
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// check validates one struct and renders its failures under the env prefix.
+func check(v any, prefix string, enforceRequired bool) []string {
+	err := validatorInstance().Struct(v)
+	if err == nil {
+		return nil
+	}
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) {
+		return []string{prefix + err.Error()}
+	}
+	var out []string
+	for _, fe := range ve {
+		if !enforceRequired && strings.HasPrefix(fe.Tag(), "required") {
+			continue
+		}
+		// Namespace is "TypeName.FIELD[.NESTED]"; drop the type name.
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		out = append(out, prefix+strings.ReplaceAll(field, ".", "_")+": "+describe(fe))
+	}
+	return out
+}
+
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required when %s is not set", fe.Param())
+	case "url":
+		return fmt.Sprintf("must be an absolute URL, got %q", fe.Value())
+	case "numeric":
+		return fmt.Sprintf("must be numeric, got %q", fe.Value())
+	case "hostname_port":
+		return fmt.Sprintf("must be host:port, got %q", fe.Value())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %q", fe.Param(), fe.Value())
+	case "gte", "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	}
+	return fmt.Sprintf("fails %q validation", fe.Tag())
+}
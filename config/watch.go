@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TMS360/backend-pkg/secrets"
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce coalesces the burst of events one save (or a Kubernetes ConfigMap
+// symlink swap) produces into a single reload.
+const debounce = 200 * time.Millisecond
+
+// WatchOption customizes a Watcher.
+type WatchOption func(*Watcher)
+
+// WatchFile re-reads path when it changes on disk. The directory is watched,
+// not the file, so editors that write-and-rename and ConfigMap updates (which
+// swap a ..data symlink) are both seen.
+func WatchFile(path string) WatchOption {
+	return func(w *Watcher) { w.loader.file = path }
+}
+
+// WatchInterval also reloads on a timer — for filesystems without inotify
+// (some network mounts), or to pick up env changes in tests.
+func WatchInterval(d time.Duration) WatchOption {
+	return func(w *Watcher) { w.interval = d }
+}
+
+// WatchLogger overrides slog.Default().
+func WatchLogger(l *slog.Logger) WatchOption {
+	return func(w *Watcher) { w.log = l }
+}
+
+// Watcher re-reads the file and environment and applies the Dynamic fields to
+// a Runtime. Changes to any other field are logged as needing a restart and
+// otherwise ignored, so a bad edit to DB_HOST cannot take a running service
+// down. It is opt-in: nothing reloads unless a service runs one.
+//
+//	rt := config.NewRuntime(cfg.Dynamic())
+//	w := config.NewWatcher(cfg, rt, config.WatchFile(".env"))
+//	go w.Run(ctx)
+//	r.Use(middleware.RateLimitAuthenticated(middleware.WithAuthRateLimitSource(rt.AuthRateLimit)))
+type Watcher struct {
+	base     map[string]any
+	rt       *Runtime
+	loader   loader
+	interval time.Duration
+	log      *slog.Logger
+}
+
+// NewWatcher watches for changes relative to base, the config the service
+// started with.
+func NewWatcher(base *Config, rt *Runtime, opts ...WatchOption) *Watcher {
+	w := &Watcher{base: base.Redacted(), rt: rt, log: slog.Default()}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Reload reads the config once and applies it. It reports whether the
+// Dynamic values changed.
+func (w *Watcher) Reload() (bool, error) {
+	cfg, err := w.loader.read()
+	if err != nil {
+		return false, err
+	}
+	if keys := w.restartKeys(cfg); len(keys) > 0 {
+		w.log.Warn("config: changes need a restart and were not applied", "keys", keys)
+	}
+	changed, err := w.rt.Apply(cfg.Dynamic())
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		w.log.Info("config: reloaded", "rate_limit", cfg.RateLimitConfig, "features", cfg.Features)
+	}
+	return changed, nil
+}
+
+// restartKeys lists the non-dynamic keys whose value differs from the base.
+// The reload does not resolve secrets, so secret fields and secret://
+// references are skipped rather than compared against resolved values.
+func (w *Watcher) restartKeys(cfg *Config) []string {
+	var keys []string
+	walk(reflect.ValueOf(cfg).Elem(), "", func(key string, f reflect.StructField, v reflect.Value) {
+		if f.Tag.Get("redact") == "true" || key == "FEATURES" || strings.HasPrefix(key, "RATE_LIMIT_") {
+			return
+		}
+		if s, ok := v.Interface().(string); ok && secrets.IsRef(s) {
+			return
+		}
+		if !reflect.DeepEqual(w.base[key], v.Interface()) {
+			keys = append(keys, key)
+		}
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// Run reloads on file events and on the interval until ctx is done. Reload
+// errors are logged and the previous values kept.
+func (w *Watcher) Run(ctx context.Context) error {
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if w.loader.file != "" {
+		fw, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("config: watch: %w", err)
+		}
+		defer fw.Close()
+		if err := fw.Add(filepath.Dir(w.loader.file)); err != nil {
+			return fmt.Errorf("config: watch %s: %w", w.loader.file, err)
+		}
+		events, errs = fw.Events, fw.Errors
+	}
+	var tick <-chan time.Time
+	if w.interval > 0 {
+		t := time.NewTicker(w.interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	if events == nil && tick == nil {
+		return errors.New("config: watch: neither a file nor an interval configured")
+	}
+
+	name := filepath.Base(w.loader.file)
+	pending := time.NewTimer(debounce)
+	pending.Stop()
+	defer pending.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-events:
+			if base := filepath.Base(ev.Name); base == name || base == "..data" {
+				pending.Reset(debounce)
+			}
+		case err := <-errs:
+			w.log.Warn("config: watch error", "error", err)
+		case <-tick:
+			w.reload()
+		case <-pending.C:
+			w.reload()
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	if _, err := w.Reload(); err != nil {
+		w.log.Error("config: reload failed, keeping previous values", "error", err)
+	}
+}
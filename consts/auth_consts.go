@@ -146,10 +146,18 @@ type UserClaims struct {
 	CompanyID *uuid.UUID `json:"company_id"`
 	ActorType ActorType  `json:"actor_type"`
 	Roles     []string   `json:"roles"`
+	// SessionID identifies the refresh session the token was minted from, so
+	// one session can be revoked without signing the user out everywhere (see
+	// auth.RevokeSession). Older tokens omit it.
+	SessionID string `json:"sid,omitempty"`
 
 	// --- Guest/Share Fields ---
 	Resource   string    `json:"res,omitempty"`
 	ResourceID uuid.UUID `json:"res_id,omitempty"`
+	// GuestResources lists every resource type a multi-resource share link
+	// grants (Resource is the first). Set by guest.Handler from the stored
+	// link, never from a token.
+	GuestResources []string `json:"-"`
 
 	// Internal Maps (Use JSON:"-" so they don't interfere with JWT parsing)
 	RolesMap       map[string]struct{} `json:"-"`
@@ -0,0 +1,172 @@
+package enums
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Permission entries: deny overrides and conditional grants.
+//
+// A stored grant used to be a bare catalog code, matched hierarchically, so the
+// only way to take one action away from a module grant was to explode the
+// module into every other leaf. Two extra entry shapes close that gap:
+//
+//	-accounting.invoices.delete          deny: beats every inherited grant
+//	loads.loads.edit?own                 grant, only on records the user owns
+//	loads.loads.view?team_id=<uuid>      grant, only on one team's records
+//	accounting.invoices.approve?amount<=5000
+//	                                     grant, only under an amount ceiling
+//
+// Conditions are ANDed within one entry; several conditional entries for the
+// same code are ORed. A deny is always unconditional — "deny unless" is a grant
+// in disguise and is rejected so the rule set stays readable.
+//
+// The grammar lives here, next to the catalog it extends, so tms-auth can
+// validate entries on write (IsValidPermissionEntry) without importing the
+// evaluator; middleware.PermissionSet evaluates them.
+
+const (
+	// PermissionDenyPrefix marks a deny entry.
+	PermissionDenyPrefix = "-"
+	// PermissionConditionSeparator splits a code from its conditions.
+	PermissionConditionSeparator = "?"
+	// permissionConditionJoiner separates ANDed conditions within one entry.
+	permissionConditionJoiner = "&"
+)
+
+// PermissionConditionOp is a comparison in a conditional grant.
+type PermissionConditionOp string
+
+const (
+	PermCondEq  PermissionConditionOp = "="
+	PermCondNe  PermissionConditionOp = "!="
+	PermCondLt  PermissionConditionOp = "<"
+	PermCondLte PermissionConditionOp = "<="
+	PermCondGt  PermissionConditionOp = ">"
+	PermCondGte PermissionConditionOp = ">="
+	// PermCondOwn is the bare `own` condition: the record's owner_id must be
+	// the acting user.
+	PermCondOwn PermissionConditionOp = "own"
+)
+
+// PermissionAttrOwnerID is the record attribute the `own` condition compares
+// against the acting user's id.
+const PermissionAttrOwnerID = "owner_id"
+
+// conditionOps is ordered longest-first so "<=" is not read as "<" + "=...".
+var conditionOps = []PermissionConditionOp{PermCondNe, PermCondLte, PermCondGte, PermCondEq, PermCondLt, PermCondGt}
+
+// PermissionCondition is one attribute test of a conditional grant.
+type PermissionCondition struct {
+	Attr  string
+	Op    PermissionConditionOp
+	Value string
+}
+
+func (c PermissionCondition) String() string {
+	if c.Op == PermCondOwn {
+		return "own"
+	}
+	return c.Attr + string(c.Op) + c.Value
+}
+
+// PermissionEntry is one parsed grant/deny row.
+type PermissionEntry struct {
+	Code       string
+	Deny       bool
+	Conditions []PermissionCondition
+}
+
+// Conditional reports whether the entry only applies to some records.
+func (e PermissionEntry) Conditional() bool { return len(e.Conditions) > 0 }
+
+// String renders the entry back to its stored form.
+func (e PermissionEntry) String() string {
+	var b strings.Builder
+	if e.Deny {
+		b.WriteString(PermissionDenyPrefix)
+	}
+	b.WriteString(e.Code)
+	for i, c := range e.Conditions {
+		if i == 0 {
+			b.WriteString(PermissionConditionSeparator)
+		} else {
+			b.WriteString(permissionConditionJoiner)
+		}
+		b.WriteString(c.String())
+	}
+	return b.String()
+}
+
+var errEmptyPermissionEntry = errors.New("empty permission entry")
+
+// ParsePermissionEntry parses the stored form of a grant. A plain code parses
+// to an unconditional grant, so every existing row is a valid entry.
+func ParsePermissionEntry(raw string) (PermissionEntry, error) {
+	var e PermissionEntry
+	s := strings.TrimSpace(raw)
+	if strings.HasPrefix(s, PermissionDenyPrefix) {
+		e.Deny = true
+		s = s[len(PermissionDenyPrefix):]
+	}
+	code, conds, hasConds := strings.Cut(s, PermissionConditionSeparator)
+	if code == "" {
+		return e, errEmptyPermissionEntry
+	}
+	e.Code = code
+	if !hasConds {
+		return e, nil
+	}
+	if e.Deny {
+		return e, fmt.Errorf("permission entry %q: a deny cannot be conditional", raw)
+	}
+	for _, part := range strings.Split(conds, permissionConditionJoiner) {
+		c, err := parsePermissionCondition(part)
+		if err != nil {
+			return e, fmt.Errorf("permission entry %q: %w", raw, err)
+		}
+		e.Conditions = append(e.Conditions, c)
+	}
+	return e, nil
+}
+
+func parsePermissionCondition(s string) (PermissionCondition, error) {
+	s = strings.TrimSpace(s)
+	if s == "own" {
+		return PermissionCondition{Attr: PermissionAttrOwnerID, Op: PermCondOwn}, nil
+	}
+	for _, op := range conditionOps {
+		attr, value, ok := strings.Cut(s, string(op))
+		if !ok {
+			continue
+		}
+		if !isConditionAttr(attr) || value == "" {
+			return PermissionCondition{}, fmt.Errorf("malformed condition %q", s)
+		}
+		return PermissionCondition{Attr: attr, Op: op, Value: value}, nil
+	}
+	return PermissionCondition{}, fmt.Errorf("unknown condition %q", s)
+}
+
+// isConditionAttr accepts snake_case identifiers — the same shape as the
+// record attributes a service passes to the evaluator.
+func isConditionAttr(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsValidPermissionEntry is IsValidPermissionCode for the extended grammar:
+// the entry must parse and its code must be a known catalog code. Use it where
+// assignPermissionsTo{User,Role} accepts input.
+func IsValidPermissionEntry(raw string) bool {
+	e, err := ParsePermissionEntry(raw)
+	return err == nil && IsValidPermissionCode(e.Code)
+}
@@ -0,0 +1,61 @@
+package enums_test
+
+import (
+	"testing"
+
+	"github.com/TMS360/backend-pkg/enums"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePermissionEntry(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want enums.PermissionEntry
+	}{
+		{"accounting", enums.PermissionEntry{Code: "accounting"}},
+		{"-accounting.invoices.delete", enums.PermissionEntry{Code: "accounting.invoices.delete", Deny: true}},
+		{"loads.loads.edit?own", enums.PermissionEntry{Code: "loads.loads.edit", Conditions: []enums.PermissionCondition{
+			{Attr: enums.PermissionAttrOwnerID, Op: enums.PermCondOwn},
+		}}},
+		{"accounting.invoices.approve?amount<=5000&team_id=t1", enums.PermissionEntry{Code: "accounting.invoices.approve", Conditions: []enums.PermissionCondition{
+			{Attr: "amount", Op: enums.PermCondLte, Value: "5000"},
+			{Attr: "team_id", Op: enums.PermCondEq, Value: "t1"},
+		}}},
+		{"x?status!=void", enums.PermissionEntry{Code: "x", Conditions: []enums.PermissionCondition{
+			{Attr: "status", Op: enums.PermCondNe, Value: "void"},
+		}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.raw, func(t *testing.T) {
+			got, err := enums.ParsePermissionEntry(tc.raw)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.raw, got.String(), "entries must round-trip to their stored form")
+		})
+	}
+}
+
+func TestParsePermissionEntry_Rejects(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"-",
+		"?own",
+		"-loads.loads.edit?own", // a deny cannot be conditional
+		"loads.loads.edit?",
+		"loads.loads.edit?mine",
+		"loads.loads.edit?Amount<5",
+		"loads.loads.edit?amount<",
+	} {
+		_, err := enums.ParsePermissionEntry(raw)
+		assert.Errorf(t, err, "entry %q", raw)
+	}
+}
+
+func TestIsValidPermissionEntry(t *testing.T) {
+	assert.True(t, enums.IsValidPermissionEntry("accounting"))
+	assert.True(t, enums.IsValidPermissionEntry("-settings.compliance.edit"))
+	assert.True(t, enums.IsValidPermissionEntry(string(enums.PermTasksCreate)+"?own"))
+	assert.False(t, enums.IsValidPermissionEntry("-not.a.real.code"))
+	assert.False(t, enums.IsValidPermissionEntry("accounting?bogus"))
+}
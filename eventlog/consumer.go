@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/TMS360/backend-pkg/consts"
@@ -12,6 +12,8 @@ import (
 	"github.com/TMS360/backend-pkg/eventlog/rules"
 	"github.com/TMS360/backend-pkg/middleware"
 	"github.com/TMS360/backend-pkg/observability"
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/observability/telemetry"
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
 )
@@ -59,7 +61,7 @@ func NewConsumer(
 func (c *Consumer) Start(ctx context.Context) {
 	defer c.reader.Close()
 	defer observability.RecoverGoroutine(ctx)
-	log.Printf("Dynamic Dispatcher started for topics: %v", c.reader.Config().GroupTopics)
+	slog.InfoContext(ctx, "event consumer started", "topics", c.reader.Config().GroupTopics, "group", c.reader.Config().GroupID)
 
 	for {
 		m, err := c.reader.FetchMessage(ctx)
@@ -67,32 +69,44 @@ func (c *Consumer) Start(ctx context.Context) {
 			if ctx.Err() != nil {
 				return
 			} // Context cancelled
-			log.Printf("Consumer fetch error: %v", err)
+			slog.ErrorContext(ctx, "consumer fetch failed", "error", err)
 			observability.CaptureWithCtx(ctx, err)
 			time.Sleep(3 * time.Second)
 			continue
 		}
 
+		group := c.reader.Config().GroupID
+		metrics.SetConsumerLag(group, m.Topic, m.Partition, m.HighWaterMark-m.Offset-1)
+
 		// 1. Parse Envelope
 		var payload events.EventPayload
 		if err := json.Unmarshal(m.Value, &payload); err != nil {
-			log.Printf("Skipping malformed event: %v", err)
+			slog.WarnContext(ctx, "skipping malformed event", "topic", m.Topic, "offset", m.Offset, "error", err)
 			observability.CaptureWithCtx(ctx, err)
+			metrics.ObserveConsumed(group, m.Topic, "malformed", 0)
 			_ = c.reader.CommitMessages(ctx, m)
 			continue
 		}
 
-		// 2. Dispatch Logic
-		if err := c.dispatch(ctx, payload); err != nil {
-			log.Printf("Error dispatching event %s: %v", payload.EventID, err)
-			observability.CaptureWithCtx(ctx, err)
+		// 2. Dispatch Logic, inside a consumer span continuing the producer's
+		// trace (Kafka headers, else the payload's trace_context).
+		started := time.Now()
+		outcome := "ok"
+		spanCtx, span := telemetry.StartConsumerSpan(ctx, group, m, payload.TraceContext)
+		if err := c.dispatch(spanCtx, payload); err != nil {
+			slog.ErrorContext(spanCtx, "event dispatch failed", "event_id", payload.EventID, "error", err)
+			observability.CaptureWithCtx(spanCtx, err)
+			span.RecordError(err)
+			outcome = "error"
 			// Decide here: Commit anyway? Or retry?
 			// Usually safe to commit if it's just a rule failure.
 		}
+		span.End()
+		metrics.ObserveConsumed(group, m.Topic, outcome, time.Since(started))
 
 		// 3. Commit Offset
 		if err := c.reader.CommitMessages(ctx, m); err != nil {
-			log.Printf("Failed to commit offset: %v", err)
+			slog.ErrorContext(ctx, "consumer offset commit failed", "topic", m.Topic, "offset", m.Offset, "error", err)
 			observability.CaptureWithCtx(ctx, err)
 		}
 	}
@@ -101,7 +115,7 @@ func (c *Consumer) Start(ctx context.Context) {
 func (c *Consumer) dispatch(ctx context.Context, event events.EventPayload) error {
 	handlerKey := fmt.Sprintf("%s.%s", event.EntityType, event.Action)
 
-	log.Printf("📥 received event: %s (id=%s)", handlerKey, event.EventID)
+	slog.DebugContext(ctx, "event received", "handler", handlerKey, "event_id", event.EventID)
 
 	// Default to Nil UUID if it's a system event without a specific actor
 	actorID := uuid.Nil
@@ -126,9 +140,9 @@ func (c *Consumer) dispatch(ctx context.Context, event events.EventPayload) erro
 	if handlers, exists := c.systemHandlers[handlerKey]; exists {
 		handlerFound = true
 		for _, handler := range handlers {
-			log.Printf("Executing System Handler for EntityType %s", event.EntityType)
+			slog.DebugContext(ctxWithActor, "executing system handler", "handler", handlerKey)
 			if err := handler(ctxWithActor, event); err != nil {
-				log.Printf("❌ System handler execution failed: %v", err)
+				slog.ErrorContext(ctxWithActor, "system handler failed", "handler", handlerKey, "event_id", event.EventID, "error", err)
 			}
 		}
 	}
@@ -136,7 +150,7 @@ func (c *Consumer) dispatch(ctx context.Context, event events.EventPayload) erro
 	// A. Find Rules
 	matchingRules, err := c.engine.GetMatchingRules(ctx, event.EntityType, event.Action, event.Data)
 	if err != nil {
-		log.Printf("Error getting matching rules: %v", err)
+		slog.ErrorContext(ctx, "matching rules lookup failed", "handler", handlerKey, "error", err)
 		return nil // Fail-safe: don't block event processing
 	}
 
@@ -146,14 +160,14 @@ func (c *Consumer) dispatch(ctx context.Context, event events.EventPayload) erro
 			continue
 		}
 
-		log.Printf("Executing Rule %s -> Action %s", rule.ID, rule.ActionType)
+		slog.DebugContext(ctxWithActor, "executing rule action", "rule_id", rule.ID, "action", rule.ActionType)
 		if err := handler(ctxWithActor, event, json.RawMessage(rule.ActionConfig)); err != nil {
-			log.Printf("Action execution failed: %v", err)
+			slog.ErrorContext(ctxWithActor, "rule action failed", "rule_id", rule.ID, "action", rule.ActionType, "event_id", event.EventID, "error", err)
 		}
 	}
 
 	if !handlerFound {
-		log.Printf("ignored event: %s", handlerKey)
+		slog.DebugContext(ctx, "event ignored: no handler", "handler", handlerKey)
 	}
 
 	return nil
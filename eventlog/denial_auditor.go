@@ -0,0 +1,159 @@
+package eventlog
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/TMS360/backend-pkg/eventlog/events"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/observability"
+	"github.com/TMS360/backend-pkg/utils"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// AccessDecisionsTopic is the topic denial events are published to; it is
+	// also their entity_type, so backend-audit picks them up like any other
+	// audited entity.
+	AccessDecisionsTopic = "access_decisions"
+	// AccessDecisionDenied is the action of a denial event.
+	AccessDecisionDenied = "denied"
+
+	denialQueueSize    = 256
+	denialBatchSize    = 50
+	denialFlushTimeout = 5 * time.Second
+)
+
+// MessageWriter is the slice of *kafka.Writer the denial auditor needs.
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// DenialAuditor publishes access denials (middleware.AccessDecision) to the
+// audit stream as SECURITY events. It goes straight to Kafka rather than
+// through the outbox: a denial has no transaction to join, and losing a
+// sampled diagnostic event is acceptable where blocking the request is not.
+//
+// RecordDenial only enqueues; Start drains the queue. When the queue is full
+// the event is dropped and counted (Dropped).
+//
+//	auditor := eventlog.NewDenialAuditor(writer, "tms-loads")
+//	go auditor.Start(ctx)
+//	middleware.SetDenialAuditor(auditor, 0.1)
+type DenialAuditor struct {
+	writer        MessageWriter
+	sourceService string
+	queue         chan events.EventPayload
+	dropped       atomic.Int64
+}
+
+func NewDenialAuditor(writer MessageWriter, sourceService string) *DenialAuditor {
+	return &DenialAuditor{
+		writer:        writer,
+		sourceService: sourceService,
+		queue:         make(chan events.EventPayload, denialQueueSize),
+	}
+}
+
+// RecordDenial implements middleware.DenialAuditor. The payload is built here,
+// on the request path, so the client origin is read while ctx still has it.
+func (a *DenialAuditor) RecordDenial(ctx context.Context, d middleware.AccessDecision) {
+	payload, err := a.payload(ctx, d)
+	if err != nil {
+		slog.Warn("access denial not audited", "err", err)
+		return
+	}
+	select {
+	case a.queue <- payload:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many denials were discarded because the queue was full.
+func (a *DenialAuditor) Dropped() int64 { return a.dropped.Load() }
+
+func (a *DenialAuditor) payload(ctx context.Context, d middleware.AccessDecision) (events.EventPayload, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return events.EventPayload{}, err
+	}
+	var actorID *uuid.UUID
+	if d.ActorID != uuid.Nil {
+		actorID = utils.Pointer(d.ActorID)
+	}
+	var actorIP, userAgent *string
+	if origin := middleware.GetClientOrigin(ctx); origin != nil {
+		if origin.IP != "" {
+			actorIP = utils.Pointer(origin.IP)
+		}
+		if origin.UserAgent != "" {
+			userAgent = utils.Pointer(origin.UserAgent)
+		}
+	}
+	return events.EventPayload{
+		EventID:       uuid.New(),
+		ActorID:       actorID,
+		CompanyID:     d.CompanyID,
+		EntityType:    AccessDecisionsTopic,
+		EntityID:      d.ActorID,
+		Action:        AccessDecisionDenied,
+		SourceService: a.sourceService,
+		Timestamp:     time.Now(),
+		Data:          data,
+		ActorIP:       actorIP,
+		UserAgent:     userAgent,
+		Sensitivity:   events.SensitivitySecurity,
+		Participants:  events.WithActor(nil, actorID),
+	}, nil
+}
+
+// Start publishes queued denials until ctx is cancelled, batching whatever is
+// queued at the time of each write.
+func (a *DenialAuditor) Start(ctx context.Context) {
+	defer observability.RecoverGoroutine(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case first := <-a.queue:
+			batch := []events.EventPayload{first}
+		fill:
+			for len(batch) < denialBatchSize {
+				select {
+				case p := <-a.queue:
+					batch = append(batch, p)
+				default:
+					break fill
+				}
+			}
+			a.flush(ctx, batch)
+		}
+	}
+}
+
+func (a *DenialAuditor) flush(ctx context.Context, batch []events.EventPayload) {
+	msgs := make([]kafka.Message, 0, len(batch))
+	for _, p := range batch {
+		value, err := json.Marshal(p)
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, kafka.Message{
+			Topic: AccessDecisionsTopic,
+			Key:   []byte(p.EntityID.String()),
+			Value: value,
+			Time:  p.Timestamp,
+		})
+	}
+	writeCtx, cancel := context.WithTimeout(ctx, denialFlushTimeout)
+	defer cancel()
+	if err := a.writer.WriteMessages(writeCtx, msgs...); err != nil {
+		slog.Warn("access denial audit publish failed", "count", len(msgs), "err", err)
+	}
+}
@@ -58,6 +58,12 @@ type EventPayload struct {
 	// the join key that lets a driver page fan in a dispatch, a pay statement and
 	// a reported issue about the same person from three different services.
 	Participants []Participant `json:"participants,omitempty"`
+
+	// TraceContext is the W3C trace context (traceparent / tracestate) of the
+	// request that emitted the event, stamped by tmsdb.writeEvent. It carries
+	// the trace through the outbox row; the relay moves it into Kafka headers,
+	// and consumers fall back to it for messages published without them.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 type Change struct {
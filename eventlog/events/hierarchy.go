@@ -81,3 +81,37 @@ var LeafToRoot = map[string]RootEntity{
 	"board_columns":          RootWorkspace,
 	"board_values":           RootWorkspace,
 }
+
+// RootOf returns the aggregate root entityType rolls up to. The singular
+// resource names share links and @authGuest use ("shipment", "trip") resolve
+// like their plural entity types.
+func RootOf(entityType string) (RootEntity, bool) {
+	if root, ok := LeafToRoot[entityType]; ok {
+		return root, true
+	}
+	root, ok := LeafToRoot[entityType+"s"]
+	return root, ok
+}
+
+// IsRoot reports whether entityType names an aggregate root itself (singular
+// or plural), rather than a leaf beneath one.
+func IsRoot(entityType string) bool {
+	root, ok := RootOf(entityType)
+	return ok && (string(root) == entityType || string(root) == entityType+"s")
+}
+
+// CoversResource reports whether access granted on the granted entity type
+// extends to resource: the same type, or any leaf of the root granted names.
+// A grant on "shipment" covers "trip" and "trip_stops"; a grant on "trip"
+// covers only trips.
+func CoversResource(granted, resource string) bool {
+	if granted == resource {
+		return true
+	}
+	if !IsRoot(granted) {
+		return false
+	}
+	gr, _ := RootOf(granted)
+	rr, ok := RootOf(resource)
+	return ok && gr == rr
+}
@@ -2,12 +2,16 @@ package outbox
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"time"
 
 	"github.com/TMS360/backend-pkg/observability"
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/observability/telemetry"
 	"github.com/TMS360/backend-pkg/tmsdb"
 	kafkaGo "github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Relay struct {
@@ -31,6 +35,8 @@ func (r *Relay) Start(ctx context.Context) {
 
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
+	backlogTicker := time.NewTicker(backlogInterval)
+	defer backlogTicker.Stop()
 
 	batchSize := 50
 
@@ -43,14 +49,37 @@ func (r *Relay) Start(ctx context.Context) {
 				observability.CaptureWithCtx(batchCtx, err)
 			}
 			cancel() // Always clean up context
+		case <-backlogTicker.C:
+			r.recordBacklog(ctx)
 		case <-ctx.Done():
 			return // Exit cleanly
 		}
 	}
 }
 
+// backlogInterval is how often Start samples the pending backlog for metrics.
+// The count is a full scan of the status index, so it runs far less often
+// than the batch loop.
+const backlogInterval = 15 * time.Second
+
+func (r *Relay) recordBacklog(ctx context.Context) {
+	stats, ok := r.repository.(StatsRepository)
+	if !ok {
+		return
+	}
+	statsCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	count, oldest, err := stats.PendingStats(statsCtx)
+	if err != nil {
+		slog.Warn("outbox backlog stats failed", "error", err)
+		return
+	}
+	metrics.SetOutboxBacklog(count, oldest)
+}
+
 // ProcessBatch processes a batch of outbox events
 func (r *Relay) ProcessBatch(ctx context.Context, limit int) error {
+	started := time.Now()
 	return r.tm.WithTransaction(ctx, func(ctx context.Context) error {
 		// 1. Fetch Pending Events with SKIP LOCKED
 		eventsList, err := r.repository.FetchPendingBatch(ctx, limit)
@@ -62,9 +91,12 @@ func (r *Relay) ProcessBatch(ctx context.Context, limit int) error {
 			return nil
 		}
 
-		// 2. Prepare Kafka Messages
+		// 2. Prepare Kafka Messages. Each message gets a producer span parented
+		// on the emitting request's trace (carried in the payload), and the
+		// span's context goes into the headers for the consumer.
 		var kafkaMessages []kafkaGo.Message
 		var idsToDelete []string
+		var spans []trace.Span
 
 		for _, event := range eventsList {
 			// Producers can route a child entity onto a parent's topic via
@@ -74,17 +106,25 @@ func (r *Relay) ProcessBatch(ctx context.Context, limit int) error {
 			if topic == "" {
 				topic = event.EntityType
 			}
-			kafkaMessages = append(kafkaMessages, kafkaGo.Message{
+			msg := kafkaGo.Message{
 				Topic: topic,
 				Key:   []byte(event.EntityID.String()), // Order by EntityID
 				Value: event.Payload,
 				Time:  event.CreatedAt,
-			})
+			}
+			spans = append(spans, telemetry.StartProducerSpan(
+				telemetry.ExtractMap(ctx, payloadTraceContext(event.Payload)), topic, &msg))
+			kafkaMessages = append(kafkaMessages, msg)
 			idsToDelete = append(idsToDelete, event.ID.String())
 		}
 
 		// 3. Publish to Kafka (Batch Write)
-		if err := r.kafkaWriter.WriteMessages(ctx, kafkaMessages...); err != nil {
+		err = r.kafkaWriter.WriteMessages(ctx, kafkaMessages...)
+		for i, span := range spans {
+			telemetry.EndProducerSpan(ctx, span, kafkaMessages[i].Topic, err)
+		}
+		metrics.ObserveOutboxBatch(len(kafkaMessages), time.Since(started), err)
+		if err != nil {
 			return err
 		}
 		slog.Debug("outbox events published", "count", len(kafkaMessages))
@@ -96,3 +136,16 @@ func (r *Relay) ProcessBatch(ctx context.Context, limit int) error {
 		return nil
 	})
 }
+
+// payloadTraceContext pulls EventPayload.TraceContext out of a stored payload
+// without decoding the rest of it. Rows written before the field existed, or
+// with telemetry off, yield nil and the message starts a new trace.
+func payloadTraceContext(payload []byte) map[string]string {
+	var p struct {
+		TraceContext map[string]string `json:"trace_context"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil
+	}
+	return p.TraceContext
+}
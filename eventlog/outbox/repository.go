@@ -2,6 +2,7 @@ package outbox
 
 import (
 	"context"
+	"time"
 
 	"github.com/TMS360/backend-pkg/tmsdb"
 	"github.com/TMS360/backend-pkg/tmsdb/model"
@@ -15,10 +16,22 @@ type Repository interface {
 	DeleteBatch(ctx context.Context, ids []string) error
 }
 
+// StatsRepository is implemented by repositories that can report the pending
+// backlog. It is optional: the relay checks for it, so Repository
+// implementations and mocks written without it keep compiling and simply
+// publish no backlog metrics.
+type StatsRepository interface {
+	// PendingStats counts pending events and returns the oldest one's
+	// created_at (zero when there are none).
+	PendingStats(ctx context.Context) (count int64, oldest time.Time, err error)
+}
+
 type repo struct {
 	tm tmsdb.TransactionManager
 }
 
+var _ StatsRepository = (*repo)(nil)
+
 func NewOutboxEventRepository(tm tmsdb.TransactionManager) Repository {
 	return &repo{tm}
 }
@@ -43,3 +56,20 @@ func (r *repo) DeleteBatch(ctx context.Context, ids []string) error {
 		Where("id IN ?", ids).
 		Delete(&model.OutboxEvent{}).Error
 }
+
+// PendingStats counts pending events and returns the oldest one's created_at.
+func (r *repo) PendingStats(ctx context.Context) (int64, time.Time, error) {
+	var row struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err := r.tm.GetDB(ctx).
+		Model(&model.OutboxEvent{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("status = ?", "PENDING").
+		Scan(&row).Error
+	if err != nil || row.Oldest == nil {
+		return row.Count, time.Time{}, err
+	}
+	return row.Count, *row.Oldest, nil
+}
@@ -3,7 +3,7 @@ package rules
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 
 	pkgRule "github.com/nikunjy/rules"
 	"gorm.io/gorm"
@@ -60,7 +60,7 @@ func (e *Engine) matchesCondition(conditions []byte, data interface{}) bool {
 	// If data is already bytes (json.RawMessage), unmarshal it
 	if bytesData, ok := data.(json.RawMessage); ok {
 		if err := json.Unmarshal(bytesData, &inputMap); err != nil {
-			log.Printf("rules engine: failed to unmarshal event data: %v", err)
+			slog.Warn("rules engine: failed to unmarshal event data", "error", err)
 			return false // Fail safe: if we can't read data, condition fails
 		}
 	} else {
@@ -75,7 +75,7 @@ func (e *Engine) matchesCondition(conditions []byte, data interface{}) bool {
 	// library signature: Evaluate(jsonRule string, data map[string]interface{})
 	match, err := pkgRule.Evaluate(string(conditions), inputMap)
 	if err != nil {
-		log.Printf("rules engine: invalid rule syntax: %v", err)
+		slog.Warn("rules engine: invalid rule syntax", "error", err)
 		return false
 	}
 
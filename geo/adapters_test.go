@@ -0,0 +1,208 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TMS360/backend-pkg/client/googlemaps"
+	"github.com/TMS360/backend-pkg/client/here"
+	"github.com/TMS360/backend-pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stub answers every request with status/body and remembers the paths.
+func stub(t *testing.T, status int, body string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &paths
+}
+
+func hereStub(t *testing.T, status int, body string) (*HERE, *[]string) {
+	srv, paths := stub(t, status, body)
+	c, err := here.NewClient(config.HereConfig{RouterHost: srv.URL, GeocodeHost: srv.URL, AutocompleteHost: srv.URL, MatrixHost: srv.URL},
+		"k", here.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	return NewHERE(c), paths
+}
+
+func googleStub(t *testing.T, status int, body string) *Google {
+	srv, _ := stub(t, status, body)
+	c, err := googlemaps.NewClient(config.GoogleMapsConfig{GeocodeHost: srv.URL, RoutesHost: srv.URL},
+		"k", googlemaps.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	return NewGoogle(c)
+}
+
+func TestHERE_GeocodeNormalizes(t *testing.T) {
+	h, _ := hereStub(t, 200, `{"items":[{"title":"6440 W Howard St","id":"here:af:1",
+		"position":{"lat":42.0208,"lng":-87.8067},
+		"address":{"label":"6440 W Howard St, Niles, IL 60714, United States","countryCode":"USA",
+		"state":"Illinois","stateCode":"IL","city":"Niles","street":"W Howard St","houseNumber":"6440","postalCode":"60714"}}]}`)
+
+	got, err := h.Geocode(context.Background(), "6440 w howard")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, Address{
+		Label: "6440 W Howard St, Niles, IL 60714, United States", Point: Point{Lat: 42.0208, Lng: -87.8067},
+		HouseNumber: "6440", Street: "W Howard St", City: "Niles", State: "Illinois", StateCode: "IL",
+		PostalCode: "60714", CountryCode: "US", PlaceID: "here:af:1", Provider: ProviderHERE,
+	}, got[0])
+}
+
+func TestGoogle_GeocodeNormalizes(t *testing.T) {
+	g := googleStub(t, 200, `{"status":"OK","results":[{"formatted_address":"6440 W Howard St, Niles, IL 60714, USA",
+		"place_id":"gp1","geometry":{"location":{"lat":42.0208,"lng":-87.8067}},
+		"address_components":[
+		{"long_name":"6440","short_name":"6440","types":["street_number"]},
+		{"long_name":"West Howard Street","short_name":"W Howard St","types":["route"]},
+		{"long_name":"Niles","short_name":"Niles","types":["locality"]},
+		{"long_name":"Cook County","short_name":"Cook County","types":["administrative_area_level_2"]},
+		{"long_name":"Illinois","short_name":"IL","types":["administrative_area_level_1"]},
+		{"long_name":"United States","short_name":"US","types":["country"]},
+		{"long_name":"60714","short_name":"60714","types":["postal_code"]}]}]}`)
+
+	got, err := g.Geocode(context.Background(), "6440 w howard")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "IL", got[0].StateCode)
+	assert.Equal(t, "Illinois", got[0].State)
+	assert.Equal(t, "US", got[0].CountryCode)
+	assert.Equal(t, "Cook County", got[0].County)
+	assert.Equal(t, ProviderGoogle, got[0].Provider)
+}
+
+func TestHERE_RouteAndAutocomplete(t *testing.T) {
+	h, paths := hereStub(t, 200, `{"routes":[{"id":"r","sections":[
+		{"summary":{"length":1000,"duration":70,"baseDuration":60}},
+		{"summary":{"length":2000,"duration":130,"baseDuration":120}}]}],
+		"items":[{"title":"Niles","id":"here:cm:1","address":{"label":"Niles, IL, United States"}}]}`)
+
+	r, err := h.Route(context.Background(), RouteRequest{Waypoints: []Point{{41, -87}, {41.5, -87.5}, {42, -88}}})
+	require.NoError(t, err)
+	assert.Equal(t, 3000, r.DistanceMeters)
+	assert.Equal(t, 180, r.DurationSeconds)
+	require.Len(t, r.Legs, 2)
+	assert.Equal(t, Point{41.5, -87.5}, r.Legs[0].To)
+
+	s, err := h.Autocomplete(context.Background(), "Nil")
+	require.NoError(t, err)
+	assert.Equal(t, []Suggestion{{Label: "Niles, IL, United States", PlaceID: "here:cm:1", Provider: ProviderHERE}}, s)
+	assert.Equal(t, []string{"/v8/routes", "/v1/autocomplete"}, *paths)
+}
+
+// The HERE stub answers each matrix block with distance = origin lat × 1000 +
+// destination lat, so a cell in the wrong place after stitching shows.
+func TestHERE_MatrixSplitsIntoBlocks(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req struct {
+			Origins, Destinations []struct{ Lat float64 }
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var dist, dur, codes []int
+		for _, o := range req.Origins {
+			for _, d := range req.Destinations {
+				dist = append(dist, int(o.Lat)*1000+int(d.Lat))
+				dur = append(dur, 60)
+				code := 0
+				if o.Lat == 7 {
+					code = 3
+				}
+				codes = append(codes, code)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"matrix": map[string]any{
+			"numOrigins": len(req.Origins), "numDestinations": len(req.Destinations),
+			"distances": dist, "travelTimes": dur, "errorCodes": codes,
+		}})
+	}))
+	t.Cleanup(srv.Close)
+	c, err := here.NewClient(config.HereConfig{MatrixHost: srv.URL}, "k", here.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+
+	origins := make([]Point, here.MaxMatrixOrigins+2)
+	for i := range origins {
+		origins[i] = Point{Lat: float64(i)}
+	}
+	m, err := NewHERE(c).Matrix(context.Background(), MatrixRequest{Origins: origins, Destinations: []Point{{Lat: 1}, {Lat: 2}}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, calls, "17 origins is two blocks")
+	assert.Equal(t, ProviderHERE, m.Provider)
+	assert.Equal(t, 16002, m.DistanceMeters[16][1])
+	assert.Equal(t, 3001, m.DistanceMeters[3][0])
+	assert.Equal(t, Unreachable, m.DistanceMeters[7][0])
+	assert.Equal(t, Unreachable, m.DurationSeconds[7][1])
+}
+
+func TestGoogle_MatrixMissingPairsAreUnreachable(t *testing.T) {
+	g := googleStub(t, 200, `[{"originIndex":0,"destinationIndex":1,"distanceMeters":5000,"duration":"300s","condition":"ROUTE_EXISTS"},
+		{"originIndex":0,"destinationIndex":0,"condition":"ROUTE_NOT_FOUND"}]`)
+
+	m, err := g.Matrix(context.Background(), MatrixRequest{Origins: []Point{{41, -87}}, Destinations: []Point{{41, -88}, {42, -88}}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]int{{Unreachable, 5000}}, m.DistanceMeters)
+	assert.Equal(t, [][]int{{Unreachable, 300}}, m.DurationSeconds)
+}
+
+func TestAdapters_ClassifyErrors(t *testing.T) {
+	ctx := context.Background()
+
+	h, _ := hereStub(t, http.StatusUnauthorized, `{"error":"Unauthorized"}`)
+	_, err := h.Geocode(ctx, "x")
+	assert.ErrorIs(t, err, ErrAuth)
+	assert.True(t, here.IsAuthError(err), "the vendor error stays reachable")
+
+	h, _ = hereStub(t, http.StatusBadGateway, `upstream`)
+	_, err = h.Geocode(ctx, "x")
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	h, _ = hereStub(t, http.StatusBadRequest, `{"title":"bad"}`)
+	_, err = h.Geocode(ctx, "x")
+	require.Error(t, err)
+	assert.False(t, IsFailover(err), "a bad request would fail at any provider")
+
+	g := googleStub(t, 200, `{"status":"REQUEST_DENIED","error_message":"key invalid"}`)
+	_, err = g.Autocomplete(ctx, "x")
+	assert.ErrorIs(t, err, ErrAuth)
+
+	g = googleStub(t, http.StatusServiceUnavailable, `{}`)
+	_, err = g.Route(ctx, RouteRequest{Waypoints: []Point{{41, -87}, {42, -88}}})
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestAdapters_UnreachableProviderIsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // nothing listens any more: every call fails to dial
+
+	hc, err := here.NewClient(config.HereConfig{RouterHost: srv.URL, GeocodeHost: srv.URL, AutocompleteHost: srv.URL, MatrixHost: srv.URL},
+		"k", here.WithHTTPClient(&http.Client{}))
+	require.NoError(t, err)
+	gc, err := googlemaps.NewClient(config.GoogleMapsConfig{GeocodeHost: srv.URL, RoutesHost: srv.URL},
+		"k", googlemaps.WithHTTPClient(&http.Client{}))
+	require.NoError(t, err)
+
+	for _, p := range []Provider{NewHERE(hc), NewGoogle(gc)} {
+		_, err := p.Geocode(context.Background(), "x")
+		assert.ErrorIs(t, err, ErrUnavailable, p.Name())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = p.Geocode(ctx, "x")
+		require.Error(t, err)
+		assert.False(t, IsFailover(err), "%s: the caller giving up is not an outage", p.Name())
+	}
+}
@@ -0,0 +1,275 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+// CacheTTL is how long results of one provider may be kept. Zero means the
+// results are not cached at all.
+type CacheTTL struct {
+	Geocode time.Duration
+	Route   time.Duration
+}
+
+// DefaultCacheTTL stays within both vendors' terms: Google allows caching
+// coordinates for at most 30 days, and HERE's storage terms are no looser
+// for a client without a storage licence. Routes are kept a week — facility
+// to facility distances rarely change, and the durations cached are the
+// traffic-free ones.
+var DefaultCacheTTL = CacheTTL{Geocode: 30 * 24 * time.Hour, Route: 7 * 24 * time.Hour}
+
+// DefaultNegativeTTL is how long "no match" is remembered. Short, so a fixed
+// typo or a newly mapped facility shows up the next day, but long enough
+// that a bad address on a recurring lane is not billed on every load.
+const DefaultNegativeTTL = 24 * time.Hour
+
+// DefaultFallbackRouteTTL is how long a route answered by a fallback
+// provider is kept. It is shared with the primary's key, and a Google car
+// route is not a HERE truck route, so it only covers the outage.
+const DefaultFallbackRouteTTL = time.Hour
+
+// CacheStore keeps cached results. Get reports whether key was found.
+type CacheStore interface {
+	Get(ctx context.Context, key string, dest any) (bool, error)
+	Set(ctx context.Context, key string, value any, ttl time.Duration) error
+}
+
+// Cached is a Provider that answers geocodes and routes from a CacheStore
+// before asking next. Autocomplete is passed through: predictions are
+// per-keystroke and Google's terms do not allow storing them. Matrix is
+// passed through too: its origins are usually moving trucks, so a key would
+// hardly ever be hit twice.
+//
+// The cache is best effort — a store error is logged and the call goes to
+// next — and errors from next are never cached.
+type Cached struct {
+	next        Provider
+	store       CacheStore
+	ttls        map[string]CacheTTL
+	negativeTTL time.Duration
+	fallbackTTL time.Duration
+	precision   int
+}
+
+// CacheOption configures NewCached.
+type CacheOption func(*Cached)
+
+// WithProviderTTL sets the TTLs for results answered by provider (matched
+// against Address.Provider / Route.Provider), overriding DefaultCacheTTL.
+func WithProviderTTL(provider string, ttl CacheTTL) CacheOption {
+	return func(c *Cached) { c.ttls[provider] = ttl }
+}
+
+// WithNegativeTTL sets how long an empty geocode is remembered; zero turns
+// negative caching off.
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(c *Cached) { c.negativeTTL = ttl }
+}
+
+// WithFallbackRouteTTL caps how long a route answered by a provider other
+// than next's primary (see Failover.Primary) is kept; zero stops caching
+// them.
+func WithFallbackRouteTTL(ttl time.Duration) CacheOption {
+	return func(c *Cached) { c.fallbackTTL = ttl }
+}
+
+// WithCoordinatePrecision sets the decimals route waypoints are rounded to
+// for the key (default 4, about 11 m).
+func WithCoordinatePrecision(decimals int) CacheOption {
+	return func(c *Cached) { c.precision = decimals }
+}
+
+// NewCached decorates next with store.
+func NewCached(next Provider, store CacheStore, opts ...CacheOption) *Cached {
+	c := &Cached{
+		next:        next,
+		store:       store,
+		ttls:        map[string]CacheTTL{},
+		negativeTTL: DefaultNegativeTTL,
+		fallbackTTL: DefaultFallbackRouteTTL,
+		precision:   4,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Cached) Name() string { return c.next.Name() }
+
+func (c *Cached) ttl(provider string) CacheTTL {
+	if t, ok := c.ttls[provider]; ok {
+		return t
+	}
+	return DefaultCacheTTL
+}
+
+// Geocode keys on NormalizeAddress(query), so casing, punctuation, spelled
+// out states and street words do not cause a second billed lookup.
+func (c *Cached) Geocode(ctx context.Context, query string) ([]Address, error) {
+	key := "geo:geocode:" + NormalizeAddress(query)
+	var hit []Address
+	if c.load(ctx, key, &hit) {
+		return hit, nil
+	}
+
+	out, err := c.next.Geocode(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	ttl := c.negativeTTL
+	for i, a := range out {
+		if t := c.ttl(a.Provider).Geocode; i == 0 || t < ttl {
+			ttl = t
+		}
+	}
+	c.save(ctx, key, out, ttl)
+	return out, nil
+}
+
+// Route keys on the waypoints rounded to the configured precision. The
+// departure time is not part of the key: the routes cached carry
+// traffic-free durations. Estimated routes are never cached, and a route
+// from a fallback provider is kept at most WithFallbackRouteTTL.
+func (c *Cached) Route(ctx context.Context, req RouteRequest) (*Route, error) {
+	if err := validateRoute(req); err != nil {
+		return nil, err
+	}
+	key := "geo:route:" + routeKey(req.Waypoints, c.precision)
+	var hit Route
+	if c.load(ctx, key, &hit) {
+		return &hit, nil
+	}
+
+	out, err := c.next.Route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// An outage's straight-line guess is not cached: the next call should
+	// ask the provider again, not serve the estimate for a week.
+	if !out.Estimated {
+		ttl := c.ttl(out.Provider).Route
+		if c.isFallback(out.Provider) && c.fallbackTTL < ttl {
+			ttl = c.fallbackTTL
+		}
+		c.save(ctx, key, out, ttl)
+	}
+	return out, nil
+}
+
+func (c *Cached) Matrix(ctx context.Context, req MatrixRequest) (*Matrix, error) {
+	return c.next.Matrix(ctx, req)
+}
+
+func (c *Cached) Autocomplete(ctx context.Context, input string) ([]Suggestion, error) {
+	return c.next.Autocomplete(ctx, input)
+}
+
+// isFallback reports whether provider answered in place of next's primary.
+func (c *Cached) isFallback(provider string) bool {
+	chain, ok := c.next.(interface{ Primary() string })
+	return ok && chain.Primary() != provider
+}
+
+func (c *Cached) load(ctx context.Context, key string, dest any) bool {
+	ok, err := c.store.Get(ctx, key, dest)
+	if err != nil {
+		slog.WarnContext(ctx, "geo: cache read failed", "key", key, "error", err)
+		return false
+	}
+	return ok
+}
+
+func (c *Cached) save(ctx context.Context, key string, value any, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	if err := c.store.Set(ctx, key, value, ttl); err != nil {
+		slog.WarnContext(ctx, "geo: cache write failed", "key", key, "error", err)
+	}
+}
+
+// RedisCache stores entries through the cache package (cache.Init must have
+// been called). With an empty company id keys are scoped to the acting
+// company, like cache.Set; pass the id explicitly for background work that
+// has no actor. Results are never shared across companies: each tenant pays
+// for its own lookups with its own key, and the vendor terms license the
+// results to that key.
+type RedisCache struct {
+	companyID string
+}
+
+func NewRedisCache(companyID string) *RedisCache {
+	return &RedisCache{companyID: companyID}
+}
+
+func (r *RedisCache) Get(ctx context.Context, key string, dest any) (bool, error) {
+	var err error
+	if r.companyID != "" {
+		err = cache.GetGlobal(ctx, cache.ScopedKey(r.companyID, key), dest)
+	} else {
+		err = cache.Get(ctx, key, dest)
+	}
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *RedisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if r.companyID != "" {
+		return cache.SetGlobal(ctx, cache.ScopedKey(r.companyID, key), value, ttl)
+	}
+	return cache.Set(ctx, key, value, ttl)
+}
+
+// MemoryCache keeps entries in-process, for tests and single-replica tools.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]memoryEntry{}, now: time.Now}
+}
+
+func (m *MemoryCache) Get(_ context.Context, key string, dest any) (bool, error) {
+	m.mu.Lock()
+	e, ok := m.entries[key]
+	if ok && !m.now().Before(e.expires) {
+		delete(m.entries, key)
+		ok = false
+	}
+	m.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(e.data, dest)
+}
+
+func (m *MemoryCache) Set(_ context.Context, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{data: data, expires: m.now().Add(ttl)}
+	return nil
+}
+
+var _ Provider = (*Cached)(nil)
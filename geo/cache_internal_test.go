@@ -0,0 +1,29 @@
+package geo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache_Expires(t *testing.T) {
+	m := NewMemoryCache()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, m.Set(ctx, "k", Route{DistanceMeters: 5}, time.Hour))
+	var r Route
+	ok, err := m.Get(ctx, "k", &r)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 5, r.DistanceMeters)
+
+	now = now.Add(time.Hour)
+	ok, err = m.Get(ctx, "k", &r)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
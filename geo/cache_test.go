@@ -0,0 +1,171 @@
+package geo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/TMS360/backend-pkg/geo/geotest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countOps(f *geotest.Fake, op string) int {
+	n := 0
+	for _, c := range f.Calls() {
+		if c.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCached_GeocodeSharesNormalizedKey(t *testing.T) {
+	fake := geotest.New(geo.ProviderHERE)
+	c := geo.NewCached(fake, geo.NewMemoryCache())
+	ctx := context.Background()
+
+	first, err := c.Geocode(ctx, "6440 W. Howard Street, Niles, Illinois 60714")
+	require.NoError(t, err)
+	again, err := c.Geocode(ctx, "6440 west howard st, NILES, IL 60714-1234, USA")
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.Equal(t, 1, countOps(fake, geotest.OpGeocode))
+
+	_, err = c.Geocode(ctx, "6440 W Howard St Suite 2, Niles, IL")
+	require.NoError(t, err)
+	assert.Equal(t, 2, countOps(fake, geotest.OpGeocode), "a different suite is a different key")
+}
+
+func TestCached_NegativesErrorsAndTTLs(t *testing.T) {
+	fake := geotest.New(geo.ProviderGoogle)
+	fake.SetAddress("nowhere")
+	c := geo.NewCached(fake, geo.NewMemoryCache(), geo.WithProviderTTL(geo.ProviderGoogle, geo.CacheTTL{Geocode: time.Hour}))
+	ctx := context.Background()
+
+	for range 2 {
+		got, err := c.Geocode(ctx, "Nowhere")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	}
+	assert.Equal(t, 1, countOps(fake, geotest.OpGeocode), "no match is remembered")
+
+	fake.Fail(geotest.OpGeocode, geo.ErrUnavailable)
+	_, err := c.Geocode(ctx, "1 Main St")
+	require.Error(t, err)
+	fake.Fail(geotest.OpGeocode, nil)
+	_, err = c.Geocode(ctx, "1 Main St")
+	require.NoError(t, err, "the failure was not cached")
+
+	route := geo.RouteRequest{Waypoints: []geo.Point{{Lat: 41.8781, Lng: -87.6298}, {Lat: 38.627, Lng: -90.1994}}}
+	for range 2 {
+		_, err = c.Route(ctx, route)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, countOps(fake, geotest.OpRoute), "a zero Route TTL disables route caching for the provider")
+}
+
+func TestCached_RouteKeyRoundsAndAutocompletePassesThrough(t *testing.T) {
+	fake := geotest.New(geo.ProviderHERE)
+	c := geo.NewCached(fake, geo.NewMemoryCache())
+	ctx := context.Background()
+
+	_, err := c.Route(ctx, geo.RouteRequest{Waypoints: []geo.Point{{Lat: 41.878113, Lng: -87.629799}, {Lat: 38.627003, Lng: -90.199404}}})
+	require.NoError(t, err)
+	got, err := c.Route(ctx, geo.RouteRequest{Waypoints: []geo.Point{{Lat: 41.87812, Lng: -87.62982}, {Lat: 38.62699, Lng: -90.19939}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, countOps(fake, geotest.OpRoute))
+	assert.Len(t, got.Legs, 1)
+
+	for range 2 {
+		_, err = c.Autocomplete(ctx, "6440 W How")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, countOps(fake, geotest.OpAutocomplete))
+}
+
+func TestCached_DoesNotCacheEstimates(t *testing.T) {
+	fake := geotest.New(geo.ProviderHERE)
+	fake.Fail(geotest.OpRoute, geo.ErrUnavailable)
+	c := geo.NewCached(geo.NewFailover([]geo.Provider{fake}, geo.WithEstimateFallback(geo.NewEstimator())), geo.NewMemoryCache())
+	ctx := context.Background()
+	route := geo.RouteRequest{Waypoints: []geo.Point{{Lat: 41.8781, Lng: -87.6298}, {Lat: 38.627, Lng: -90.1994}}}
+
+	got, err := c.Route(ctx, route)
+	require.NoError(t, err)
+	assert.True(t, got.Estimated)
+
+	fake.Fail(geotest.OpRoute, nil)
+	got, err = c.Route(ctx, route)
+	require.NoError(t, err)
+	assert.False(t, got.Estimated, "the provider is asked again once it recovers")
+	assert.Equal(t, geo.ProviderHERE, got.Provider)
+	assert.Equal(t, 2, countOps(fake, geotest.OpRoute))
+}
+
+type ttlStore struct {
+	*geo.MemoryCache
+	ttls map[string]time.Duration
+}
+
+func (s *ttlStore) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	s.ttls[key] = ttl
+	return s.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func TestCached_FallbackRoutesAreShortLived(t *testing.T) {
+	here, google := geotest.New(geo.ProviderHERE), geotest.New(geo.ProviderGoogle)
+	here.Fail(geotest.OpRoute, geo.ErrUnavailable)
+	store := &ttlStore{MemoryCache: geo.NewMemoryCache(), ttls: map[string]time.Duration{}}
+	c := geo.NewCached(geo.NewFailover([]geo.Provider{here, google}), store)
+	ctx := context.Background()
+	route := geo.RouteRequest{Waypoints: []geo.Point{{Lat: 41.8781, Lng: -87.6298}, {Lat: 38.627, Lng: -90.1994}}}
+
+	got, err := c.Route(ctx, route)
+	require.NoError(t, err)
+	assert.Equal(t, geo.ProviderGoogle, got.Provider)
+	require.Len(t, store.ttls, 1)
+	for _, ttl := range store.ttls {
+		assert.Equal(t, geo.DefaultFallbackRouteTTL, ttl, "the fallback's route only covers the outage")
+	}
+
+	here.Fail(geotest.OpRoute, nil)
+	c = geo.NewCached(geo.NewFailover([]geo.Provider{here, google}), store, geo.WithFallbackRouteTTL(0))
+	store.ttls = map[string]time.Duration{}
+	route.Waypoints[1].Lat = 39.0997
+	got, err = c.Route(ctx, route)
+	require.NoError(t, err)
+	assert.Equal(t, geo.ProviderHERE, got.Provider)
+	require.Len(t, store.ttls, 1)
+	for _, ttl := range store.ttls {
+		assert.Equal(t, geo.DefaultCacheTTL.Route, ttl, "the primary's route is kept the full TTL")
+	}
+}
+
+func TestRedisCache_ScopedToCompany(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache.Init(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	fake := geotest.New(geo.ProviderHERE)
+	c := geo.NewCached(fake, geo.NewRedisCache("42"))
+	ctx := context.Background()
+
+	_, err := c.Geocode(ctx, "6440 W Howard St, Niles, IL")
+	require.NoError(t, err)
+	key := "42:geo:geocode:6440 w howard st, niles, il"
+	require.True(t, mr.Exists(key))
+	assert.Equal(t, geo.DefaultCacheTTL.Geocode, mr.TTL(key))
+
+	_, err = c.Geocode(ctx, "6440 W HOWARD ST, NILES, IL")
+	require.NoError(t, err)
+	assert.Equal(t, 1, countOps(fake, geotest.OpGeocode))
+
+	other := geo.NewCached(fake, geo.NewRedisCache("7"))
+	_, err = other.Geocode(ctx, "6440 W Howard St, Niles, IL")
+	require.NoError(t, err)
+	assert.Equal(t, 2, countOps(fake, geotest.OpGeocode), "tenants do not share results")
+}
@@ -0,0 +1,134 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Failover is a Provider that asks its providers in order and moves on to
+// the next only when one fails with a failover error (IsFailover by default):
+// a rejected key or an outage. Any other error — a bad request — and any
+// answer, including "no results", is final, because the next provider would
+// only be billed to say the same.
+type Failover struct {
+	providers  []Provider
+	shouldFail func(error) bool
+	estimator  *Estimator
+}
+
+// FailoverOption configures NewFailover.
+type FailoverOption func(*Failover)
+
+// WithFailoverOn replaces IsFailover as the test for moving on — e.g. to
+// also fall over on quota.ErrBudgetExceeded.
+func WithFailoverOn(fn func(error) bool) FailoverOption {
+	return func(f *Failover) { f.shouldFail = fn }
+}
+
+// WithEstimateFallback answers Route and Matrix from e, marked Estimated,
+// when every provider fails over — so dispatch can still rank trucks during
+// an outage. Geocode and Autocomplete have no offline answer and still fail.
+func WithEstimateFallback(e *Estimator) FailoverOption {
+	return func(f *Failover) { f.estimator = e }
+}
+
+// NewFailover chains providers, first preferred. Nil providers are skipped,
+// so a tenant without a Google key can pass a nil fallback.
+func NewFailover(providers []Provider, opts ...FailoverOption) *Failover {
+	f := &Failover{shouldFail: IsFailover}
+	for _, p := range providers {
+		if p != nil {
+			f.providers = append(f.providers, p)
+		}
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Name reports the chain, e.g. "here>google".
+func (f *Failover) Name() string {
+	name := ""
+	for i, p := range f.providers {
+		if i > 0 {
+			name += ">"
+		}
+		name += p.Name()
+	}
+	return name
+}
+
+// Primary names the preferred provider, or "" for an empty chain.
+func (f *Failover) Primary() string {
+	if len(f.providers) == 0 {
+		return ""
+	}
+	return f.providers[0].Name()
+}
+
+func (f *Failover) Geocode(ctx context.Context, query string) ([]Address, error) {
+	return try(ctx, f, "geocode", func(p Provider) ([]Address, error) { return p.Geocode(ctx, query) })
+}
+
+func (f *Failover) Route(ctx context.Context, req RouteRequest) (*Route, error) {
+	out, err := try(ctx, f, "route", func(p Provider) (*Route, error) { return p.Route(ctx, req) })
+	if f.estimate(ctx, "route", err) {
+		return f.estimator.Route(ctx, req)
+	}
+	return out, err
+}
+
+func (f *Failover) Matrix(ctx context.Context, req MatrixRequest) (*Matrix, error) {
+	out, err := try(ctx, f, "matrix", func(p Provider) (*Matrix, error) { return p.Matrix(ctx, req) })
+	if f.estimate(ctx, "matrix", err) {
+		return f.estimator.Matrix(ctx, req)
+	}
+	return out, err
+}
+
+func (f *Failover) Autocomplete(ctx context.Context, input string) ([]Suggestion, error) {
+	return try(ctx, f, "autocomplete", func(p Provider) ([]Suggestion, error) { return p.Autocomplete(ctx, input) })
+}
+
+// estimate reports whether err from try should be answered by the
+// Estimator: one is configured and every provider failed over.
+func (f *Failover) estimate(ctx context.Context, op string, err error) bool {
+	if err == nil || f.estimator == nil || !f.shouldFail(err) {
+		return false
+	}
+	slog.WarnContext(ctx, "geo: every provider failed; estimating", "op", op, "error", err)
+	return true
+}
+
+// try runs call against each provider until one answers or fails for good.
+// When every provider fails over, the errors are joined so the caller sees
+// each provider's reason (and errors.Is still finds ErrAuth/ErrUnavailable).
+func try[T any](ctx context.Context, f *Failover, op string, call func(Provider) (T, error)) (T, error) {
+	var (
+		zero T
+		errs []error
+	)
+	if len(f.providers) == 0 {
+		return zero, errors.New("geo: no providers configured")
+	}
+	for i, p := range f.providers {
+		out, err := call(p)
+		if err == nil {
+			return out, nil
+		}
+		if !f.shouldFail(err) {
+			return zero, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		if i+1 < len(f.providers) {
+			slog.WarnContext(ctx, "geo: provider failed; trying the next",
+				"op", op, "provider", p.Name(), "next", f.providers[i+1].Name(), "error", err)
+		}
+	}
+	return zero, fmt.Errorf("geo: every provider failed: %w", errors.Join(errs...))
+}
+
+var _ Provider = (*Failover)(nil)
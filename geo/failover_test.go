@@ -0,0 +1,116 @@
+package geo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/TMS360/backend-pkg/geo/geotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailover_MovesOnForAuthAndOutages(t *testing.T) {
+	for _, cause := range []error{geo.ErrAuth, geo.ErrUnavailable} {
+		primary, fallback := geotest.New("here"), geotest.New("google")
+		primary.Fail(geotest.OpGeocode, fmt.Errorf("%w: status 503", cause))
+		f := geo.NewFailover([]geo.Provider{primary, fallback})
+
+		got, err := f.Geocode(context.Background(), "6440 W Howard St, Niles, IL")
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "google", got[0].Provider)
+		assert.Len(t, fallback.Calls(), 1)
+	}
+}
+
+func TestFailover_OtherErrorsAndEmptyAnswersAreFinal(t *testing.T) {
+	primary, fallback := geotest.New("here"), geotest.New("google")
+	primary.Fail(geotest.OpRoute, errors.New("status 400: bad waypoint"))
+	primary.SetAddress("nowhere")
+	f := geo.NewFailover([]geo.Provider{primary, fallback})
+
+	_, err := f.Route(context.Background(), geo.RouteRequest{Waypoints: []geo.Point{{Lat: 41, Lng: -87}, {Lat: 42, Lng: -88}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad waypoint")
+
+	got, err := f.Geocode(context.Background(), "nowhere")
+	require.NoError(t, err)
+	assert.Empty(t, got, "no match is an answer")
+	assert.Empty(t, fallback.Calls())
+}
+
+func TestFailover_AllFailed(t *testing.T) {
+	primary, fallback := geotest.New("here"), geotest.New("google")
+	primary.Fail(geotest.OpAutocomplete, geo.ErrAuth)
+	fallback.Fail(geotest.OpAutocomplete, geo.ErrUnavailable)
+	f := geo.NewFailover([]geo.Provider{primary, nil, fallback})
+	assert.Equal(t, "here>google", f.Name(), "nil providers are skipped")
+
+	_, err := f.Autocomplete(context.Background(), "6440 W How")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, geo.ErrAuth)
+	assert.ErrorIs(t, err, geo.ErrUnavailable)
+	assert.Contains(t, err.Error(), "here: ")
+	assert.Contains(t, err.Error(), "google: ")
+}
+
+func TestFailover_CustomPredicate(t *testing.T) {
+	budget := errors.New("budget exceeded")
+	primary, fallback := geotest.New("here"), geotest.New("google")
+	primary.Fail(geotest.OpGeocode, budget)
+	f := geo.NewFailover([]geo.Provider{primary, fallback},
+		geo.WithFailoverOn(func(err error) bool { return geo.IsFailover(err) || errors.Is(err, budget) }))
+
+	got, err := f.Geocode(context.Background(), "x")
+	require.NoError(t, err)
+	assert.Equal(t, "google", got[0].Provider)
+}
+
+func TestFailover_EstimatesWhenEveryProviderIsDown(t *testing.T) {
+	primary, fallback := geotest.New("here"), geotest.New("google")
+	for _, p := range []*geotest.Fake{primary, fallback} {
+		p.Fail(geotest.OpMatrix, geo.ErrUnavailable)
+		p.Fail(geotest.OpRoute, geo.ErrAuth)
+		p.Fail(geotest.OpGeocode, geo.ErrUnavailable)
+	}
+	f := geo.NewFailover([]geo.Provider{primary, fallback}, geo.WithEstimateFallback(geo.NewEstimator()))
+	chicago, stLouis := geo.Point{Lat: 41.8781, Lng: -87.6298}, geo.Point{Lat: 38.6270, Lng: -90.1994}
+
+	m, err := f.Matrix(context.Background(), geo.MatrixRequest{Origins: []geo.Point{chicago}, Destinations: []geo.Point{stLouis}})
+	require.NoError(t, err)
+	assert.True(t, m.Estimated)
+	assert.InDelta(t, 422_000*geo.DefaultRoadFactor, m.DistanceMeters[0][0], 2_000)
+
+	r, err := f.Route(context.Background(), geo.RouteRequest{Waypoints: []geo.Point{chicago, stLouis}})
+	require.NoError(t, err)
+	assert.True(t, r.Estimated)
+	assert.Equal(t, m.DurationSeconds[0][0], r.DurationSeconds)
+
+	_, err = f.Geocode(context.Background(), "x")
+	assert.ErrorIs(t, err, geo.ErrUnavailable, "geocodes have no offline answer")
+
+	// A bad request is not an outage; estimating it would hide the bug.
+	primary.Fail(geotest.OpMatrix, errors.New("status 400"))
+	_, err = f.Matrix(context.Background(), geo.MatrixRequest{Origins: []geo.Point{chicago}, Destinations: []geo.Point{stLouis}})
+	require.Error(t, err)
+}
+
+func TestFake_IsDeterministic(t *testing.T) {
+	a, b := geotest.New(""), geotest.New("")
+	x, err := a.Geocode(context.Background(), "  1 Main St,  Springfield IL ")
+	require.NoError(t, err)
+	y, err := b.Geocode(context.Background(), "1 main st, springfield il")
+	require.NoError(t, err)
+	assert.Equal(t, x[0].Point, y[0].Point)
+	assert.Equal(t, geotest.PointFor("1 Main St, Springfield IL"), x[0].Point)
+
+	chicago, stLouis := geo.Point{Lat: 41.8781, Lng: -87.6298}, geo.Point{Lat: 38.627, Lng: -90.1994}
+	r, err := a.Route(context.Background(), geo.RouteRequest{Waypoints: []geo.Point{chicago, stLouis, chicago}})
+	require.NoError(t, err)
+	require.Len(t, r.Legs, 2)
+	assert.InDelta(t, 422000*geotest.DefaultRoadFactor, r.Legs[0].DistanceMeters, 2000)
+	assert.Equal(t, r.Legs[0].DistanceMeters*2, r.DistanceMeters)
+}
@@ -0,0 +1,169 @@
+// Package geo is the provider-agnostic face of geocoding, routing and address
+// autocomplete. Services depend on the Geocoder, Router and Autocompleter
+// interfaces and the normalized Address / Route types instead of picking
+// here.Service or googlemaps.Client and mapping each vendor's result shapes
+// themselves.
+//
+// NewHERE and NewGoogle adapt the vendor clients; NewFailover chains
+// providers so a rejected key or an outage at the first one is answered by
+// the next; NewCached keeps geocodes and routes in a CacheStore (Redis via
+// the cache package) so the same facility address is not billed every day.
+// Matrix answers many-to-many distances in one billed call per block, and
+// SequenceStops orders a multi-stop load from one matrix.
+// geotest.Fake is a deterministic Provider for tests.
+//
+// Adapters classify vendor errors into ErrAuth and ErrUnavailable (wrapping,
+// so the vendor error is still reachable with errors.As); everything else —
+// a bad request, no route — passes through unclassified and is final.
+package geo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"time"
+)
+
+var (
+	// ErrAuth marks an error caused by the provider rejecting the credential
+	// (401/403, or Google's REQUEST_DENIED / OVER_QUERY_LIMIT).
+	ErrAuth = errors.New("geo: provider rejected the credential")
+	// ErrUnavailable marks a provider outage: a 5xx, a transport failure
+	// (dial, TLS, reset, the client's own timeout), or the circuit breaker
+	// refusing the call after a run of them.
+	ErrUnavailable = errors.New("geo: provider unavailable")
+)
+
+// Provider names, as reported in Address.Provider and Route.Provider.
+const (
+	ProviderHERE   = "here"
+	ProviderGoogle = "google"
+)
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Address is a geocoded address. Components a provider does not return are
+// empty.
+type Address struct {
+	Label string // formatted, one line
+	Point Point
+
+	HouseNumber string
+	Street      string
+	City        string
+	County      string
+	State       string // as the provider spells it
+	// StateCode is the canonical 2-letter US state / Canadian province code
+	// (address.StateCode), empty when the state does not map to one.
+	StateCode  string
+	PostalCode string
+	// CountryCode is ISO 3166-1 alpha-2 ("US"); HERE's alpha-3 codes are
+	// converted.
+	CountryCode string
+
+	// PlaceID is the provider's own id for the place (HERE id, Google
+	// place_id). It only means something to the Provider that issued it.
+	PlaceID  string
+	Provider string
+}
+
+// Suggestion is one autocomplete result. Resolve it to an Address by
+// geocoding Label.
+type Suggestion struct {
+	Label    string
+	PlaceID  string
+	Provider string
+}
+
+// RouteRequest asks for a driving route through Waypoints in order.
+type RouteRequest struct {
+	Waypoints []Point // origin, stops..., destination; at least 2
+	Departure *time.Time
+}
+
+// Route is a driving route.
+type Route struct {
+	DistanceMeters  int
+	DurationSeconds int
+	// Legs has one entry per waypoint pair when the provider reports legs
+	// (HERE); it is empty otherwise (Google Routes is asked for totals only).
+	Legs     []RouteLeg
+	Provider string
+	// Estimated is set when the route is the Estimator's straight-line guess
+	// (see WithEstimateFallback).
+	Estimated bool
+}
+
+// RouteLeg is the part of a route between two consecutive waypoints.
+type RouteLeg struct {
+	From            Point
+	To              Point
+	DistanceMeters  int
+	DurationSeconds int
+}
+
+// Geocoder resolves free-form addresses. A query that matches nothing
+// returns no addresses and no error.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) ([]Address, error)
+}
+
+// Router computes driving routes.
+type Router interface {
+	Route(ctx context.Context, req RouteRequest) (*Route, error)
+}
+
+// Autocompleter suggests addresses for partial input.
+type Autocompleter interface {
+	Autocomplete(ctx context.Context, input string) ([]Suggestion, error)
+}
+
+// Provider is a vendor that does all of them. Name is one of the Provider*
+// constants (or the fake's name).
+type Provider interface {
+	Geocoder
+	Router
+	Matrixer
+	Autocompleter
+	Name() string
+}
+
+// IsFailover reports whether err means the provider, not the request, is the
+// problem — the errors NewFailover moves on to the next provider for.
+func IsFailover(err error) bool {
+	return errors.Is(err, ErrAuth) || errors.Is(err, ErrUnavailable)
+}
+
+// transportFailure reports whether err means the provider could not be
+// reached — a dial or TLS failure, a reset, the HTTP client's own timeout.
+// Once ctx is done the caller gave up, and that is not the provider's fault.
+func transportFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var ne net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &ne)
+}
+
+const earthRadiusMeters = 6371008.8
+
+// Haversine returns the great-circle distance between a and b in meters.
+func Haversine(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func validateRoute(req RouteRequest) error {
+	if len(req.Waypoints) < 2 {
+		return errors.New("geo: a route needs at least 2 waypoints")
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Package geotest provides Fake, a deterministic geo.Provider for tests: the
+// same query always geocodes to the same point, routes are great-circle
+// distances at a fixed road factor and speed, and any operation can be made
+// to fail on demand. Nothing touches the network.
+package geotest
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/TMS360/backend-pkg/geo"
+)
+
+// Operations, for Fail and Call.Op.
+const (
+	OpGeocode      = "geocode"
+	OpRoute        = "route"
+	OpMatrix       = "matrix"
+	OpAutocomplete = "autocomplete"
+)
+
+// Defaults for synthesized routes, the same as geo.NewEstimator's.
+const (
+	DefaultRoadFactor = geo.DefaultRoadFactor
+	DefaultSpeed      = geo.DefaultSpeed
+)
+
+// Call is one request the fake answered (or failed).
+type Call struct {
+	Op     string
+	Input  string // query or input; "" for routes and matrices
+	Route  *geo.RouteRequest
+	Matrix *geo.MatrixRequest
+}
+
+// Fake is a geo.Provider. The zero value is not usable; call New.
+type Fake struct {
+	name string
+
+	// RoadFactor and Speed shape synthesized routes; set them before use.
+	RoadFactor float64
+	Speed      float64 // m/s
+
+	mu        sync.Mutex
+	addresses map[string][]geo.Address
+	failures  map[string]error
+	calls     []Call
+}
+
+// New returns a fake reporting name as its provider name ("fake" if empty).
+func New(name string) *Fake {
+	if name == "" {
+		name = "fake"
+	}
+	return &Fake{
+		name:       name,
+		RoadFactor: DefaultRoadFactor,
+		Speed:      DefaultSpeed,
+		addresses:  map[string][]geo.Address{},
+		failures:   map[string]error{},
+	}
+}
+
+func (f *Fake) Name() string { return f.name }
+
+// SetAddress makes query geocode to addrs instead of a synthesized address.
+// With no addrs the query finds nothing. Provider is filled in when empty.
+func (f *Fake) SetAddress(query string, addrs ...geo.Address) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]geo.Address, len(addrs))
+	for i, a := range addrs {
+		if a.Provider == "" {
+			a.Provider = f.name
+		}
+		out[i] = a
+	}
+	f.addresses[key(query)] = out
+}
+
+// Fail makes every later call of op return err; a nil err clears it. Wrap
+// geo.ErrAuth or geo.ErrUnavailable to exercise failover.
+func (f *Fake) Fail(op string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, op)
+		return
+	}
+	f.failures[op] = err
+}
+
+// Calls returns the calls so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+func (f *Fake) record(c Call) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, c)
+	return f.failures[c.Op]
+}
+
+// Geocode returns the SetAddress fixture for query, or one address
+// synthesized from a hash of the normalized query: a point in the
+// contiguous US, the query as its label.
+func (f *Fake) Geocode(_ context.Context, query string) ([]geo.Address, error) {
+	if err := f.record(Call{Op: OpGeocode, Input: query}); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	fixed, ok := f.addresses[key(query)]
+	f.mu.Unlock()
+	if ok {
+		return append([]geo.Address(nil), fixed...), nil
+	}
+	h := hash(key(query))
+	return []geo.Address{{
+		Label:       strings.TrimSpace(query),
+		Point:       PointFor(query),
+		CountryCode: "US",
+		PlaceID:     fmt.Sprintf("%s:%016x", f.name, h),
+		Provider:    f.name,
+	}}, nil
+}
+
+// Autocomplete suggests the SetAddress fixtures whose query starts with
+// input (case-insensitively), sorted; with none, it echoes input.
+func (f *Fake) Autocomplete(_ context.Context, input string) ([]geo.Suggestion, error) {
+	if err := f.record(Call{Op: OpAutocomplete, Input: input}); err != nil {
+		return nil, err
+	}
+	prefix := key(input)
+	f.mu.Lock()
+	var out []geo.Suggestion
+	for q, addrs := range f.addresses {
+		if len(addrs) > 0 && strings.HasPrefix(q, prefix) {
+			out = append(out, geo.Suggestion{Label: addrs[0].Label, PlaceID: addrs[0].PlaceID, Provider: f.name})
+		}
+	}
+	f.mu.Unlock()
+	if len(out) == 0 {
+		return []geo.Suggestion{{Label: strings.TrimSpace(input), Provider: f.name}}, nil
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Label < out[j].Label })
+	return out, nil
+}
+
+// Route returns great-circle distance times RoadFactor per leg, driven at
+// Speed.
+func (f *Fake) Route(_ context.Context, req geo.RouteRequest) (*geo.Route, error) {
+	if err := f.record(Call{Op: OpRoute, Route: &req}); err != nil {
+		return nil, err
+	}
+	if len(req.Waypoints) < 2 {
+		return nil, fmt.Errorf("geotest: a route needs at least 2 waypoints")
+	}
+	route := &geo.Route{Provider: f.name}
+	for i := 1; i < len(req.Waypoints); i++ {
+		from, to := req.Waypoints[i-1], req.Waypoints[i]
+		meters := geo.Haversine(from, to) * f.RoadFactor
+		leg := geo.RouteLeg{From: from, To: to, DistanceMeters: int(meters), DurationSeconds: int(meters / f.Speed)}
+		route.Legs = append(route.Legs, leg)
+		route.DistanceMeters += leg.DistanceMeters
+		route.DurationSeconds += leg.DurationSeconds
+	}
+	return route, nil
+}
+
+// Matrix is Route's leg estimate for every origin × destination pair.
+func (f *Fake) Matrix(ctx context.Context, req geo.MatrixRequest) (*geo.Matrix, error) {
+	if err := f.record(Call{Op: OpMatrix, Matrix: &req}); err != nil {
+		return nil, err
+	}
+	m, err := (&geo.Estimator{RoadFactor: f.RoadFactor, Speed: f.Speed}).Matrix(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	m.Provider, m.Estimated = f.name, false
+	return m, nil
+}
+
+// PointFor is the point Geocode synthesizes for query, for assertions.
+func PointFor(query string) geo.Point {
+	h := hash(key(query))
+	// Contiguous US bounding box, 25..49N, 67..124W.
+	lat := 25 + float64(h%1_000_000)/1_000_000*24
+	lng := -124 + float64((h/1_000_000)%1_000_000)/1_000_000*57
+	return geo.Point{Lat: lat, Lng: lng}
+}
+
+func key(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64()
+}
+
+var _ geo.Provider = (*Fake)(nil)
@@ -0,0 +1,136 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TMS360/backend-pkg/address"
+	"github.com/TMS360/backend-pkg/client/googlemaps"
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// Google adapts a googlemaps.Client. Routes carry totals only: Routes v2 is
+// asked for distance and duration, not legs.
+type Google struct {
+	client *googlemaps.Client
+}
+
+// NewGoogle wraps client.
+func NewGoogle(client *googlemaps.Client) *Google {
+	return &Google{client: client}
+}
+
+func (g *Google) Name() string { return ProviderGoogle }
+
+func (g *Google) Geocode(ctx context.Context, query string) ([]Address, error) {
+	results, err := g.client.Geocode(ctx, query)
+	if err != nil {
+		return nil, classifyGoogle(ctx, err)
+	}
+	out := make([]Address, 0, len(results))
+	for _, r := range results {
+		out = append(out, addressFromGoogle(r))
+	}
+	return out, nil
+}
+
+func (g *Google) Autocomplete(ctx context.Context, input string) ([]Suggestion, error) {
+	predictions, err := g.client.PlacesAutocomplete(ctx, input)
+	if err != nil {
+		return nil, classifyGoogle(ctx, err)
+	}
+	out := make([]Suggestion, 0, len(predictions))
+	for _, p := range predictions {
+		out = append(out, Suggestion{Label: p.Description, PlaceID: p.PlaceID, Provider: ProviderGoogle})
+	}
+	return out, nil
+}
+
+// Route ignores req.Departure: the Routes call is traffic-unaware.
+func (g *Google) Route(ctx context.Context, req RouteRequest) (*Route, error) {
+	if err := validateRoute(req); err != nil {
+		return nil, err
+	}
+	rd, err := g.client.ComputeRouteDistance(ctx, googleCoordinates(req.Waypoints))
+	if err != nil {
+		return nil, classifyGoogle(ctx, err)
+	}
+	return &Route{DistanceMeters: rd.DistanceMeters, DurationSeconds: rd.DurationSeconds, Provider: ProviderGoogle}, nil
+}
+
+// googleMatrixSide keeps a block within both Routes API caps: 25 × 25 is 625
+// elements and 50 waypoints.
+const googleMatrixSide = 25
+
+// Matrix asks the Routes API for traffic-unaware distances (req.Departure is
+// ignored, as in Route), in blocks of at most 25 × 25.
+func (g *Google) Matrix(ctx context.Context, req MatrixRequest) (*Matrix, error) {
+	if err := validateMatrix(req); err != nil {
+		return nil, err
+	}
+	return chunkMatrix(ctx, req, googleMatrixSide, googleMatrixSide, ProviderGoogle,
+		func(ctx context.Context, origins, destinations []Point, oOff, dOff int, m *Matrix) error {
+			elements, err := g.client.ComputeRouteMatrix(ctx, googleCoordinates(origins), googleCoordinates(destinations))
+			if err != nil {
+				return classifyGoogle(ctx, err)
+			}
+			// Google answers only the pairs it evaluated; anything missing
+			// stays unreachable.
+			for i := range origins {
+				for j := range destinations {
+					m.DistanceMeters[oOff+i][dOff+j] = Unreachable
+					m.DurationSeconds[oOff+i][dOff+j] = Unreachable
+				}
+			}
+			for _, e := range elements {
+				if !e.RouteExists || e.OriginIndex < 0 || e.DestinationIndex < 0 ||
+					e.OriginIndex >= len(origins) || e.DestinationIndex >= len(destinations) {
+					continue
+				}
+				m.DistanceMeters[oOff+e.OriginIndex][dOff+e.DestinationIndex] = e.DistanceMeters
+				m.DurationSeconds[oOff+e.OriginIndex][dOff+e.DestinationIndex] = e.DurationSeconds
+			}
+			return nil
+		})
+}
+
+func googleCoordinates(points []Point) []googlemaps.Coordinates {
+	out := make([]googlemaps.Coordinates, len(points))
+	for i, p := range points {
+		out[i] = googlemaps.Coordinates{Latitude: p.Lat, Longitude: p.Lng}
+	}
+	return out
+}
+
+func addressFromGoogle(r googlemaps.GeocodeResult) Address {
+	state := googlemaps.ComponentByType(r, "administrative_area_level_1")
+	return Address{
+		Label:       r.FormattedAddress,
+		Point:       Point{Lat: r.Geometry.Location.Lat, Lng: r.Geometry.Location.Lng},
+		HouseNumber: googlemaps.ComponentByType(r, "street_number"),
+		Street:      googlemaps.ComponentByType(r, "route"),
+		City:        googlemaps.ComponentByType(r, "locality"),
+		County:      googlemaps.ComponentByType(r, "administrative_area_level_2"),
+		State:       state,
+		StateCode:   address.StateCode(googlemaps.ShortComponentByType(r, "administrative_area_level_1")),
+		PostalCode:  googlemaps.ComponentByType(r, "postal_code"),
+		CountryCode: googlemaps.ShortComponentByType(r, "country"),
+		PlaceID:     r.PlaceID,
+		Provider:    ProviderGoogle,
+	}
+}
+
+// classifyGoogle marks auth failures and outages; the message stays Google's.
+func classifyGoogle(ctx context.Context, err error) error {
+	var status *googlemaps.StatusError
+	switch {
+	case googlemaps.IsAuthError(err):
+		return fmt.Errorf("%w: %w", ErrAuth, err)
+	case errors.As(err, &status) && status.StatusCode >= 500,
+		errors.Is(err, resilience.ErrCircuitOpen),
+		transportFailure(ctx, err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TMS360/backend-pkg/address"
+	"github.com/TMS360/backend-pkg/client/here"
+	"github.com/TMS360/backend-pkg/resilience"
+)
+
+// HERE adapts a here.Client. Routes are truck routes (the legacy
+// here.Service.CalculateMultiStopRoute), one billed call per route.
+type HERE struct {
+	client  *here.Client
+	service here.Service
+}
+
+// NewHERE wraps client.
+func NewHERE(client *here.Client) *HERE {
+	return &HERE{client: client, service: here.NewService(client)}
+}
+
+func (h *HERE) Name() string { return ProviderHERE }
+
+func (h *HERE) Geocode(ctx context.Context, query string) ([]Address, error) {
+	resp, err := h.client.Geocode(ctx, here.GeocodeRequest{Query: query, Limit: 5})
+	if err != nil {
+		return nil, classifyHERE(ctx, err)
+	}
+	out := make([]Address, 0, len(resp.Items))
+	for i := range resp.Items {
+		out = append(out, addressFromHERE(&resp.Items[i]))
+	}
+	return out, nil
+}
+
+func (h *HERE) Autocomplete(ctx context.Context, input string) ([]Suggestion, error) {
+	resp, err := h.client.Autocomplete(ctx, here.AutocompleteRequest{Query: input, Limit: 5})
+	if err != nil {
+		return nil, classifyHERE(ctx, err)
+	}
+	out := make([]Suggestion, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		label := item.Title
+		if item.Address != nil && item.Address.Label != "" {
+			label = item.Address.Label
+		}
+		out = append(out, Suggestion{Label: label, PlaceID: item.ID, Provider: ProviderHERE})
+	}
+	return out, nil
+}
+
+func (h *HERE) Route(ctx context.Context, req RouteRequest) (*Route, error) {
+	if err := validateRoute(req); err != nil {
+		return nil, err
+	}
+	info, err := h.service.CalculateMultiStopRoute(ctx, hereCoordinates(req.Waypoints), req.Departure)
+	if err != nil {
+		return nil, classifyHERE(ctx, err)
+	}
+	out := &Route{
+		DistanceMeters:  info.TotalDistanceMeters,
+		DurationSeconds: info.TotalDurationSeconds,
+		Legs:            make([]RouteLeg, 0, len(info.Legs)),
+		Provider:        ProviderHERE,
+	}
+	for _, leg := range info.Legs {
+		out.Legs = append(out.Legs, RouteLeg{
+			From:            Point{Lat: leg.Origin.Latitude, Lng: leg.Origin.Longitude},
+			To:              Point{Lat: leg.Destination.Latitude, Lng: leg.Destination.Longitude},
+			DistanceMeters:  leg.DistanceMeters,
+			DurationSeconds: leg.DurationSeconds,
+		})
+	}
+	return out, nil
+}
+
+// Matrix asks Matrix Routing v8 for truck distances, split into blocks of
+// here.MaxMatrixOrigins × here.MaxMatrixDestinations.
+func (h *HERE) Matrix(ctx context.Context, req MatrixRequest) (*Matrix, error) {
+	if err := validateMatrix(req); err != nil {
+		return nil, err
+	}
+	return chunkMatrix(ctx, req, here.MaxMatrixOrigins, here.MaxMatrixDestinations, ProviderHERE,
+		func(ctx context.Context, origins, destinations []Point, oOff, dOff int, m *Matrix) error {
+			resp, err := h.client.CalculateMatrix(ctx, here.MatrixRequest{
+				Origins:       hereCoordinates(origins),
+				Destinations:  hereCoordinates(destinations),
+				DepartureTime: req.Departure,
+			})
+			if err != nil {
+				return classifyHERE(ctx, err)
+			}
+			for i := range origins {
+				for j := range destinations {
+					dist, dur, ok := resp.At(i, j)
+					if !ok {
+						dist, dur = Unreachable, Unreachable
+					}
+					m.DistanceMeters[oOff+i][dOff+j] = dist
+					m.DurationSeconds[oOff+i][dOff+j] = dur
+				}
+			}
+			return nil
+		})
+}
+
+func hereCoordinates(points []Point) []here.Coordinates {
+	out := make([]here.Coordinates, len(points))
+	for i, p := range points {
+		out[i] = here.Coordinates{Latitude: p.Lat, Longitude: p.Lng}
+	}
+	return out
+}
+
+func addressFromHERE(item *here.GeocodeItem) Address {
+	a := Address{Label: item.Title, PlaceID: item.ID, Provider: ProviderHERE}
+	if item.Position != nil {
+		a.Point = Point{Lat: item.Position.Lat, Lng: item.Position.Lng}
+	}
+	if ad := item.Address; ad != nil {
+		if ad.Label != "" {
+			a.Label = ad.Label
+		}
+		a.HouseNumber = ad.HouseNumber
+		a.Street = ad.Street
+		a.City = ad.City
+		a.County = ad.County
+		a.State = ad.State
+		a.StateCode = address.StateCode(ad.StateCode)
+		if a.StateCode == "" {
+			a.StateCode = address.StateCode(ad.State)
+		}
+		a.PostalCode = ad.PostalCode
+		a.CountryCode = countryAlpha2(ad.CountryCode)
+	}
+	return a
+}
+
+// classifyHERE marks auth failures and outages; the message stays HERE's.
+func classifyHERE(ctx context.Context, err error) error {
+	var status *here.StatusError
+	switch {
+	case here.IsAuthError(err):
+		return fmt.Errorf("%w: %w", ErrAuth, err)
+	case errors.As(err, &status) && status.StatusCode >= 500,
+		errors.Is(err, resilience.ErrCircuitOpen),
+		transportFailure(ctx, err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// alpha3To2 covers the countries TMS360 routes in; other codes pass through.
+var alpha3To2 = map[string]string{"USA": "US", "CAN": "CA", "MEX": "MX"}
+
+func countryAlpha2(code string) string {
+	if c, ok := alpha3To2[code]; ok {
+		return c
+	}
+	return code
+}
@@ -0,0 +1,140 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Unreachable marks a matrix cell the provider found no route for.
+const Unreachable = -1
+
+// Defaults of the Estimator.
+const (
+	// DefaultRoadFactor is road distance over great-circle distance, about
+	// what US interstate routing averages.
+	DefaultRoadFactor = 1.25
+	// DefaultSpeed is 55 mph in meters per second.
+	DefaultSpeed = 24.5872
+)
+
+// MatrixRequest asks for driving distance and duration from every origin to
+// every destination.
+type MatrixRequest struct {
+	Origins      []Point
+	Destinations []Point
+	// Departure nil asks for traffic-free durations.
+	Departure *time.Time
+}
+
+// Matrix holds one cell per origin × destination, indexed
+// [origin][destination]. A cell without a route is Unreachable in both.
+type Matrix struct {
+	DistanceMeters  [][]int
+	DurationSeconds [][]int
+	Provider        string
+	// Estimated is set when the cells are the Estimator's straight-line
+	// guesses rather than a road network's answer.
+	Estimated bool
+}
+
+func newMatrix(origins, destinations int, provider string) *Matrix {
+	m := &Matrix{
+		DistanceMeters:  make([][]int, origins),
+		DurationSeconds: make([][]int, origins),
+		Provider:        provider,
+	}
+	for i := range m.DistanceMeters {
+		m.DistanceMeters[i] = make([]int, destinations)
+		m.DurationSeconds[i] = make([]int, destinations)
+	}
+	return m
+}
+
+// Matrixer computes distance matrices. "Distance from these 40 trucks to this
+// pickup" is one Matrix call with 40 origins and 1 destination.
+type Matrixer interface {
+	Matrix(ctx context.Context, req MatrixRequest) (*Matrix, error)
+}
+
+func validateMatrix(req MatrixRequest) error {
+	if len(req.Origins) == 0 || len(req.Destinations) == 0 {
+		return errors.New("geo: a matrix needs origins and destinations")
+	}
+	return nil
+}
+
+// matrixBlock fills the cells of m for origins[oOff:] × destinations[dOff:].
+type matrixBlock func(ctx context.Context, origins, destinations []Point, oOff, dOff int, m *Matrix) error
+
+// chunkMatrix splits req into blocks no larger than maxOrigins ×
+// maxDestinations, the most a provider answers in one call.
+func chunkMatrix(ctx context.Context, req MatrixRequest, maxOrigins, maxDestinations int, provider string, block matrixBlock) (*Matrix, error) {
+	m := newMatrix(len(req.Origins), len(req.Destinations), provider)
+	for o := 0; o < len(req.Origins); o += maxOrigins {
+		origins := req.Origins[o:min(o+maxOrigins, len(req.Origins))]
+		for d := 0; d < len(req.Destinations); d += maxDestinations {
+			destinations := req.Destinations[d:min(d+maxDestinations, len(req.Destinations))]
+			if err := block(ctx, origins, destinations, o, d, m); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// Estimator answers matrices and routes offline from great-circle distance
+// times RoadFactor, driven at Speed. It is the last resort when every
+// provider is down (see WithEstimateFallback), and good enough to rank
+// candidates; never bill or pay from it.
+type Estimator struct {
+	RoadFactor float64
+	Speed      float64 // m/s
+}
+
+// NewEstimator returns an Estimator with the default road factor and speed.
+func NewEstimator() *Estimator {
+	return &Estimator{RoadFactor: DefaultRoadFactor, Speed: DefaultSpeed}
+}
+
+// Name is "estimate".
+func (e *Estimator) Name() string { return "estimate" }
+
+func (e *Estimator) leg(from, to Point) (meters, seconds int) {
+	d := Haversine(from, to) * e.RoadFactor
+	return int(d), int(d / e.Speed)
+}
+
+func (e *Estimator) Matrix(_ context.Context, req MatrixRequest) (*Matrix, error) {
+	if err := validateMatrix(req); err != nil {
+		return nil, err
+	}
+	m := newMatrix(len(req.Origins), len(req.Destinations), e.Name())
+	m.Estimated = true
+	for i, o := range req.Origins {
+		for j, d := range req.Destinations {
+			m.DistanceMeters[i][j], m.DurationSeconds[i][j] = e.leg(o, d)
+		}
+	}
+	return m, nil
+}
+
+func (e *Estimator) Route(_ context.Context, req RouteRequest) (*Route, error) {
+	if err := validateRoute(req); err != nil {
+		return nil, err
+	}
+	r := &Route{Provider: e.Name(), Estimated: true}
+	for i := 1; i < len(req.Waypoints); i++ {
+		leg := RouteLeg{From: req.Waypoints[i-1], To: req.Waypoints[i]}
+		leg.DistanceMeters, leg.DurationSeconds = e.leg(leg.From, leg.To)
+		r.Legs = append(r.Legs, leg)
+		r.DistanceMeters += leg.DistanceMeters
+		r.DurationSeconds += leg.DurationSeconds
+	}
+	return r, nil
+}
+
+var (
+	_ Matrixer = (*Estimator)(nil)
+	_ Router   = (*Estimator)(nil)
+)
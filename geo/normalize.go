@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TMS360/backend-pkg/address"
+)
+
+// streetWords maps USPS street suffixes, directionals and unit designators
+// (Publication 28) to one spelling, so "123 North Main Street Suite 4" and
+// "123 N Main St Ste 4" share a key. Every unit designator becomes "ste":
+// geocoders resolve the building, not the unit, so the designator word must
+// not split the cache — the unit number still does.
+var streetWords = map[string]string{
+	"street": "st", "str": "st",
+	"avenue": "ave", "av": "ave",
+	"road":       "rd",
+	"drive":      "dr",
+	"boulevard":  "blvd",
+	"highway":    "hwy",
+	"lane":       "ln",
+	"court":      "ct",
+	"parkway":    "pkwy",
+	"place":      "pl",
+	"circle":     "cir",
+	"terrace":    "ter",
+	"trail":      "trl",
+	"expressway": "expy",
+	"freeway":    "fwy",
+	"north":      "n", "south": "s", "east": "e", "west": "w",
+	"northeast": "ne", "northwest": "nw", "southeast": "se", "southwest": "sw",
+	"suite": "ste", "unit": "ste", "apt": "ste", "apartment": "ste", "room": "ste", "rm": "ste", "#": "ste",
+	"building": "bldg",
+}
+
+// countryWords are trailing parts dropped from the key: the geocoders are
+// asked for US addresses either way.
+var countryWords = map[string]bool{"us": true, "usa": true, "united states": true, "united states of america": true}
+
+var (
+	zipSuffix = regexp.MustCompile(`^(.*?)\s*\b(\d{5})(?:-\d{4})?$`)
+	zipOnly   = regexp.MustCompile(`^\d{5}(?:-\d{4})?$`)
+	// unitHash splits "#200" and "ste#200" so the designator is a word of
+	// its own.
+	unitHash = regexp.MustCompile(`#\s*`)
+	// punct is everything but letters, digits, spaces, commas, '#' and '-'.
+	punct = regexp.MustCompile(`[^\p{L}\p{N}\s,#-]+`)
+)
+
+// NormalizeAddress is the cache key form of a free-form address: lower case,
+// punctuation and repeated spaces dropped, street words and unit designators
+// spelled one way, the state reduced to its 2-letter code
+// (address.StateCode), ZIP+4 cut to the ZIP and a trailing country dropped.
+// It is a key, not an address to display or geocode with.
+func NormalizeAddress(query string) string {
+	s := strings.ToLower(query)
+	s = unitHash.ReplaceAllString(s, " # ")
+	s = punct.ReplaceAllString(s, " ")
+
+	var parts []string
+	for _, raw := range strings.Split(s, ",") {
+		if p := strings.Join(strings.Fields(raw), " "); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	for len(parts) > 1 && countryWords[parts[len(parts)-1]] {
+		parts = parts[:len(parts)-1]
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	parts[0] = normalizeStreet(parts[0])
+	// The state is in the last part, or the one before a lone ZIP; earlier
+	// parts are the city, where "Washington" must stay a word.
+	last := len(parts) - 1
+	if last > 1 && zipOnly.MatchString(parts[last]) {
+		parts[last] = parts[last][:5]
+		last--
+	}
+	if last > 0 {
+		parts[last] = normalizeLocality(parts[last])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func normalizeStreet(p string) string {
+	words := strings.Fields(p)
+	for i, w := range words {
+		if short, ok := streetWords[w]; ok {
+			words[i] = short
+		}
+	}
+	// "ste ste 4" can come out of "Suite #4".
+	out := words[:0]
+	for i, w := range words {
+		if w == "ste" && i > 0 && words[i-1] == "ste" {
+			continue
+		}
+		out = append(out, w)
+	}
+	return strings.Join(out, " ")
+}
+
+// normalizeLocality reduces "niles illinois 60714-1234" to "niles il 60714":
+// the state may end the part or sit before the ZIP, and may be spelled out
+// in up to three words ("district of columbia").
+func normalizeLocality(p string) string {
+	rest, zip := p, ""
+	if m := zipSuffix.FindStringSubmatch(p); m != nil {
+		rest, zip = m[1], m[2]
+	}
+	words := strings.Fields(rest)
+	for n := min(3, len(words)); n >= 1; n-- {
+		if code := address.StateCode(strings.Join(words[len(words)-n:], " ")); code != "" {
+			words = append(words[:len(words)-n], strings.ToLower(code))
+			break
+		}
+	}
+	if zip != "" {
+		words = append(words, zip)
+	}
+	return strings.Join(words, " ")
+}
+
+// routeKey rounds every waypoint to precision decimals; 4 is about 11 m,
+// finer than the spread of geocodes of one facility.
+func routeKey(points []Point, precision int) string {
+	var b strings.Builder
+	for i, p := range points {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		fmt.Fprintf(&b, "%.*f,%.*f", precision, p.Lat, precision, p.Lng)
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"6440 W. Howard St., Niles, IL 60714", "6440 w howard st, niles, il 60714"},
+		{"6440 West Howard Street, Niles, Illinois 60714-1234, USA", "6440 w howard st, niles, il 60714"},
+		{"  6440 w howard st ,niles,   il  ", "6440 w howard st, niles, il"},
+		{"100 Main St Suite 200, Springfield IL", "100 main st ste 200, springfield il"},
+		{"100 Main St #200, Springfield, IL", "100 main st ste 200, springfield, il"},
+		{"100 Main St Ste. #200, Springfield, IL", "100 main st ste 200, springfield, il"},
+		{"100 Main St Unit 200, Springfield, IL", "100 main st ste 200, springfield, il"},
+		{"1 Pennsylvania Ave NW, Washington, District of Columbia 20500", "1 pennsylvania ave nw, washington, dc 20500"},
+		{"1 Main St, New York New York 10001", "1 main st, new york ny 10001"},
+		{"1 Main St, Monterrey, Nuevo Leon", "1 main st, monterrey, nuevo leon"},
+		{"Denver", "denver"},
+		{" , ", ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, NormalizeAddress(c.in), c.in)
+	}
+	// The street part is never read as a state.
+	assert.Equal(t, "1 georgia ave, atlanta, ga", NormalizeAddress("1 Georgia Avenue, Atlanta, Georgia"))
+}
+
+func TestRouteKey_Rounds(t *testing.T) {
+	a := []Point{{Lat: 41.878113, Lng: -87.629799}, {Lat: 38.627003, Lng: -90.199404}}
+	b := []Point{{Lat: 41.87812, Lng: -87.62982}, {Lat: 38.62699, Lng: -90.19939}}
+	assert.Equal(t, routeKey(a, 4), routeKey(b, 4))
+	assert.Equal(t, "41.8781,-87.6298|38.6270,-90.1994", routeKey(a, 4))
+	assert.NotEqual(t, routeKey(a, 4), routeKey([]Point{a[1], a[0]}, 4), "direction matters")
+}
+
+func TestNormalizeAddress_StateBeforeLoneZip(t *testing.T) {
+	assert.Equal(t, "1 main st, springfield, il, 62701", NormalizeAddress("1 Main St, Springfield, Illinois, 62701-0001"))
+}
@@ -0,0 +1,331 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/TMS360/backend-pkg/client/here"
+)
+
+// MaxSequenceStops bounds SequenceStops. The solver is a heuristic for a
+// load's stops, not a fleet planner: 25 stops is one 27 × 27 matrix and a
+// few milliseconds of 2-opt.
+const MaxSequenceStops = 25
+
+// ErrNoSequence is returned when no order visits every stop: a stop is
+// unreachable, or the pickup references form a cycle.
+var ErrNoSequence = errors.New("geo: no order visits every stop")
+
+// latePenalty weighs a second of lateness against a second of driving, so
+// the solver gives up almost any amount of driving to meet a window but
+// still returns an order — with Late set — when no order meets them all.
+const latePenalty = 1000
+
+// TimeWindow is when a stop can be served. A zero Open or Close leaves that
+// side unbounded.
+type TimeWindow struct {
+	Open  time.Time
+	Close time.Time
+}
+
+// Stop is one stop to sequence.
+type Stop struct {
+	ID    string
+	Point Point
+	// PickupID names the stop that must be visited first — a delivery names
+	// its pickup. Empty for stops with no predecessor.
+	PickupID string
+	Window   TimeWindow
+	// Service is the time spent at the stop (loading, paperwork).
+	Service time.Duration
+}
+
+// SequenceRequest asks for the best order to visit Stops from Start.
+type SequenceRequest struct {
+	Start Point // the truck's position
+	// End is where the truck must finish (a yard); nil ends at the last stop.
+	End   *Point
+	Stops []Stop
+	// Departure is when the truck leaves Start; zero means now.
+	Departure time.Time
+}
+
+// SequenceLeg is the drive to one stop (or to End, with an empty StopID) and
+// the time spent there.
+type SequenceLeg struct {
+	StopID          string
+	From            Point
+	To              Point
+	DistanceMeters  int
+	DurationSeconds int
+	Arrival         time.Time
+	Wait            time.Duration // until the window opens
+	Late            time.Duration // past the window close
+	Departure       time.Time
+}
+
+// Sequence is the chosen order.
+type Sequence struct {
+	Stops []Stop        // in visiting order
+	Legs  []SequenceLeg // one per stop, then one to End if set
+	// Points is Start, the stops in order, then End if set.
+	Points          []Point
+	DistanceMeters  int
+	DurationSeconds int // driving only
+	Finish          time.Time
+	// Late is the total lateness; zero when every window is met.
+	Late time.Duration
+	// Estimated is set when the matrix came from the Estimator.
+	Estimated bool
+}
+
+// Coordinates returns Points as here.Coordinates, ready for
+// here.Service.CalculateMultiStopRoute.
+func (s *Sequence) Coordinates() []here.Coordinates {
+	return hereCoordinates(s.Points)
+}
+
+// SequenceStops orders req.Stops with one matrix from m: nearest neighbour
+// by the time each stop can be served, then 2-opt until no reversal of a
+// run of stops lowers the cost. The cost is the time to finish plus a heavy
+// penalty per second late; an order that visits a delivery before its pickup
+// is never considered. Durations are traffic-free.
+func SequenceStops(ctx context.Context, m Matrixer, req SequenceRequest) (*Sequence, error) {
+	if err := validateSequence(req); err != nil {
+		return nil, err
+	}
+	if req.Departure.IsZero() {
+		req.Departure = time.Now()
+	}
+
+	// Matrix index 0 is Start, 1..n the stops, n+1 End.
+	points := make([]Point, 0, len(req.Stops)+2)
+	points = append(points, req.Start)
+	for _, s := range req.Stops {
+		points = append(points, s.Point)
+	}
+	if req.End != nil {
+		points = append(points, *req.End)
+	}
+	matrix, err := m.Matrix(ctx, MatrixRequest{Origins: points, Destinations: points})
+	if err != nil {
+		return nil, fmt.Errorf("geo: sequence matrix: %w", err)
+	}
+
+	p := newSequencer(req, matrix)
+	order, ok := p.nearestNeighbour()
+	if !ok {
+		return nil, ErrNoSequence
+	}
+	order = p.twoOpt(order)
+	if _, ok := p.cost(order); !ok {
+		return nil, ErrNoSequence
+	}
+	return p.sequence(order, matrix), nil
+}
+
+func validateSequence(req SequenceRequest) error {
+	if len(req.Stops) == 0 {
+		return errors.New("geo: nothing to sequence")
+	}
+	if len(req.Stops) > MaxSequenceStops {
+		return fmt.Errorf("geo: %d stops exceeds the sequencing limit of %d", len(req.Stops), MaxSequenceStops)
+	}
+	ids := make(map[string]bool, len(req.Stops))
+	for _, s := range req.Stops {
+		if s.ID == "" {
+			return errors.New("geo: every stop needs an id")
+		}
+		if ids[s.ID] {
+			return fmt.Errorf("geo: duplicate stop id %q", s.ID)
+		}
+		ids[s.ID] = true
+	}
+	for _, s := range req.Stops {
+		if s.PickupID != "" && !ids[s.PickupID] {
+			return fmt.Errorf("geo: stop %q names unknown pickup %q", s.ID, s.PickupID)
+		}
+	}
+	return nil
+}
+
+// sequencer holds one request's matrix. Orders are permutations of stop
+// indexes 0..n-1; stop i is matrix index i+1.
+type sequencer struct {
+	req    SequenceRequest
+	dur    [][]int
+	pickup []int // stop index of each stop's pickup, -1 for none
+	end    int   // matrix index of End, -1 for none
+}
+
+func newSequencer(req SequenceRequest, m *Matrix) *sequencer {
+	p := &sequencer{req: req, dur: m.DurationSeconds, pickup: make([]int, len(req.Stops)), end: -1}
+	index := make(map[string]int, len(req.Stops))
+	for i, s := range req.Stops {
+		index[s.ID] = i
+	}
+	for i, s := range req.Stops {
+		p.pickup[i] = -1
+		if s.PickupID != "" {
+			p.pickup[i] = index[s.PickupID]
+		}
+	}
+	if req.End != nil {
+		p.end = len(req.Stops) + 1
+	}
+	return p
+}
+
+// visit drives from matrix index from to stop i leaving at t; it returns
+// when the truck leaves the stop and how late it was, or false when there
+// is no route.
+func (p *sequencer) visit(from, i int, t time.Time) (leave time.Time, late time.Duration, ok bool) {
+	d := p.dur[from][i+1]
+	if d == Unreachable {
+		return time.Time{}, 0, false
+	}
+	stop := p.req.Stops[i]
+	arrive := t.Add(time.Duration(d) * time.Second)
+	start := arrive
+	if !stop.Window.Open.IsZero() && start.Before(stop.Window.Open) {
+		start = stop.Window.Open
+	}
+	if !stop.Window.Close.IsZero() && start.After(stop.Window.Close) {
+		late = start.Sub(stop.Window.Close)
+	}
+	return start.Add(stop.Service), late, true
+}
+
+// cost is the seconds from departure to finish plus the lateness penalty;
+// false means the order is infeasible.
+func (p *sequencer) cost(order []int) (int64, bool) {
+	t, from := p.req.Departure, 0
+	var late time.Duration
+	seen := make([]bool, len(order))
+	for _, i := range order {
+		if pk := p.pickup[i]; pk >= 0 && !seen[pk] {
+			return 0, false
+		}
+		leave, l, ok := p.visit(from, i, t)
+		if !ok {
+			return 0, false
+		}
+		seen[i] = true
+		t, from, late = leave, i+1, late+l
+	}
+	if p.end >= 0 {
+		d := p.dur[from][p.end]
+		if d == Unreachable {
+			return 0, false
+		}
+		t = t.Add(time.Duration(d) * time.Second)
+	}
+	return int64(t.Sub(p.req.Departure)/time.Second) + latePenalty*int64(late/time.Second), true
+}
+
+// nearestNeighbour builds an order by always going next to the stop that can
+// be served soonest (penalizing lateness), among those whose pickup is done.
+func (p *sequencer) nearestNeighbour() ([]int, bool) {
+	n := len(p.req.Stops)
+	order := make([]int, 0, n)
+	done := make([]bool, n)
+	t, from := p.req.Departure, 0
+	for len(order) < n {
+		best, bestScore, bestLeave := -1, int64(math.MaxInt64), time.Time{}
+		for i := 0; i < n; i++ {
+			if done[i] || (p.pickup[i] >= 0 && !done[p.pickup[i]]) {
+				continue
+			}
+			leave, late, ok := p.visit(from, i, t)
+			if !ok {
+				continue
+			}
+			score := int64(leave.Sub(t)/time.Second) + latePenalty*int64(late/time.Second)
+			if score < bestScore {
+				best, bestScore, bestLeave = i, score, leave
+			}
+		}
+		if best < 0 {
+			return nil, false
+		}
+		order = append(order, best)
+		done[best] = true
+		t, from = bestLeave, best+1
+	}
+	return order, true
+}
+
+// twoOpt reverses runs of the order while that lowers the cost. Reversals
+// that put a delivery before its pickup are infeasible and never taken.
+func (p *sequencer) twoOpt(order []int) []int {
+	best, _ := p.cost(order)
+	candidate := make([]int, len(order))
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < len(order)-1; i++ {
+			for j := i + 1; j < len(order); j++ {
+				copy(candidate, order)
+				for a, b := i, j; a < b; a, b = a+1, b-1 {
+					candidate[a], candidate[b] = candidate[b], candidate[a]
+				}
+				if c, ok := p.cost(candidate); ok && c < best {
+					best = c
+					copy(order, candidate)
+					improved = true
+				}
+			}
+		}
+	}
+	return order
+}
+
+func (p *sequencer) sequence(order []int, m *Matrix) *Sequence {
+	s := &Sequence{Points: []Point{p.req.Start}, Estimated: m.Estimated}
+	t, from := p.req.Departure, 0
+	for _, i := range order {
+		stop := p.req.Stops[i]
+		leave, late, _ := p.visit(from, i, t)
+		leg := SequenceLeg{
+			StopID:          stop.ID,
+			From:            s.Points[len(s.Points)-1],
+			To:              stop.Point,
+			DistanceMeters:  m.DistanceMeters[from][i+1],
+			DurationSeconds: m.DurationSeconds[from][i+1],
+			Late:            late,
+			Departure:       leave,
+		}
+		leg.Arrival = t.Add(time.Duration(leg.DurationSeconds) * time.Second)
+		if !stop.Window.Open.IsZero() && leg.Arrival.Before(stop.Window.Open) {
+			leg.Wait = stop.Window.Open.Sub(leg.Arrival)
+		}
+		s.add(leg, stop.Point)
+		s.Stops = append(s.Stops, stop)
+		s.Late += late
+		t, from = leave, i+1
+	}
+	if p.end >= 0 {
+		end := *p.req.End
+		leg := SequenceLeg{
+			From:            s.Points[len(s.Points)-1],
+			To:              end,
+			DistanceMeters:  m.DistanceMeters[from][p.end],
+			DurationSeconds: m.DurationSeconds[from][p.end],
+		}
+		leg.Arrival = t.Add(time.Duration(leg.DurationSeconds) * time.Second)
+		leg.Departure = leg.Arrival
+		s.add(leg, end)
+		t = leg.Arrival
+	}
+	s.Finish = t
+	return s
+}
+
+func (s *Sequence) add(leg SequenceLeg, to Point) {
+	s.Legs = append(s.Legs, leg)
+	s.Points = append(s.Points, to)
+	s.DistanceMeters += leg.DistanceMeters
+	s.DurationSeconds += leg.DurationSeconds
+}
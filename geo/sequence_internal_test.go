@@ -0,0 +1,74 @@
+package geo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoOpt_UncrossesAnOrder(t *testing.T) {
+	req := SequenceRequest{Start: Point{Lat: 40, Lng: -90}, Departure: time.Unix(0, 0)}
+	for i := 1; i <= 5; i++ {
+		req.Stops = append(req.Stops, Stop{ID: string(rune('a' + i - 1)), Point: Point{Lat: 40, Lng: -90 + float64(i)}})
+	}
+	points := []Point{req.Start}
+	for _, s := range req.Stops {
+		points = append(points, s.Point)
+	}
+	m, err := NewEstimator().Matrix(context.Background(), MatrixRequest{Origins: points, Destinations: points})
+	require.NoError(t, err)
+	p := newSequencer(req, m)
+
+	crossed := []int{0, 3, 2, 1, 4}
+	before, _ := p.cost(crossed)
+	got := p.twoOpt(crossed)
+	after, ok := p.cost(got)
+	require.True(t, ok)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, got)
+	assert.Less(t, after, before)
+}
+
+func TestTwoOpt_NeverBreaksPrecedence(t *testing.T) {
+	req := SequenceRequest{
+		Start: Point{Lat: 40, Lng: -90},
+		Stops: []Stop{
+			{ID: "drop", Point: Point{Lat: 40, Lng: -89}, PickupID: "pick"},
+			{ID: "pick", Point: Point{Lat: 40, Lng: -88}},
+		},
+		Departure: time.Unix(0, 0),
+	}
+	m, err := NewEstimator().Matrix(context.Background(), MatrixRequest{
+		Origins:      []Point{req.Start, req.Stops[0].Point, req.Stops[1].Point},
+		Destinations: []Point{req.Start, req.Stops[0].Point, req.Stops[1].Point},
+	})
+	require.NoError(t, err)
+	p := newSequencer(req, m)
+
+	_, ok := p.cost([]int{0, 1})
+	assert.False(t, ok, "delivery before pickup is infeasible")
+	assert.Equal(t, []int{1, 0}, p.twoOpt([]int{1, 0}))
+}
+
+func TestSequenceStops_UnreachableStop(t *testing.T) {
+	req := SequenceRequest{Start: Point{Lat: 40, Lng: -90}, Stops: []Stop{{ID: "island", Point: Point{Lat: 21, Lng: -157}}}}
+	_, err := SequenceStops(context.Background(), unreachableMatrix{}, req)
+	assert.ErrorIs(t, err, ErrNoSequence)
+}
+
+// unreachableMatrix finds no route between any two different points.
+type unreachableMatrix struct{}
+
+func (unreachableMatrix) Matrix(_ context.Context, req MatrixRequest) (*Matrix, error) {
+	m := newMatrix(len(req.Origins), len(req.Destinations), "none")
+	for i := range req.Origins {
+		for j := range req.Destinations {
+			if i != j {
+				m.DistanceMeters[i][j], m.DurationSeconds[i][j] = Unreachable, Unreachable
+			}
+		}
+	}
+	return m, nil
+}
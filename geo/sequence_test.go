@@ -0,0 +1,126 @@
+package geo_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/TMS360/backend-pkg/geo/geotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var departure = time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+
+// onLine is a point due north of (35, -90), one degree of latitude per x. A
+// meridian is a great circle, so distances along it add up.
+func onLine(x float64) geo.Point { return geo.Point{Lat: 35 + x, Lng: -90} }
+
+func ids(stops []geo.Stop) []string {
+	out := make([]string, len(stops))
+	for i, s := range stops {
+		out[i] = s.ID
+	}
+	return out
+}
+
+func TestSequenceStops_OrdersAlongTheWay(t *testing.T) {
+	fake := geotest.New("")
+	end := onLine(5)
+	req := geo.SequenceRequest{
+		Start: onLine(0),
+		End:   &end,
+		Stops: []geo.Stop{
+			{ID: "c", Point: onLine(3)},
+			{ID: "a", Point: onLine(1)},
+			{ID: "d", Point: onLine(4)},
+			{ID: "b", Point: onLine(2)},
+		},
+		Departure: departure,
+	}
+
+	seq, err := geo.SequenceStops(context.Background(), fake, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids(seq.Stops))
+	require.Len(t, seq.Legs, 5, "four stops and the drive to End")
+	assert.Empty(t, seq.Legs[4].StopID)
+	assert.Equal(t, []geo.Point{onLine(0), onLine(1), onLine(2), onLine(3), onLine(4), onLine(5)}, seq.Points)
+	assert.Len(t, seq.Coordinates(), 6)
+	assert.InDelta(t, geo.Haversine(onLine(0), onLine(5))*geo.DefaultRoadFactor, seq.DistanceMeters, 10)
+	assert.Equal(t, departure.Add(time.Duration(seq.DurationSeconds)*time.Second), seq.Finish)
+	assert.Len(t, fake.Calls(), 1, "one matrix for the whole sequence")
+}
+
+func TestSequenceStops_PickupBeforeDelivery(t *testing.T) {
+	// The delivery is on the way to its pickup; going there first would be
+	// shorter and wrong.
+	seq, err := geo.SequenceStops(context.Background(), geo.NewEstimator(), geo.SequenceRequest{
+		Start: onLine(0),
+		Stops: []geo.Stop{
+			{ID: "drop", Point: onLine(1), PickupID: "pick"},
+			{ID: "pick", Point: onLine(2)},
+		},
+		Departure: departure,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pick", "drop"}, ids(seq.Stops))
+	assert.True(t, seq.Estimated)
+}
+
+func TestSequenceStops_TimeWindows(t *testing.T) {
+	// "near" is closer, but its dock opens in the afternoon; "far" closes
+	// before the truck could get back to it.
+	seq, err := geo.SequenceStops(context.Background(), geo.NewEstimator(), geo.SequenceRequest{
+		Start: onLine(0),
+		Stops: []geo.Stop{
+			{ID: "near", Point: onLine(0.5), Window: geo.TimeWindow{Open: departure.Add(8 * time.Hour)}},
+			{ID: "far", Point: onLine(2), Window: geo.TimeWindow{Close: departure.Add(4 * time.Hour)}, Service: time.Hour},
+		},
+		Departure: departure,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"far", "near"}, ids(seq.Stops))
+	assert.Zero(t, seq.Late)
+	assert.Zero(t, seq.Legs[0].Wait)
+	assert.Positive(t, seq.Legs[1].Wait, "the truck waits for the dock to open")
+	assert.Equal(t, departure.Add(8*time.Hour), seq.Legs[1].Departure)
+}
+
+func TestSequenceStops_ReportsUnavoidableLateness(t *testing.T) {
+	seq, err := geo.SequenceStops(context.Background(), geo.NewEstimator(), geo.SequenceRequest{
+		Start:     onLine(0),
+		Stops:     []geo.Stop{{ID: "a", Point: onLine(3), Window: geo.TimeWindow{Close: departure.Add(time.Hour)}}},
+		Departure: departure,
+	})
+	require.NoError(t, err)
+	assert.Positive(t, seq.Late)
+	assert.Equal(t, seq.Late, seq.Legs[0].Late)
+}
+
+func TestSequenceStops_RejectsBadRequests(t *testing.T) {
+	est := geo.NewEstimator()
+	ctx := context.Background()
+
+	_, err := geo.SequenceStops(ctx, est, geo.SequenceRequest{Start: onLine(0)})
+	assert.Error(t, err)
+
+	_, err = geo.SequenceStops(ctx, est, geo.SequenceRequest{Start: onLine(0), Stops: []geo.Stop{{ID: "a"}, {ID: "a"}}})
+	assert.ErrorContains(t, err, "duplicate")
+
+	_, err = geo.SequenceStops(ctx, est, geo.SequenceRequest{Start: onLine(0), Stops: []geo.Stop{{ID: "a", PickupID: "x"}}})
+	assert.ErrorContains(t, err, "unknown pickup")
+
+	stops := make([]geo.Stop, geo.MaxSequenceStops+1)
+	for i := range stops {
+		stops[i] = geo.Stop{ID: fmt.Sprint(i), Point: onLine(float64(i) / 10)}
+	}
+	_, err = geo.SequenceStops(ctx, est, geo.SequenceRequest{Start: onLine(0), Stops: stops})
+	assert.ErrorContains(t, err, "sequencing limit")
+
+	_, err = geo.SequenceStops(ctx, est, geo.SequenceRequest{Start: onLine(0), Stops: []geo.Stop{
+		{ID: "a", PickupID: "b"}, {ID: "b", PickupID: "a"},
+	}})
+	assert.ErrorIs(t, err, geo.ErrNoSequence)
+}
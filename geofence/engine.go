@@ -0,0 +1,247 @@
+package geofence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/google/uuid"
+)
+
+// DefaultMaxAccuracy drops fixes less accurate than this many meters: a
+// phone on cell towers alone reports positions kilometers off, and one such
+// fix would read as a departure.
+const DefaultMaxAccuracy = 200.0
+
+// EventType is what happened at a fence; it is the outbox event type.
+type EventType string
+
+const (
+	EventEnter EventType = "enter"
+	EventExit  EventType = "exit"
+	EventDwell EventType = "dwell"
+)
+
+// Event is one arrival, departure or dwell.
+type Event struct {
+	Type EventType `json:"type"`
+	// CompanyID is the tenant the engine evaluates for (WithCompany); the
+	// outbox row is stamped with it, since feed consumers have no actor.
+	CompanyID uuid.UUID `json:"company_id"`
+	FenceID   uuid.UUID `json:"fence_id"`
+	FenceName string    `json:"fence_name,omitempty"`
+	Ref       string    `json:"ref,omitempty"`
+	VehicleID string    `json:"vehicle_id"`
+	// Lat, Lng and At are the fix that triggered the event.
+	Lat float64   `json:"lat"`
+	Lng float64   `json:"lng"`
+	At  time.Time `json:"at"`
+	// EnteredAt is when the visit began; InsideSeconds is how long it has
+	// lasted by At. Both are set on every event (zero seconds on enter).
+	EnteredAt     time.Time `json:"entered_at"`
+	InsideSeconds int64     `json:"inside_seconds"`
+}
+
+// Location is one fix from a feed.
+type Location struct {
+	VehicleID string
+	Point     geo.Point
+	At        time.Time
+	// AccuracyMeters is the fix's horizontal accuracy; zero means unknown
+	// and is accepted.
+	AccuracyMeters float64
+}
+
+// Sink receives events in the order they happened.
+type Sink interface {
+	Emit(ctx context.Context, ev Event) error
+}
+
+// SinkFunc adapts a function to Sink.
+type SinkFunc func(ctx context.Context, ev Event) error
+
+func (f SinkFunc) Emit(ctx context.Context, ev Event) error { return f(ctx, ev) }
+
+// Engine evaluates fixes against a set of fences.
+//
+// Fixes of one vehicle must be fed one at a time (consume the feed
+// partitioned by vehicle); fixes of different vehicles may be fed
+// concurrently. A fix older than the last one seen for the vehicle is
+// dropped, so a redelivered or late webhook cannot replay a visit. Dwell is
+// evaluated on fixes: it is reported with the first fix after the dwell time
+// has passed.
+//
+// Delivery is at least once: state is saved after the events are emitted, so
+// a Sink or store failure makes the fix's events come again on retry.
+type Engine struct {
+	companyID   uuid.UUID
+	store       StateStore
+	sink        Sink
+	hysteresis  float64
+	maxAccuracy float64
+
+	mu     sync.RWMutex
+	fences []*compiledFence
+}
+
+type compiledFence struct {
+	Fence
+	hysteresis float64
+	box        box
+}
+
+// Option configures NewEngine.
+type Option func(*Engine)
+
+// WithHysteresis sets the hysteresis for fences that do not set their own
+// (default DefaultHysteresis).
+func WithHysteresis(meters float64) Option {
+	return func(e *Engine) { e.hysteresis = meters }
+}
+
+// WithMaxAccuracy sets the least accurate fix accepted (default
+// DefaultMaxAccuracy); zero accepts every fix.
+func WithMaxAccuracy(meters float64) Option {
+	return func(e *Engine) { e.maxAccuracy = meters }
+}
+
+// WithCompany sets the company whose fences and vehicles the engine
+// evaluates; it is stamped on every event. Set it whenever the fixes come
+// from a feed rather than a user request.
+func WithCompany(companyID uuid.UUID) Option {
+	return func(e *Engine) { e.companyID = companyID }
+}
+
+// NewEngine returns an engine with no fences; call SetFences.
+func NewEngine(store StateStore, sink Sink, opts ...Option) *Engine {
+	e := &Engine{store: store, sink: sink, hysteresis: DefaultHysteresis, maxAccuracy: DefaultMaxAccuracy}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// SetFences replaces the fences. Nothing is replaced if any fence is
+// invalid. A vehicle inside a fence that is removed leaves it silently: no
+// exit is reported for a fence that no longer exists.
+func (e *Engine) SetFences(fences ...Fence) error {
+	compiled := make([]*compiledFence, 0, len(fences))
+	seen := make(map[uuid.UUID]bool, len(fences))
+	for _, f := range fences {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+		if seen[f.ID] {
+			return fmt.Errorf("%w %s: duplicate id", ErrInvalidFence, f.ID)
+		}
+		seen[f.ID] = true
+		c := &compiledFence{Fence: f, hysteresis: f.Hysteresis}
+		if c.hysteresis == 0 {
+			c.hysteresis = e.hysteresis
+		}
+		c.Polygon = openRing(f.Polygon)
+		c.box = c.bounds(c.hysteresis)
+		compiled = append(compiled, c)
+	}
+	e.mu.Lock()
+	e.fences = compiled
+	e.mu.Unlock()
+	return nil
+}
+
+// Update evaluates one fix and returns the events it caused, after they have
+// been emitted. A dropped fix (stale or inaccurate) returns no events and no
+// error.
+func (e *Engine) Update(ctx context.Context, loc Location) ([]Event, error) {
+	if loc.VehicleID == "" {
+		return nil, errors.New("geofence: location has no vehicle id")
+	}
+	if loc.At.IsZero() {
+		return nil, errors.New("geofence: location has no time")
+	}
+	if e.maxAccuracy > 0 && loc.AccuracyMeters > e.maxAccuracy {
+		return nil, nil
+	}
+
+	st, ok, err := e.store.Load(ctx, loc.VehicleID)
+	if err != nil {
+		return nil, fmt.Errorf("geofence: load state of %s: %w", loc.VehicleID, err)
+	}
+	if !ok {
+		st = &VehicleState{}
+	}
+	if !st.LastAt.IsZero() && !loc.At.After(st.LastAt) {
+		return nil, nil
+	}
+	if st.Visits == nil {
+		st.Visits = map[uuid.UUID]Visit{}
+	}
+
+	e.mu.RLock()
+	fences := e.fences
+	e.mu.RUnlock()
+
+	var out []Event
+	configured := make(map[uuid.UUID]bool, len(fences))
+	for _, f := range fences {
+		visit, inside := st.Visits[f.ID]
+		configured[f.ID] = true
+		if !inside && !f.box.contains(loc.Point) {
+			continue
+		}
+		d := f.Distance(loc.Point)
+		switch {
+		case !inside && d <= 0:
+			visit = Visit{EnteredAt: loc.At}
+			st.Visits[f.ID] = visit
+			out = append(out, e.newEvent(EventEnter, f, loc, visit))
+		case inside && d > f.hysteresis:
+			delete(st.Visits, f.ID)
+			out = append(out, e.newEvent(EventExit, f, loc, visit))
+		case inside && f.Dwell > 0 && !visit.Dwelled && loc.At.Sub(visit.EnteredAt) >= f.Dwell:
+			visit.Dwelled = true
+			st.Visits[f.ID] = visit
+			out = append(out, e.newEvent(EventDwell, f, loc, visit))
+		}
+	}
+	for id := range st.Visits {
+		if !configured[id] {
+			delete(st.Visits, id)
+		}
+	}
+	// Leaving one fence for an adjacent one reads exit, then enter.
+	sort.SliceStable(out, func(i, j int) bool { return eventRank[out[i].Type] < eventRank[out[j].Type] })
+
+	for _, ev := range out {
+		if err := e.sink.Emit(ctx, ev); err != nil {
+			return nil, fmt.Errorf("geofence: emit %s %s for %s: %w", ev.Type, ev.FenceID, ev.VehicleID, err)
+		}
+	}
+	st.LastAt = loc.At
+	if err := e.store.Save(ctx, loc.VehicleID, st); err != nil {
+		return nil, fmt.Errorf("geofence: save state of %s: %w", loc.VehicleID, err)
+	}
+	return out, nil
+}
+
+var eventRank = map[EventType]int{EventExit: 0, EventEnter: 1, EventDwell: 2}
+
+func (e *Engine) newEvent(t EventType, f *compiledFence, loc Location, v Visit) Event {
+	return Event{
+		Type:          t,
+		CompanyID:     e.companyID,
+		FenceID:       f.ID,
+		FenceName:     f.Name,
+		Ref:           f.Ref,
+		VehicleID:     loc.VehicleID,
+		Lat:           loc.Point.Lat,
+		Lng:           loc.Point.Lng,
+		At:            loc.At,
+		EnteredAt:     v.EnteredAt,
+		InsideSeconds: int64(loc.At.Sub(v.EnteredAt) / time.Second),
+	}
+}
@@ -0,0 +1,278 @@
+package geofence_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/TMS360/backend-pkg/geofence"
+	"github.com/TMS360/backend-pkg/tmsdb"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	yard = geo.Point{Lat: 41.705, Lng: -87.705}
+	t0   = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+)
+
+// north is the point meters due north of yard.
+func north(meters float64) geo.Point {
+	return geo.Point{Lat: yard.Lat + meters/111_195.08, Lng: yard.Lng}
+}
+
+// recorder is a Sink that keeps what it was sent and can be made to fail.
+type recorder struct {
+	events []geofence.Event
+	err    error
+}
+
+func (r *recorder) Emit(_ context.Context, ev geofence.Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, ev)
+	return nil
+}
+
+func types(evs []geofence.Event) []geofence.EventType {
+	out := make([]geofence.EventType, len(evs))
+	for i, ev := range evs {
+		out[i] = ev.Type
+	}
+	return out
+}
+
+func newEngine(t *testing.T, fences ...geofence.Fence) (*geofence.Engine, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	e := geofence.NewEngine(geofence.NewMemoryStore(), rec)
+	require.NoError(t, e.SetFences(fences...))
+	return e, rec
+}
+
+func TestEngine_HysteresisStopsFlapping(t *testing.T) {
+	fence := geofence.Circle(uuid.New(), yard, 200)
+	fence.Name, fence.Ref = "Joliet yard", "stop-1"
+	e, rec := newEngine(t, fence)
+	ctx := context.Background()
+
+	// Outside, inside, then GPS jitter back and forth across the boundary,
+	// then really gone.
+	for i, d := range []float64{300, 100, 220, 190, 240, 150, 260} {
+		_, err := e.Update(ctx, geofence.Location{VehicleID: "truck-7", Point: north(d), At: t0.Add(time.Duration(i) * time.Minute)})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []geofence.EventType{geofence.EventEnter, geofence.EventExit}, types(rec.events))
+	enter, exit := rec.events[0], rec.events[1]
+	assert.Equal(t, fence.ID, enter.FenceID)
+	assert.Equal(t, "Joliet yard", enter.FenceName)
+	assert.Equal(t, "stop-1", enter.Ref)
+	assert.Equal(t, "truck-7", enter.VehicleID)
+	assert.Equal(t, t0.Add(time.Minute), enter.At)
+	assert.Zero(t, enter.InsideSeconds)
+	assert.Equal(t, t0.Add(6*time.Minute), exit.At)
+	assert.Equal(t, enter.At, exit.EnteredAt)
+	assert.Equal(t, int64(5*60), exit.InsideSeconds)
+}
+
+func TestEngine_PerFenceHysteresis(t *testing.T) {
+	fence := geofence.Circle(uuid.New(), yard, 200)
+	fence.Hysteresis = 10
+	e, rec := newEngine(t, fence)
+	ctx := context.Background()
+
+	_, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(100), At: t0})
+	require.NoError(t, err)
+	evs, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(220), At: t0.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, []geofence.EventType{geofence.EventExit}, types(evs))
+	assert.Len(t, rec.events, 2)
+}
+
+func TestEngine_DwellOncePerVisit(t *testing.T) {
+	fence := geofence.Circle(uuid.New(), yard, 200)
+	fence.Dwell = 30 * time.Minute
+	e, rec := newEngine(t, fence)
+	ctx := context.Background()
+
+	for _, f := range []struct {
+		at time.Duration
+		d  float64
+	}{{0, 50}, {10 * time.Minute, 60}, {31 * time.Minute, 40}, {45 * time.Minute, 50}, {50 * time.Minute, 500}, {51 * time.Minute, 50}} {
+		_, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(f.d), At: t0.Add(f.at)})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []geofence.EventType{geofence.EventEnter, geofence.EventDwell, geofence.EventExit, geofence.EventEnter}, types(rec.events))
+	assert.Equal(t, int64(31*60), rec.events[1].InsideSeconds)
+	assert.Equal(t, int64(50*60), rec.events[2].InsideSeconds)
+}
+
+func TestEngine_DropsStaleAndInaccurateFixes(t *testing.T) {
+	e, rec := newEngine(t, geofence.Circle(uuid.New(), yard, 200))
+	ctx := context.Background()
+
+	_, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(50), At: t0})
+	require.NoError(t, err)
+
+	// A late webhook from before the arrival, a duplicate, and a cell-tower
+	// fix far away: none of them is a departure.
+	for _, loc := range []geofence.Location{
+		{VehicleID: "v", Point: north(5000), At: t0.Add(-time.Minute)},
+		{VehicleID: "v", Point: north(5000), At: t0},
+		{VehicleID: "v", Point: north(5000), At: t0.Add(time.Minute), AccuracyMeters: 3000},
+	} {
+		evs, err := e.Update(ctx, loc)
+		require.NoError(t, err)
+		assert.Empty(t, evs)
+	}
+	assert.Len(t, rec.events, 1)
+
+	_, err = e.Update(ctx, geofence.Location{Point: north(50), At: t0})
+	assert.Error(t, err, "no vehicle id")
+	_, err = e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(50)})
+	assert.Error(t, err, "no time")
+}
+
+func TestEngine_AdjacentFencesExitBeforeEnter(t *testing.T) {
+	// Two docks sharing the edge at lat 41.705.
+	south := geofence.Polygon(uuid.New(), []geo.Point{{Lat: 41.70, Lng: -87.71}, {Lat: 41.70, Lng: -87.70}, {Lat: 41.705, Lng: -87.70}, {Lat: 41.705, Lng: -87.71}})
+	northDock := geofence.Polygon(uuid.New(), []geo.Point{{Lat: 41.705, Lng: -87.71}, {Lat: 41.705, Lng: -87.70}, {Lat: 41.71, Lng: -87.70}, {Lat: 41.71, Lng: -87.71}})
+	// List the entered fence first, so order is not an accident of the list.
+	e, _ := newEngine(t, northDock, south)
+	ctx := context.Background()
+
+	_, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: geo.Point{Lat: 41.702, Lng: -87.705}, At: t0})
+	require.NoError(t, err)
+	evs, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: geo.Point{Lat: 41.708, Lng: -87.705}, At: t0.Add(time.Minute)})
+	require.NoError(t, err)
+
+	require.Len(t, evs, 2)
+	assert.Equal(t, geofence.EventExit, evs[0].Type)
+	assert.Equal(t, south.ID, evs[0].FenceID)
+	assert.Equal(t, geofence.EventEnter, evs[1].Type)
+	assert.Equal(t, northDock.ID, evs[1].FenceID)
+}
+
+func TestEngine_SinkFailureRetriesTheFix(t *testing.T) {
+	e, rec := newEngine(t, geofence.Circle(uuid.New(), yard, 200))
+	ctx := context.Background()
+	loc := geofence.Location{VehicleID: "v", Point: north(50), At: t0}
+
+	rec.err = errors.New("db down")
+	_, err := e.Update(ctx, loc)
+	require.ErrorIs(t, err, rec.err)
+
+	rec.err = nil
+	evs, err := e.Update(ctx, loc)
+	require.NoError(t, err)
+	assert.Equal(t, []geofence.EventType{geofence.EventEnter}, types(evs), "the failed fix was not recorded as seen")
+}
+
+func TestEngine_SetFences(t *testing.T) {
+	fence := geofence.Circle(uuid.New(), yard, 200)
+	e, rec := newEngine(t, fence)
+	ctx := context.Background()
+
+	_, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(50), At: t0})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, e.SetFences(fence, fence), geofence.ErrInvalidFence)
+	assert.ErrorIs(t, e.SetFences(geofence.Circle(uuid.New(), yard, -1)), geofence.ErrInvalidFence)
+
+	// A removed fence is left silently, and re-adding it later is a new visit.
+	require.NoError(t, e.SetFences())
+	_, err = e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(5000), At: t0.Add(time.Minute)})
+	require.NoError(t, err)
+	require.NoError(t, e.SetFences(fence))
+	_, err = e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(50), At: t0.Add(2 * time.Minute)})
+	require.NoError(t, err)
+
+	assert.Equal(t, []geofence.EventType{geofence.EventEnter, geofence.EventEnter}, types(rec.events))
+}
+
+type publishCall struct {
+	aggType, evtType string
+	aggID            uuid.UUID
+	data             interface{}
+	company          uuid.UUID
+}
+
+type fakeTM struct{ calls []publishCall }
+
+func (f *fakeTM) Publish(ctx context.Context, aggType, evtType string, aggID uuid.UUID, data interface{}, _ ...interface{}) error {
+	company, _ := tmsdb.EventCompany(ctx)
+	f.calls = append(f.calls, publishCall{aggType, evtType, aggID, data, company})
+	return nil
+}
+
+func TestOutboxSink_PublishesPerFence(t *testing.T) {
+	tm := &fakeTM{}
+	fence := geofence.Circle(uuid.New(), yard, 200)
+	e := geofence.NewEngine(geofence.NewMemoryStore(), geofence.NewOutboxSink(tm))
+	require.NoError(t, e.SetFences(fence))
+
+	_, err := e.Update(context.Background(), geofence.Location{VehicleID: "v", Point: north(50), At: t0})
+	require.NoError(t, err)
+
+	require.Len(t, tm.calls, 1)
+	assert.Equal(t, geofence.Topic, tm.calls[0].aggType)
+	assert.Equal(t, "enter", tm.calls[0].evtType)
+	assert.Equal(t, fence.ID, tm.calls[0].aggID)
+	assert.Equal(t, "v", tm.calls[0].data.(geofence.Event).VehicleID)
+}
+
+func TestOutboxSink_StampsCompanyWithoutActor(t *testing.T) {
+	tm := &fakeTM{}
+	company := uuid.New()
+	fence := geofence.Circle(uuid.New(), yard, 200)
+	e := geofence.NewEngine(geofence.NewMemoryStore(), geofence.NewOutboxSink(tm), geofence.WithCompany(company))
+	require.NoError(t, e.SetFences(fence))
+
+	// A feed consumer's context: no actor anywhere.
+	_, err := e.Update(context.Background(), geofence.Location{VehicleID: "v", Point: north(50), At: t0})
+	require.NoError(t, err)
+
+	require.Len(t, tm.calls, 1)
+	assert.Equal(t, company, tm.calls[0].company)
+	assert.Equal(t, company, tm.calls[0].data.(geofence.Event).CompanyID)
+}
+
+func TestRedisStore_SurvivesRestart(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache.Init(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	ctx := context.Background()
+	fence := geofence.Circle(uuid.New(), yard, 200)
+
+	first := &recorder{}
+	e := geofence.NewEngine(geofence.NewRedisStore("42"), first)
+	require.NoError(t, e.SetFences(fence))
+	_, err := e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(50), At: t0})
+	require.NoError(t, err)
+	require.Len(t, first.events, 1)
+	assert.True(t, mr.Exists(cache.ScopedKey("42", "geofence:vehicle:v")))
+
+	// A new process picks up where the last left off: no second arrival.
+	second := &recorder{}
+	e = geofence.NewEngine(geofence.NewRedisStore("42"), second)
+	require.NoError(t, e.SetFences(fence))
+	_, err = e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(60), At: t0.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Empty(t, second.events)
+
+	// Another company's state is its own.
+	other := &recorder{}
+	e = geofence.NewEngine(geofence.NewRedisStore("43"), other)
+	require.NoError(t, e.SetFences(fence))
+	_, err = e.Update(ctx, geofence.Location{VehicleID: "v", Point: north(60), At: t0.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Len(t, other.events, 1)
+}
@@ -0,0 +1,232 @@
+// Package geofence detects arrivals at and departures from places, from any
+// location feed: ELD webhooks, the driver app (trackers.UpdateVehicleLocation),
+// a carrier's own GPS. Samsara geofences and their webhooks only cover
+// Samsara-equipped trucks; this package evaluates the same circle and polygon
+// fences for every fleet.
+//
+// An Engine keeps, per vehicle, which fences it is inside. A vehicle enters a
+// fence when a fix lands inside it, and leaves only once a fix lands more than
+// the fence's hysteresis outside — so GPS jitter along a dock wall does not
+// flap arrive/depart. A fence with Dwell set also reports, once per visit,
+// that the vehicle has been inside that long (detention starts the clock
+// there). Events go to a Sink; NewOutboxSink publishes them through the
+// transactional outbox like every other domain event.
+package geofence
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidFence is returned for a fence that cannot be evaluated.
+var ErrInvalidFence = errors.New("geofence: invalid fence")
+
+// Shape is the geometry of a fence.
+type Shape string
+
+const (
+	ShapeCircle  Shape = "circle"
+	ShapePolygon Shape = "polygon"
+)
+
+// DefaultHysteresis is how far outside a fence a vehicle must be before it
+// has left, for fences that do not set their own: a few GPS error radii.
+const DefaultHysteresis = 50.0
+
+// Fence is a circle or a polygon.
+type Fence struct {
+	ID   uuid.UUID
+	Name string
+	// Ref is the caller's handle for what the fence marks (a stop or
+	// facility id); it is copied to every event.
+	Ref   string
+	Shape Shape
+
+	Center       geo.Point // ShapeCircle
+	RadiusMeters float64   // ShapeCircle
+	// Polygon is the ring for ShapePolygon, in either winding, at least 3
+	// vertices; a closing vertex equal to the first is allowed.
+	Polygon []geo.Point
+
+	// Hysteresis in meters; zero uses the engine's (DefaultHysteresis unless
+	// set with WithHysteresis).
+	Hysteresis float64
+	// Dwell, when set, reports a dwell event once a vehicle has been inside
+	// this long.
+	Dwell time.Duration
+}
+
+// Circle returns a circular fence.
+func Circle(id uuid.UUID, center geo.Point, radiusMeters float64) Fence {
+	return Fence{ID: id, Shape: ShapeCircle, Center: center, RadiusMeters: radiusMeters}
+}
+
+// Polygon returns a polygonal fence.
+func Polygon(id uuid.UUID, ring []geo.Point) Fence {
+	return Fence{ID: id, Shape: ShapePolygon, Polygon: ring}
+}
+
+// Validate reports why f cannot be evaluated, wrapping ErrInvalidFence.
+func (f *Fence) Validate() error {
+	if f.ID == uuid.Nil {
+		return fmt.Errorf("%w: no id", ErrInvalidFence)
+	}
+	if f.Hysteresis < 0 || f.Dwell < 0 {
+		return fmt.Errorf("%w %s: negative hysteresis or dwell", ErrInvalidFence, f.ID)
+	}
+	switch f.Shape {
+	case ShapeCircle:
+		if f.RadiusMeters <= 0 {
+			return fmt.Errorf("%w %s: radius must be positive", ErrInvalidFence, f.ID)
+		}
+		if !validPoint(f.Center) {
+			return fmt.Errorf("%w %s: center out of range", ErrInvalidFence, f.ID)
+		}
+	case ShapePolygon:
+		ring := openRing(f.Polygon)
+		if len(ring) < 3 {
+			return fmt.Errorf("%w %s: a polygon needs at least 3 vertices", ErrInvalidFence, f.ID)
+		}
+		for _, p := range ring {
+			if !validPoint(p) {
+				return fmt.Errorf("%w %s: vertex out of range", ErrInvalidFence, f.ID)
+			}
+		}
+	default:
+		return fmt.Errorf("%w %s: unknown shape %q", ErrInvalidFence, f.ID, f.Shape)
+	}
+	return nil
+}
+
+// Contains reports whether p is inside f. Points on a polygon's edge count as
+// inside.
+func (f *Fence) Contains(p geo.Point) bool {
+	return f.Distance(p) <= 0
+}
+
+// Distance is the signed distance in meters from p to f's boundary:
+// negative inside, positive outside.
+func (f *Fence) Distance(p geo.Point) float64 {
+	if f.Shape == ShapeCircle {
+		return geo.Haversine(f.Center, p) - f.RadiusMeters
+	}
+	ring := openRing(f.Polygon)
+	d := distanceToRing(p, ring)
+	if inPolygon(p, ring) {
+		return -d
+	}
+	return d
+}
+
+// bounds is the fence's bounding box grown by margin meters, so the engine
+// can skip fences nowhere near a fix.
+func (f *Fence) bounds(margin float64) box {
+	var b box
+	if f.Shape == ShapeCircle {
+		b = box{f.Center.Lat, f.Center.Lat, f.Center.Lng, f.Center.Lng}
+		margin += f.RadiusMeters
+	} else {
+		b = box{math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)}
+		for _, p := range f.Polygon {
+			b.minLat, b.maxLat = min(b.minLat, p.Lat), max(b.maxLat, p.Lat)
+			b.minLng, b.maxLng = min(b.minLng, p.Lng), max(b.maxLng, p.Lng)
+		}
+	}
+	dLat := margin / metersPerDegree
+	// The widest degree of longitude in the box is at the latitude nearest
+	// the pole.
+	cos := math.Cos(math.Min(89, math.Max(math.Abs(b.minLat), math.Abs(b.maxLat))+dLat) * math.Pi / 180)
+	dLng := margin / (metersPerDegree * cos)
+	return box{b.minLat - dLat, b.maxLat + dLat, b.minLng - dLng, b.maxLng + dLng}
+}
+
+type box struct{ minLat, maxLat, minLng, maxLng float64 }
+
+func (b box) contains(p geo.Point) bool {
+	return p.Lat >= b.minLat && p.Lat <= b.maxLat && p.Lng >= b.minLng && p.Lng <= b.maxLng
+}
+
+// metersPerDegree of latitude, on the sphere geo.Haversine uses.
+const metersPerDegree = 6371008.8 * math.Pi / 180
+
+func validPoint(p geo.Point) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// openRing drops a closing vertex equal to the first.
+func openRing(ring []geo.Point) []geo.Point {
+	if n := len(ring); n > 1 && ring[0] == ring[n-1] {
+		return ring[:n-1]
+	}
+	return ring
+}
+
+// inPolygon is the even-odd ray cast, with points on an edge counted inside.
+// Coordinates are treated as planar, which is exact enough at fence scale;
+// fences crossing the antimeridian are not supported.
+func inPolygon(p geo.Point, ring []geo.Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if onSegment(p, a, b) {
+			return true
+		}
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func onSegment(p, a, b geo.Point) bool {
+	const eps = 1e-12
+	cross := (b.Lng-a.Lng)*(p.Lat-a.Lat) - (b.Lat-a.Lat)*(p.Lng-a.Lng)
+	if math.Abs(cross) > eps {
+		return false
+	}
+	return p.Lng >= min(a.Lng, b.Lng)-eps && p.Lng <= max(a.Lng, b.Lng)+eps &&
+		p.Lat >= min(a.Lat, b.Lat)-eps && p.Lat <= max(a.Lat, b.Lat)+eps
+}
+
+// distanceToRing is the distance in meters from p to the nearest edge of
+// ring, on a local equirectangular projection centred on p.
+func distanceToRing(p geo.Point, ring []geo.Point) float64 {
+	proj := newProjection(p)
+	best := math.Inf(1)
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		ax, ay := proj.xy(ring[j])
+		bx, by := proj.xy(ring[i])
+		best = math.Min(best, segmentDistance(0, 0, ax, ay, bx, by))
+	}
+	return best
+}
+
+// projection maps points near an origin to planar meters.
+type projection struct {
+	origin geo.Point
+	cos    float64
+}
+
+func newProjection(origin geo.Point) projection {
+	return projection{origin: origin, cos: math.Cos(origin.Lat * math.Pi / 180)}
+}
+
+func (pr projection) xy(p geo.Point) (x, y float64) {
+	return (p.Lng - pr.origin.Lng) * pr.cos * metersPerDegree, (p.Lat - pr.origin.Lat) * metersPerDegree
+}
+
+// segmentDistance is the distance from (px, py) to the segment a–b.
+func segmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((px-ax)*dx+(py-ay)*dy)/l))
+	}
+	return math.Hypot(px-(ax+t*dx), py-(ay+t*dy))
+}
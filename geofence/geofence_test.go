@@ -0,0 +1,96 @@
+package geofence
+
+import (
+	"testing"
+
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// square is a yard south-west of Chicago, (41.70..41.71, -87.71..-87.70):
+// 1.1 km north to south, 830 m east to west.
+var square = []geo.Point{{Lat: 41.70, Lng: -87.71}, {Lat: 41.70, Lng: -87.70}, {Lat: 41.71, Lng: -87.70}, {Lat: 41.71, Lng: -87.71}}
+
+// notch is a U: the square with its top-middle cut out down to 41.705.
+var notch = []geo.Point{
+	{Lat: 41.70, Lng: -87.71}, {Lat: 41.70, Lng: -87.70}, {Lat: 41.71, Lng: -87.70}, {Lat: 41.71, Lng: -87.703},
+	{Lat: 41.705, Lng: -87.703}, {Lat: 41.705, Lng: -87.707}, {Lat: 41.71, Lng: -87.707}, {Lat: 41.71, Lng: -87.71},
+}
+
+func TestInPolygon(t *testing.T) {
+	reversed := make([]geo.Point, len(square))
+	for i, p := range square {
+		reversed[len(square)-1-i] = p
+	}
+	closed := append(append([]geo.Point(nil), square...), square[0])
+
+	cases := []struct {
+		name string
+		ring []geo.Point
+		p    geo.Point
+		want bool
+	}{
+		{"centre", square, geo.Point{Lat: 41.705, Lng: -87.705}, true},
+		{"outside east", square, geo.Point{Lat: 41.705, Lng: -87.69}, false},
+		{"outside north", square, geo.Point{Lat: 41.72, Lng: -87.705}, false},
+		{"on an edge", square, geo.Point{Lat: 41.70, Lng: -87.705}, true},
+		{"on a vertex", square, geo.Point{Lat: 41.71, Lng: -87.70}, true},
+		{"level with a vertex, outside", square, geo.Point{Lat: 41.71, Lng: -87.72}, false},
+		{"clockwise ring", reversed, geo.Point{Lat: 41.705, Lng: -87.705}, true},
+		{"closed ring", closed, geo.Point{Lat: 41.705, Lng: -87.705}, true},
+		{"in the notch", notch, geo.Point{Lat: 41.708, Lng: -87.705}, false},
+		{"left arm of the U", notch, geo.Point{Lat: 41.708, Lng: -87.709}, true},
+		{"right arm of the U", notch, geo.Point{Lat: 41.708, Lng: -87.701}, true},
+		{"below the notch", notch, geo.Point{Lat: 41.702, Lng: -87.705}, true},
+		{"ray through two notch vertices", notch, geo.Point{Lat: 41.705, Lng: -87.72}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, inPolygon(c.p, openRing(c.ring)))
+		})
+	}
+}
+
+func TestFence_Distance(t *testing.T) {
+	poly := Polygon(uuid.New(), square)
+	// 0.001° of latitude is ~111 m; the nearest edges from the centre are
+	// the east and west ones, 415 m away.
+	assert.InDelta(t, -415, poly.Distance(geo.Point{Lat: 41.705, Lng: -87.705}), 1, "centre")
+	assert.InDelta(t, 111, poly.Distance(geo.Point{Lat: 41.711, Lng: -87.705}), 1)
+	assert.InDelta(t, 0, poly.Distance(geo.Point{Lat: 41.70, Lng: -87.705}), 0.01)
+	assert.True(t, poly.Contains(geo.Point{Lat: 41.70, Lng: -87.705}))
+
+	circle := Circle(uuid.New(), geo.Point{Lat: 41.705, Lng: -87.705}, 300)
+	assert.InDelta(t, -300, circle.Distance(circle.Center), 0.01)
+	assert.InDelta(t, 111-300, circle.Distance(geo.Point{Lat: 41.706, Lng: -87.705}), 1)
+	assert.False(t, circle.Contains(geo.Point{Lat: 41.71, Lng: -87.705}))
+}
+
+func TestFence_Validate(t *testing.T) {
+	id := uuid.New()
+	for name, f := range map[string]Fence{
+		"no id":          Circle(uuid.Nil, geo.Point{}, 100),
+		"zero radius":    Circle(id, geo.Point{}, 0),
+		"bad center":     Circle(id, geo.Point{Lat: 91}, 100),
+		"two vertices":   Polygon(id, square[:2]),
+		"closed segment": Polygon(id, []geo.Point{square[0], square[1], square[0]}),
+		"bad vertex":     Polygon(id, []geo.Point{square[0], square[1], {Lat: 41, Lng: -190}}),
+		"no shape":       {ID: id},
+	} {
+		f := f
+		assert.ErrorIs(t, f.Validate(), ErrInvalidFence, name)
+	}
+	ok := Polygon(id, square)
+	assert.NoError(t, ok.Validate())
+}
+
+func TestFence_BoundsCoverHysteresis(t *testing.T) {
+	f := Circle(uuid.New(), geo.Point{Lat: 41.705, Lng: -87.705}, 300)
+	b := f.bounds(100)
+	// 399 m due east and due north are inside the grown box, 420 m not.
+	for _, p := range []geo.Point{{Lat: 41.705, Lng: -87.705 + 399/(metersPerDegree*0.7460)}, {Lat: 41.705 + 399/metersPerDegree, Lng: -87.705}} {
+		assert.True(t, b.contains(p), "%v", p)
+	}
+	assert.False(t, b.contains(geo.Point{Lat: 41.705 + 420/metersPerDegree, Lng: -87.705}))
+}
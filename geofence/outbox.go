@@ -0,0 +1,41 @@
+package geofence
+
+import (
+	"context"
+
+	"github.com/TMS360/backend-pkg/tmsdb"
+	"github.com/google/uuid"
+)
+
+// Topic is the outbox aggregate type of geofence events, and so their Kafka
+// topic (the relay's default). The event type is the EventType; the
+// aggregate id is the fence id.
+const Topic = "geofence_events"
+
+// TransactionManager is the subset of tmsdb.TransactionManager needed here.
+type TransactionManager interface {
+	Publish(ctx context.Context, aggType, evtType string, aggID uuid.UUID, data interface{}, oldData ...interface{}) error
+}
+
+// OutboxSink publishes events through the transactional outbox. Run
+// Engine.Update inside tm.WithTransaction to tie the events to whatever the
+// caller writes for the same fix (a check-in, a stop status).
+type OutboxSink struct {
+	tm TransactionManager
+}
+
+// NewOutboxSink publishes through tm (tmsdb.GormTransactionManager).
+func NewOutboxSink(tm TransactionManager) *OutboxSink {
+	return &OutboxSink{tm: tm}
+}
+
+// Emit publishes ev. The row's company is ev.CompanyID when the engine has
+// one — a feed consumer's context has no actor to take it from.
+func (s *OutboxSink) Emit(ctx context.Context, ev Event) error {
+	if ev.CompanyID != uuid.Nil {
+		ctx = tmsdb.WithEventCompany(ctx, ev.CompanyID)
+	}
+	return s.tm.Publish(ctx, Topic, string(ev.Type), ev.FenceID, ev)
+}
+
+var _ Sink = (*OutboxSink)(nil)
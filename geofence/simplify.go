@@ -0,0 +1,86 @@
+package geofence
+
+import (
+	"github.com/TMS360/backend-pkg/geo"
+)
+
+// Simplify reduces a polygon ring with Douglas–Peucker: every dropped vertex
+// is within toleranceMeters of the simplified outline. Drawn and imported
+// facility outlines often carry hundreds of vertices; a few meters of
+// tolerance keeps the shape, makes every fix cheaper to test and fits
+// vendors' vertex limits (Samsara takes at most 40).
+//
+// The result is open (no closing vertex) and keeps at least 3 vertices; a
+// ring that is already that small is returned as is.
+func Simplify(ring []geo.Point, toleranceMeters float64) []geo.Point {
+	ring = openRing(ring)
+	if len(ring) <= 3 || toleranceMeters <= 0 {
+		return append([]geo.Point(nil), ring...)
+	}
+
+	proj := newProjection(ring[0])
+	xs, ys := make([]float64, len(ring)), make([]float64, len(ring))
+	for i, p := range ring {
+		xs[i], ys[i] = proj.xy(p)
+	}
+
+	// Split the ring at the vertex farthest from the first, so each half
+	// is an open polyline with fixed ends.
+	far := 1
+	for i := 2; i < len(ring); i++ {
+		if sq(xs[i]-xs[0])+sq(ys[i]-ys[0]) > sq(xs[far]-xs[0])+sq(ys[far]-ys[0]) {
+			far = i
+		}
+	}
+	keep := make([]bool, len(ring)+1) // index len(ring) is the first vertex again
+	keep[0], keep[far], keep[len(ring)] = true, true, true
+	at := func(i int) (float64, float64) { return xs[i%len(ring)], ys[i%len(ring)] }
+	var reduce func(from, to int)
+	reduce = func(from, to int) {
+		ax, ay := at(from)
+		bx, by := at(to)
+		worst, worstD := -1, toleranceMeters
+		for i := from + 1; i < to; i++ {
+			px, py := at(i)
+			if d := segmentDistance(px, py, ax, ay, bx, by); d > worstD {
+				worst, worstD = i, d
+			}
+		}
+		if worst >= 0 {
+			keep[worst] = true
+			reduce(from, worst)
+			reduce(worst, to)
+		}
+	}
+	reduce(0, far)
+	reduce(far, len(ring))
+
+	out := make([]geo.Point, 0, len(ring))
+	for i, p := range ring {
+		if keep[i] {
+			out = append(out, p)
+		}
+	}
+	// Two kept vertices is a line; add back the one farthest from it.
+	if len(out) < 3 {
+		best, bestD := -1, -1.0
+		for i := range ring {
+			if keep[i] {
+				continue
+			}
+			if d := segmentDistance(xs[i], ys[i], xs[0], ys[0], xs[far], ys[far]); d > bestD {
+				best, bestD = i, d
+			}
+		}
+		keep[best] = true
+		out = out[:0]
+		for i, p := range ring {
+			if keep[i] {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+func sq(v float64) float64 { return v * v }
@@ -0,0 +1,70 @@
+package geofence
+
+import (
+	"math"
+	"testing"
+
+	"github.com/TMS360/backend-pkg/geo"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// densify adds n evenly spaced vertices along every edge of ring, each
+// nudged off the edge by jitter meters, alternating sides.
+func densify(ring []geo.Point, n int, jitter float64) []geo.Point {
+	var out []geo.Point
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		out = append(out, a)
+		for k := 1; k <= n; k++ {
+			t := float64(k) / float64(n+1)
+			p := geo.Point{Lat: a.Lat + (b.Lat-a.Lat)*t, Lng: a.Lng + (b.Lng-a.Lng)*t}
+			off := jitter / metersPerDegree
+			if k%2 == 0 {
+				off = -off
+			}
+			// Nudge perpendicular to the edge, roughly.
+			if a.Lat == b.Lat {
+				p.Lat += off
+			} else {
+				p.Lng += off / math.Cos(p.Lat*math.Pi/180)
+			}
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func TestSimplify_DropsVerticesWithinTolerance(t *testing.T) {
+	dense := densify(square, 50, 2)
+	require.Len(t, dense, 204)
+
+	got := Simplify(dense, 5)
+	assert.Equal(t, square, got)
+
+	// Every dropped vertex is within tolerance of the simplified outline.
+	f := Polygon(uuid.New(), got)
+	for _, p := range dense {
+		assert.LessOrEqual(t, math.Abs(f.Distance(p)), 5.0)
+	}
+}
+
+func TestSimplify_KeepsShapeAboveTolerance(t *testing.T) {
+	got := Simplify(notch, 5)
+	assert.Equal(t, notch, got, "the notch is ~400 m deep, far above 5 m")
+
+	f := Polygon(uuid.New(), got)
+	assert.False(t, f.Contains(geo.Point{Lat: 41.708, Lng: -87.705}))
+}
+
+func TestSimplify_EdgeCases(t *testing.T) {
+	tri := []geo.Point{{Lat: 41, Lng: -87}, {Lat: 41, Lng: -86}, {Lat: 42, Lng: -86.5}}
+	assert.Equal(t, tri, Simplify(append(tri, tri[0]), 1e6), "a triangle is already minimal; the closing vertex is dropped")
+
+	// A huge tolerance still leaves a polygon, not a line.
+	got := Simplify(densify(square, 10, 0), 1e6)
+	assert.Len(t, got, 3)
+
+	assert.Equal(t, square, Simplify(square, 0))
+}
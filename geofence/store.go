@@ -0,0 +1,114 @@
+package geofence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// VehicleState is what the engine remembers about one vehicle.
+type VehicleState struct {
+	// LastAt is the time of the last fix evaluated.
+	LastAt time.Time `json:"last_at"`
+	// Visits holds the fences the vehicle is inside.
+	Visits map[uuid.UUID]Visit `json:"visits,omitempty"`
+}
+
+// Visit is a vehicle's stay inside one fence.
+type Visit struct {
+	EnteredAt time.Time `json:"entered_at"`
+	Dwelled   bool      `json:"dwelled,omitempty"` // the dwell event was sent
+}
+
+// StateStore keeps vehicle state between fixes. Load reports whether the
+// vehicle was found.
+type StateStore interface {
+	Load(ctx context.Context, vehicleID string) (*VehicleState, bool, error)
+	Save(ctx context.Context, vehicleID string, st *VehicleState) error
+}
+
+// DefaultStateTTL is how long a silent vehicle's state is kept. Past it, the
+// next fix from inside a fence reads as a fresh arrival.
+const DefaultStateTTL = 30 * 24 * time.Hour
+
+// RedisStore keeps state through the cache package (cache.Init must have been
+// called), so it survives restarts and is shared by replicas. With an empty
+// company id keys are scoped to the acting company, like cache.Set; feed
+// consumers have no actor and pass the id explicitly.
+type RedisStore struct {
+	companyID string
+	ttl       time.Duration
+}
+
+// NewRedisStore returns a store keeping state for DefaultStateTTL.
+func NewRedisStore(companyID string) *RedisStore {
+	return &RedisStore{companyID: companyID, ttl: DefaultStateTTL}
+}
+
+func stateKey(vehicleID string) string { return "geofence:vehicle:" + vehicleID }
+
+func (r *RedisStore) Load(ctx context.Context, vehicleID string) (*VehicleState, bool, error) {
+	var st VehicleState
+	var err error
+	if r.companyID != "" {
+		err = cache.GetGlobal(ctx, cache.ScopedKey(r.companyID, stateKey(vehicleID)), &st)
+	} else {
+		err = cache.Get(ctx, stateKey(vehicleID), &st)
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &st, true, nil
+}
+
+func (r *RedisStore) Save(ctx context.Context, vehicleID string, st *VehicleState) error {
+	if r.companyID != "" {
+		return cache.SetGlobal(ctx, cache.ScopedKey(r.companyID, stateKey(vehicleID)), st, r.ttl)
+	}
+	return cache.Set(ctx, stateKey(vehicleID), st, r.ttl)
+}
+
+// MemoryStore keeps state in-process, for tests and single-replica tools.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: map[string][]byte{}}
+}
+
+func (m *MemoryStore) Load(_ context.Context, vehicleID string) (*VehicleState, bool, error) {
+	m.mu.Lock()
+	data, ok := m.states[vehicleID]
+	m.mu.Unlock()
+	if !ok {
+		return nil, false, nil
+	}
+	var st VehicleState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, false, err
+	}
+	return &st, true, nil
+}
+
+// Save stores a copy: the caller may keep mutating st.
+func (m *MemoryStore) Save(_ context.Context, vehicleID string, st *VehicleState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[vehicleID] = data
+	return nil
+}
@@ -0,0 +1,287 @@
+package guest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/TMS360/backend-pkg/hash"
+	"github.com/TMS360/backend-pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// A share link with a Challenge is honoured only together with a guest pass:
+// an opaque value the guest earns by answering the challenge (VerifyChallenge)
+// and then sends as GuestPassHeader next to the token. The pass is bound to
+// the link, so one earned for link A opens nothing else.
+//
+//	POST /guest/challenge         {"token": "…"}                → email OTP sent
+//	POST /guest/challenge/verify  {"token": "…", "code": "…"}   → {"pass": "…"}
+//
+// The token travels in the body rather than X-Guest-Token, so the challenge
+// routes are reachable through Middleware before a pass exists.
+const (
+	GuestTokenHeader = "X-Guest-Token"
+	GuestPassHeader  = "X-Guest-Pass"
+
+	// GuestPassTTL is how long an earned pass is honoured; never past the
+	// link's own expiry.
+	GuestPassTTL = 12 * time.Hour
+	// OTPTTL is the lifetime of an emailed code.
+	OTPTTL = 10 * time.Minute
+
+	otpDigits = 6
+	// maxChallengeAttempts per challengeAttemptWindow per link, counting every
+	// verify call — a 6-digit code or a short PIN must not be guessable.
+	maxChallengeAttempts   = 5
+	challengeAttemptWindow = 15 * time.Minute
+)
+
+// OTPSender delivers an email OTP for a share link. Services implement it on
+// top of their mailer.Sender and template.
+type OTPSender interface {
+	SendShareLinkOTP(ctx context.Context, email, code string, expiresAt time.Time) error
+}
+
+// WithOTPSender enables ChallengeEmailOTP links.
+func WithOTPSender(s OTPSender) Option {
+	return func(h *Handler) {
+		h.otpSender = s
+	}
+}
+
+func otpKey(companyID, shareLinkID uuid.UUID) string {
+	return fmt.Sprintf("%s:share_link_otp:%s", companyID, shareLinkID)
+}
+
+func passKey(companyID, shareLinkID uuid.UUID, pass string) string {
+	return fmt.Sprintf("%s:share_link_pass:%s:%s", companyID, shareLinkID, digest(pass))
+}
+
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// StartChallenge begins the challenge of the link token belongs to. For an
+// email OTP link it generates, stores and sends a fresh code; a PIN link needs
+// no start and returns nil.
+func (gh *Handler) StartChallenge(ctx context.Context, token string) (ChallengeType, error) {
+	claims, data, err := gh.resolveForChallenge(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if data.Challenge != ChallengeEmailOTP {
+		return data.Challenge, nil
+	}
+	if gh.otpSender == nil {
+		return "", ErrOTPSenderNotAvailable
+	}
+	// Sending is throttled like verifying, so the endpoint cannot be used to
+	// spam the recipient.
+	if !gh.allowChallengeAttempt(ctx, claims.ShareLinkID, "send") {
+		return "", ErrChallengeLocked
+	}
+
+	code, err := randomDigits(otpDigits)
+	if err != nil {
+		return "", err
+	}
+	expiresAt := time.Now().Add(OTPTTL)
+	if err := cache.Client().Set(ctx, otpKey(claims.CompanyID, claims.ShareLinkID), digest(code), OTPTTL).Err(); err != nil {
+		return "", fmt.Errorf("store share link otp: %w", err)
+	}
+	if err := gh.otpSender.SendShareLinkOTP(ctx, data.Email, code, expiresAt); err != nil {
+		return "", fmt.Errorf("send share link otp: %w", err)
+	}
+	return data.Challenge, nil
+}
+
+// VerifyChallenge checks code (the PIN, or the emailed OTP) for the link token
+// belongs to and returns a guest pass on success. An OTP is single-use.
+func (gh *Handler) VerifyChallenge(ctx context.Context, token, code string, r *http.Request) (string, error) {
+	claims, data, err := gh.resolveForChallenge(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if !gh.allowChallengeAttempt(ctx, claims.ShareLinkID, "verify") {
+		return "", ErrChallengeLocked
+	}
+
+	ok, err := checkChallengeCode(ctx, claims.CompanyID, claims.ShareLinkID, data, code)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		gh.publishAccessEvent(ctx, claims.ShareLinkID, r, AccessEventChallengeFailed, 0, string(data.Challenge))
+		return "", ErrChallengeFailed
+	}
+
+	pass, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	ttl := GuestPassTTL
+	if data.ExpiresAt != nil {
+		ttl = min(ttl, time.Until(*data.ExpiresAt))
+	}
+	if err := cache.Client().Set(ctx, passKey(claims.CompanyID, claims.ShareLinkID, pass), "1", ttl).Err(); err != nil {
+		return "", fmt.Errorf("store guest pass: %w", err)
+	}
+	gh.publishAccessEvent(ctx, claims.ShareLinkID, r, AccessEventChallengePassed, 0, string(data.Challenge))
+	return pass, nil
+}
+
+func checkChallengeCode(ctx context.Context, companyID, shareLinkID uuid.UUID, data *ShareLinkRedisData, code string) (bool, error) {
+	if code == "" {
+		return false, nil
+	}
+	switch data.Challenge {
+	case ChallengePIN:
+		return hash.NewBcryptHasher(10).Compare(data.PINHash, code) == nil, nil
+	case ChallengeEmailOTP:
+		stored, err := cache.Client().Get(ctx, otpKey(companyID, shareLinkID)).Result()
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(digest(code))) != 1 {
+			return false, nil
+		}
+		// Single use: only the caller that deletes the code wins a race.
+		n, err := cache.Client().Del(ctx, otpKey(companyID, shareLinkID)).Result()
+		return n == 1, err
+	}
+	return false, nil
+}
+
+// resolveForChallenge loads a live, challenge-protected link for token.
+func (gh *Handler) resolveForChallenge(ctx context.Context, token string) (*ShareLinkClaims, *ShareLinkRedisData, error) {
+	claims, err := parseGuestToken(token, gh.secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := loadShareLink(ctx, claims.CompanyID, claims.ShareLinkID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := data.Check(time.Now()); err != nil {
+		return nil, nil, err
+	}
+	if data.Challenge == "" {
+		return nil, nil, ErrChallengeNotRequired
+	}
+	return claims, data, nil
+}
+
+func (gh *Handler) allowChallengeAttempt(ctx context.Context, shareLinkID uuid.UUID, op string) bool {
+	allowed, err := ratelimit.Allow(ctx, fmt.Sprintf("share_link_challenge:%s:%s", op, shareLinkID), maxChallengeAttempts, challengeAttemptWindow)
+	if err != nil {
+		slog.Warn("share link challenge throttle failed — failing open", "err", err)
+	}
+	return allowed
+}
+
+// passValid reports whether pass was earned for the link.
+func passValid(ctx context.Context, companyID, shareLinkID uuid.UUID, pass string) bool {
+	if pass == "" {
+		return false
+	}
+	n, err := cache.Client().Exists(ctx, passKey(companyID, shareLinkID, pass)).Result()
+	return err == nil && n > 0
+}
+
+func randomDigits(n int) (string, error) {
+	buf := make([]byte, n)
+	for i := range buf {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		buf[i] = byte('0' + d.Int64())
+	}
+	return string(buf), nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+type challengeRequest struct {
+	Token string `json:"token" binding:"required"`
+	Code  string `json:"code"`
+}
+
+// RegisterChallengeRoutes mounts the challenge endpoints on r (see the
+// comment at the top of this file).
+func (gh *Handler) RegisterChallengeRoutes(r gin.IRouter) {
+	r.POST("/guest/challenge", gh.startChallengeHandler)
+	r.POST("/guest/challenge/verify", gh.verifyChallengeHandler)
+}
+
+func (gh *Handler) startChallengeHandler(c *gin.Context) {
+	var req challengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	challenge, err := gh.StartChallenge(c.Request.Context(), req.Token)
+	if err != nil {
+		abortShareLink(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"challenge": challenge})
+}
+
+func (gh *Handler) verifyChallengeHandler(c *gin.Context) {
+	var req challengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	pass, err := gh.VerifyChallenge(c.Request.Context(), req.Token, req.Code, c.Request)
+	if err != nil {
+		abortShareLink(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pass": pass})
+}
+
+// abortShareLink answers a share-link failure with its code. Unknown errors
+// (Redis, bad token) collapse to 401 so nothing about the link leaks.
+func abortShareLink(c *gin.Context, err error) {
+	status := http.StatusUnauthorized
+	switch {
+	case errors.Is(err, ErrShareLinkExpired), errors.Is(err, ErrShareLinkRevoked), errors.Is(err, ErrShareLinkViewLimit):
+		status = http.StatusGone
+	case errors.Is(err, ErrShareLinkNotYetValid), errors.Is(err, ErrShareLinkScope), errors.Is(err, ErrChallengeNotRequired):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrChallengeLocked):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, ErrChallengeRequired), errors.Is(err, ErrChallengeFailed):
+		status = http.StatusUnauthorized
+	default:
+		slog.Debug("share link request failed", "err", err)
+		c.AbortWithStatusJSON(status, gin.H{"error": "Unauthorized"})
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+}
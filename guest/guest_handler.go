@@ -2,6 +2,7 @@ package guest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -18,6 +19,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 // Per-guest rate limit for @authGuest-protected resolvers. Keyed by share link
@@ -33,6 +35,7 @@ type Handler struct {
 	tm         tmsdb.TransactionManager
 	rateLimit  int
 	rateWindow time.Duration
+	otpSender  OTPSender
 }
 
 type Option func(*Handler)
@@ -58,7 +61,13 @@ func NewHandler(secret []byte, tm tmsdb.TransactionManager, opts ...Option) *Han
 	return h
 }
 
-// Middleware — Gin middleware that resolves guest token and sets actor
+// Middleware — Gin middleware that resolves guest token and sets actor.
+//
+// Besides the token and the stored link it enforces the link's lifecycle:
+// not-before/expiry, the challenge pass (see challenge.go) and the view limit.
+// A lifecycle failure aborts with its code (share_link_expired, …) so the
+// share page can explain itself; a token or link that simply does not resolve
+// still falls through as anonymous, as before.
 func (gh *Handler) Middleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if _, err := middleware.GetActor(ctx.Request.Context()); err == nil {
@@ -66,62 +75,89 @@ func (gh *Handler) Middleware() gin.HandlerFunc {
 			return
 		}
 
-		token := ctx.GetHeader("X-Guest-Token")
+		token := ctx.GetHeader(GuestTokenHeader)
 		if token == "" {
 			ctx.Next()
 			return
 		}
 
-		fmt.Printf("Received guest token: %s\n", token)
-
 		claims, err := parseGuestToken(token, gh.secret)
 		if err != nil {
-			slog.Debug("guest token parse failed", "error", err)
+			slog.DebugContext(ctx.Request.Context(), "guest token parse failed", "error", err)
 			ctx.Next()
 			return
 		}
 
-		fmt.Printf("Parsed guest token claims: %+v\n", claims)
-
 		companyID := claims.CompanyID
 
-		key := fmt.Sprintf("%s:share_link:%s", companyID, claims.ShareLinkID)
-		var data ShareLinkRedisData
-		if err := cache.Get(ctx.Request.Context(), key, &data); err != nil {
-			fmt.Printf("share link not found or revoked: %s", claims.ShareLinkID)
-			slog.Debug("share link not found or revoked", "slid", claims.ShareLinkID)
+		data, err := loadShareLink(ctx.Request.Context(), companyID, claims.ShareLinkID)
+		if errors.Is(err, ErrShareLinkRevoked) {
+			gh.denyAccess(ctx, companyID, claims.ShareLinkID, err)
+			return
+		}
+		if err != nil {
+			slog.DebugContext(ctx.Request.Context(), "share link not found or revoked", "slid", claims.ShareLinkID)
 			ctx.Next()
 			return
 		}
 
-		fmt.Printf("Fetched share link data from Redis: %+v\n", data)
-
-		resourceID, err := uuid.Parse(data.ResourceID)
+		grants, err := data.grants()
 		if err != nil {
 			slog.Debug("invalid resource ID in redis", "err", err)
 			ctx.Next()
 			return
 		}
+		resourceID := grants[0].ResourceID
+
+		if err := data.Check(time.Now()); err != nil {
+			gh.denyAccess(ctx, companyID, claims.ShareLinkID, err)
+			return
+		}
+		if data.Challenge != "" && !passValid(ctx.Request.Context(), companyID, claims.ShareLinkID, ctx.GetHeader(GuestPassHeader)) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":     ErrChallengeRequired.Error(),
+				"challenge": data.Challenge,
+			})
+			return
+		}
 
 		actor := &consts.Actor{
 			ID:      uuid.Nil,
 			IsGuest: true,
 			Claims: &consts.UserClaims{
-				CompanyID:  &companyID,
-				Resource:   data.Resource,
-				ResourceID: resourceID,
+				CompanyID:      &companyID,
+				Resource:       data.Resource,
+				ResourceID:     resourceID,
+				GuestResources: resourceNames(grants),
 			},
 		}
 
 		guestCtx := middleware.WithActor(ctx.Request.Context(), actor)
-		gh.maybeLogAccess(guestCtx, claims.ShareLinkID, ctx.Request)
+		guestCtx = WithResolvedGuest(guestCtx, &ResolvedGuest{
+			ShareLinkID: claims.ShareLinkID,
+			Resource:    data.Resource,
+			ResourceID:  resourceID,
+			CompanyID:   companyID,
+			Resources:   grants,
+			Scopes:      data.Scopes,
+			ExpiresAt:   data.ExpiresAt,
+		})
+		if err := gh.maybeLogAccess(guestCtx, companyID, claims.ShareLinkID, data, ctx.Request); err != nil {
+			gh.denyAccess(ctx, companyID, claims.ShareLinkID, err)
+			return
+		}
 
 		ctx.Request = ctx.Request.WithContext(guestCtx)
 		ctx.Next()
 	}
 }
 
-// Directive implements @authGuest(resource: "shipment")
+// Directive implements @authGuest(resource: "shipment"). Any resource the link
+// grants passes, as do leaves of a granted root (@authGuest(resource: "trip")
+// on a shipment link); resolvers then check the record with CanAccessRecord.
+// Mutations additionally
+// need a link scoped to write (ScopePODUpload); a read-only tracking link can
+// only query.
 func (gh *Handler) Directive(ctx context.Context, obj interface{}, next graphql.Resolver, resource string) (interface{}, error) {
 	actor, err := middleware.GetActor(ctx)
 	if err != nil {
@@ -131,9 +167,12 @@ func (gh *Handler) Directive(ctx context.Context, obj interface{}, next graphql.
 		return next(ctx)
 	}
 
-	if actor.Claims.Resource != resource {
+	if !guestCoversResource(ctx, actor, resource) {
 		return nil, fmt.Errorf("unauthorized: guest access not allowed for this resource")
 	}
+	if g := ResolvedGuestFromContext(ctx); g != nil && isMutation(ctx) && !g.allowsWrites() {
+		return nil, fmt.Errorf("unauthorized: share link does not allow changes")
+	}
 
 	companyID := ""
 	if actor.Claims.CompanyID != nil {
@@ -151,24 +190,117 @@ func (gh *Handler) Directive(ctx context.Context, obj interface{}, next graphql.
 	return next(ctx)
 }
 
-// maybeLogAccess debounce access logs via Redis SETNX and publishes via outbox
-func (gh *Handler) maybeLogAccess(ctx context.Context, shareLinkID uuid.UUID, r *http.Request) {
+// ScopeDirective implements @guestScope(scope: "documents") for fields only
+// some links may reach — document downloads, POD upload. Non-guests pass;
+// combine it with @authGuest, which checks the resource.
+func (gh *Handler) ScopeDirective(ctx context.Context, obj interface{}, next graphql.Resolver, scope string) (interface{}, error) {
+	actor, err := middleware.GetActor(ctx)
+	if err != nil {
+		return nil, consts.ErrUnauthorized
+	}
+	if !actor.IsGuest {
+		return next(ctx)
+	}
+	if g := ResolvedGuestFromContext(ctx); g == nil || !g.Allows(Scope(scope)) {
+		return nil, fmt.Errorf("unauthorized: share link does not include %q", scope)
+	}
+	return next(ctx)
+}
+
+// RequireScope is the REST mirror of @guestScope, for routes such as the
+// document download handlers. Non-guests pass.
+func RequireScope(scope Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		actor, err := middleware.GetActor(ctx.Request.Context())
+		if err != nil || !actor.IsGuest {
+			ctx.Next()
+			return
+		}
+		if g := ResolvedGuestFromContext(ctx.Request.Context()); g == nil || !g.Allows(scope) {
+			abortShareLink(ctx, ErrShareLinkScope)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func isMutation(ctx context.Context) bool {
+	if !graphql.HasOperationContext(ctx) {
+		return false
+	}
+	op := graphql.GetOperationContext(ctx).Operation
+	return op != nil && op.Operation == ast.Mutation
+}
+
+// maybeLogAccess counts a new view (one per IP per viewDedupeWindow, debounced
+// via Redis SETNX) against the link's limit and publishes it via outbox.
+// Repeat requests inside the window share the first request's verdict.
+func (gh *Handler) maybeLogAccess(ctx context.Context, companyID, shareLinkID uuid.UUID, data *ShareLinkRedisData, r *http.Request) error {
 	ip := resolveClientIP(r)
 	dedupeKey := fmt.Sprintf("access_seen:%s:%s", shareLinkID, ip)
 
-	set, err := cache.SetNX(ctx, dedupeKey, "1", 3*time.Minute)
-	if err != nil || !set {
-		return
+	set, err := cache.SetNX(ctx, dedupeKey, viewAllowed, viewDedupeWindow)
+	if err != nil {
+		// Fail open like the rate limiter: an uncounted view beats an outage.
+		return nil
+	}
+	if !set {
+		var verdict string
+		if err := cache.Get(ctx, dedupeKey, &verdict); err == nil && verdict == viewDenied {
+			return ErrShareLinkViewLimit
+		}
+		return nil
 	}
 
+	views, err := takeView(ctx, companyID, shareLinkID, data)
+	if errors.Is(err, ErrShareLinkViewLimit) {
+		_ = cache.Set(ctx, dedupeKey, viewDenied, viewDedupeWindow)
+		return err
+	}
+	if err != nil {
+		slog.Warn("share link view count failed", "slid", shareLinkID, "err", err)
+	}
+	gh.publishAccessEvent(ctx, shareLinkID, r, AccessEventView, views, "")
+	return nil
+}
+
+// Verdicts cached under the view dedupe key.
+const (
+	viewAllowed = "1"
+	viewDenied  = "0"
+)
+
+// denyAccess aborts a guest request on a lifecycle failure and records it in
+// the access log, debounced per IP like views.
+func (gh *Handler) denyAccess(ctx *gin.Context, companyID, shareLinkID uuid.UUID, reason error) {
+	dedupeKey := fmt.Sprintf("access_denied_seen:%s:%s", shareLinkID, resolveClientIP(ctx.Request))
+	if set, err := cache.SetNX(ctx.Request.Context(), dedupeKey, "1", viewDedupeWindow); err == nil && set {
+		views, _ := ShareLinkViews(ctx.Request.Context(), companyID, shareLinkID)
+		gh.publishAccessEvent(ctx.Request.Context(), shareLinkID, ctx.Request, AccessEventDenied, views, reason.Error())
+	}
+	abortShareLink(ctx, reason)
+}
+
+func (gh *Handler) publishAccessEvent(ctx context.Context, shareLinkID uuid.UUID, r *http.Request, kind AccessEvent, views int64, reason string) {
 	event := AccessLogEvent{
 		ShareLinkID: shareLinkID.String(),
-		IPAddress:   ip,
-		UserAgent:   r.UserAgent(),
 		AccessedAt:  time.Now().UTC().Format(time.RFC3339),
+		Event:       kind,
+		ViewCount:   views,
+		Reason:      reason,
+	}
+	if r != nil {
+		event.IPAddress = resolveClientIP(r)
+		event.UserAgent = r.UserAgent()
 	}
 
-	if err := gh.tm.Publish(ctx, "share_links", "access_log", shareLinkID, event); err != nil {
+	// The original view event kept the "access_log" action; the other kinds
+	// publish under their own name.
+	action := "access_log"
+	if kind != AccessEventView {
+		action = string(kind)
+	}
+	if err := gh.tm.Publish(ctx, "share_links", action, shareLinkID, event); err != nil {
 		slog.Error("failed to publish access log event", "err", err)
 	}
 }
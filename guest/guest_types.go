@@ -1,28 +1,79 @@
 package guest
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// ShareLinkRedisData is the share link as the owning service stores it under
+// "<companyID>:share_link:<shareLinkID>". Every lifecycle field is optional so
+// links written before they existed keep working: no scopes means the legacy
+// unrestricted link, zero MaxViews means unlimited, nil bounds mean the Redis
+// TTL alone decides the lifetime, and no Challenge means the token suffices.
 type ShareLinkRedisData struct {
 	Resource   string `json:"resource"`
 	ResourceID string `json:"resource_id"`
 	CompanyID  string `json:"company_id"`
+	// Resources are further resources the link grants besides
+	// Resource/ResourceID — e.g. a shipment's documents. A grant on an
+	// aggregate root reaches its leaves (see events.CoversResource).
+	Resources []ShareLinkResource `json:"resources,omitempty"`
+
+	Scopes    []Scope    `json:"scopes,omitempty"`
+	MaxViews  int64      `json:"max_views,omitempty"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Challenge is the second factor the guest must pass before the token is
+	// honoured. PINHash (see HashPIN) backs ChallengePIN; Email receives the
+	// code for ChallengeEmailOTP.
+	Challenge ChallengeType `json:"challenge,omitempty"`
+	PINHash   string        `json:"pin_hash,omitempty"`
+	Email     string        `json:"email,omitempty"`
 }
 
+// AccessLogEvent is published (entity "share_links") for every new view of a
+// link and for the lifecycle events around it. Event says which; ViewCount is
+// the link's view total after the event.
 type AccessLogEvent struct {
 	ShareLinkID string `json:"share_link_id"`
 	IPAddress   string `json:"ip_address"`
 	UserAgent   string `json:"user_agent"`
 	AccessedAt  string `json:"accessed_at"`
+
+	Event     AccessEvent `json:"event,omitempty"`
+	ViewCount int64       `json:"view_count,omitempty"`
+	// Reason is the denial code (ErrShareLink*) or the revoker's note.
+	Reason string `json:"reason,omitempty"`
 }
 
+// ResolvedGuest is the share link a guest request was authorized by. The
+// middleware stores it on the context (ResolvedGuestFromContext) for scope
+// checks downstream.
 type ResolvedGuest struct {
 	ShareLinkID uuid.UUID
 	Resource    string
 	ResourceID  uuid.UUID
 	CompanyID   uuid.UUID
+	// Resources is every resource the link grants, the primary one first.
+	Resources []ResourceGrant
+	Scopes    []Scope
+	ExpiresAt *time.Time
+}
+
+// ShareLinkResource is one entry of ShareLinkRedisData.Resources, in the same
+// string form as the primary resource.
+type ShareLinkResource struct {
+	Resource   string `json:"resource"`
+	ResourceID string `json:"resource_id"`
+}
+
+// ResourceGrant is a parsed resource grant of a resolved link.
+type ResourceGrant struct {
+	Resource   string
+	ResourceID uuid.UUID
 }
 
 type ShareLinkClaims struct {
@@ -0,0 +1,143 @@
+package guest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/eventlog/events"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/google/uuid"
+)
+
+// grants parses every resource the stored link grants, the primary one first.
+// Malformed extra entries are an error: a link that cannot say what it shares
+// must not be honoured partially.
+func (d *ShareLinkRedisData) grants() ([]ResourceGrant, error) {
+	primaryID, err := uuid.Parse(d.ResourceID)
+	if err != nil {
+		return nil, fmt.Errorf("share link resource id: %w", err)
+	}
+	out := make([]ResourceGrant, 0, 1+len(d.Resources))
+	out = append(out, ResourceGrant{Resource: d.Resource, ResourceID: primaryID})
+	for _, r := range d.Resources {
+		id, err := uuid.Parse(r.ResourceID)
+		if err != nil || r.Resource == "" {
+			return nil, fmt.Errorf("share link resource %q %q: invalid", r.Resource, r.ResourceID)
+		}
+		out = append(out, ResourceGrant{Resource: r.Resource, ResourceID: id})
+	}
+	return out, nil
+}
+
+func resourceNames(grants []ResourceGrant) []string {
+	names := make([]string, 0, len(grants))
+	for _, g := range grants {
+		names = append(names, g.Resource)
+	}
+	return names
+}
+
+// Covers reports whether the link reaches resource at all — a granted type, or
+// a leaf of a granted aggregate root ("trip" under a "shipment" link). It is
+// the type-level check @authGuest makes; which record is CoversRecord's.
+func (g *ResolvedGuest) Covers(resource string) bool {
+	for _, r := range g.grantList() {
+		if events.CoversResource(r.Resource, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// CoversRecord reports whether the link reaches one record: id granted
+// directly as resource, or the record's aggregate root (rootID) granted as a
+// root covering resource. Pass uuid.Nil as rootID for records that are roots
+// themselves.
+func (g *ResolvedGuest) CoversRecord(resource string, id, rootID uuid.UUID) bool {
+	for _, r := range g.grantList() {
+		if r.ResourceID == id && events.CoversResource(r.Resource, resource) {
+			return true
+		}
+		if rootID != uuid.Nil && r.ResourceID == rootID && events.IsRoot(r.Resource) && events.CoversResource(r.Resource, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantList falls back to the primary resource for a ResolvedGuest built by
+// hand (tests, older callers).
+func (g *ResolvedGuest) grantList() []ResourceGrant {
+	if len(g.Resources) > 0 {
+		return g.Resources
+	}
+	return []ResourceGrant{{Resource: g.Resource, ResourceID: g.ResourceID}}
+}
+
+// CanAccessRecord is the resolver-side check for a loaded record, the guest
+// counterpart of middleware.CanAccess: non-guests pass (their access is
+// decided by permissions), guests pass only for records their link covers.
+//
+//	if !guest.CanAccessRecord(ctx, "trip", trip.ID, trip.ShipmentID) {
+//		return nil, consts.ErrUnauthorized
+//	}
+func CanAccessRecord(ctx context.Context, resource string, id, rootID uuid.UUID) bool {
+	actor, err := middleware.GetActor(ctx)
+	if err != nil {
+		return false
+	}
+	if !actor.IsGuest {
+		return true
+	}
+	g := ResolvedGuestFromContext(ctx)
+	return g != nil && g.CoversRecord(resource, id, rootID)
+}
+
+// guestCoversResource is @authGuest's type-level check. Guests put on the
+// context without a resolved link (older setups) fall back to the claims.
+func guestCoversResource(ctx context.Context, actor *consts.Actor, resource string) bool {
+	if g := ResolvedGuestFromContext(ctx); g != nil {
+		return g.Covers(resource)
+	}
+	return events.CoversResource(actor.Claims.Resource, resource)
+}
+
+// MaskDirective implements @guestMask(scope: "rates"): a guest whose link lacks
+// scope gets the field's zero value instead of its data — nil for nullable
+// fields, so mask only nullable fields in the schema (a masked non-null field
+// would read as a real 0 or ""). Staff and links granting scope explicitly see
+// the value; a legacy link stored without scopes is masked — masking is opt-in
+// per field, and a field someone chose to mask should not leak through a link
+// that predates the choice.
+//
+//	rate: Float @guestMask(scope: "rates")
+//	driverPhone: String @guestMask(scope: "contacts")
+//
+// Unlike @guestScope the query still succeeds, so one shipment page serves a
+// broker (rates) and a consignee (masked) from the same operation.
+func (gh *Handler) MaskDirective(ctx context.Context, obj interface{}, next graphql.Resolver, scope string) (interface{}, error) {
+	actor, err := middleware.GetActor(ctx)
+	if err != nil || !actor.IsGuest {
+		return next(ctx)
+	}
+	if g := ResolvedGuestFromContext(ctx); g != nil && len(g.Scopes) > 0 && g.Allows(Scope(scope)) {
+		return next(ctx)
+	}
+	v, err := next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return maskValue(v), nil
+}
+
+// maskValue returns the zero value of v's dynamic type (nil for pointers,
+// slices and maps).
+func maskValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return reflect.Zero(reflect.TypeOf(v)).Interface()
+}
@@ -0,0 +1,96 @@
+package guest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareLinkGrants(t *testing.T) {
+	shipment, file := uuid.New(), uuid.New()
+	data := &ShareLinkRedisData{
+		Resource:   "shipment",
+		ResourceID: shipment.String(),
+		Resources:  []ShareLinkResource{{Resource: "files", ResourceID: file.String()}},
+	}
+	grants, err := data.grants()
+	require.NoError(t, err)
+	assert.Equal(t, []ResourceGrant{{"shipment", shipment}, {"files", file}}, grants)
+	assert.Equal(t, []string{"shipment", "files"}, resourceNames(grants))
+
+	data.Resources = append(data.Resources, ShareLinkResource{Resource: "files", ResourceID: "nope"})
+	_, err = data.grants()
+	assert.Error(t, err, "one malformed entry voids the link")
+}
+
+func TestResolvedGuestCovers(t *testing.T) {
+	shipment, file, trip := uuid.New(), uuid.New(), uuid.New()
+	g := &ResolvedGuest{Resources: []ResourceGrant{{"shipment", shipment}, {"files", file}}}
+
+	assert.True(t, g.Covers("shipment"))
+	assert.True(t, g.Covers("trip"), "leaf of a granted root")
+	assert.True(t, g.Covers("trip_stops"))
+	assert.True(t, g.Covers("files"))
+	assert.False(t, g.Covers("invoice"))
+
+	assert.True(t, g.CoversRecord("shipment", shipment, uuid.Nil))
+	assert.False(t, g.CoversRecord("shipment", uuid.New(), uuid.Nil))
+	assert.True(t, g.CoversRecord("trip", trip, shipment), "trip of the shared shipment")
+	assert.False(t, g.CoversRecord("trip", trip, uuid.New()), "trip of another shipment")
+	assert.True(t, g.CoversRecord("files", file, uuid.Nil))
+	assert.False(t, g.CoversRecord("files", uuid.New(), uuid.Nil))
+}
+
+func TestLeafGrantDoesNotReachSiblings(t *testing.T) {
+	trip := uuid.New()
+	g := &ResolvedGuest{Resource: "trip", ResourceID: trip}
+	assert.True(t, g.Covers("trip"))
+	assert.False(t, g.Covers("shipment"))
+	assert.False(t, g.Covers("trip_stops"))
+}
+
+func TestCanAccessRecord(t *testing.T) {
+	shipment := uuid.New()
+	ctx := middleware.WithActor(context.Background(), &consts.Actor{IsGuest: true, Claims: &consts.UserClaims{}})
+	ctx = WithResolvedGuest(ctx, &ResolvedGuest{Resources: []ResourceGrant{{"shipment", shipment}}})
+
+	assert.True(t, CanAccessRecord(ctx, "trip", uuid.New(), shipment))
+	assert.False(t, CanAccessRecord(ctx, "trip", uuid.New(), uuid.New()))
+
+	staff := middleware.WithActor(context.Background(), &consts.Actor{ID: uuid.New(), Claims: &consts.UserClaims{}})
+	assert.True(t, CanAccessRecord(staff, "trip", uuid.New(), uuid.New()))
+	assert.False(t, CanAccessRecord(context.Background(), "trip", uuid.New(), uuid.New()))
+}
+
+func TestMaskDirective(t *testing.T) {
+	gh := NewHandler([]byte("secret"), nil)
+	rate := 1250.5
+	next := func(context.Context) (interface{}, error) { return &rate, nil }
+
+	v, err := gh.MaskDirective(guestCtx(ScopeTracking), nil, next, string(ScopeRates))
+	require.NoError(t, err)
+	assert.Nil(t, v.(*float64), "consignee link without rates scope")
+
+	v, err = gh.MaskDirective(guestCtx(ScopeTracking, ScopeRates), nil, next, string(ScopeRates))
+	require.NoError(t, err)
+	assert.Equal(t, &rate, v)
+
+	v, err = gh.MaskDirective(guestCtx(), nil, next, string(ScopeRates))
+	require.NoError(t, err)
+	assert.Nil(t, v.(*float64), "legacy unscoped link is masked")
+
+	staff := middleware.WithActor(context.Background(), &consts.Actor{ID: uuid.New(), Claims: &consts.UserClaims{}})
+	v, err = gh.MaskDirective(staff, nil, next, string(ScopeRates))
+	require.NoError(t, err)
+	assert.Equal(t, &rate, v)
+
+	phone := func(context.Context) (interface{}, error) { return "+1 555 0100", nil }
+	v, err = gh.MaskDirective(guestCtx(ScopeTracking), nil, phone, string(ScopeContacts))
+	require.NoError(t, err)
+	assert.Equal(t, "", v)
+}
@@ -0,0 +1,235 @@
+package guest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/TMS360/backend-pkg/hash"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Scope is what a share link lets its guest do.
+type Scope string
+
+const (
+	// ScopeTracking — read-only tracking of the shared resource.
+	ScopeTracking Scope = "tracking"
+	// ScopeDocuments — download the resource's documents.
+	ScopeDocuments Scope = "documents"
+	// ScopePODUpload — upload proof of delivery; the only scope that lets a
+	// guest run mutations.
+	ScopePODUpload Scope = "pod_upload"
+	// ScopeRates — see rate and money fields; masked otherwise (@guestMask).
+	ScopeRates Scope = "rates"
+	// ScopeContacts — see contact details such as the driver's phone; masked
+	// otherwise.
+	ScopeContacts Scope = "contacts"
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeTracking, ScopeDocuments, ScopePODUpload, ScopeRates, ScopeContacts:
+		return true
+	}
+	return false
+}
+
+// writes reports whether the scope permits mutations.
+func (s Scope) writes() bool { return s == ScopePODUpload }
+
+// ChallengeType is the second factor a share link may require.
+type ChallengeType string
+
+const (
+	ChallengePIN      ChallengeType = "pin"
+	ChallengeEmailOTP ChallengeType = "email_otp"
+)
+
+// AccessEvent classifies an AccessLogEvent.
+type AccessEvent string
+
+const (
+	AccessEventView            AccessEvent = "view"
+	AccessEventDenied          AccessEvent = "denied"
+	AccessEventRevoked         AccessEvent = "revoked"
+	AccessEventChallengePassed AccessEvent = "challenge_passed"
+	AccessEventChallengeFailed AccessEvent = "challenge_failed"
+)
+
+// Lifecycle denials. The message doubles as the error code returned to the
+// client and the Reason of the "denied" AccessLogEvent, so the share page can
+// tell "expired" from "used up" from "revoked".
+var (
+	ErrShareLinkRevoked      = errors.New("share_link_revoked")
+	ErrShareLinkNotYetValid  = errors.New("share_link_not_yet_valid")
+	ErrShareLinkExpired      = errors.New("share_link_expired")
+	ErrShareLinkViewLimit    = errors.New("share_link_view_limit")
+	ErrShareLinkScope        = errors.New("share_link_scope")
+	ErrChallengeRequired     = errors.New("share_link_challenge_required")
+	ErrChallengeFailed       = errors.New("share_link_challenge_failed")
+	ErrChallengeLocked       = errors.New("share_link_challenge_locked")
+	ErrChallengeNotRequired  = errors.New("share_link_challenge_not_required")
+	ErrShareLinkNotFound     = errors.New("share_link_not_found")
+	ErrOTPSenderNotAvailable = errors.New("share link email OTP requested but no OTPSender configured")
+)
+
+// Lifetimes of the state kept next to a link.
+const (
+	// revokedTombstoneTTL keeps "revoked" distinguishable from "never existed"
+	// for about as long as anyone still holds the URL.
+	revokedTombstoneTTL = 30 * 24 * time.Hour
+	// viewCounterTTL bounds the counter of a link with no ExpiresAt.
+	viewCounterTTL = 90 * 24 * time.Hour
+	// viewDedupeWindow is how long repeat requests from one IP count as the
+	// same view (and the same access log entry).
+	viewDedupeWindow = 3 * time.Minute
+)
+
+// Check applies the link's time bounds at now.
+func (d *ShareLinkRedisData) Check(now time.Time) error {
+	if d.NotBefore != nil && now.Before(*d.NotBefore) {
+		return ErrShareLinkNotYetValid
+	}
+	if d.ExpiresAt != nil && !now.Before(*d.ExpiresAt) {
+		return ErrShareLinkExpired
+	}
+	return nil
+}
+
+// Allows reports whether the link grants scope. A link stored without scopes
+// predates them and stays unrestricted.
+func (d *ShareLinkRedisData) Allows(scope Scope) bool {
+	return len(d.Scopes) == 0 || slices.Contains(d.Scopes, scope)
+}
+
+// Allows is ShareLinkRedisData.Allows for the resolved link on a request.
+func (g *ResolvedGuest) Allows(scope Scope) bool {
+	return len(g.Scopes) == 0 || slices.Contains(g.Scopes, scope)
+}
+
+// allowsWrites reports whether the link may run mutations: legacy
+// unrestricted links and links carrying a writing scope.
+func (g *ResolvedGuest) allowsWrites() bool {
+	if len(g.Scopes) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(g.Scopes, Scope.writes)
+}
+
+// HashPIN hashes a share-link PIN for ShareLinkRedisData.PINHash.
+func HashPIN(pin string) (string, error) {
+	return hash.NewBcryptHasher(10).Hash(pin)
+}
+
+type resolvedGuestKey struct{}
+
+// WithResolvedGuest stores the link a guest request was authorized by.
+func WithResolvedGuest(ctx context.Context, g *ResolvedGuest) context.Context {
+	return context.WithValue(ctx, resolvedGuestKey{}, g)
+}
+
+// ResolvedGuestFromContext returns the link set by Handler.Middleware, or nil
+// for non-guest requests.
+func ResolvedGuestFromContext(ctx context.Context) *ResolvedGuest {
+	g, _ := ctx.Value(resolvedGuestKey{}).(*ResolvedGuest)
+	return g
+}
+
+// --- Redis state ------------------------------------------------------------
+//
+// All keys carry the link's company explicitly: the guest middleware runs
+// before there is an actor, and revocation runs as a staff user, so deriving
+// the prefix from the acting user would address different keys.
+
+func shareLinkKey(companyID, shareLinkID uuid.UUID) string {
+	return fmt.Sprintf("%s:share_link:%s", companyID, shareLinkID)
+}
+
+func revokedKey(companyID, shareLinkID uuid.UUID) string {
+	return fmt.Sprintf("%s:share_link_revoked:%s", companyID, shareLinkID)
+}
+
+func viewsKey(companyID, shareLinkID uuid.UUID) string {
+	return fmt.Sprintf("%s:share_link_views:%s", companyID, shareLinkID)
+}
+
+// loadShareLink returns the stored link, ErrShareLinkRevoked when it was
+// revoked, or ErrShareLinkNotFound.
+func loadShareLink(ctx context.Context, companyID, shareLinkID uuid.UUID) (*ShareLinkRedisData, error) {
+	var data ShareLinkRedisData
+	err := cache.GetGlobal(ctx, shareLinkKey(companyID, shareLinkID), &data)
+	if err == nil {
+		return &data, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if n, xerr := cache.Client().Exists(ctx, revokedKey(companyID, shareLinkID)).Result(); xerr == nil && n > 0 {
+		return nil, ErrShareLinkRevoked
+	}
+	return nil, ErrShareLinkNotFound
+}
+
+// ShareLinkViews returns how many distinct views the link has served.
+func ShareLinkViews(ctx context.Context, companyID, shareLinkID uuid.UUID) (int64, error) {
+	n, err := cache.Client().Get(ctx, viewsKey(companyID, shareLinkID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// takeView counts one new view against the link's limit. It returns the view
+// total and ErrShareLinkViewLimit (without consuming a view) once the limit is
+// reached.
+func takeView(ctx context.Context, companyID, shareLinkID uuid.UUID, data *ShareLinkRedisData) (int64, error) {
+	rdb := cache.Client()
+	key := viewsKey(companyID, shareLinkID)
+	n, err := rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		ttl := viewCounterTTL
+		if data.ExpiresAt != nil {
+			ttl = time.Until(*data.ExpiresAt) + viewDedupeWindow
+		}
+		rdb.Expire(ctx, key, ttl)
+	}
+	if data.MaxViews > 0 && n > data.MaxViews {
+		rdb.Decr(ctx, key)
+		return n - 1, ErrShareLinkViewLimit
+	}
+	return n, nil
+}
+
+// RevokeShareLink ends a link immediately: the stored link is deleted, a
+// tombstone lets the guest page say "revoked" rather than "not found", and a
+// "revoked" AccessLogEvent carrying the final view count is published. reason
+// is the revoker's note and may be empty.
+func (gh *Handler) RevokeShareLink(ctx context.Context, companyID, shareLinkID uuid.UUID, reason string) error {
+	rdb := cache.Client()
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, shareLinkKey(companyID, shareLinkID), otpKey(companyID, shareLinkID))
+	pipe.Set(ctx, revokedKey(companyID, shareLinkID), "1", revokedTombstoneTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("revoke share link: %w", err)
+	}
+
+	views, err := ShareLinkViews(ctx, companyID, shareLinkID)
+	if err != nil {
+		return fmt.Errorf("revoke share link: read views: %w", err)
+	}
+	return gh.tm.Publish(ctx, "share_links", string(AccessEventRevoked), shareLinkID, AccessLogEvent{
+		ShareLinkID: shareLinkID.String(),
+		AccessedAt:  time.Now().UTC().Format(time.RFC3339),
+		Event:       AccessEventRevoked,
+		ViewCount:   views,
+		Reason:      reason,
+	})
+}
@@ -0,0 +1,145 @@
+package guest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestShareLinkCheck(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	assert.NoError(t, (&ShareLinkRedisData{}).Check(now), "legacy link has no bounds")
+	assert.NoError(t, (&ShareLinkRedisData{NotBefore: &before, ExpiresAt: &after}).Check(now))
+	assert.ErrorIs(t, (&ShareLinkRedisData{NotBefore: &after}).Check(now), ErrShareLinkNotYetValid)
+	assert.ErrorIs(t, (&ShareLinkRedisData{ExpiresAt: &before}).Check(now), ErrShareLinkExpired)
+	assert.ErrorIs(t, (&ShareLinkRedisData{ExpiresAt: &now}).Check(now), ErrShareLinkExpired, "expiry is exclusive")
+}
+
+func TestShareLinkScopes(t *testing.T) {
+	legacy := &ResolvedGuest{}
+	assert.True(t, legacy.Allows(ScopeDocuments))
+	assert.True(t, legacy.allowsWrites())
+
+	tracking := &ResolvedGuest{Scopes: []Scope{ScopeTracking}}
+	assert.True(t, tracking.Allows(ScopeTracking))
+	assert.False(t, tracking.Allows(ScopeDocuments))
+	assert.False(t, tracking.allowsWrites())
+
+	pod := &ResolvedGuest{Scopes: []Scope{ScopeTracking, ScopePODUpload}}
+	assert.True(t, pod.allowsWrites())
+}
+
+func guestCtx(scopes ...Scope) context.Context {
+	companyID := uuid.New()
+	ctx := middleware.WithActor(context.Background(), &consts.Actor{
+		IsGuest: true,
+		Claims:  &consts.UserClaims{CompanyID: &companyID, Resource: "shipment", ResourceID: uuid.New()},
+	})
+	return WithResolvedGuest(ctx, &ResolvedGuest{CompanyID: companyID, Resource: "shipment", Scopes: scopes})
+}
+
+func withOperation(ctx context.Context, op ast.Operation) context.Context {
+	return graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: op},
+	})
+}
+
+func TestDirective_ReadOnlyLinkCannotMutate(t *testing.T) {
+	gh := NewHandler([]byte("secret"), nil)
+	next := func(context.Context) (interface{}, error) { return "ok", nil }
+
+	_, err := gh.Directive(withOperation(guestCtx(ScopeTracking), ast.Query), nil, next, "shipment")
+	assert.NoError(t, err)
+
+	_, err = gh.Directive(withOperation(guestCtx(ScopeTracking), ast.Mutation), nil, next, "shipment")
+	assert.Error(t, err)
+
+	_, err = gh.Directive(withOperation(guestCtx(ScopePODUpload), ast.Mutation), nil, next, "shipment")
+	assert.NoError(t, err)
+}
+
+func TestScopeDirective(t *testing.T) {
+	gh := NewHandler([]byte("secret"), nil)
+	next := func(context.Context) (interface{}, error) { return "ok", nil }
+
+	_, err := gh.ScopeDirective(guestCtx(ScopeTracking), nil, next, string(ScopeDocuments))
+	assert.Error(t, err)
+	_, err = gh.ScopeDirective(guestCtx(ScopeDocuments), nil, next, string(ScopeDocuments))
+	assert.NoError(t, err)
+
+	staff := middleware.WithActor(context.Background(), &consts.Actor{ID: uuid.New(), Claims: &consts.UserClaims{}})
+	_, err = gh.ScopeDirective(staff, nil, next, string(ScopeDocuments))
+	assert.NoError(t, err, "non-guests are not scoped")
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(ctx context.Context) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(func(c *gin.Context) { c.Request = c.Request.WithContext(ctx); c.Next() })
+		r.GET("/docs", RequireScope(ScopeDocuments), func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve(guestCtx(ScopeDocuments)).Code)
+	w := serve(guestCtx(ScopeTracking))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), ErrShareLinkScope.Error())
+}
+
+func TestAbortShareLinkStatuses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := map[error]int{
+		ErrShareLinkExpired:     http.StatusGone,
+		ErrShareLinkRevoked:     http.StatusGone,
+		ErrShareLinkViewLimit:   http.StatusGone,
+		ErrShareLinkNotYetValid: http.StatusForbidden,
+		ErrChallengeRequired:    http.StatusUnauthorized,
+		ErrChallengeLocked:      http.StatusTooManyRequests,
+		ErrShareLinkNotFound:    http.StatusUnauthorized,
+	}
+	for err, want := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		abortShareLink(c, err)
+		assert.Equal(t, want, w.Code, err.Error())
+	}
+}
+
+func TestRandomDigits(t *testing.T) {
+	code, err := randomDigits(otpDigits)
+	require.NoError(t, err)
+	assert.Len(t, code, otpDigits)
+	for _, r := range code {
+		assert.True(t, r >= '0' && r <= '9')
+	}
+}
+
+func TestPINHashRoundTrip(t *testing.T) {
+	h, err := HashPIN("4821")
+	require.NoError(t, err)
+	data := &ShareLinkRedisData{Challenge: ChallengePIN, PINHash: h}
+
+	ok, err := checkChallengeCode(context.Background(), uuid.Nil, uuid.Nil, data, "4821")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = checkChallengeCode(context.Background(), uuid.Nil, uuid.Nil, data, "0000")
+	assert.False(t, ok)
+	ok, _ = checkChallengeCode(context.Background(), uuid.Nil, uuid.Nil, data, "")
+	assert.False(t, ok)
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+)
+
+// Pinger is anything with a context-aware Ping — *clickhouse.Client,
+// *sql.DB.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Tester is anything with the TestConnection every integration client and
+// factoring/toll provider exposes.
+type Tester interface {
+	TestConnection(ctx context.Context) error
+}
+
+// PingCheck checks a Pinger.
+func PingCheck(p Pinger) CheckFunc {
+	return p.Ping
+}
+
+// ConnectionCheck checks an integration client through its TestConnection.
+// Most of those calls are billed or rate limited — register them NonCritical
+// with a long WithTTL.
+func ConnectionCheck(t Tester) CheckFunc {
+	return t.TestConnection
+}
+
+// GormCheck pings the database behind db.
+func GormCheck(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// RedisCheck pings Redis.
+func RedisCheck(rdb redis.UniversalClient) CheckFunc {
+	return func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}
+}
+
+// KafkaCheck dials the first reachable broker in brokers (comma-separated
+// lists are accepted) and reads the cluster's broker list.
+func KafkaCheck(brokers ...string) CheckFunc {
+	var addrs []string
+	for _, b := range brokers {
+		for _, a := range strings.Split(b, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				addrs = append(addrs, a)
+			}
+		}
+	}
+	return func(ctx context.Context) error {
+		if len(addrs) == 0 {
+			return errors.New("kafka: no brokers configured")
+		}
+		var errs []error
+		for _, addr := range addrs {
+			conn, err := kafka.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if dl, ok := ctx.Deadline(); ok {
+				_ = conn.SetDeadline(dl)
+			}
+			_, err = conn.Brokers()
+			_ = conn.Close()
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return fmt.Errorf("kafka: no broker reachable: %w", errors.Join(errs...))
+	}
+}
+
+// TCPCheck only dials addr — for dependencies with no cheaper probe.
+func TCPCheck(addr string) CheckFunc {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// LiveHandler answers the liveness probe: 200 unless a liveness check is
+// down. Mount it outside the auth chain.
+func (r *Registry) LiveHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeReport(c, r.Live(c.Request.Context()))
+	}
+}
+
+// ReadyHandler answers the readiness probe: 503 when a critical check is
+// down, 200 (with status "degraded") when only non-critical ones are.
+// ?check=<name> narrows the answer to one check.
+func (r *Registry) ReadyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if name := c.Query("check"); name != "" {
+			res, err := r.CheckOne(c.Request.Context(), name)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			rep := Report{Status: res.Status, Checks: map[string]Result{name: res}}
+			writeReport(c, rep)
+			return
+		}
+		writeReport(c, r.Ready(c.Request.Context()))
+	}
+}
+
+func writeReport(c *gin.Context, rep Report) {
+	code := http.StatusOK
+	if rep.Status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, rep)
+}
+
+// WatchInterval is how often GRPCServer re-evaluates for Watch streams.
+const WatchInterval = 5 * time.Second
+
+// GRPCServer adapts the registry to grpc.health.v1.Health. The empty service
+// name is overall readiness; any other name is the registered check of that
+// name. Degraded counts as SERVING.
+//
+//	healthpb.RegisterHealthServer(srv, reg.GRPCServer())
+func (r *Registry) GRPCServer() healthpb.HealthServer {
+	return &grpcServer{reg: r}
+}
+
+type grpcServer struct {
+	healthpb.UnimplementedHealthServer
+	reg *Registry
+}
+
+func (s *grpcServer) servingStatus(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	var st Status
+	if service == "" {
+		st = s.reg.Ready(ctx).Status
+	} else {
+		res, err := s.reg.CheckOne(ctx, service)
+		if err != nil {
+			return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, status.Error(codes.NotFound, err.Error())
+		}
+		st = res.Status
+	}
+	if st == StatusDown {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
+
+func (s *grpcServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, err := s.servingStatus(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the current status, then every change until the client goes
+// away. An unknown service is reported as SERVICE_UNKNOWN, per the protocol.
+func (s *grpcServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		st, _ := s.servingStatus(ctx, req.GetService())
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *grpcServer) List(ctx context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	rep := s.reg.Ready(ctx)
+	out := &healthpb.HealthListResponse{Statuses: map[string]*healthpb.HealthCheckResponse{}}
+	overall := healthpb.HealthCheckResponse_SERVING
+	if rep.Status == StatusDown {
+		overall = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	out.Statuses[""] = &healthpb.HealthCheckResponse{Status: overall}
+	for name, res := range rep.Checks {
+		st := healthpb.HealthCheckResponse_SERVING
+		if res.Status == StatusDown {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		out.Statuses[name] = &healthpb.HealthCheckResponse{Status: st}
+	}
+	return out, nil
+}
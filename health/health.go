@@ -0,0 +1,265 @@
+// Package health aggregates dependency checks into liveness and readiness.
+// Components register a check once at boot — Postgres, Redis, ClickHouse,
+// Kafka, an external API's TestConnection — with a criticality and a timeout;
+// the registry runs them concurrently, caches each result for its TTL so a
+// probe storm never reaches the dependency, and serves the report over Gin
+// (LiveHandler, ReadyHandler) and the standard gRPC health service
+// (GRPCServer).
+//
+//	reg := health.NewRegistry()
+//	reg.Register("postgres", health.GormCheck(db))
+//	reg.Register("redis", health.RedisCheck(rdb))
+//	reg.Register("here", hereClient.TestConnection, health.NonCritical(), health.WithTTL(5*time.Minute))
+//	r.GET("/healthz", reg.LiveHandler())
+//	r.GET("/readyz", reg.ReadyHandler())
+//
+// Tenants' own integrations (one credential per company) are checked
+// separately, on demand, by Integrations.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Status is the state of one check or of the whole report.
+type Status string
+
+const (
+	// StatusUp means the check passed.
+	StatusUp Status = "up"
+	// StatusDegraded means only non-critical checks failed: still ready.
+	StatusDegraded Status = "degraded"
+	// StatusDown means a critical check failed: not ready.
+	StatusDown Status = "down"
+	// StatusNotConfigured is reported by Integrations for a tenant that has
+	// no credential for the integration.
+	StatusNotConfigured Status = "not_configured"
+)
+
+// CheckFunc probes one dependency. A nil error is healthy.
+type CheckFunc func(ctx context.Context) error
+
+const (
+	// DefaultTimeout bounds one check run.
+	DefaultTimeout = 3 * time.Second
+	// DefaultTTL is how long a result is served from cache. Readiness probes
+	// arrive every few seconds from every replica's kubelet; the dependency
+	// sees at most one check per TTL per process.
+	DefaultTTL = 10 * time.Second
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Status     Status    `json:"status"`
+	Critical   bool      `json:"critical"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report is the aggregate of a registry run.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+	timeout  time.Duration
+	ttl      time.Duration
+
+	mu     sync.Mutex
+	last   Result
+	cached bool
+}
+
+// CheckOption configures one registered check.
+type CheckOption func(*check)
+
+// NonCritical marks a check whose failure degrades the report without
+// taking the service out of rotation — an external API the service can work
+// around, as opposed to its own database.
+func NonCritical() CheckOption { return func(c *check) { c.critical = false } }
+
+// WithTimeout bounds the check; non-positive values are ignored.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// WithTTL sets how long the check's result is cached. Paid external APIs
+// deserve minutes, not seconds.
+func WithTTL(d time.Duration) CheckOption {
+	return func(c *check) {
+		if d > 0 {
+			c.ttl = d
+		}
+	}
+}
+
+// Registry holds the registered checks. The zero value is not usable; call
+// NewRegistry.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]*check
+	live   map[string]*check
+	sf     singleflight.Group
+	now    func() time.Time
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: map[string]*check{},
+		live:   map[string]*check{},
+		now:    time.Now,
+	}
+}
+
+// Register adds a readiness check. Checks are critical unless NonCritical is
+// passed. Registering a name twice replaces the earlier check.
+func (r *Registry) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	r.add(r.checks, name, fn, opts)
+}
+
+// RegisterLiveness adds a check that decides liveness — only for failures a
+// restart fixes (a wedged worker loop), never for dependencies: a database
+// outage must not make the orchestrator kill every replica.
+func (r *Registry) RegisterLiveness(name string, fn CheckFunc, opts ...CheckOption) {
+	r.add(r.live, name, fn, opts)
+}
+
+func (r *Registry) add(into map[string]*check, name string, fn CheckFunc, opts []CheckOption) {
+	c := &check{name: name, fn: fn, critical: true, timeout: DefaultTimeout, ttl: DefaultTTL}
+	for _, o := range opts {
+		o(c)
+	}
+	r.mu.Lock()
+	into[name] = c
+	r.mu.Unlock()
+}
+
+// Ready runs (or serves from cache) every readiness check.
+func (r *Registry) Ready(ctx context.Context) Report {
+	return r.run(ctx, r.snapshot(r.checks))
+}
+
+// Live runs the liveness checks; with none registered the process is live.
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.run(ctx, r.snapshot(r.live))
+}
+
+// CheckOne runs a single readiness check by name.
+func (r *Registry) CheckOne(ctx context.Context, name string) (Result, error) {
+	r.mu.RLock()
+	c, ok := r.checks[name]
+	r.mu.RUnlock()
+	if !ok {
+		return Result{}, fmt.Errorf("health: no check %q", name)
+	}
+	return r.result(ctx, c), nil
+}
+
+func (r *Registry) snapshot(m map[string]*check) []*check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*check, 0, len(m))
+	for _, c := range m {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+func (r *Registry) run(ctx context.Context, checks []*check) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.result(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		res := results[i]
+		rep.Checks[c.name] = res
+		if res.Status == StatusUp {
+			continue
+		}
+		if c.critical {
+			rep.Status = StatusDown
+		} else if rep.Status == StatusUp {
+			rep.Status = StatusDegraded
+		}
+	}
+	return rep
+}
+
+// result serves a cached result while fresh; otherwise one caller runs the
+// check (singleflight) and the rest wait for it.
+func (r *Registry) result(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	if c.cached && r.now().Sub(c.last.CheckedAt) < c.ttl {
+		res := c.last
+		c.mu.Unlock()
+		return res
+	}
+	c.mu.Unlock()
+
+	v, _, _ := r.sf.Do(c.name, func() (interface{}, error) {
+		res := runCheck(ctx, c.fn, c.timeout, r.now)
+		res.Critical = c.critical
+		c.mu.Lock()
+		c.last, c.cached = res, true
+		c.mu.Unlock()
+		return res, nil
+	})
+	return v.(Result)
+}
+
+// runCheck executes fn under timeout, converting a panic into a failure so
+// one broken check cannot take the probe endpoint down.
+func runCheck(ctx context.Context, fn CheckFunc, timeout time.Duration, now func() time.Time) (res Result) {
+	// Detached from the probe's request context: a kubelet that gives up
+	// early must not poison the cached result with "context canceled".
+	cctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	start := now()
+	defer func() {
+		if p := recover(); p != nil {
+			res = Result{Status: StatusDown, Error: fmt.Sprintf("panic: %v", p), CheckedAt: start, DurationMS: now().Sub(start).Milliseconds()}
+		}
+	}()
+
+	err := fn(cctx)
+	if err == nil && cctx.Err() != nil {
+		err = cctx.Err()
+	}
+	res = Result{Status: StatusUp, CheckedAt: start, DurationMS: now().Sub(start).Milliseconds()}
+	if err != nil {
+		res.Status = StatusDown
+		if errors.Is(err, context.DeadlineExceeded) {
+			res.Error = fmt.Sprintf("timed out after %s", timeout)
+		} else {
+			res.Error = err.Error()
+		}
+	}
+	return res
+}
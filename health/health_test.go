@@ -0,0 +1,168 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func ok(context.Context) error   { return nil }
+func fail(context.Context) error { return errors.New("connection refused") }
+
+func TestReadyAggregatesByCriticality(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("postgres", ok)
+	reg.Register("here", fail, NonCritical())
+	rep := reg.Ready(context.Background())
+	assert.Equal(t, StatusDegraded, rep.Status)
+	assert.Equal(t, StatusDown, rep.Checks["here"].Status)
+	assert.Equal(t, "connection refused", rep.Checks["here"].Error)
+
+	reg.Register("redis", fail)
+	assert.Equal(t, StatusDown, reg.Ready(context.Background()).Status)
+	assert.Equal(t, StatusUp, reg.Live(context.Background()).Status, "dependencies never decide liveness")
+}
+
+func TestResultsAreCachedForTTL(t *testing.T) {
+	reg := NewRegistry()
+	now := time.Now()
+	reg.now = func() time.Time { return now }
+	var calls atomic.Int32
+	reg.Register("samsara", func(context.Context) error { calls.Add(1); return nil }, WithTTL(time.Minute))
+
+	for i := 0; i < 5; i++ {
+		reg.Ready(context.Background())
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	now = now.Add(2 * time.Minute)
+	reg.Ready(context.Background())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestTimeoutAndPanicAreFailures(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("slow", func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }, WithTimeout(10*time.Millisecond))
+	reg.Register("broken", func(context.Context) error { panic("nil map") })
+
+	rep := reg.Ready(context.Background())
+	assert.Equal(t, "timed out after 10ms", rep.Checks["slow"].Error)
+	assert.Equal(t, "panic: nil map", rep.Checks["broken"].Error)
+}
+
+func TestReadyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := NewRegistry()
+	reg.Register("postgres", fail)
+	reg.Register("clickhouse", ok, NonCritical())
+	r := gin.New()
+	r.GET("/readyz", reg.ReadyHandler())
+	r.GET("/healthz", reg.LiveHandler())
+
+	for path, want := range map[string]int{
+		"/readyz":                  http.StatusServiceUnavailable,
+		"/readyz?check=clickhouse": http.StatusOK,
+		"/readyz?check=nope":       http.StatusNotFound,
+		"/healthz":                 http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, rec.Code, path)
+	}
+}
+
+func TestGRPCHealth(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register("postgres", ok)
+	reg.Register("kafka", fail, NonCritical())
+	srv := reg.GRPCServer()
+
+	resp, err := srv.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status, "degraded still serves")
+
+	resp, err = srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "kafka"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	_, err = srv.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "nope"})
+	assert.Error(t, err)
+}
+
+type fakeClient struct{ err error }
+
+func (c *fakeClient) TestConnection(context.Context) error { return c.err }
+
+type fakeSource map[string]*fakeClient
+
+func (s fakeSource) GetByCompanyID(_ context.Context, companyID string) (*fakeClient, error) {
+	c, ok := s[companyID]
+	if !ok {
+		return nil, fmt.Errorf("provider: samsara_api_key not found for company %s: %w", companyID, redis.Nil)
+	}
+	return c, nil
+}
+
+func TestIntegrationsPerTenant(t *testing.T) {
+	configured, broken, absent := uuid.New(), uuid.New(), uuid.New()
+	src := fakeSource{
+		configured.String(): {},
+		broken.String():     {err: errors.New("401 unauthorized")},
+	}
+	ints := NewIntegrations(0, 0)
+	ints.Register("samsara", FromProvider[*fakeClient](src))
+
+	assert.Equal(t, StatusUp, ints.Check(context.Background(), configured.String()).Status)
+
+	rep := ints.Check(context.Background(), broken.String())
+	assert.Equal(t, StatusDegraded, rep.Status)
+	assert.Equal(t, "401 unauthorized", rep.Checks["samsara"].Error)
+
+	rep = ints.Check(context.Background(), absent.String())
+	assert.Equal(t, StatusUp, rep.Status)
+	assert.Equal(t, StatusNotConfigured, rep.Checks["samsara"].Status)
+
+	// Cached until invalidated.
+	src[broken.String()].err = nil
+	assert.Equal(t, StatusDegraded, ints.Check(context.Background(), broken.String()).Status)
+	ints.Invalidate(broken.String())
+	assert.Equal(t, StatusUp, ints.Check(context.Background(), broken.String()).Status)
+}
+
+func TestIntegrationsHandlerUsesActorCompany(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	company := uuid.New()
+	ints := NewIntegrations(0, 0)
+	var asked string
+	ints.Register("here", func(_ context.Context, companyID string) error { asked = companyID; return nil })
+
+	serve := func(actor *consts.Actor) int {
+		r := gin.New()
+		r.GET("/integrations/health", func(c *gin.Context) {
+			if actor != nil {
+				c.Request = c.Request.WithContext(middleware.WithActor(c.Request.Context(), actor))
+			}
+		}, ints.Handler())
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/integrations/health?company_id=other", nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(nil))
+	assert.Equal(t, http.StatusOK, serve(&consts.Actor{ID: uuid.New(), Claims: &consts.UserClaims{CompanyID: &company}}))
+	assert.Equal(t, company.String(), asked)
+}
@@ -0,0 +1,177 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// TenantCheckFunc checks one integration for one company.
+type TenantCheckFunc func(ctx context.Context, companyID string) error
+
+// ErrNotConfigured reports that the company has no credential for the
+// integration. Provider lookups that fail on a missing Redis setting are
+// recognised without it (see FromProvider).
+var ErrNotConfigured = errors.New("health: integration not configured")
+
+// DefaultIntegrationTTL caches a tenant's integration result. TestConnection
+// calls are billed, and the settings page that shows them is reloaded often.
+const DefaultIntegrationTTL = 5 * time.Minute
+
+// ClientSource is the shape of provider.ClientProvider and
+// provider.JSONClientProvider: a per-company client built from the tenant's
+// stored credential.
+type ClientSource[T Tester] interface {
+	GetByCompanyID(ctx context.Context, companyID string) (T, error)
+}
+
+// FromProvider checks a multi-credential integration: it builds the
+// company's client from its stored credential and calls TestConnection. A
+// missing credential reports ErrNotConfigured.
+//
+//	ints.Register("samsara", health.FromProvider[*samsara.Client](samsaraProvider))
+func FromProvider[T Tester](src ClientSource[T]) TenantCheckFunc {
+	return func(ctx context.Context, companyID string) error {
+		client, err := src.GetByCompanyID(ctx, companyID)
+		if errors.Is(err, redis.Nil) {
+			return ErrNotConfigured
+		}
+		if err != nil {
+			return err
+		}
+		return client.TestConnection(ctx)
+	}
+}
+
+// Integrations checks tenants' own integrations — the ones configured per
+// company rather than per service. Unlike Registry nothing runs at probe
+// time: a company's integrations are checked when asked, results cached per
+// (integration, company).
+type Integrations struct {
+	mu      sync.RWMutex
+	checks  map[string]TenantCheckFunc
+	timeout time.Duration
+	ttl     time.Duration
+
+	cacheMu sync.Mutex
+	cache   map[string]Result
+	now     func() time.Time
+}
+
+// NewIntegrations creates an empty set. Non-positive timeout or ttl use
+// DefaultTimeout and DefaultIntegrationTTL.
+func NewIntegrations(timeout, ttl time.Duration) *Integrations {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if ttl <= 0 {
+		ttl = DefaultIntegrationTTL
+	}
+	return &Integrations{
+		checks:  map[string]TenantCheckFunc{},
+		timeout: timeout,
+		ttl:     ttl,
+		cache:   map[string]Result{},
+		now:     time.Now,
+	}
+}
+
+// Register adds an integration.
+func (in *Integrations) Register(name string, fn TenantCheckFunc) {
+	in.mu.Lock()
+	in.checks[name] = fn
+	in.mu.Unlock()
+}
+
+// Check runs every integration for companyID. Integrations the company has
+// not configured report StatusNotConfigured and do not affect the overall
+// status.
+func (in *Integrations) Check(ctx context.Context, companyID string) Report {
+	in.mu.RLock()
+	names := make([]string, 0, len(in.checks))
+	for name := range in.checks {
+		names = append(names, name)
+	}
+	in.mu.RUnlock()
+	sort.Strings(names)
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = in.result(ctx, name, companyID)
+		}()
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusUp, Checks: make(map[string]Result, len(names))}
+	for i, name := range names {
+		rep.Checks[name] = results[i]
+		if results[i].Status == StatusDown {
+			rep.Status = StatusDegraded
+		}
+	}
+	return rep
+}
+
+// Invalidate drops cached results for a company — call it after the
+// company saves new credentials.
+func (in *Integrations) Invalidate(companyID string) {
+	in.cacheMu.Lock()
+	defer in.cacheMu.Unlock()
+	for key := range in.cache {
+		if _, cid, _ := strings.Cut(key, "\x00"); cid == companyID {
+			delete(in.cache, key)
+		}
+	}
+}
+
+func (in *Integrations) result(ctx context.Context, name, companyID string) Result {
+	key := name + "\x00" + companyID
+	in.cacheMu.Lock()
+	if res, ok := in.cache[key]; ok && in.now().Sub(res.CheckedAt) < in.ttl {
+		in.cacheMu.Unlock()
+		return res
+	}
+	in.cacheMu.Unlock()
+
+	in.mu.RLock()
+	fn := in.checks[name]
+	in.mu.RUnlock()
+	var checkErr error
+	res := runCheck(ctx, func(ctx context.Context) error {
+		checkErr = fn(ctx, companyID)
+		return checkErr
+	}, in.timeout, in.now)
+	if errors.Is(checkErr, ErrNotConfigured) {
+		res.Status, res.Error = StatusNotConfigured, ""
+	}
+
+	in.cacheMu.Lock()
+	in.cache[key] = res
+	in.cacheMu.Unlock()
+	return res
+}
+
+// Handler reports the calling company's integrations. It sits behind the
+// auth chain; the company comes from the actor, never from the request.
+func (in *Integrations) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, err := middleware.GetActor(c.Request.Context())
+		if err != nil || actor == nil || actor.IsGuest || actor.GetCompanyID() == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.JSON(http.StatusOK, in.Check(c.Request.Context(), actor.GetCompanyID().String()))
+	}
+}
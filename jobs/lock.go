@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+// Locker hands out the per-job mutex that makes a job run once per cluster
+// rather than once per replica. TryLock never blocks: ok=false means another
+// replica holds the lock and this tick should be skipped.
+type Locker interface {
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(context.Context) error, ok bool, err error)
+}
+
+// LocalLocker always grants the lock. It is the default for a runner without
+// WithLocker — correct for a single-replica service and for tests.
+type LocalLocker struct{}
+
+func (LocalLocker) TryLock(context.Context, string, time.Duration) (func(context.Context) error, bool, error) {
+	return func(context.Context) error { return nil }, true, nil
+}
+
+// unlockScript deletes the lock only if it still holds our token. Without the
+// compare, a run that outlived its TTL would delete the lock a second replica
+// has since taken, letting a third one start alongside it.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker is a SET NX PX lock on a shared Redis — the same instance every
+// replica already talks to through cache.Init. Keys are global (not
+// tenant-prefixed): the lock is about the job, not about whoever is acting.
+type RedisLocker struct {
+	rdb *redis.Client
+}
+
+func NewRedisLocker(rdb *redis.Client) *RedisLocker {
+	return &RedisLocker{rdb: rdb}
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(context.Context) error, bool, error) {
+	token := uuid.NewString()
+	ok, err := l.rdb.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return func(ctx context.Context) error {
+		return unlockScript.Run(ctx, l.rdb, []string{key}, token).Err()
+	}, true, nil
+}
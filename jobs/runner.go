@@ -0,0 +1,456 @@
+// Package jobs runs named background jobs on a cron expression or a fixed
+// interval, replacing the hand-rolled ticker goroutine every service used to
+// write around observability.RecoverGoroutine.
+//
+// A job can fan out per company: the runner lists tenants and calls the job
+// once per company with a system actor carrying that CompanyID, so
+// tenant-scoped repositories and cache keys behave exactly as they do inside a
+// request. With a RedisLocker the job runs once per cluster, not once per
+// replica; with a RedisStore its pause flag and last-run status are shared.
+//
+//	runner := jobs.NewRunner(
+//		jobs.WithLocker(jobs.NewRedisLocker(rdb)),
+//		jobs.WithStore(jobs.NewRedisStore(rdb)),
+//		jobs.WithCompanies(companyRepo.ListActiveIDs),
+//	)
+//	runner.MustRegister(jobs.Job{
+//		Name:       "expire-quotes",
+//		Schedule:   jobs.MustCron("*/15 * * * *"),
+//		PerCompany: true,
+//		Run:        quoteSvc.ExpireStale,
+//	})
+//	go runner.Start(ctx)
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/observability"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrJobNotFound      = errors.New("jobs: job not found")
+	ErrJobExists        = errors.New("jobs: job already registered")
+	ErrJobRunning       = errors.New("jobs: job is already running")
+	ErrJobLocked        = errors.New("jobs: job is running on another replica")
+	ErrNoCompanyLister  = errors.New("jobs: per-company job registered without WithCompanies")
+	ErrInvalidJobConfig = errors.New("jobs: invalid job")
+)
+
+const (
+	// DefaultTimeout bounds a single run (the whole per-company fan-out, not
+	// each tenant) when Job.Timeout is zero.
+	DefaultTimeout = 5 * time.Minute
+
+	defaultTick = time.Second
+)
+
+// Job is one named unit of background work.
+type Job struct {
+	// Name is the stable identifier used for the lock, the status record and
+	// Trigger/Pause. Renaming a job orphans its history.
+	Name     string
+	Schedule Schedule
+	// Run does the work. For a PerCompany job ctx carries a system actor whose
+	// Claims.CompanyID is the tenant being processed: creates are stamped with
+	// it and cache keys are prefixed by it. Note that TenantScopePlugin does not
+	// filter reads for system actors, so queries must still filter on
+	// actor.GetCompanyID() explicitly.
+	Run func(ctx context.Context) error
+	// PerCompany calls Run once per company returned by the runner's
+	// CompanyLister. One tenant failing does not stop the others; the errors
+	// are joined into the run's LastError.
+	PerCompany bool
+	// Timeout bounds one run; zero means DefaultTimeout.
+	Timeout time.Duration
+	// LockTTL is how long the cluster lock is held if the replica dies
+	// mid-run; zero means Timeout plus a minute, so the lock always outlives a
+	// healthy run.
+	LockTTL time.Duration
+}
+
+func (j Job) timeout() time.Duration {
+	if j.Timeout > 0 {
+		return j.Timeout
+	}
+	return DefaultTimeout
+}
+
+func (j Job) lockTTL() time.Duration {
+	if j.LockTTL > 0 {
+		return j.LockTTL
+	}
+	return j.timeout() + time.Minute
+}
+
+// CompanyLister returns the tenants a PerCompany job should run for. A
+// service typically passes a repository method listing active companies.
+type CompanyLister func(ctx context.Context) ([]uuid.UUID, error)
+
+type options struct {
+	locker    Locker
+	store     Store
+	companies CompanyLister
+	log       *slog.Logger
+	tick      time.Duration
+	now       func() time.Time
+}
+
+type Option func(*options)
+
+// WithLocker sets the cluster lock (defaults to LocalLocker).
+func WithLocker(l Locker) Option { return func(o *options) { o.locker = l } }
+
+// WithStore sets where status and pause flags live (defaults to MemoryStore).
+func WithStore(s Store) Option { return func(o *options) { o.store = s } }
+
+// WithCompanies sets the tenant source for PerCompany jobs.
+func WithCompanies(fn CompanyLister) Option { return func(o *options) { o.companies = fn } }
+
+func WithLogger(l *slog.Logger) Option { return func(o *options) { o.log = l } }
+
+// WithTick changes how often the runner checks for due jobs (default 1s).
+// Cron resolution is a minute, so there is rarely a reason to touch it outside
+// tests.
+func WithTick(d time.Duration) Option { return func(o *options) { o.tick = d } }
+
+type entry struct {
+	job     Job
+	next    time.Time
+	running bool
+}
+
+// Runner owns the registered jobs and the scheduling loop.
+type Runner struct {
+	opt options
+
+	mu   sync.Mutex
+	jobs map[string]*entry
+	wg   sync.WaitGroup
+}
+
+func NewRunner(opts ...Option) *Runner {
+	o := options{
+		locker: LocalLocker{},
+		store:  NewMemoryStore(),
+		log:    slog.Default(),
+		tick:   defaultTick,
+		now:    time.Now,
+	}
+	for _, fn := range opts {
+		fn(&o)
+	}
+	return &Runner{opt: o, jobs: map[string]*entry{}}
+}
+
+// Register adds a job. It validates eagerly so a misconfigured job fails the
+// service at boot instead of silently never running.
+func (r *Runner) Register(job Job) error {
+	switch {
+	case job.Name == "":
+		return fmt.Errorf("%w: empty name", ErrInvalidJobConfig)
+	case job.Schedule == nil:
+		return fmt.Errorf("%w: %s has no schedule", ErrInvalidJobConfig, job.Name)
+	case job.Run == nil:
+		return fmt.Errorf("%w: %s has no Run func", ErrInvalidJobConfig, job.Name)
+	case job.PerCompany && r.opt.companies == nil:
+		return fmt.Errorf("%w (%s)", ErrNoCompanyLister, job.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.jobs[job.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, job.Name)
+	}
+	r.jobs[job.Name] = &entry{job: job, next: job.Schedule.Next(r.opt.now())}
+	return nil
+}
+
+// MustRegister is Register for boot-time wiring; it panics on error.
+func (r *Runner) MustRegister(job Job) {
+	if err := r.Register(job); err != nil {
+		panic(err)
+	}
+}
+
+// Start runs the scheduling loop until ctx is cancelled, then waits for
+// in-flight runs to finish. Call it in its own goroutine.
+func (r *Runner) Start(ctx context.Context) {
+	defer observability.RecoverGoroutine(ctx)
+	defer r.wg.Wait()
+
+	ticker := time.NewTicker(r.opt.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.dispatchDue(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchDue starts every job whose next run has passed. The next run is
+// advanced BEFORE the job starts, from "now" rather than from the missed slot,
+// so a runner that was paused or blocked for an hour fires once, not sixty
+// times.
+func (r *Runner) dispatchDue(ctx context.Context) {
+	now := r.opt.now()
+
+	r.mu.Lock()
+	var due []*entry
+	for _, e := range r.jobs {
+		if e.running || e.next.IsZero() || now.Before(e.next) {
+			continue
+		}
+		e.next = e.job.Schedule.Next(now)
+		due = append(due, e)
+	}
+	r.mu.Unlock()
+
+	for _, e := range due {
+		paused, err := r.opt.store.IsPaused(ctx, e.job.Name)
+		if err != nil {
+			r.opt.log.WarnContext(ctx, "job pause check failed, skipping tick", "job", e.job.Name, "err", err)
+			continue
+		}
+		if paused {
+			continue
+		}
+		if !r.claim(e) {
+			continue
+		}
+		r.wg.Add(1)
+		go func(e *entry) {
+			defer r.wg.Done()
+			defer r.release(e)
+			defer observability.RecoverGoroutine(ctx)
+			_ = r.run(ctx, e)
+		}(e)
+	}
+}
+
+// Trigger runs a job now, outside its schedule, and returns the run's error.
+// It ignores the pause flag — a manual trigger is an explicit operator action —
+// but still honours the cluster lock, returning ErrJobLocked when another
+// replica is mid-run.
+func (r *Runner) Trigger(ctx context.Context, name string) error {
+	r.mu.Lock()
+	e, ok := r.jobs[name]
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	if !r.claim(e) {
+		return fmt.Errorf("%w: %s", ErrJobRunning, name)
+	}
+	defer r.release(e)
+	return r.run(ctx, e)
+}
+
+// Pause stops scheduled runs of a job until Resume. A run already in flight is
+// not interrupted.
+func (r *Runner) Pause(ctx context.Context, name string) error {
+	if !r.has(name) {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	return r.opt.store.SetPaused(ctx, name, true)
+}
+
+func (r *Runner) Resume(ctx context.Context, name string) error {
+	if !r.has(name) {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	return r.opt.store.SetPaused(ctx, name, false)
+}
+
+// Status returns the job's stored status, overlaid with this replica's view of
+// whether it is running and when it fires next.
+func (r *Runner) Status(ctx context.Context, name string) (Status, error) {
+	r.mu.Lock()
+	e, ok := r.jobs[name]
+	var running bool
+	var next time.Time
+	if ok {
+		running, next = e.running, e.next
+	}
+	r.mu.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+
+	st, _, err := r.opt.store.LoadStatus(ctx, name)
+	if err != nil {
+		return Status{}, err
+	}
+	paused, err := r.opt.store.IsPaused(ctx, name)
+	if err != nil {
+		return Status{}, err
+	}
+	st.Name = name
+	st.Paused = paused
+	st.Running = st.Running || running
+	st.NextRunAt = next
+	return st, nil
+}
+
+// Statuses returns Status for every registered job, sorted by name.
+func (r *Runner) Statuses(ctx context.Context) ([]Status, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.jobs))
+	for name := range r.jobs {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	out := make([]Status, 0, len(names))
+	for _, name := range names {
+		st, err := r.Status(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (r *Runner) has(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.jobs[name]
+	return ok
+}
+
+// claim marks the entry running on this replica; false means it already is.
+func (r *Runner) claim(e *entry) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.running {
+		return false
+	}
+	e.running = true
+	return true
+}
+
+func (r *Runner) release(e *entry) {
+	r.mu.Lock()
+	e.running = false
+	r.mu.Unlock()
+}
+
+// run executes one (already claimed) run of e under the cluster lock and
+// records the outcome.
+func (r *Runner) run(ctx context.Context, e *entry) error {
+	job := e.job
+	unlock, ok, err := r.opt.locker.TryLock(ctx, "jobs:lock:"+job.Name, job.lockTTL())
+	if err != nil {
+		// Fail closed: running twice is the failure mode a cluster lock exists
+		// to prevent, and the next tick will try again.
+		r.opt.log.WarnContext(ctx, "job lock unavailable, skipping run", "job", job.Name, "err", err)
+		return err
+	}
+	if !ok {
+		r.opt.log.DebugContext(ctx, "job locked by another replica", "job", job.Name)
+		return fmt.Errorf("%w: %s", ErrJobLocked, job.Name)
+	}
+	defer func() {
+		// The run ctx may already be cancelled; the unlock must still land.
+		if err := unlock(context.WithoutCancel(ctx)); err != nil {
+			r.opt.log.WarnContext(ctx, "job unlock failed, lock will expire on its TTL", "job", job.Name, "err", err)
+		}
+	}()
+
+	// A run id on the request_id slot ties every log line and Sentry event of
+	// one run together, the same way a request id does for HTTP.
+	runCtx := middleware.WithRequestID(ctx, "job:"+job.Name+":"+uuid.NewString())
+	runCtx, cancel := context.WithTimeout(runCtx, job.timeout())
+	defer cancel()
+
+	st, _, _ := r.opt.store.LoadStatus(ctx, job.Name)
+	st.Name = job.Name
+	st.Running = true
+	r.saveStatus(ctx, st)
+
+	start := r.opt.now()
+	var runErr error
+	if job.PerCompany {
+		runErr = r.runPerCompany(runCtx, job)
+	} else {
+		runErr = r.invoke(consts.WithSystemActor(runCtx), job)
+	}
+
+	st.Running = false
+	st.LastRunAt = start
+	st.LastDuration = r.opt.now().Sub(start)
+	st.RunCount++
+	st.LastError = ""
+	if runErr != nil {
+		st.FailCount++
+		st.LastError = runErr.Error()
+		r.opt.log.ErrorContext(runCtx, "job failed", "job", job.Name, "duration", st.LastDuration, "err", runErr)
+		observability.CaptureWithCtx(runCtx, fmt.Errorf("job %s: %w", job.Name, runErr))
+	} else {
+		r.opt.log.InfoContext(runCtx, "job finished", "job", job.Name, "duration", st.LastDuration)
+	}
+	r.saveStatus(ctx, st)
+	return runErr
+}
+
+func (r *Runner) saveStatus(ctx context.Context, st Status) {
+	if err := r.opt.store.SaveStatus(context.WithoutCancel(ctx), st); err != nil {
+		r.opt.log.WarnContext(ctx, "job status save failed", "job", st.Name, "err", err)
+	}
+}
+
+func (r *Runner) runPerCompany(ctx context.Context, job Job) error {
+	companies, err := r.opt.companies(consts.WithSystemActor(ctx))
+	if err != nil {
+		return fmt.Errorf("list companies: %w", err)
+	}
+	var errs []error
+	for _, cid := range companies {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if err := r.invoke(consts.WithActor(ctx, companyActor(cid)), job); err != nil {
+			errs = append(errs, fmt.Errorf("company %s: %w", cid, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// invoke calls job.Run, turning a panic into an error so one bad tenant is
+// recorded as a failure instead of killing the rest of the fan-out.
+func (r *Runner) invoke(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.opt.log.ErrorContext(ctx, "job panicked", "job", job.Name, "panic", p, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// companyActor is the system actor a per-company run executes as — the same
+// shape AuthServerInterceptor builds for an x-internal-token call carrying
+// x-company-id, so tenant scoping treats both identically.
+func companyActor(companyID uuid.UUID) *consts.Actor {
+	cid := companyID
+	claims := &consts.UserClaims{CompanyID: &cid}
+	claims.PopulateMaps()
+	return &consts.Actor{ID: uuid.Nil, IsSystem: true, Claims: claims}
+}
@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// denyLocker models another replica holding every lock.
+type denyLocker struct{}
+
+func (denyLocker) TryLock(context.Context, string, time.Duration) (func(context.Context) error, bool, error) {
+	return nil, false, nil
+}
+
+func TestRegister_Validation(t *testing.T) {
+	r := NewRunner()
+	noop := func(context.Context) error { return nil }
+
+	assert.ErrorIs(t, r.Register(Job{Schedule: Every(time.Second), Run: noop}), ErrInvalidJobConfig)
+	assert.ErrorIs(t, r.Register(Job{Name: "a", Run: noop}), ErrInvalidJobConfig)
+	assert.ErrorIs(t, r.Register(Job{Name: "a", Schedule: Every(time.Second)}), ErrInvalidJobConfig)
+	assert.ErrorIs(t, r.Register(Job{Name: "a", Schedule: Every(time.Second), Run: noop, PerCompany: true}), ErrNoCompanyLister)
+
+	require.NoError(t, r.Register(Job{Name: "a", Schedule: Every(time.Second), Run: noop}))
+	assert.ErrorIs(t, r.Register(Job{Name: "a", Schedule: Every(time.Second), Run: noop}), ErrJobExists)
+}
+
+func TestTrigger_RecordsStatus(t *testing.T) {
+	ctx := context.Background()
+	r := NewRunner()
+	boom := errors.New("boom")
+	fail := true
+	r.MustRegister(Job{Name: "sync", Schedule: Every(time.Hour), Run: func(ctx context.Context) error {
+		actor := consts.MustGetActor(ctx)
+		assert.True(t, actor.IsSystem)
+		if fail {
+			return boom
+		}
+		return nil
+	}})
+
+	err := r.Trigger(ctx, "sync")
+	assert.ErrorIs(t, err, boom)
+	st, err := r.Status(ctx, "sync")
+	require.NoError(t, err)
+	assert.Equal(t, "boom", st.LastError)
+	assert.EqualValues(t, 1, st.RunCount)
+	assert.EqualValues(t, 1, st.FailCount)
+	assert.False(t, st.LastRunAt.IsZero())
+	assert.False(t, st.NextRunAt.IsZero())
+
+	fail = false
+	require.NoError(t, r.Trigger(ctx, "sync"))
+	st, _ = r.Status(ctx, "sync")
+	assert.Empty(t, st.LastError)
+	assert.EqualValues(t, 2, st.RunCount)
+	assert.EqualValues(t, 1, st.FailCount)
+
+	assert.ErrorIs(t, r.Trigger(ctx, "missing"), ErrJobNotFound)
+}
+
+func TestTrigger_PerCompanyActorAndErrorIsolation(t *testing.T) {
+	ctx := context.Background()
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	r := NewRunner(WithCompanies(func(context.Context) ([]uuid.UUID, error) {
+		return []uuid.UUID{a, b, c}, nil
+	}))
+
+	var mu sync.Mutex
+	var seen []uuid.UUID
+	r.MustRegister(Job{Name: "per-tenant", Schedule: Every(time.Hour), PerCompany: true, Run: func(ctx context.Context) error {
+		actor := consts.MustGetActor(ctx)
+		require.True(t, actor.IsSystem)
+		cid := *actor.GetCompanyID()
+		mu.Lock()
+		seen = append(seen, cid)
+		mu.Unlock()
+		if cid == b {
+			panic("tenant b is broken")
+		}
+		return nil
+	}})
+
+	err := r.Trigger(ctx, "per-tenant")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), b.String())
+	assert.Equal(t, []uuid.UUID{a, b, c}, seen, "a failing tenant must not stop the rest")
+}
+
+func TestTrigger_LockedElsewhere(t *testing.T) {
+	r := NewRunner(WithLocker(denyLocker{}))
+	var ran bool
+	r.MustRegister(Job{Name: "x", Schedule: Every(time.Hour), Run: func(context.Context) error { ran = true; return nil }})
+
+	assert.ErrorIs(t, r.Trigger(context.Background(), "x"), ErrJobLocked)
+	assert.False(t, ran)
+}
+
+func TestStart_RunsDueJobsAndHonoursPause(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := NewRunner(WithTick(5 * time.Millisecond))
+	var fast, paused atomic.Int32
+	r.MustRegister(Job{Name: "fast", Schedule: Every(10 * time.Millisecond), Run: func(context.Context) error {
+		fast.Add(1)
+		return nil
+	}})
+	r.MustRegister(Job{Name: "paused", Schedule: Every(10 * time.Millisecond), Run: func(context.Context) error {
+		paused.Add(1)
+		return nil
+	}})
+	require.NoError(t, r.Pause(ctx, "paused"))
+
+	done := make(chan struct{})
+	go func() { r.Start(ctx); close(done) }()
+
+	require.Eventually(t, func() bool { return fast.Load() >= 3 }, 2*time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Zero(t, paused.Load())
+	st, err := r.Status(context.Background(), "paused")
+	require.NoError(t, err)
+	assert.True(t, st.Paused)
+
+	require.NoError(t, r.Resume(context.Background(), "paused"))
+	st, _ = r.Status(context.Background(), "paused")
+	assert.False(t, st.Paused)
+}
+
+func TestTrigger_RejectsConcurrentRunOnSameReplica(t *testing.T) {
+	r := NewRunner()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r.MustRegister(Job{Name: "slow", Schedule: Every(time.Hour), Run: func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}})
+
+	go func() { _ = r.Trigger(context.Background(), "slow") }()
+	<-started
+	assert.ErrorIs(t, r.Trigger(context.Background(), "slow"), ErrJobRunning)
+	st, _ := r.Status(context.Background(), "slow")
+	assert.True(t, st.Running)
+	close(release)
+}
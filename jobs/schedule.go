@@ -0,0 +1,231 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a job runs next. Next must return a time strictly
+// after t, or the zero time when the schedule has no further occurrence (an
+// impossible cron such as "0 0 30 2 *") — the runner then never fires the job.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Every runs a job at a fixed interval, measured from the moment the runner
+// last scheduled it — not aligned to the wall clock. Use a cron expression when
+// "at :00 every hour" matters.
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+type interval time.Duration
+
+func (i interval) Next(t time.Time) time.Time {
+	if i <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(i))
+}
+
+// cronSearchLimit bounds how far Next walks forward before concluding the
+// expression can never match. Four years covers every leap-day expression.
+const cronSearchLimit = 4 * 366 * 24 * time.Hour
+
+// cronSchedule is a parsed standard 5-field cron expression
+// (minute hour day-of-month month day-of-week). Each field is a bitset of the
+// values it accepts.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar / dowStar record a field written with a leading "*". Classic cron
+	// ORs the two day fields when BOTH are restricted ("the 1st, or any Monday")
+	// and otherwise uses whichever one is restricted.
+	domStar, dowStar bool
+	loc              *time.Location
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day-of-month", 1, 31},
+	{"month", 1, 12},
+	{"day-of-week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// ParseCron parses a standard 5-field cron expression or one of the
+// @hourly/@daily/@weekly/@monthly/@yearly descriptors. Fields accept "*",
+// single values, ranges ("1-5"), steps ("*/15", "0-30/5") and comma lists.
+// Times are evaluated in UTC; use ParseCronIn for a tenant-local schedule.
+func ParseCron(expr string) (Schedule, error) {
+	return ParseCronIn(expr, time.UTC)
+}
+
+// ParseCronIn is ParseCron evaluated in loc, so "0 6 * * *" means 06:00 local
+// time across DST changes.
+func ParseCronIn(expr string, loc *time.Location) (Schedule, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	expr = strings.TrimSpace(expr)
+	if d, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("jobs: cron %q: expected %d fields, got %d", expr, len(cronFields), len(fields))
+	}
+
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("jobs: cron %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+	// Fold Sunday=7 onto Sunday=0 so lookups only need time.Weekday.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+		loc:     loc,
+	}, nil
+}
+
+// MustCron is ParseCron for expressions fixed at compile time; it panics on a
+// malformed expression so the mistake surfaces at boot, not at 3am.
+func MustCron(expr string) Schedule {
+	s, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", spec.name, stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := spec.min, spec.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = cronValue(a, spec); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(b, spec); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: range %q is reversed", spec.name, rng)
+			}
+		default:
+			v, err := cronValue(rng, spec)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/10" means "from 5 every 10"; a bare "5" is just 5.
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, spec cronField) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", spec.name, s)
+	}
+	if v < spec.min || v > spec.max {
+		return 0, fmt.Errorf("%s: %d out of range %d-%d", spec.name, v, spec.min, spec.max)
+	}
+	return v, nil
+}
+
+// Next walks forward from t in the coarsest unit that fails to match (month,
+// then day, then hour, then minute), so even a yearly expression resolves in a
+// few dozen steps rather than half a million minute checks.
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t.In(origLoc)
+	}
+	return time.Time{}
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domStar && c.dowStar:
+		return true
+	case c.domStar:
+		return dowOK
+	case c.dowStar:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
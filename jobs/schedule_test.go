@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseCron_Next(t *testing.T) {
+	cases := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2026-03-10 10:04", "2026-03-10 10:05"},
+		{"*/15 * * * *", "2026-03-10 10:04", "2026-03-10 10:15"},
+		{"*/15 * * * *", "2026-03-10 10:45", "2026-03-10 11:00"},
+		{"0 6 * * *", "2026-03-10 06:00", "2026-03-11 06:00"},
+		{"30 2 1 * *", "2026-03-10 00:00", "2026-04-01 02:30"},
+		{"0 9 * * 1-5", "2026-03-13 09:00", "2026-03-16 09:00"}, // Fri → Mon
+		{"0 0 * * 7", "2026-03-10 00:00", "2026-03-15 00:00"},   // 7 == Sunday
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},  // next leap day
+		{"0 0 1 * 1", "2026-03-02 00:00", "2026-03-09 00:00"},   // dom OR dow
+		{"5,10-12 0 * * *", "2026-03-10 00:10", "2026-03-10 00:11"},
+		{"0-30/10 1 * * *", "2026-03-10 01:20", "2026-03-10 01:30"},
+		{"@hourly", "2026-03-10 10:00", "2026-03-10 11:00"},
+		{"@monthly", "2026-12-15 00:00", "2027-01-01 00:00"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr+"@"+tc.from, func(t *testing.T) {
+			s, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, at(tc.want), s.Next(at(tc.from)))
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, "expr %q", expr)
+	}
+}
+
+func TestParseCron_ImpossibleReturnsZero(t *testing.T) {
+	s := MustCron("0 0 30 2 *")
+	assert.True(t, s.Next(at("2026-01-01 00:00")).IsZero())
+}
+
+func TestParseCronIn_LocalTime(t *testing.T) {
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip("tzdata unavailable")
+	}
+	s, err := ParseCronIn("0 6 * * *", loc)
+	require.NoError(t, err)
+	// 06:00 CST is 12:00 UTC.
+	next := s.Next(at("2026-01-10 00:00"))
+	assert.Equal(t, at("2026-01-10 12:00"), next.UTC())
+}
+
+func TestEvery(t *testing.T) {
+	from := at("2026-03-10 10:00")
+	assert.Equal(t, from.Add(90*time.Second), Every(90*time.Second).Next(from))
+	assert.True(t, Every(0).Next(from).IsZero())
+}
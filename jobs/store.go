@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Status is the last-known state of a job, as shown on an ops/admin screen.
+// LastError is a string, not an error, so the record survives a round-trip
+// through Redis and reads the same on every replica.
+type Status struct {
+	Name         string        `json:"name"`
+	Paused       bool          `json:"paused"`
+	Running      bool          `json:"running"`
+	LastRunAt    time.Time     `json:"last_run_at,omitempty"`
+	LastDuration time.Duration `json:"last_duration,omitempty"`
+	LastError    string        `json:"last_error,omitempty"`
+	NextRunAt    time.Time     `json:"next_run_at,omitempty"`
+	RunCount     int64         `json:"run_count"`
+	FailCount    int64         `json:"fail_count"`
+}
+
+// Store persists job status and the pause flag. With MemoryStore (the default)
+// both are local to the replica; RedisStore shares them, so "pause" taken on
+// one pod stops the job cluster-wide and every pod reports the same last run.
+type Store interface {
+	SaveStatus(ctx context.Context, st Status) error
+	LoadStatus(ctx context.Context, name string) (Status, bool, error)
+	SetPaused(ctx context.Context, name string, paused bool) error
+	IsPaused(ctx context.Context, name string) (bool, error)
+}
+
+// MemoryStore keeps status in-process.
+type MemoryStore struct {
+	mu     sync.Mutex
+	status map[string]Status
+	paused map[string]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{status: map[string]Status{}, paused: map[string]bool{}}
+}
+
+func (s *MemoryStore) SaveStatus(_ context.Context, st Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[st.Name] = st
+	return nil
+}
+
+func (s *MemoryStore) LoadStatus(_ context.Context, name string) (Status, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.status[name]
+	return st, ok, nil
+}
+
+func (s *MemoryStore) SetPaused(_ context.Context, name string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused[name] = paused
+	return nil
+}
+
+func (s *MemoryStore) IsPaused(_ context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused[name], nil
+}
+
+// statusTTL keeps a retired job's status from lingering in Redis forever; any
+// live job rewrites its record on every run.
+const statusTTL = 30 * 24 * time.Hour
+
+// RedisStore keeps status and the pause flag in Redis under jobs:status:<name>
+// and jobs:paused:<name>. Like the lock, the keys are global.
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func (s *RedisStore) SaveStatus(ctx context.Context, st Status) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, "jobs:status:"+st.Name, data, statusTTL).Err()
+}
+
+func (s *RedisStore) LoadStatus(ctx context.Context, name string) (Status, bool, error) {
+	var st Status
+	data, err := s.rdb.Get(ctx, "jobs:status:"+name).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return st, false, nil
+	}
+	if err != nil {
+		return st, false, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, false, err
+	}
+	return st, true, nil
+}
+
+// SetPaused has no TTL on purpose: a paused job stays paused until someone
+// resumes it, however long that takes.
+func (s *RedisStore) SetPaused(ctx context.Context, name string, paused bool) error {
+	if !paused {
+		return s.rdb.Del(ctx, "jobs:paused:"+name).Err()
+	}
+	return s.rdb.Set(ctx, "jobs:paused:"+name, "1", 0).Err()
+}
+
+func (s *RedisStore) IsPaused(ctx context.Context, name string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, "jobs:paused:"+name).Result()
+	return n > 0, err
+}
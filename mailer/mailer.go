@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"log/slog"
 	"strconv"
 
 	"github.com/TMS360/backend-pkg/config"
@@ -139,7 +140,7 @@ func (s *SMTPSender) SendEmailWithAttachments(to []string, subject string, templ
 		return fmt.Errorf("failed to send email to %v: %w", to, err)
 	}
 
-	fmt.Printf("Email sent successfully to %v with subject '%s' (attachments=%d)\n", to, subject, len(attachments))
+	slog.Info("email sent", "transport", "smtp", "recipients", len(to), "attachments", len(attachments))
 
 	return nil
 }
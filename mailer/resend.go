@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -131,7 +132,7 @@ func (s *ResendSender) send(to []string, subject, templateFile string, data inte
 	}
 	_ = json.Unmarshal(respBody, &decoded)
 
-	fmt.Printf("Email sent via Resend to %v with subject '%s' (attachments=%d, id=%s)\n", to, subject, len(attachments), decoded.ID)
+	slog.Info("email sent", "transport", "resend", "recipients", len(to), "attachments", len(attachments), "message_id", decoded.ID)
 	return decoded.ID, nil
 }
 
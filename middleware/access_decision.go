@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// AccessReason says why a gate reached its decision. Support reads it off the
+// audit stream or the debug extension to answer "why was I denied?" without
+// reproducing the user's perms by hand.
+type AccessReason string
+
+const (
+	AccessGranted    AccessReason = "granted"     // a grant covers a required code
+	AccessSuperAdmin AccessReason = "super_admin" // super-admin bypass
+	AccessGuest      AccessReason = "guest"       // guest bypass; scoped by @authGuest
+	// AccessUnauthenticated — no actor in context.
+	AccessUnauthenticated AccessReason = "unauthenticated"
+	// AccessPermsUnresolved — the perm lookup failed; not a real RBAC denial
+	// (DEV-1555).
+	AccessPermsUnresolved AccessReason = "perms_unresolved"
+	// AccessDeniedByEntry — no required code is granted and a deny entry is
+	// what blocked at least one of them.
+	AccessDeniedByEntry AccessReason = "denied_by_entry"
+	// AccessMissingPermission — nothing the user holds covers any required code.
+	AccessMissingPermission AccessReason = "missing_permission"
+	// AccessTokenRevoked — the token or its session was revoked; recorded by
+	// auth.IdentifyUserPerms before any perms are read.
+	AccessTokenRevoked AccessReason = "token_revoked"
+)
+
+// PermsResolution is the state of the caller's perm list when the decision was
+// taken.
+type PermsResolution string
+
+const (
+	ResolutionResolved   PermsResolution = "resolved"
+	ResolutionUnresolved PermsResolution = "unresolved"
+	ResolutionRevoked    PermsResolution = "revoked"
+	// ResolutionNotLoaded — the decision did not need perms (guest, super-admin,
+	// anonymous).
+	ResolutionNotLoaded PermsResolution = "not_loaded"
+)
+
+// AccessDecision is the structured outcome of a permission gate: what was
+// required, what the caller holds, which entry decided it and why.
+type AccessDecision struct {
+	Allowed  bool         `json:"allowed"`
+	Reason   AccessReason `json:"reason"`
+	Required []string     `json:"required"`
+	// MatchedGrant is the entry (stored form) that passed the gate, e.g.
+	// "accounting" or "loads.loads.edit?own".
+	MatchedGrant string `json:"matched_grant,omitempty"`
+	// DeniedBy is the deny entry that blocked the gate, e.g.
+	// "-accounting.invoices.delete".
+	DeniedBy   string          `json:"denied_by,omitempty"`
+	Resolution PermsResolution `json:"resolution"`
+	// Held is the caller's perm list as resolved for this request.
+	Held      []string   `json:"held,omitempty"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	CompanyID *uuid.UUID `json:"company_id,omitempty"`
+	RequestID string     `json:"request_id,omitempty"`
+	// Gate names the checkpoint: "rest", "graphql" or "auth".
+	Gate string `json:"gate,omitempty"`
+}
+
+// Unresolved reports that the PermsUnresolved state applied to this decision.
+func (d AccessDecision) Unresolved() bool { return d.Resolution == ResolutionUnresolved }
+
+// CheckAccess is the gate check shared by RequirePerms and @hasPerm, returning
+// the full decision instead of a bool: OR over perms, conditional grants
+// counting, deny entries winning, guests and super-admins bypassing. Denials
+// are handed to the DenialAuditor (sampled) and every decision is recorded on
+// the request's AccessTrace when one is attached.
+func CheckAccess(ctx context.Context, gate string, perms ...string) AccessDecision {
+	d := decide(ctx, perms)
+	d.Gate = gate
+	d.RequestID = GetRequestID(ctx)
+	if t := accessTraceFrom(ctx); t != nil {
+		t.add(d)
+	}
+	if !d.Allowed {
+		AuditDenial(ctx, d)
+	}
+	return d
+}
+
+func decide(ctx context.Context, perms []string) AccessDecision {
+	d := AccessDecision{Required: perms, Resolution: ResolutionNotLoaded}
+	actor, err := GetActor(ctx)
+	if err != nil || actor == nil {
+		d.Reason = AccessUnauthenticated
+		return d
+	}
+	d.ActorID = actor.ID
+	d.CompanyID = actor.GetCompanyID()
+	switch {
+	case actor.IsGuest:
+		d.Allowed, d.Reason = true, AccessGuest
+		return d
+	case actor.IsSuperAdmin():
+		d.Allowed, d.Reason = true, AccessSuperAdmin
+		return d
+	case PermsUnresolved(ctx):
+		d.Reason, d.Resolution = AccessPermsUnresolved, ResolutionUnresolved
+		return d
+	}
+
+	d.Resolution = ResolutionResolved
+	d.Held = GetUserPermsFromContext(ctx)
+	ps := NewPermissionSet(d.Held, actor.ID)
+	for _, required := range perms {
+		grant, deniedBy := ps.Explain(required)
+		if grant != "" {
+			d.Allowed, d.Reason, d.MatchedGrant, d.DeniedBy = true, AccessGranted, grant, ""
+			return d
+		}
+		if deniedBy != "" && d.DeniedBy == "" {
+			d.DeniedBy = deniedBy
+		}
+	}
+	d.Reason = AccessMissingPermission
+	if d.DeniedBy != "" {
+		d.Reason = AccessDeniedByEntry
+	}
+	return d
+}
+
+// DenialAuditor receives sampled access denials — in services, the
+// eventlog.DenialAuditor publishing them to the audit stream. RecordDenial is
+// called on the request path and must not block.
+type DenialAuditor interface {
+	RecordDenial(ctx context.Context, d AccessDecision)
+}
+
+type denialAudit struct {
+	auditor DenialAuditor
+	rate    float64
+}
+
+var denialAuditCfg atomic.Pointer[denialAudit]
+
+// SetDenialAuditor installs the process-wide denial sink. sampleRate is the
+// fraction of denials forwarded (clamped to [0,1]): a misconfigured client
+// looping on a forbidden query would otherwise flood the audit stream. Revoked
+// tokens and unresolved perms are sampled like any other denial. A nil auditor
+// turns auditing off.
+func SetDenialAuditor(a DenialAuditor, sampleRate float64) {
+	if a == nil {
+		denialAuditCfg.Store(nil)
+		return
+	}
+	sampleRate = min(max(sampleRate, 0), 1)
+	denialAuditCfg.Store(&denialAudit{auditor: a, rate: sampleRate})
+}
+
+// AuditDenial forwards d to the installed DenialAuditor, subject to sampling.
+// CheckAccess calls it for its own denials; other gates (the revocation gate in
+// auth) call it directly.
+func AuditDenial(ctx context.Context, d AccessDecision) {
+	cfg := denialAuditCfg.Load()
+	if cfg == nil || d.Allowed {
+		return
+	}
+	if cfg.rate < 1 && rand.Float64() >= cfg.rate {
+		return
+	}
+	slog.Debug("access denied", "actorID", d.ActorID, "reason", d.Reason, "required", d.Required, "gate", d.Gate)
+	cfg.auditor.RecordDenial(ctx, d)
+}
+
+// AccessTrace collects the decisions taken while serving one request, for the
+// admin debug extension (tmsgraphql.AccessDecisionExtension). Safe for the
+// concurrent field resolvers of one operation.
+type AccessTrace struct {
+	mu        sync.Mutex
+	decisions []AccessDecision
+}
+
+type accessTraceKey struct{}
+
+// WithAccessTrace attaches a fresh trace to ctx and returns both.
+func WithAccessTrace(ctx context.Context) (context.Context, *AccessTrace) {
+	t := &AccessTrace{}
+	return context.WithValue(ctx, accessTraceKey{}, t), t
+}
+
+func accessTraceFrom(ctx context.Context) *AccessTrace {
+	t, _ := ctx.Value(accessTraceKey{}).(*AccessTrace)
+	return t
+}
+
+func (t *AccessTrace) add(d AccessDecision) {
+	t.mu.Lock()
+	t.decisions = append(t.decisions, d)
+	t.mu.Unlock()
+}
+
+// Decisions returns a copy of the decisions recorded so far.
+func (t *AccessTrace) Decisions() []AccessDecision {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]AccessDecision(nil), t.decisions...)
+}
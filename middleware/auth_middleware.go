@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/eventlog/events"
 	"github.com/TMS360/backend-pkg/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -23,6 +24,13 @@ import (
 // каждого запроса без правок в самих сабграфах — ровно так же, как actor.
 // tmsdb.writeEvent читает его при штамповке outbox-события (DEV-1411).
 func IdentifyUser(rsaPubKey *rsa.PublicKey) gin.HandlerFunc {
+	return IdentifyUserWithKeys(SingleRSAKeySet(rsaPubKey))
+}
+
+// IdentifyUserWithKeys is IdentifyUser verifying against a KeySet, so the
+// signing key can be rotated (selected by the token's kid) without redeploying
+// every service at once. See jwt_keyset.go.
+func IdentifyUserWithKeys(keys KeySet) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 0. Origin ставим до аутентификации: он нужен и анонимным, и гостевым
 		// запросам, и запросам с невалидным токеном.
@@ -32,7 +40,7 @@ func IdentifyUser(rsaPubKey *rsa.PublicKey) gin.HandlerFunc {
 
 		// 1. Attempt System User Authentication
 		if authHeader := ctx.GetHeader("Authorization"); authHeader != "" {
-			actor, err := parseAuthToken(authHeader, rsaPubKey)
+			actor, err := parseAuthToken(authHeader, keys)
 			if err == nil {
 				ctx.Request = ctx.Request.WithContext(WithActor(ctx.Request.Context(), actor))
 				ctx.Next()
@@ -66,43 +74,29 @@ func RequireAuth() gin.HandlerFunc {
 }
 
 // RequirePerms is the REST mirror of the GraphQL @hasPerm directive. Both read
-// the perms IdentifyUserPerms stashed in ctx and check them with the same
-// hierarchical auth.HasPermission — so a route and a resolver gated on the same
-// code behave identically. Holding ANY of perms passes (OR semantics, like the
-// directive).
+// the perms IdentifyUserPerms stashed in ctx and decide with the same
+// CheckAccess — so a route and a resolver gated on the same code behave
+// identically, and both feed the denial audit. Holding ANY of perms passes (OR
+// semantics, like the directive).
 func RequirePerms(perms ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		actor, err := GetActor(ctx.Request.Context())
-		if err != nil || actor == nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			return
-		}
-
-		// Mirror the directive's early-outs exactly.
-		if actor.IsGuest || actor.IsSuperAdmin() {
+		// Conditional grants pass the gate (the record is not loaded yet); the
+		// handler then checks the record with CanAccess. Deny entries win.
+		d := CheckAccess(ctx.Request.Context(), "rest", perms...)
+		switch {
+		case d.Allowed:
 			ctx.Next()
-			return
-		}
-
-		reqCtx := ctx.Request.Context()
-		if PermsUnresolved(reqCtx) {
+		case d.Reason == AccessUnauthenticated:
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		case d.Unresolved():
 			// DEV-1555: infra failure loading perms is not a real grant denial.
 			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
 				"error":   "PERMS_UNRESOLVED",
 				"message": "permission service unavailable, please retry",
 			})
-			return
-		}
-
-		userPerms := GetUserPermsFromContext(reqCtx)
-		for _, required := range perms {
-			if HasPermission(userPerms, required) {
-				ctx.Next()
-				return
-			}
+		default:
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: missing permission"})
 		}
-
-		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: missing permission"})
 	}
 }
 
@@ -174,6 +168,9 @@ func RequireAuthOrGuest() gin.HandlerFunc {
 }
 
 // CheckGuestResource checks if the actor is a guest and if so, verifies that their claims allow access to the specified resources.
+// A link granting an aggregate root ("shipment") also reaches the root's leaves
+// ("trip"), per events.CoversResource; which record a leaf belongs to is still
+// the handler's check (guest.CanAccessRecord).
 func CheckGuestResource(allowedResources []string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		actor, _ := GetActor(ctx.Request.Context())
@@ -182,10 +179,16 @@ func CheckGuestResource(allowedResources []string) gin.HandlerFunc {
 			return
 		}
 
+		granted := actor.Claims.GuestResources
+		if len(granted) == 0 {
+			granted = []string{actor.Claims.Resource}
+		}
 		for _, r := range allowedResources {
-			if actor.Claims.Resource == r {
-				ctx.Next()
-				return
+			for _, g := range granted {
+				if events.CoversResource(g, r) {
+					ctx.Next()
+					return
+				}
 			}
 		}
 
@@ -226,19 +229,14 @@ func MustGetActor(ctx context.Context) *consts.Actor {
 	return actor
 }
 
-func parseAuthToken(authHeader string, publicKey *rsa.PublicKey) (*consts.Actor, error) {
+func parseAuthToken(authHeader string, keys KeySet) (*consts.Actor, error) {
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
 		return nil, errors.New("invalid authorization header format")
 	}
 
 	tokenString := parts[1]
-	token, err := jwt.ParseWithClaims(tokenString, &consts.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return publicKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &consts.UserClaims{}, keyfuncFor(keys))
 
 	if err != nil || !token.Valid {
 		return nil, fmt.Errorf("invalid token: %w", err)
@@ -18,13 +18,19 @@ import (
 // across services), a system Actor is built from x-actor-id and x-company-id
 // instead of parsing a JWT.
 func AuthServerInterceptor(rsaPubKey *rsa.PublicKey, internalToken string) grpc.UnaryServerInterceptor {
+	return AuthServerInterceptorWithKeys(SingleRSAKeySet(rsaPubKey), internalToken)
+}
+
+// AuthServerInterceptorWithKeys is AuthServerInterceptor verifying JWTs against
+// a KeySet (kid-selected, rotatable) instead of a single RSA key.
+func AuthServerInterceptorWithKeys(keys KeySet, internalToken string) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		actor, err := extractActorFromIncomingMetadata(ctx, rsaPubKey, internalToken)
+		actor, err := extractActorFromIncomingMetadata(ctx, keys, internalToken)
 		if err != nil {
 			return nil, err
 		}
@@ -37,13 +43,19 @@ func AuthServerInterceptor(rsaPubKey *rsa.PublicKey, internalToken string) grpc.
 // validates it, and exposes an Actor-enriched context via the wrapped
 // ServerStream's Context().
 func AuthStreamServerInterceptor(rsaPubKey *rsa.PublicKey, internalToken string) grpc.StreamServerInterceptor {
+	return AuthStreamServerInterceptorWithKeys(SingleRSAKeySet(rsaPubKey), internalToken)
+}
+
+// AuthStreamServerInterceptorWithKeys is the KeySet variant of
+// AuthStreamServerInterceptor.
+func AuthStreamServerInterceptorWithKeys(keys KeySet, internalToken string) grpc.StreamServerInterceptor {
 	return func(
 		srv interface{},
 		ss grpc.ServerStream,
 		info *grpc.StreamServerInfo,
 		handler grpc.StreamHandler,
 	) error {
-		actor, err := extractActorFromIncomingMetadata(ss.Context(), rsaPubKey, internalToken)
+		actor, err := extractActorFromIncomingMetadata(ss.Context(), keys, internalToken)
 		if err != nil {
 			return err
 		}
@@ -58,7 +70,7 @@ type actorServerStream struct {
 
 func (s *actorServerStream) Context() context.Context { return s.ctx }
 
-func extractActorFromIncomingMetadata(ctx context.Context, rsaPubKey *rsa.PublicKey, internalToken string) (*consts.Actor, error) {
+func extractActorFromIncomingMetadata(ctx context.Context, keys KeySet, internalToken string) (*consts.Actor, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, status.Error(codes.Unauthenticated, "missing metadata in request")
@@ -73,7 +85,7 @@ func extractActorFromIncomingMetadata(ctx context.Context, rsaPubKey *rsa.Public
 	if len(authHeaders) == 0 {
 		return nil, status.Error(codes.Unauthenticated, "authorization token is not provided")
 	}
-	actor, err := parseAuthToken(authHeaders[0], rsaPubKey)
+	actor, err := parseAuthToken(authHeaders[0], keys)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "authentication failed: %v", err)
 	}
@@ -0,0 +1,426 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing-key rotation.
+//
+// IdentifyUser and AuthServerInterceptor used to take exactly one
+// *rsa.PublicKey, so rotating tms-auth's signing key meant redeploying every
+// service in lock-step with it. A KeySet instead selects the verification key
+// by the token's `kid` header, so tms-auth can publish the next key, start
+// signing with it, and retire the old one on its own schedule.
+//
+// Rotation runbook:
+//  1. publish the new key alongside the old one (JWKS endpoint, file or env);
+//  2. switch tms-auth to sign with the new kid;
+//  3. once the longest-lived access token signed by the old key has expired,
+//     drop it (JWKS) or let its RetireAt pass (static sets).
+//
+// Tokens minted before kid headers existed carry no kid; they are tried against
+// every active key of a compatible type, so turning this on needs no flag day.
+
+// JWTKey is one verification key. Key is an *rsa.PublicKey or an
+// ed25519.PublicKey (see utils.LoadRSAPublicKey / utils.LoadEd25519PublicKey).
+type JWTKey struct {
+	ID  string
+	Key crypto.PublicKey
+	// RetireAt, when set, is the end of the key's grace period: tokens signed by
+	// it are refused from then on. Zero means the key never retires on its own.
+	RetireAt time.Time
+}
+
+func (k JWTKey) activeAt(now time.Time) bool {
+	return k.Key != nil && (k.RetireAt.IsZero() || now.Before(k.RetireAt))
+}
+
+// KeySet resolves verification keys for incoming tokens.
+type KeySet interface {
+	// Keys returns the active keys for kid. An empty kid (a legacy token) asks
+	// for every active key; the verifier tries each.
+	Keys(kid string) []JWTKey
+}
+
+// StaticKeySet is a fixed set of keys — from env, a mounted file, or code.
+type StaticKeySet struct {
+	keys []JWTKey
+	now  func() time.Time
+}
+
+func NewStaticKeySet(keys ...JWTKey) *StaticKeySet {
+	return &StaticKeySet{keys: keys, now: time.Now}
+}
+
+// SingleRSAKeySet wraps the one-key setup IdentifyUser(rsaPubKey) has always
+// used. A nil key yields an empty set, which rejects every token.
+func SingleRSAKeySet(pub *rsa.PublicKey) *StaticKeySet {
+	if pub == nil {
+		return NewStaticKeySet()
+	}
+	return NewStaticKeySet(JWTKey{Key: pub})
+}
+
+func (s *StaticKeySet) Keys(kid string) []JWTKey {
+	return selectKeys(s.keys, kid, s.now())
+}
+
+// selectKeys picks the active keys matching kid. A key without an ID is the
+// legacy/default key and answers for any kid, so a set built from a single
+// un-named PEM keeps verifying tokens once tms-auth starts stamping kids.
+func selectKeys(keys []JWTKey, kid string, now time.Time) []JWTKey {
+	var out []JWTKey
+	for _, k := range keys {
+		if !k.activeAt(now) {
+			continue
+		}
+		if kid == "" || k.ID == "" || k.ID == kid {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// --- JWKS ---------------------------------------------------------------------
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// OKP (Ed25519)
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// ParseJWKS decodes a JWKS document (RFC 7517) into verification keys. RSA and
+// Ed25519 (OKP) signing keys are supported; encryption keys and unknown key
+// types are skipped rather than failing the whole set, so tms-auth can publish a
+// new key type before every service understands it.
+func ParseJWKS(data []byte) ([]JWTKey, error) {
+	var doc jwksDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("jwks: decode: %w", err)
+	}
+	keys := make([]JWTKey, 0, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var pub crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			pub, err = rsaFromJWK(k)
+		case "OKP":
+			if k.Crv != "Ed25519" {
+				continue
+			}
+			pub, err = ed25519FromJWK(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %w", k.Kid, err)
+		}
+		keys = append(keys, JWTKey{ID: k.Kid, Key: pub})
+	}
+	return keys, nil
+}
+
+func rsaFromJWK(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+	exp := 0
+	for _, b := range e {
+		exp = exp<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}, nil
+}
+
+func ed25519FromJWK(k jwk) (ed25519.PublicKey, error) {
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	return ed25519.PublicKey(x), nil
+}
+
+// LoadJWKSFromPath reads a JWKS document from a mounted file into a static set.
+func LoadJWKSFromPath(path string) (*StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: read %s: %w", path, err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticKeySet(keys...), nil
+}
+
+// LoadJWKSFromEnv reads a JWKS document from the named env var into a static
+// set — the no-network option for services that get their config from env.
+func LoadJWKSFromEnv(name string) (*StaticKeySet, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil, fmt.Errorf("jwks: %s is empty or not set in environment", name)
+	}
+	keys, err := ParseJWKS([]byte(v))
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticKeySet(keys...), nil
+}
+
+const (
+	// DefaultJWKSRefreshInterval is how often a JWKSKeySet re-fetches in the
+	// background.
+	DefaultJWKSRefreshInterval = 10 * time.Minute
+	// DefaultJWKSGracePeriod is how long a key that disappeared from the
+	// endpoint keeps verifying. It must outlive the longest access token the
+	// key could have signed.
+	DefaultJWKSGracePeriod = time.Hour
+	// jwksMinRefetch rate-limits the on-demand refetch an unknown kid triggers,
+	// so a flood of tokens with a garbage kid cannot hammer tms-auth.
+	jwksMinRefetch   = 30 * time.Second
+	jwksFetchTimeout = 5 * time.Second
+)
+
+// JWKSKeySet serves keys fetched from a JWKS endpoint (tms-auth's
+// /.well-known/jwks.json). It refreshes on a timer, and on demand when a token
+// arrives with a kid it has not seen — the normal case right after tms-auth
+// starts signing with a freshly published key.
+type JWKSKeySet struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+	grace    time.Duration
+	now      func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string]JWTKey
+	lastAttempt time.Time
+}
+
+type JWKSOption func(*JWKSKeySet)
+
+func WithJWKSRefreshInterval(d time.Duration) JWKSOption {
+	return func(s *JWKSKeySet) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithJWKSGracePeriod sets how long a key removed from the endpoint is still
+// accepted.
+func WithJWKSGracePeriod(d time.Duration) JWKSOption {
+	return func(s *JWKSKeySet) {
+		if d >= 0 {
+			s.grace = d
+		}
+	}
+}
+
+func WithJWKSHTTPClient(c *http.Client) JWKSOption {
+	return func(s *JWKSKeySet) {
+		if c != nil {
+			s.client = c
+		}
+	}
+}
+
+// NewJWKSKeySet fetches the endpoint once and fails if that first fetch does:
+// a service that boots without any verification key would 401 every request.
+func NewJWKSKeySet(ctx context.Context, url string, opts ...JWKSOption) (*JWKSKeySet, error) {
+	s := &JWKSKeySet{
+		url:      url,
+		client:   &http.Client{Timeout: jwksFetchTimeout},
+		interval: DefaultJWKSRefreshInterval,
+		grace:    DefaultJWKSGracePeriod,
+		now:      time.Now,
+		keys:     map[string]JWTKey{},
+	}
+	for _, fn := range opts {
+		fn(s)
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start refreshes the set every interval until ctx is cancelled. Run it in its
+// own goroutine. A failed refresh keeps serving the last good keys.
+func (s *JWKSKeySet) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				slog.WarnContext(ctx, "jwks refresh failed, keeping last known keys", "url", s.url, "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh fetches the endpoint and merges the result. Keys still published are
+// (re)activated; keys no longer published get RetireAt = now + grace instead of
+// vanishing, so tokens they signed a minute ago keep working through the
+// rotation.
+func (s *JWKSKeySet) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.lastAttempt = s.now()
+	s.mu.Unlock()
+
+	fetched, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]struct{}, len(fetched))
+	for _, k := range fetched {
+		seen[k.ID] = struct{}{}
+		s.keys[k.ID] = k
+	}
+	for id, k := range s.keys {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		switch {
+		case k.RetireAt.IsZero():
+			k.RetireAt = now.Add(s.grace)
+			s.keys[id] = k
+		case !now.Before(k.RetireAt):
+			delete(s.keys, id)
+		}
+	}
+	return nil
+}
+
+func (s *JWKSKeySet) fetch(ctx context.Context) ([]JWTKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: fetch %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetch %s: status %d", s.url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("jwks: read body: %w", err)
+	}
+	return ParseJWKS(body)
+}
+
+func (s *JWKSKeySet) Keys(kid string) []JWTKey {
+	if keys := s.snapshot(kid); len(keys) > 0 || kid == "" {
+		return keys
+	}
+
+	// Unknown kid: most likely tms-auth just started signing with a key we have
+	// not fetched yet. Refetch now, at most once per jwksMinRefetch.
+	s.mu.RLock()
+	recent := s.now().Sub(s.lastAttempt) < jwksMinRefetch
+	s.mu.RUnlock()
+	if recent {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	if err := s.Refresh(ctx); err != nil {
+		slog.Warn("jwks refetch for unknown kid failed", "kid", kid, "err", err)
+		return nil
+	}
+	return s.snapshot(kid)
+}
+
+func (s *JWKSKeySet) snapshot(kid string) []JWTKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]JWTKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	return selectKeys(keys, kid, s.now())
+}
+
+// --- verification -------------------------------------------------------------
+
+// keyfuncFor adapts a KeySet to jwt.Keyfunc. Only keys whose type matches the
+// token's algorithm are offered — an RSA key never verifies an EdDSA token and
+// vice versa — which also closes the classic alg-confusion hole.
+func keyfuncFor(ks KeySet) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if ks == nil {
+			return nil, errors.New("no verification keys configured")
+		}
+		kid, _ := token.Header["kid"].(string)
+
+		var match []jwt.VerificationKey
+		for _, k := range ks.Keys(kid) {
+			switch token.Method.(type) {
+			case *jwt.SigningMethodRSA:
+				if pub, ok := k.Key.(*rsa.PublicKey); ok {
+					match = append(match, pub)
+				}
+			case *jwt.SigningMethodEd25519:
+				if pub, ok := k.Key.(ed25519.PublicKey); ok {
+					match = append(match, pub)
+				}
+			default:
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		}
+		switch len(match) {
+		case 0:
+			return nil, fmt.Errorf("no active verification key for kid %q", kid)
+		case 1:
+			return match[0], nil
+		default:
+			return jwt.VerificationKeySet{Keys: match}, nil
+		}
+	}
+}
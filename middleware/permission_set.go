@@ -0,0 +1,345 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/TMS360/backend-pkg/enums"
+	"github.com/google/uuid"
+)
+
+// Attributes describes the record a conditional grant is evaluated against,
+// keyed by the attribute names used in the grant ("owner_id", "team_id",
+// "amount", …). Values may be uuid.UUID, *uuid.UUID, string, any integer or
+// float, a fmt.Stringer (decimal.Decimal), or a slice of those — a slice
+// matches "=" when any element does, so a load on several teams passes
+// "team_id=X" for each of them.
+type Attributes map[string]any
+
+// PermissionSet evaluates a user's permission entries (see
+// enums.ParsePermissionEntry): plain and conditional grants matched
+// hierarchically like HasPermission, and deny entries that beat any grant.
+//
+// There are two questions to ask it:
+//
+//   - MayGrant — could this code be granted for SOME record? This is what the
+//     route/field gates (RequirePerms, @hasPerm) ask, because they run before
+//     the record is loaded. A user holding only "loads.loads.edit?own" passes
+//     the gate; the service then decides per record.
+//   - Allows — is it granted for THIS record? Service-layer checks ask it with
+//     the record's Attributes once loaded (see CanAccess).
+//
+// Grants answers the unconditional question and backs HasPermission, so
+// existing service-layer HasPermission calls never start passing on a grant
+// they cannot see the condition of.
+type PermissionSet struct {
+	subject     uuid.UUID
+	grants      []string
+	conditional []enums.PermissionEntry
+	denies      []string
+}
+
+// NewPermissionSet parses perms for the acting user subject (used by the `own`
+// condition). Unparseable entries are dropped and logged — failing closed on
+// that one entry rather than the whole set.
+func NewPermissionSet(perms []string, subject uuid.UUID) *PermissionSet {
+	ps := &PermissionSet{subject: subject}
+	for _, raw := range perms {
+		// Fast path: the overwhelming majority of entries are plain codes.
+		if !strings.HasPrefix(raw, enums.PermissionDenyPrefix) && !strings.Contains(raw, enums.PermissionConditionSeparator) {
+			if raw != "" {
+				ps.grants = append(ps.grants, raw)
+			}
+			continue
+		}
+		e, err := enums.ParsePermissionEntry(raw)
+		if err != nil {
+			slog.Warn("ignoring malformed permission entry", "entry", raw, "err", err)
+			continue
+		}
+		switch {
+		case e.Deny:
+			ps.denies = append(ps.denies, e.Code)
+		case e.Conditional():
+			ps.conditional = append(ps.conditional, e)
+		default:
+			ps.grants = append(ps.grants, e.Code)
+		}
+	}
+	return ps
+}
+
+// PermissionSetFromContext builds the set for the request's actor from the
+// perms IdentifyUserPerms stashed.
+func PermissionSetFromContext(ctx context.Context) *PermissionSet {
+	subject := uuid.Nil
+	if actor, err := GetActor(ctx); err == nil && actor != nil {
+		subject = actor.ID
+	}
+	return NewPermissionSet(GetUserPermsFromContext(ctx), subject)
+}
+
+// Denied reports whether a deny entry covers required — the deny itself or any
+// of its ancestors, so "-accounting.invoices" denies every invoice action.
+func (ps *PermissionSet) Denied(required string) bool {
+	return required != "" && coveredBy(ps.denies, required)
+}
+
+// Grants reports an unconditional, non-denied grant of required.
+func (ps *PermissionSet) Grants(required string) bool {
+	return required != "" && !ps.Denied(required) && coveredBy(ps.grants, required)
+}
+
+// MayGrant reports whether required is granted for at least some records:
+// unconditionally, or by a conditional grant. Gate semantics — see the type doc.
+func (ps *PermissionSet) MayGrant(required string) bool {
+	if ps.Grants(required) {
+		return true
+	}
+	if required == "" || ps.Denied(required) {
+		return false
+	}
+	for _, e := range ps.conditional {
+		if covers(e.Code, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether required is granted for the record described by attrs.
+func (ps *PermissionSet) Allows(required string, attrs Attributes) bool {
+	if ps.Grants(required) {
+		return true
+	}
+	if required == "" || ps.Denied(required) {
+		return false
+	}
+	for _, e := range ps.conditional {
+		if covers(e.Code, required) && ps.conditionsHold(e.Conditions, attrs) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conditions returns the condition sets under which required is granted, for
+// callers that push the check into a query (WHERE owner_id = ? OR …). nil with
+// unconditional=true means no filter is needed; nil with false means no access.
+func (ps *PermissionSet) Conditions(required string) (sets [][]enums.PermissionCondition, unconditional bool) {
+	if ps.Grants(required) {
+		return nil, true
+	}
+	if required == "" || ps.Denied(required) {
+		return nil, false
+	}
+	for _, e := range ps.conditional {
+		if covers(e.Code, required) {
+			sets = append(sets, e.Conditions)
+		}
+	}
+	return sets, false
+}
+
+// Explain reports which entries decide required under gate semantics: the
+// deny entry that blocks it, else the grant (plain or conditional, rendered in
+// its stored form) that passes it. Both empty means nothing covers it.
+func (ps *PermissionSet) Explain(required string) (grant string, deniedBy string) {
+	if required == "" {
+		return "", ""
+	}
+	for _, d := range ps.denies {
+		if covers(d, required) {
+			return "", enums.PermissionDenyPrefix + d
+		}
+	}
+	for _, g := range ps.grants {
+		if covers(g, required) {
+			return g, ""
+		}
+	}
+	for _, e := range ps.conditional {
+		if covers(e.Code, required) {
+			return e.String(), ""
+		}
+	}
+	return "", ""
+}
+
+func (ps *PermissionSet) conditionsHold(conds []enums.PermissionCondition, attrs Attributes) bool {
+	for _, c := range conds {
+		v, ok := attrs[c.Attr]
+		if !ok || v == nil {
+			// A record that does not expose the attribute cannot satisfy the
+			// condition — fail closed.
+			return false
+		}
+		if c.Op == enums.PermCondOwn {
+			if ps.subject == uuid.Nil || !matchAny(v, func(s string) bool { return strings.EqualFold(s, ps.subject.String()) }) {
+				return false
+			}
+			continue
+		}
+		if !evalCondition(c, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func evalCondition(c enums.PermissionCondition, v any) bool {
+	switch c.Op {
+	case enums.PermCondEq:
+		return matchAny(v, func(s string) bool { return strings.EqualFold(s, c.Value) })
+	case enums.PermCondNe:
+		return !matchAny(v, func(s string) bool { return strings.EqualFold(s, c.Value) })
+	}
+	want, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil {
+		return false
+	}
+	got, ok := attrFloat(v)
+	if !ok {
+		return false
+	}
+	switch c.Op {
+	case enums.PermCondLt:
+		return got < want
+	case enums.PermCondLte:
+		return got <= want
+	case enums.PermCondGt:
+		return got > want
+	case enums.PermCondGte:
+		return got >= want
+	}
+	return false
+}
+
+// matchAny applies pred to v's string form, or to each element's when v is a
+// slice.
+func matchAny(v any, pred func(string) bool) bool {
+	switch vs := v.(type) {
+	case []uuid.UUID:
+		for _, x := range vs {
+			if pred(x.String()) {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for _, x := range vs {
+			if pred(x) {
+				return true
+			}
+		}
+		return false
+	case []any:
+		for _, x := range vs {
+			if matchAny(x, pred) {
+				return true
+			}
+		}
+		return false
+	}
+	s, ok := attrString(v)
+	return ok && pred(s)
+}
+
+func attrString(v any) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case uuid.UUID:
+		return x.String(), true
+	case *uuid.UUID:
+		if x == nil {
+			return "", false
+		}
+		return x.String(), true
+	case fmt.Stringer:
+		return x.String(), true
+	case int, int32, int64, uint, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(x), true
+	}
+	return "", false
+}
+
+func attrFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	case *float64:
+		if x == nil {
+			return 0, false
+		}
+		return *x, true
+	}
+	if s, ok := attrString(v); ok {
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// covers reports whether code is required itself or one of its dotted
+// ancestors — the hierarchical match HasPermission has always used.
+func covers(code, required string) bool {
+	return code == required || strings.HasPrefix(required, code+".")
+}
+
+func coveredBy(codes []string, required string) bool {
+	for _, c := range codes {
+		if covers(c, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccess is the service-layer check for a loaded record: does the request's
+// actor hold required for the record described by attrs? Super-admins pass, as
+// they do at the gates. Guests and unresolved perms never pass — a guest's
+// access is scoped by its share link, and an unresolved set is not a grant.
+func CanAccess(ctx context.Context, required string, attrs Attributes) bool {
+	actor, err := GetActor(ctx)
+	if err != nil || actor == nil || actor.IsGuest {
+		return false
+	}
+	if actor.IsSuperAdmin() {
+		return true
+	}
+	if PermsUnresolved(ctx) {
+		return false
+	}
+	return PermissionSetFromContext(ctx).Allows(required, attrs)
+}
+
+// MayHaveAnyPermission is the gate check shared by RequirePerms and @hasPerm:
+// OR over perms, conditional grants counting, deny entries winning. The caller
+// handles the guest / super-admin / unresolved early-outs first.
+func MayHaveAnyPermission(ctx context.Context, perms []string) bool {
+	ps := PermissionSetFromContext(ctx)
+	for _, required := range perms {
+		if ps.MayGrant(required) {
+			return true
+		}
+	}
+	return false
+}
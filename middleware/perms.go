@@ -3,6 +3,8 @@ package middleware
 import (
 	"sort"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 // HasPermission reports whether userPerms grants required via hierarchical
@@ -10,20 +12,13 @@ import (
 // implies "accounting.invoices.create" — but never the reverse: holding
 // "accounting.invoices.create" does NOT imply "accounting". Sibling perms
 // do not imply each other.
+//
+// A deny entry ("-accounting.invoices.delete") covering required wins over any
+// grant. Conditional grants ("…?own") do NOT count here: this answers the
+// unconditional question, so use PermissionSet.Allows / CanAccess when the
+// record is at hand.
 func HasPermission(userPerms []string, required string) bool {
-	if required == "" {
-		return false
-	}
-	parts := strings.Split(required, ".")
-	for i := 1; i <= len(parts); i++ {
-		prefix := strings.Join(parts[:i], ".")
-		for _, p := range userPerms {
-			if p == prefix {
-				return true
-			}
-		}
-	}
-	return false
+	return NewPermissionSet(userPerms, uuid.Nil).Grants(required)
 }
 
 // HasAnyPermission reports whether userPerms grants at least one of required.
@@ -61,9 +61,27 @@ type RateLimiterFunc func(ctx context.Context, key string, limit int, window tim
 type rateLimitConfig struct {
 	max    int
 	window time.Duration
+	source func() (int, time.Duration)
 	allow  RateLimiterFunc
 }
 
+// limits returns the ceiling and window for this request: the source's values
+// when set and positive, else the static ones.
+func (c *rateLimitConfig) limits() (int, time.Duration) {
+	max, window := c.max, c.window
+	if c.source == nil {
+		return max, window
+	}
+	m, w := c.source()
+	if m > 0 {
+		max = m
+	}
+	if w > 0 {
+		window = w
+	}
+	return max, window
+}
+
 // RateLimitOption customizes RateLimitAuthenticated.
 type RateLimitOption func(*rateLimitConfig)
 
@@ -81,6 +99,13 @@ func WithAuthRateLimit(max int, window time.Duration) RateLimitOption {
 	}
 }
 
+// WithAuthRateLimitSource reads the ceiling and window on every request, so
+// they can change without a restart (config.Runtime.AuthRateLimit). A
+// non-positive value from fn falls back to the static one.
+func WithAuthRateLimitSource(fn func() (int, time.Duration)) RateLimitOption {
+	return func(c *rateLimitConfig) { c.source = fn }
+}
+
 // WithRateLimiter injects the limiter implementation (defaults to
 // ratelimit.Allow). Used by tests to make the throttle decision deterministic.
 func WithRateLimiter(fn RateLimiterFunc) RateLimitOption {
@@ -140,7 +165,8 @@ func RateLimitAuthenticated(opts ...RateLimitOption) gin.HandlerFunc {
 			key += ":" + ip
 		}
 
-		allowed, rlErr := cfg.allow(reqCtx, key, cfg.max, cfg.window)
+		max, window := cfg.limits()
+		allowed, rlErr := cfg.allow(reqCtx, key, max, window)
 		if rlErr != nil {
 			// Fail open, but make the infra blip visible.
 			slog.WarnContext(reqCtx, "auth rate limit check failed — allowing request",
@@ -151,7 +177,7 @@ func RateLimitAuthenticated(opts ...RateLimitOption) gin.HandlerFunc {
 			return
 		}
 
-		retryAfter := int(cfg.window.Seconds())
+		retryAfter := int(window.Seconds())
 		if retryAfter < 1 {
 			retryAfter = 1
 		}
@@ -163,8 +189,8 @@ func RateLimitAuthenticated(opts ...RateLimitOption) gin.HandlerFunc {
 			"userID", actor.ID,
 			"ip", ip,
 			"operation", operationName(c),
-			"limit", cfg.max,
-			"window", cfg.window.String(),
+			"limit", max,
+			"window", window.String(),
 		)
 
 		c.Header("Retry-After", strconv.Itoa(retryAfter))
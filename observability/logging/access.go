@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AccessLogOption customizes the access-log middlewares.
+type AccessLogOption func(*accessLogConfig)
+
+type accessLogConfig struct {
+	logger    *slog.Logger
+	skipPaths map[string]bool
+}
+
+// WithAccessLogger logs through l instead of slog.Default().
+func WithAccessLogger(l *slog.Logger) AccessLogOption {
+	return func(c *accessLogConfig) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// WithSkipPaths suppresses access lines for probe and scrape routes
+// ("/metrics", "/healthz") or gRPC full method names.
+func WithSkipPaths(paths ...string) AccessLogOption {
+	return func(c *accessLogConfig) {
+		for _, p := range paths {
+			c.skipPaths[p] = true
+		}
+	}
+}
+
+func newAccessLogConfig(opts []AccessLogOption) *accessLogConfig {
+	c := &accessLogConfig{skipPaths: map[string]bool{}}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+func (c *accessLogConfig) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
+
+// GinAccessLog writes one "http_request" line per request after the chain has
+// run, so the actor the auth middleware resolved is on it. 5xx log at Error,
+// 4xx at Warn, the rest at Info. The query string is never logged — share
+// links and several vendor callbacks carry credentials there.
+func GinAccessLog(opts ...AccessLogOption) gin.HandlerFunc {
+	cfg := newAccessLogConfig(opts)
+	return func(c *gin.Context) {
+		if cfg.skipPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+
+		code := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case code >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case code >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", code),
+			slog.Int64("dur_ms", time.Since(start).Milliseconds()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if origin := middleware.GetClientOrigin(c.Request.Context()); origin != nil {
+			attrs = append(attrs, slog.String("ip", origin.IP))
+		} else {
+			attrs = append(attrs, slog.String("ip", c.ClientIP()))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.Last().Error()))
+		}
+		cfg.log().LogAttrs(c.Request.Context(), level, "http_request", attrs...)
+	}
+}
+
+// UnaryServerAccessLog writes one "grpc_request" line per unary call. Chain
+// it after middleware.AuthServerInterceptor so the actor is on the context.
+func UnaryServerAccessLog(opts ...AccessLogOption) grpc.UnaryServerInterceptor {
+	cfg := newAccessLogConfig(opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if !cfg.skipPaths[info.FullMethod] {
+			logRPC(ctx, cfg, info.FullMethod, start, err)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerAccessLog writes one "grpc_request" line when a stream ends.
+func StreamServerAccessLog(opts ...AccessLogOption) grpc.StreamServerInterceptor {
+	cfg := newAccessLogConfig(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		if !cfg.skipPaths[info.FullMethod] {
+			logRPC(ss.Context(), cfg, info.FullMethod, start, err)
+		}
+		return err
+	}
+}
+
+// logRPC levels by code: server-side faults at Error, caller mistakes at Warn.
+func logRPC(ctx context.Context, cfg *accessLogConfig, fullMethod string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", strings.TrimPrefix(fullMethod, "/")),
+		slog.String("code", code.String()),
+		slog.Int64("dur_ms", time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	cfg.log().LogAttrs(ctx, level, "grpc_request", attrs...)
+}
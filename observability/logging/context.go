@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/TMS360/backend-pkg/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextHandler appends the request's identifiers to every record logged
+// with a context: request_id, actor_id, company_id (guest links add guest),
+// trace_id and span_id. Records logged without one pass through unchanged.
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(ContextAttrs(ctx)...)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
+
+// ContextAttrs returns the identifiers the handler attaches for ctx — exported
+// for code that builds its own records or logs through another library.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if rid := middleware.GetRequestID(ctx); rid != "" {
+		attrs = append(attrs, slog.String("request_id", rid))
+	}
+	if actor, err := middleware.GetActor(ctx); err == nil && actor != nil {
+		switch {
+		case actor.IsGuest:
+			attrs = append(attrs, slog.Bool("guest", true))
+		case actor.IsSystem:
+			attrs = append(attrs, slog.Bool("system", true))
+		}
+		if !actor.IsGuest {
+			attrs = append(attrs, slog.String("actor_id", actor.ID.String()))
+		}
+		if cid := actor.GetCompanyID(); cid != nil {
+			attrs = append(attrs, slog.String("company_id", cid.String()))
+		}
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return attrs
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Redacted replaces the value of every attribute Redact hides.
+const Redacted = "[REDACTED]"
+
+// secretKeyParts mark an attribute key as secret when any appears in it
+// (case-insensitive, separators ignored): "guest_token", "apiKey",
+// "X-Internal-Token", "client_secret" all match.
+var secretKeyParts = []string{
+	"token", "secret", "password", "passwd", "apikey", "authorization",
+	"cookie", "credential", "privatekey", "signature",
+}
+
+// secretKeyWords are too short to match as substrings ("pin" is in
+// "shipping", "cred" in "credit") and only count as a whole word of the key:
+// "pin", "pin_hash", "otpCode", "db.dsn".
+var secretKeyWords = map[string]bool{
+	"pin": true, "otp": true, "cred": true, "creds": true, "dsn": true, "pwd": true,
+}
+
+// jwtPattern matches a compact JWS — three base64url segments, the first
+// starting with the encoded '{"'. Caught by value, whatever the key says.
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+
+// Redact is the handler's ReplaceAttr. It hides an attribute when its key
+// looks secret, when its value is a credential struct (a type named *Cred or
+// *Credential, e.g. usps.Cred, toll.Credential), and it masks JWTs embedded in
+// any string value. Use it directly when building another handler.
+func Redact(_ []string, a slog.Attr) slog.Attr {
+	if isSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); strings.Contains(s, "eyJ") {
+			return slog.String(a.Key, jwtPattern.ReplaceAllString(s, Redacted))
+		}
+	case slog.KindAny:
+		if isCredentialType(a.Value.Any()) {
+			return slog.String(a.Key, Redacted)
+		}
+	}
+	return a
+}
+
+func isSecretKey(key string) bool {
+	k := strings.ToLower(key)
+	k = strings.NewReplacer("_", "", "-", "", ".", "", " ", "").Replace(k)
+	for _, part := range secretKeyParts {
+		if strings.Contains(k, part) {
+			return true
+		}
+	}
+	for _, w := range keyWords(key) {
+		if secretKeyWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords splits a key on separators and camelCase humps, lower-cased:
+// "otpCode" → [otp code], "pin_hash" → [pin hash].
+func keyWords(key string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, strings.ToLower(string(cur)))
+			cur = cur[:0]
+		}
+	}
+	for i, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && len(cur) > 0 && unicode.IsLower(cur[len(cur)-1]):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return words
+}
+
+func isCredentialType(v any) bool {
+	if v == nil {
+		return false
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := t.Name()
+	return strings.HasSuffix(name, "Cred") || strings.HasSuffix(name, "Credential") ||
+		strings.HasSuffix(name, "Credentials")
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// samplingHandler drops repetitive Debug-and-below records: per message, the
+// first First in each Tick pass, then one in every Thereafter. Counters are
+// shared by every handler derived through WithAttrs/WithGroup.
+type samplingHandler struct {
+	next  slog.Handler
+	cfg   Sampling
+	state *sampleState
+}
+
+type sampleState struct {
+	mu     sync.Mutex
+	window time.Time
+	counts map[string]int
+}
+
+// maxSampledMessages bounds the per-window map; beyond it new messages are
+// passed through unsampled rather than growing memory.
+const maxSampledMessages = 4096
+
+func newSamplingHandler(next slog.Handler, cfg Sampling) *samplingHandler {
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	return &samplingHandler{next: next, cfg: cfg, state: &sampleState{counts: map[string]int{}}}
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelInfo || h.keep(r.Message, r.Time) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *samplingHandler) keep(msg string, now time.Time) bool {
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.window) >= h.cfg.Tick {
+		s.window = now
+		clear(s.counts)
+	}
+	n, seen := s.counts[msg]
+	if !seen && len(s.counts) >= maxSampledMessages {
+		return true
+	}
+	n++
+	s.counts[msg] = n
+	if n <= h.cfg.First {
+		return true
+	}
+	if h.cfg.Thereafter > 0 && (n-h.cfg.First)%h.cfg.Thereafter == 0 {
+		return true
+	}
+	return false
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), cfg: h.cfg, state: h.state}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), cfg: h.cfg, state: h.state}
+}
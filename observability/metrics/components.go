@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	outboxBacklog = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace, Subsystem: "outbox", Name: "backlog_events",
+		Help: "Outbox rows still pending publication.",
+	})
+	outboxOldestAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace, Subsystem: "outbox", Name: "oldest_pending_age_seconds",
+		Help: "Age of the oldest pending outbox row; 0 when the outbox is empty.",
+	})
+	outboxPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace, Subsystem: "outbox", Name: "published_events_total",
+		Help: "Outbox events handed to Kafka, by outcome.",
+	}, []string{"outcome"})
+	outboxBatchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace, Subsystem: "outbox", Name: "batch_duration_seconds",
+		Help:    "Duration of one relay batch (fetch, publish, delete).",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	consumerLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace, Subsystem: "consumer", Name: "lag_messages",
+		Help: "Messages behind the partition high-water mark at the last fetch.",
+	}, []string{"group", "topic", "partition"})
+	consumerMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace, Subsystem: "consumer", Name: "messages_total",
+		Help: "Messages consumed, by outcome (ok, error, malformed).",
+	}, []string{"group", "topic", "outcome"})
+	consumerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace, Subsystem: "consumer", Name: "dispatch_duration_seconds",
+		Help:    "Duration of dispatching one consumed message.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"group", "topic"})
+
+	rateLimitDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace, Subsystem: "ratelimit", Name: "decisions_total",
+		Help: "Rate-limit checks by limiter and outcome (allowed, throttled, fail_open).",
+	}, []string{"limiter", "outcome"})
+
+	permCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace, Subsystem: "perm_cache", Name: "lookups_total",
+		Help: "Permission cache lookups by result (hit, miss, error).",
+	}, []string{"result"})
+
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace, Subsystem: "breaker", Name: "state",
+		Help: "Circuit-breaker state: 0 closed, 1 open.",
+	}, []string{"breaker"})
+	breakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace, Subsystem: "breaker", Name: "opened_total",
+		Help: "Times a circuit breaker opened.",
+	}, []string{"breaker"})
+	gateDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace, Subsystem: "rmsgate", Name: "decisions_total",
+		Help: "RmsGate decisions by process and outcome (allow, deny, fail_open, fail_closed).",
+	}, []string{"process", "outcome"})
+	gateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace, Subsystem: "rmsgate", Name: "evaluate_duration_seconds",
+		Help:    "Latency of RmsGate Evaluate calls.",
+		Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"process"})
+
+	quotaEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace, Subsystem: "external", Name: "budget_events_total",
+		Help: "External API budget events by provider and kind (soft_limit, blocked).",
+	}, []string{"provider", "event"})
+)
+
+func init() {
+	registry.MustRegister(
+		outboxBacklog, outboxOldestAge, outboxPublished, outboxBatchDuration,
+		consumerLag, consumerMessages, consumerDuration,
+		rateLimitDecisions, permCacheLookups,
+		breakerState, breakerTransitions, gateDecisions, gateDuration,
+		quotaEvents,
+	)
+}
+
+// SetOutboxBacklog records the pending row count and the age of the oldest.
+func SetOutboxBacklog(pending int64, oldest time.Time) {
+	outboxBacklog.Set(float64(pending))
+	age := 0.0
+	if pending > 0 && !oldest.IsZero() {
+		age = time.Since(oldest).Seconds()
+	}
+	outboxOldestAge.Set(age)
+}
+
+// ObserveOutboxBatch records one relay batch of n events.
+func ObserveOutboxBatch(n int, d time.Duration, err error) {
+	outcome := OutcomeOK
+	if err != nil {
+		outcome = "error"
+	}
+	outboxPublished.WithLabelValues(outcome).Add(float64(n))
+	outboxBatchDuration.Observe(d.Seconds())
+}
+
+// SetConsumerLag records how far group is behind on one partition.
+func SetConsumerLag(group, topic string, partition int, lag int64) {
+	if lag < 0 {
+		lag = 0
+	}
+	consumerLag.WithLabelValues(group, topic, strconv.Itoa(partition)).Set(float64(lag))
+}
+
+// ObserveConsumed records one consumed message; outcome is "ok", "error" or
+// "malformed".
+func ObserveConsumed(group, topic, outcome string, d time.Duration) {
+	consumerMessages.WithLabelValues(group, topic, outcome).Inc()
+	if outcome != "malformed" {
+		consumerDuration.WithLabelValues(group, topic).Observe(d.Seconds())
+	}
+}
+
+// Rate-limit outcomes.
+const (
+	RateLimitAllowed   = "allowed"
+	RateLimitThrottled = "throttled"
+	RateLimitFailOpen  = "fail_open"
+)
+
+// ObserveRateLimit records one limiter decision. The limiter label is the
+// key's leading segment ("auth", "guest", "share_link_challenge") — the rest
+// of a key names a user or a link and never becomes a label.
+func ObserveRateLimit(key string, allowed bool, err error) {
+	limiter, _, _ := strings.Cut(key, ":")
+	outcome := RateLimitAllowed
+	switch {
+	case err != nil:
+		outcome = RateLimitFailOpen
+	case !allowed:
+		outcome = RateLimitThrottled
+	}
+	rateLimitDecisions.WithLabelValues(limiter, outcome).Inc()
+}
+
+// Perm cache lookup results.
+const (
+	PermCacheHit   = "hit"
+	PermCacheMiss  = "miss"
+	PermCacheError = "error"
+)
+
+// ObservePermCache records one perm cache lookup.
+func ObservePermCache(result string) {
+	permCacheLookups.WithLabelValues(result).Inc()
+}
+
+// SetBreakerOpen records a breaker's state; opening also counts a transition.
+func SetBreakerOpen(breaker string, open bool) {
+	if open {
+		breakerState.WithLabelValues(breaker).Set(1)
+		breakerTransitions.WithLabelValues(breaker).Inc()
+		return
+	}
+	breakerState.WithLabelValues(breaker).Set(0)
+}
+
+// ObserveGateDecision records one rmsgate decision. d is zero for decisions
+// taken without a call (circuit open).
+func ObserveGateDecision(process, outcome string, d time.Duration) {
+	gateDecisions.WithLabelValues(process, outcome).Inc()
+	if d > 0 {
+		gateDuration.WithLabelValues(process).Observe(d.Seconds())
+	}
+}
+
+// External API budget events.
+const (
+	BudgetSoftLimit = "soft_limit"
+	BudgetBlocked   = "blocked"
+)
+
+// ObserveBudgetEvent records a tenant crossing a soft budget or a call
+// blocked by a hard one. The tenant is deliberately not a label.
+func ObserveBudgetEvent(provider, event string) {
+	quotaEvents.WithLabelValues(provider, event).Inc()
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Providers are the external APIs whose calls are metered. One constant per
+// vendor keeps the provider label bounded.
+const (
+	ProviderHERE        = "here"
+	ProviderGoogleMaps  = "googlemaps"
+	ProviderSamsara     = "samsara"
+	ProviderUSPS        = "usps"
+	ProviderRelay       = "relay"
+	ProviderRingCentral = "ringcentral"
+	ProviderSaferAPI    = "saferapi"
+	ProviderFMCSA       = "fmcsa"
+)
+
+// Outcomes classify a finished external call. They are the vocabulary of the
+// here_call / google_call log lines, shared so logs and metrics agree.
+const (
+	OutcomeOK          = "ok"
+	OutcomeCtxCanceled = "ctx_canceled"
+	OutcomeAuth        = "auth"
+	OutcomeHTTP5xx     = "http_5xx"
+	OutcomeHTTP4xx     = "http_4xx"
+	OutcomeHTTPOther   = "http_other"
+	OutcomeTransport   = "transport"
+)
+
+var (
+	externalRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "external",
+		Name:      "requests_total",
+		Help:      "Outbound external API calls by provider, operation and outcome.",
+	}, []string{"provider", "op", "outcome"})
+
+	externalDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "external",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of outbound external API calls.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"provider", "op", "outcome"})
+)
+
+func init() {
+	registry.MustRegister(externalRequests, externalDuration)
+}
+
+// Outcome splits calls into paid-and-useful vs paid-and-wasted, and separates
+// retryable failures from permanent ones. authErr is the provider's own
+// verdict (here.IsAuthError, googlemaps.IsAuthError): Google reports a rejected
+// key as HTTP 200 with REQUEST_DENIED in the body, so status alone cannot tell.
+func Outcome(ctx context.Context, status int, err error, authErr bool) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case ctx != nil && ctx.Err() != nil:
+		return OutcomeCtxCanceled
+	case authErr:
+		return OutcomeAuth
+	case status >= 500:
+		return OutcomeHTTP5xx
+	case status >= 400:
+		return OutcomeHTTP4xx
+	case status > 0:
+		return OutcomeHTTPOther
+	default:
+		return OutcomeTransport
+	}
+}
+
+// ObserveExternal records one finished external call.
+func ObserveExternal(provider, op, outcome string, d time.Duration) {
+	externalRequests.WithLabelValues(provider, op, outcome).Inc()
+	externalDuration.WithLabelValues(provider, op, outcome).Observe(d.Seconds())
+}
+
+// OpFunc names the operation a request performs. It must return a bounded
+// set of values — never the raw URL.
+type OpFunc func(*http.Request) string
+
+// Transport wraps next (nil means http.DefaultTransport) so every request
+// through it is recorded under provider. It is for clients whose calls do not
+// funnel through one place that knows the operation; op nil uses PathOp. A
+// status of 401/403 is classified as auth, 4xx/5xx by class.
+func Transport(provider string, next http.RoundTripper, op OpFunc) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if op == nil {
+		op = PathOp
+	}
+	return &meteredTransport{provider: provider, next: next, op: op}
+}
+
+type meteredTransport struct {
+	provider string
+	next     http.RoundTripper
+	op       OpFunc
+}
+
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := 0
+	callErr := err
+	if resp != nil {
+		status = resp.StatusCode
+		if err == nil && status >= 400 {
+			callErr = errHTTPStatus
+		}
+	}
+	auth := status == http.StatusUnauthorized || status == http.StatusForbidden
+	ObserveExternal(t.provider, t.op(req), Outcome(req.Context(), status, callErr, auth), time.Since(start))
+	return resp, err
+}
+
+// errHTTPStatus stands in for "the call completed with an error status" when
+// classifying a response the transport itself returned without error.
+var errHTTPStatus = errors.New("http error status")
+
+// maxOpSegments bounds how much of a path PathOp keeps.
+const maxOpSegments = 4
+
+// PathOp names a request "METHOD /a/b/{id}/c": the path's first segments with
+// anything that looks like an identifier — a UUID, a number, or a long token
+// containing digits — collapsed to {id}. The query string is dropped; several
+// providers carry credentials there.
+func PathOp(req *http.Request) string {
+	segs := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(segs) > maxOpSegments {
+		segs = segs[:maxOpSegments]
+	}
+	for i, s := range segs {
+		if looksLikeID(s) {
+			segs[i] = "{id}"
+		}
+	}
+	return req.Method + " /" + strings.Join(segs, "/")
+}
+
+func looksLikeID(s string) bool {
+	if s == "" {
+		return false
+	}
+	if _, err := uuid.Parse(s); err == nil {
+		return true
+	}
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits == len(s) || (len(s) >= 16 && digits > 0)
+}
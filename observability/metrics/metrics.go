@@ -0,0 +1,58 @@
+// Package metrics is the platform's Prometheus registry. Shared components —
+// the outbox relay, eventlog.Consumer, rate limiting, the perm cache, the
+// external API clients and rmsgate — record RED metrics into it, and a service
+// exposes everything with one route:
+//
+//	r.GET("/metrics", metrics.GinHandler())
+//
+// Every label here is bounded by construction: provider and op are constants
+// chosen by the calling package, outcome comes from Outcome, and nothing keyed
+// by user, company, share link or URL is ever used as a label value.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes every metric the shared components register.
+const Namespace = "tms"
+
+var registry = newRegistry()
+
+func newRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return r
+}
+
+// Registry returns the standard registry. Services register their own
+// collectors here (not on prometheus.DefaultRegisterer) so /metrics serves
+// one consistent set.
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// MustRegister registers service-specific collectors on Registry. It panics
+// on a duplicate, like prometheus.MustRegister.
+func MustRegister(cs ...prometheus.Collector) {
+	registry.MustRegister(cs...)
+}
+
+// Handler serves Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// GinHandler is Handler for a Gin route. Mount it outside the auth chain — the
+// scraper carries no token.
+func GinHandler() gin.HandlerFunc {
+	return gin.WrapH(Handler())
+}
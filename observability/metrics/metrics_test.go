@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutcome(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	boom := errors.New("boom")
+
+	cases := []struct {
+		name    string
+		ctx     context.Context
+		status  int
+		err     error
+		authErr bool
+		want    string
+	}{
+		{"ok", context.Background(), 200, nil, false, OutcomeOK},
+		{"canceled", canceled, 0, boom, false, OutcomeCtxCanceled},
+		{"auth beats status", context.Background(), 200, boom, true, OutcomeAuth},
+		{"5xx", context.Background(), 503, boom, false, OutcomeHTTP5xx},
+		{"4xx", context.Background(), 404, boom, false, OutcomeHTTP4xx},
+		{"other", context.Background(), 302, boom, false, OutcomeHTTPOther},
+		{"transport", context.Background(), 0, boom, false, OutcomeTransport},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Outcome(tc.ctx, tc.status, tc.err, tc.authErr))
+		})
+	}
+}
+
+func TestPathOpCollapsesIdentifiers(t *testing.T) {
+	op := func(method, rawURL string) string {
+		req := httptest.NewRequest(method, rawURL, nil)
+		return PathOp(req)
+	}
+	assert.Equal(t, "GET /fleet/vehicles/{id}/locations", op(http.MethodGet, "https://api.samsara.com/fleet/vehicles/281474977075805/locations?apiKey=secret"))
+	assert.Equal(t, "PATCH /addresses/{id}", op(http.MethodPatch, "/addresses/0f8fad5b-d9cb-469f-a165-70867728950e"))
+	assert.Equal(t, "GET /restapi/v1.0/account/~", op(http.MethodGet, "/restapi/v1.0/account/~/extension/~/call-log"))
+	assert.Equal(t, "POST /oauth2/v3/token", op(http.MethodPost, "/oauth2/v3/token"))
+}
+
+func TestTransportRecordsProviderOpOutcome(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/denied":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport("test_provider", nil, nil)}
+	for _, path := range []string{"/ok", "/denied", "/down", "/down"} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(externalRequests.WithLabelValues("test_provider", "GET /ok", OutcomeOK)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(externalRequests.WithLabelValues("test_provider", "GET /denied", OutcomeAuth)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(externalRequests.WithLabelValues("test_provider", "GET /down", OutcomeHTTP5xx)))
+}
+
+func TestRateLimitLabelIsKeyPrefix(t *testing.T) {
+	ObserveRateLimit("auth:6b1d…:10.0.0.1", false, nil)
+	ObserveRateLimit("auth:6b1d…:10.0.0.2", true, errors.New("redis down"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rateLimitDecisions.WithLabelValues("auth", RateLimitThrottled)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rateLimitDecisions.WithLabelValues("auth", RateLimitFailOpen)))
+}
+
+func TestOutboxBacklogAge(t *testing.T) {
+	SetOutboxBacklog(3, time.Now().Add(-time.Minute))
+	assert.Equal(t, 3.0, testutil.ToFloat64(outboxBacklog))
+	assert.InDelta(t, 60, testutil.ToFloat64(outboxOldestAge), 5)
+
+	SetOutboxBacklog(0, time.Time{})
+	assert.Equal(t, 0.0, testutil.ToFloat64(outboxOldestAge))
+}
+
+func TestHandlerExposesRegistry(t *testing.T) {
+	SetBreakerOpen("test_breaker", true)
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, string(body), `tms_breaker_state{breaker="test_breaker"} 1`)
+	assert.True(t, strings.Contains(string(body), "go_goroutines"), "runtime collectors registered")
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,25 +35,38 @@ type Config struct {
 	Env     string
 	Release string
 	Service string
-	// Sample rate for non-error events (traces). 0 disables.
+	// Sample rate for non-error events (traces). 0 disables. The same
+	// SENTRY_TRACES_SAMPLE_RATE drives OpenTelemetry sampling (telemetry).
 	TracesSampleRate float64
 }
 
+// TracesSampleRateFromEnv reads SENTRY_TRACES_SAMPLE_RATE; ok is false when
+// it is unset or not a number.
+func TracesSampleRateFromEnv() (rate float64, ok bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("SENTRY_TRACES_SAMPLE_RATE")), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
 var enabled bool
 
-// LoadConfigFromEnv reads SENTRY_DSN / SENTRY_ENVIRONMENT / SENTRY_RELEASE
-// from the process environment. SENTRY_ENV is accepted as a legacy fallback
+// LoadConfigFromEnv reads SENTRY_DSN / SENTRY_ENVIRONMENT / SENTRY_RELEASE /
+// SENTRY_TRACES_SAMPLE_RATE from the process environment. SENTRY_ENV is accepted as a legacy fallback
 // when SENTRY_ENVIRONMENT is unset.
 func LoadConfigFromEnv(service string) Config {
 	env := os.Getenv("SENTRY_ENVIRONMENT")
 	if env == "" {
 		env = os.Getenv("SENTRY_ENV")
 	}
+	rate, _ := TracesSampleRateFromEnv()
 	return Config{
-		DSN:     os.Getenv("SENTRY_DSN"),
-		Env:     env,
-		Release: os.Getenv("SENTRY_RELEASE"),
-		Service: service,
+		DSN:              os.Getenv("SENTRY_DSN"),
+		Env:              env,
+		Release:          os.Getenv("SENTRY_RELEASE"),
+		Service:          service,
+		TracesSampleRate: rate,
 	}
 }
 
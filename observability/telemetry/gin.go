@@ -0,0 +1,80 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GinMiddleware opens a server span per request, continuing the caller's trace
+// from the traceparent header, and records http.server.request.duration.
+// Install it first in the chain so every later middleware (auth, perms, rate
+// limit) runs inside the span; request id and actor are read after the chain
+// has run and stamped onto the span.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := Propagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := Tracer().Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		span.SetAttributes(requestAttributes(c.Request.Context())...)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+
+		instruments().httpDuration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			semconv.HTTPResponseStatusCode(status),
+		))
+	}
+}
+
+// requestAttributes are the platform identifiers worth searching spans by.
+func requestAttributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if rid := middleware.GetRequestID(ctx); rid != "" {
+		attrs = append(attrs, attribute.String("tms.request_id", rid))
+	}
+	if actor, err := middleware.GetActor(ctx); err == nil && actor != nil {
+		attrs = append(attrs, attribute.String("enduser.id", actor.ID.String()))
+		if cid := actor.GetCompanyID(); cid != nil {
+			attrs = append(attrs, attribute.String("tms.company_id", cid.String()))
+		}
+		if actor.IsGuest {
+			attrs = append(attrs, attribute.Bool("tms.guest", true))
+		}
+	}
+	return attrs
+}
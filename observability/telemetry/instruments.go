@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// instruments are created lazily from the global meter provider, and
+// recreated after Init swaps it (resetInstruments), so helpers used before
+// Init do not keep no-op instruments forever.
+type instrumentSet struct {
+	httpDuration     metric.Float64Histogram
+	rpcServerLatency metric.Float64Histogram
+	rpcClientLatency metric.Float64Histogram
+	dbDuration       metric.Float64Histogram
+	redisDuration    metric.Float64Histogram
+	kafkaPublished   metric.Int64Counter
+	kafkaConsumed    metric.Int64Counter
+}
+
+var (
+	instrumentsMu sync.Mutex
+	instrumentsV  *instrumentSet
+)
+
+// durationBuckets are in seconds, 5ms … 10s — request-latency shaped.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func instruments() *instrumentSet {
+	instrumentsMu.Lock()
+	defer instrumentsMu.Unlock()
+	if instrumentsV != nil {
+		return instrumentsV
+	}
+	m := Meter()
+	hist := func(name, desc string) metric.Float64Histogram {
+		h, _ := m.Float64Histogram(name, metric.WithDescription(desc), metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(durationBuckets...))
+		return h
+	}
+	counter := func(name, desc string) metric.Int64Counter {
+		c, _ := m.Int64Counter(name, metric.WithDescription(desc))
+		return c
+	}
+	instrumentsV = &instrumentSet{
+		httpDuration:     hist("http.server.request.duration", "Duration of HTTP server requests."),
+		rpcServerLatency: hist("rpc.server.duration", "Duration of gRPC server calls."),
+		rpcClientLatency: hist("rpc.client.duration", "Duration of gRPC client calls."),
+		dbDuration:       hist("db.client.operation.duration", "Duration of database operations."),
+		redisDuration:    hist("redis.client.operation.duration", "Duration of Redis commands."),
+		kafkaPublished:   counter("messaging.client.published.messages", "Kafka messages published."),
+		kafkaConsumed:    counter("messaging.client.consumed.messages", "Kafka messages consumed."),
+	}
+	return instrumentsV
+}
+
+func resetInstruments() {
+	instrumentsMu.Lock()
+	instrumentsV = nil
+	instrumentsMu.Unlock()
+}
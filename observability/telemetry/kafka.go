@@ -0,0 +1,111 @@
+package telemetry
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// kafkaCarrier adapts kafka-go message headers to propagation.TextMapCarrier.
+type kafkaCarrier struct{ msg *kafka.Message }
+
+func (c kafkaCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c kafkaCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if h.Key == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c kafkaCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// InjectKafkaHeaders writes ctx's trace context into msg's headers,
+// replacing any previous traceparent.
+func InjectKafkaHeaders(ctx context.Context, msg *kafka.Message) {
+	Propagator().Inject(ctx, kafkaCarrier{msg: msg})
+}
+
+// ExtractKafkaHeaders returns ctx carrying the trace context found in msg's
+// headers; ctx is returned unchanged when there is none.
+func ExtractKafkaHeaders(ctx context.Context, msg kafka.Message) context.Context {
+	return Propagator().Extract(ctx, kafkaCarrier{msg: &msg})
+}
+
+// StartProducerSpan opens a producer span for one message about to be written
+// to topic and stamps its context into msg's headers, so the consumer's span
+// becomes its child. End the span once the write returns.
+func StartProducerSpan(ctx context.Context, topic string, msg *kafka.Message) trace.Span {
+	ctx, span := Tracer().Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeSend,
+			semconv.MessagingDestinationName(topic),
+		),
+	)
+	InjectKafkaHeaders(ctx, msg)
+	return span
+}
+
+// EndProducerSpan finishes a span from StartProducerSpan and counts the
+// message as published when err is nil.
+func EndProducerSpan(ctx context.Context, span trace.Span, topic string, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		instruments().kafkaPublished.Add(ctx, 1, metric.WithAttributes(
+			semconv.MessagingSystemKafka, semconv.MessagingDestinationName(topic)))
+	}
+	span.End()
+}
+
+// StartConsumerSpan continues the producer's trace from msg's headers — or,
+// for messages written before headers were stamped, from fallback (the
+// EventPayload.TraceContext map) — and opens a consumer span. The message is
+// counted as consumed.
+func StartConsumerSpan(ctx context.Context, group string, msg kafka.Message, fallback map[string]string) (context.Context, trace.Span) {
+	parent := ExtractKafkaHeaders(ctx, msg)
+	if !trace.SpanContextFromContext(parent).IsValid() {
+		parent = ExtractMap(ctx, fallback)
+	}
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKafka,
+		semconv.MessagingOperationTypeProcess,
+		semconv.MessagingDestinationName(msg.Topic),
+		semconv.MessagingConsumerGroupName(group),
+	}
+	ctx, span := Tracer().Start(parent, "process "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(
+			semconv.MessagingKafkaOffset(int(msg.Offset)),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(msg.Partition)),
+		),
+	)
+	instruments().kafkaConsumed.Add(ctx, 1, metric.WithAttributes(attrs...))
+	return ctx, span
+}
@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook traces go-redis commands and pipelines. Only the command name is
+// recorded — keys carry company ids and share-link tokens. Install with
+// rdb.AddHook(telemetry.RedisHook{}).
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+type redisStartKey struct{}
+
+func (RedisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return startRedisSpan(ctx, cmd.Name()), nil
+}
+
+func (RedisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endRedisSpan(ctx, cmd.Name(), cmd.Err())
+	return nil
+}
+
+func (RedisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return startRedisSpan(ctx, pipelineName(cmds)), nil
+}
+
+func (RedisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && cmd.Err() != redis.Nil {
+			err = cmd.Err()
+			break
+		}
+	}
+	endRedisSpan(ctx, "pipeline", err) // bounded metric cardinality; the span name lists the commands
+	return nil
+}
+
+func pipelineName(cmds []redis.Cmder) string {
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name())
+	}
+	return "pipeline " + strings.Join(names, " ")
+}
+
+func startRedisSpan(ctx context.Context, op string) context.Context {
+	ctx, _ = Tracer().Start(ctx, "redis "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(op)),
+	)
+	return context.WithValue(ctx, redisStartKey{}, time.Now())
+}
+
+func endRedisSpan(ctx context.Context, op string, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && err != redis.Nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	if start, ok := ctx.Value(redisStartKey{}).(time.Time); ok {
+		instruments().redisDuration.Record(ctx, time.Since(start).Seconds(),
+			metric.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(op)))
+	}
+}
@@ -0,0 +1,232 @@
+// Package telemetry wires OpenTelemetry tracing and metrics across the
+// platform's transports: the Gin chain (GinMiddleware), gRPC servers and
+// clients (the *Interceptor funcs), GORM (GormPlugin), go-redis (RedisHook) and
+// Kafka, where trace context travels in message headers (InjectKafkaHeaders /
+// ExtractKafkaHeaders) so one trace follows a request from the gateway through
+// the outbox relay into every consumer.
+//
+// Init installs the global providers. Before Init — or with the "none"
+// exporter — every helper here works against the no-op providers, so services
+// compile and run identically with telemetry off, as they do without a
+// SENTRY_DSN.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TMS360/backend-pkg/observability"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName scopes every tracer and meter this package creates.
+const instrumentationName = "github.com/TMS360/backend-pkg/observability/telemetry"
+
+// Exporter selects where spans and metrics go.
+type Exporter string
+
+const (
+	// ExporterNone keeps the no-op providers.
+	ExporterNone Exporter = "none"
+	// ExporterOTLP ships to an OTLP/gRPC collector (Endpoint).
+	ExporterOTLP Exporter = "otlp"
+	// ExporterStdout pretty-prints to stdout — for local runs and tests.
+	ExporterStdout Exporter = "stdout"
+)
+
+// Config carries telemetry init parameters.
+type Config struct {
+	Service string
+	Env     string
+	Release string
+	// Exporter defaults to ExporterNone.
+	Exporter Exporter
+	// Endpoint is the OTLP collector host:port. Empty uses the exporter's own
+	// default (OTEL_EXPORTER_OTLP_ENDPOINT, else localhost:4317).
+	Endpoint string
+	// Insecure disables TLS to the collector (in-cluster collectors).
+	Insecure bool
+	// TracesSampleRate is the fraction of new traces sampled; a parent's
+	// decision is always honoured. As in observability.Config, 0 samples no
+	// new trace — LoadConfigFromEnv sets 1 when no rate is configured.
+	TracesSampleRate float64
+	// MetricInterval is how often metrics are exported. <=0 means 30s.
+	MetricInterval time.Duration
+}
+
+// LoadConfigFromEnv reads the standard OTel variables: OTEL_TRACES_EXPORTER
+// ("otlp", "stdout"/"console", "none"), OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_INSECURE and OTEL_TRACES_SAMPLER_ARG. SENTRY_ENVIRONMENT
+// and SENTRY_RELEASE double as the deployment environment and version, and
+// SENTRY_TRACES_SAMPLE_RATE as the sample rate when OTEL_TRACES_SAMPLER_ARG
+// is unset, so one set of variables configures both systems. With neither
+// set every trace is sampled.
+func LoadConfigFromEnv(service string) Config {
+	cfg := Config{
+		Service:  service,
+		Env:      os.Getenv("SENTRY_ENVIRONMENT"),
+		Release:  os.Getenv("SENTRY_RELEASE"),
+		Exporter: ExporterNone,
+		Endpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Insecure: os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true",
+	}
+	switch strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")) {
+	case "otlp":
+		cfg.Exporter = ExporterOTLP
+	case "stdout", "console":
+		cfg.Exporter = ExporterStdout
+	}
+	cfg.TracesSampleRate = 1
+	if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("OTEL_TRACES_SAMPLER_ARG")), 64); err == nil {
+		cfg.TracesSampleRate = v
+	} else if v, ok := observability.TracesSampleRateFromEnv(); ok {
+		cfg.TracesSampleRate = v
+	}
+	return cfg
+}
+
+// sampler samples rate of new traces and follows the parent's decision
+// otherwise, so a trace is never cut in half between services.
+func sampler(rate float64) sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(rate))
+}
+
+// Init installs the global tracer and meter providers and the W3C
+// tracecontext+baggage propagator. The returned shutdown flushes both
+// providers; call it from main on exit. With ExporterNone only the propagator
+// is installed and shutdown is a no-op.
+func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	noop := func(context.Context) error { return nil }
+	if cfg.Exporter == "" || cfg.Exporter == ExporterNone {
+		slog.Info("OpenTelemetry disabled: no exporter configured", "service", cfg.Service)
+		return noop, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.Service),
+		semconv.ServiceVersion(cfg.Release),
+		semconv.DeploymentEnvironmentName(cfg.Env),
+	))
+	if err != nil {
+		return noop, fmt.Errorf("telemetry: resource: %w", err)
+	}
+
+	spanExp, metricExp, err := newExporters(ctx, cfg)
+	if err != nil {
+		return noop, err
+	}
+
+	ratio := cfg.TracesSampleRate
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(spanExp),
+		sdktrace.WithSampler(sampler(ratio)),
+	)
+	interval := cfg.MetricInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExp, sdkmetric.WithInterval(interval))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+	resetInstruments()
+
+	slog.Info("OpenTelemetry enabled", "service", cfg.Service, "exporter", cfg.Exporter, "sample_ratio", ratio)
+	return func(ctx context.Context) error {
+		return errors.Join(tp.Shutdown(ctx), mp.Shutdown(ctx))
+	}, nil
+}
+
+func newExporters(ctx context.Context, cfg Config) (sdktrace.SpanExporter, sdkmetric.Exporter, error) {
+	switch cfg.Exporter {
+	case ExporterStdout:
+		se, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, fmt.Errorf("telemetry: stdout trace exporter: %w", err)
+		}
+		me, err := stdoutmetric.New()
+		if err != nil {
+			return nil, nil, fmt.Errorf("telemetry: stdout metric exporter: %w", err)
+		}
+		return se, me, nil
+	case ExporterOTLP:
+		var topts []otlptracegrpc.Option
+		var mopts []otlpmetricgrpc.Option
+		if cfg.Endpoint != "" {
+			endpoint := strings.TrimPrefix(strings.TrimPrefix(cfg.Endpoint, "http://"), "https://")
+			topts = append(topts, otlptracegrpc.WithEndpoint(endpoint))
+			mopts = append(mopts, otlpmetricgrpc.WithEndpoint(endpoint))
+		}
+		if cfg.Insecure {
+			topts = append(topts, otlptracegrpc.WithInsecure())
+			mopts = append(mopts, otlpmetricgrpc.WithInsecure())
+		}
+		se, err := otlptracegrpc.New(ctx, topts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("telemetry: otlp trace exporter: %w", err)
+		}
+		me, err := otlpmetricgrpc.New(ctx, mopts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("telemetry: otlp metric exporter: %w", err)
+		}
+		return se, me, nil
+	}
+	return nil, nil, fmt.Errorf("telemetry: unknown exporter %q", cfg.Exporter)
+}
+
+// Tracer returns the package tracer from the current global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Meter returns the package meter from the current global provider.
+func Meter() metric.Meter {
+	return otel.Meter(instrumentationName)
+}
+
+// Propagator returns the global propagator Init installed.
+func Propagator() propagation.TextMapPropagator {
+	return otel.GetTextMapPropagator()
+}
+
+// InjectMap serializes ctx's trace context into a plain map — for carrying it
+// through storage, e.g. an outbox row's payload until the relay publishes it.
+func InjectMap(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	Propagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// ExtractMap is the inverse of InjectMap.
+func ExtractMap(ctx context.Context, m map[string]string) context.Context {
+	if len(m) == 0 {
+		return ctx
+	}
+	return Propagator().Extract(ctx, propagation.MapCarrier(m))
+}
@@ -0,0 +1,234 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// setup installs in-memory providers for the test and restores the previous
+// globals afterwards.
+func setup(t *testing.T) (*tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	t.Helper()
+	prevTP, prevMP, prevProp := otel.GetTracerProvider(), otel.GetMeterProvider(), otel.GetTextMapPropagator()
+	exp := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	resetInstruments()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetMeterProvider(prevMP)
+		otel.SetTextMapPropagator(prevProp)
+		resetInstruments()
+	})
+	return exp, reader
+}
+
+func attr(span tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func hasMetric(t *testing.T, reader *sdkmetric.ManualReader, name string) bool {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestInitNoneKeepsNoopProviders(t *testing.T) {
+	setup(t)
+	shutdown, err := Init(context.Background(), Config{Service: "svc"})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), Config{Service: "svc", Exporter: "zipkin"})
+	assert.Error(t, err)
+}
+
+func TestLoadConfigReadsTracesSampleRate(t *testing.T) {
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "")
+	t.Setenv("SENTRY_TRACES_SAMPLE_RATE", "")
+	assert.Equal(t, 1.0, LoadConfigFromEnv("svc").TracesSampleRate, "unset samples everything")
+
+	t.Setenv("SENTRY_TRACES_SAMPLE_RATE", "0")
+	assert.Equal(t, 0.0, LoadConfigFromEnv("svc").TracesSampleRate, "the Sentry rate is shared, 0 included")
+
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.25")
+	assert.Equal(t, 0.25, LoadConfigFromEnv("svc").TracesSampleRate, "the OTel variable wins")
+}
+
+func TestSamplerFollowsRateAndParent(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp), sdktrace.WithSampler(sampler(0)))
+	tr := tp.Tracer("test")
+
+	_, root := tr.Start(context.Background(), "root")
+	assert.False(t, root.SpanContext().IsSampled(), "a rate of 0 samples no new trace")
+	root.End()
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled, Remote: true,
+	})
+	_, child := tr.Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "child")
+	assert.True(t, child.SpanContext().IsSampled(), "a sampled parent is followed")
+	child.End()
+}
+
+func TestGinMiddlewareContinuesTrace(t *testing.T) {
+	exp, reader := setup(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GinMiddleware())
+	r.GET("/loads/:id", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled, Remote: true,
+	})
+	req := httptest.NewRequest(http.MethodGet, "/loads/42", nil)
+	Propagator().Inject(trace.ContextWithRemoteSpanContext(context.Background(), parent), propagation.HeaderCarrier(req.Header))
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, "GET /loads/:id", s.Name)
+	assert.Equal(t, trace.SpanKindServer, s.SpanKind)
+	assert.Equal(t, parent.TraceID(), s.SpanContext.TraceID())
+	assert.Equal(t, parent.SpanID(), s.Parent.SpanID())
+	assert.Equal(t, int64(http.StatusBadGateway), attr(s, "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Error, s.Status.Code)
+	assert.True(t, hasMetric(t, reader, "http.server.request.duration"))
+}
+
+func TestGRPCInterceptorsPropagate(t *testing.T) {
+	exp, _ := setup(t)
+	const method = "/tasks.TasksService/CreateTask"
+
+	var serverSpan trace.SpanContext
+	server := UnaryServerInterceptor()
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		in := metadata.NewIncomingContext(context.Background(), md)
+		_, err := server(in, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			serverSpan = trace.SpanContextFromContext(ctx)
+			return nil, grpcstatus.Error(grpccodes.NotFound, "no task")
+		})
+		return err
+	}
+
+	ctx, root := Tracer().Start(context.Background(), "root")
+	err := UnaryClientInterceptor()(ctx, method, nil, nil, nil, invoker)
+	root.End()
+	require.Error(t, err)
+
+	assert.Equal(t, root.SpanContext().TraceID(), serverSpan.TraceID())
+	byName := map[trace.SpanKind]tracetest.SpanStub{}
+	for _, s := range exp.GetSpans() {
+		byName[s.SpanKind] = s
+	}
+	client, srv := byName[trace.SpanKindClient], byName[trace.SpanKindServer]
+	assert.Equal(t, "tasks.TasksService/CreateTask", client.Name)
+	assert.Equal(t, client.SpanContext.SpanID(), srv.Parent.SpanID())
+	assert.Equal(t, "CreateTask", attr(srv, "rpc.method").AsString())
+	assert.Equal(t, int64(grpccodes.NotFound), attr(srv, "rpc.grpc.status_code").AsInt64())
+}
+
+func TestKafkaHeadersCarryTrace(t *testing.T) {
+	exp, reader := setup(t)
+	ctx, root := Tracer().Start(context.Background(), "request")
+
+	msg := kafka.Message{Topic: "shipments", Headers: []kafka.Header{{Key: "traceparent", Value: []byte("stale")}}}
+	span := StartProducerSpan(ctx, msg.Topic, &msg)
+	EndProducerSpan(ctx, span, msg.Topic, nil)
+	root.End()
+
+	n := 0
+	for _, h := range msg.Headers {
+		if h.Key == "traceparent" {
+			n++
+		}
+	}
+	assert.Equal(t, 1, n, "traceparent replaced, not duplicated")
+
+	_, consumer := StartConsumerSpan(context.Background(), "audit", msg, nil)
+	consumer.End()
+	assert.Equal(t, root.SpanContext().TraceID(), consumer.SpanContext().TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), exp.GetSpans()[len(exp.GetSpans())-1].Parent.SpanID())
+	assert.True(t, hasMetric(t, reader, "messaging.client.consumed.messages"))
+}
+
+func TestConsumerFallsBackToPayloadTraceContext(t *testing.T) {
+	setup(t)
+	ctx, root := Tracer().Start(context.Background(), "request")
+	carried := InjectMap(ctx)
+	root.End()
+	require.Contains(t, carried, "traceparent")
+
+	_, span := StartConsumerSpan(context.Background(), "audit", kafka.Message{Topic: "shipments"}, carried)
+	span.End()
+	assert.Equal(t, root.SpanContext().TraceID(), span.SpanContext().TraceID())
+
+	assert.Nil(t, InjectMap(context.Background()), "no span, nothing to carry")
+	assert.Equal(t, context.Background(), ExtractMap(context.Background(), nil))
+}
+
+func TestRedisHookRecordsCommandNameOnly(t *testing.T) {
+	exp, _ := setup(t)
+	hook := RedisHook{}
+
+	cmd := redis.NewStringCmd(context.Background(), "get", "company:share_link:secret-token")
+	ctx, err := hook.BeforeProcess(context.Background(), cmd)
+	require.NoError(t, err)
+	cmd.SetErr(redis.Nil)
+	require.NoError(t, hook.AfterProcess(ctx, cmd))
+
+	failed := redis.NewStatusCmd(context.Background(), "set", "k", "v")
+	ctx, _ = hook.BeforeProcessPipeline(context.Background(), []redis.Cmder{failed})
+	failed.SetErr(errors.New("READONLY"))
+	require.NoError(t, hook.AfterProcessPipeline(ctx, []redis.Cmder{failed}))
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "redis get", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code, "redis.Nil is a miss, not an error")
+	for _, kv := range spans[0].Attributes {
+		assert.NotContains(t, kv.Value.Emit(), "secret-token")
+	}
+	assert.Equal(t, "redis pipeline set", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
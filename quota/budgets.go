@@ -0,0 +1,129 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+// Period is a budget window. Windows are calendar UTC days and months, the
+// way the providers bill.
+type Period string
+
+const (
+	Daily   Period = "daily"
+	Monthly Period = "monthly"
+)
+
+// Budget caps one company's billed units with a provider — or with one op
+// of it — per Period. A unit is what the provider bills: one request, or one
+// origin × destination element of a matrix (see Meter.CheckN). Soft logs a
+// warning when reached; Hard blocks further calls. Zero disables either
+// limit.
+type Budget struct {
+	Provider string `json:"provider"`
+	// Op narrows the budget to one operation ("route", "geocode"); empty
+	// covers every call to Provider.
+	Op     string `json:"op,omitempty"`
+	Period Period `json:"period"`
+	Soft   int64  `json:"soft,omitempty"`
+	Hard   int64  `json:"hard,omitempty"`
+}
+
+func (b Budget) covers(provider, op string) bool {
+	return b.Provider == provider && (b.Op == "" || b.Op == op)
+}
+
+// field is the counter the budget is checked against.
+func (b Budget) field() string {
+	if b.Op == "" {
+		return b.Provider
+	}
+	return b.Provider + ":" + b.Op
+}
+
+func (b Budget) sameScope(o Budget) bool {
+	return b.Provider == o.Provider && b.Op == o.Op && b.Period == o.Period
+}
+
+// BudgetSource returns the budgets that apply to a company's calls to
+// provider. On error it may still return budgets; the Meter enforces them.
+type BudgetSource interface {
+	Budgets(ctx context.Context, companyID, provider string) ([]Budget, error)
+}
+
+// StaticBudgets applies the same budgets to every company.
+type StaticBudgets []Budget
+
+// Budgets implements BudgetSource.
+func (s StaticBudgets) Budgets(_ context.Context, _, provider string) ([]Budget, error) {
+	var out []Budget
+	for _, b := range s {
+		if b.Provider == provider {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// SettingKey is the company setting holding per-company budget overrides: a
+// JSON array of Budget at "{company_id}:setting:external_api_budgets".
+const SettingKey = "external_api_budgets"
+
+// CompanyBudgets layers per-company overrides from the SettingKey company
+// setting over Defaults. An override replaces the default with the same
+// provider, op and period; a missing setting means the defaults apply.
+type CompanyBudgets struct {
+	Defaults StaticBudgets
+	// Redis defaults to cache.Client().
+	Redis redis.Cmdable
+}
+
+// Budgets implements BudgetSource.
+func (c CompanyBudgets) Budgets(ctx context.Context, companyID, provider string) ([]Budget, error) {
+	defaults, _ := c.Defaults.Budgets(ctx, companyID, provider)
+	rdb := c.Redis
+	if rdb == nil {
+		if cl := cache.Client(); cl != nil {
+			rdb = cl
+		}
+	}
+	if rdb == nil {
+		return defaults, nil
+	}
+	data, err := rdb.Get(ctx, cache.ScopedKey(companyID, "setting:"+SettingKey)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return defaults, nil
+	}
+	if err != nil {
+		return defaults, err
+	}
+	var overrides []Budget
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return defaults, fmt.Errorf("quota: company %s budgets: %w", companyID, err)
+	}
+
+	out := make([]Budget, 0, len(defaults)+len(overrides))
+	for _, o := range overrides {
+		if o.Provider == provider {
+			out = append(out, o)
+		}
+	}
+	for _, d := range defaults {
+		overridden := false
+		for _, o := range out {
+			if d.sameScope(o) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,196 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// Event is one metered call.
+type Event struct {
+	Time      time.Time
+	CompanyID string
+	Provider  string
+	Op        string
+	Status    int
+	Billed    bool
+	// Units is what the call is billed as: 1, or a matrix's element count.
+	Units int64
+}
+
+// Sink receives every metered call. Record is called on the request path and
+// must not block.
+type Sink interface {
+	Record(Event)
+}
+
+// Conn is the part of *clickhouse.Client the sink uses.
+type Conn interface {
+	Exec(ctx context.Context, query string, args ...interface{}) error
+	Query(ctx context.Context, query string, args ...interface{}) (driver.Rows, error)
+	PrepareBatch(ctx context.Context, query string) (driver.Batch, error)
+}
+
+// DefaultTable is the ClickHouse table calls are written to.
+const DefaultTable = "external_api_calls"
+
+const (
+	sinkBuffer    = 4096
+	sinkBatchSize = 500
+	sinkFlush     = 5 * time.Second
+)
+
+// ClickHouseSink buffers events and writes them to ClickHouse in batches from
+// Run. When the buffer is full events are dropped (and counted) rather than
+// slowing the call that produced them: Redis stays authoritative for
+// enforcement, ClickHouse is for reporting.
+type ClickHouseSink struct {
+	conn    Conn
+	table   string
+	events  chan Event
+	dropped atomic.Int64
+	log     *slog.Logger
+}
+
+// NewClickHouseSink writes to table (DefaultTable when empty).
+func NewClickHouseSink(conn Conn, table string) *ClickHouseSink {
+	if table == "" {
+		table = DefaultTable
+	}
+	return &ClickHouseSink{conn: conn, table: table, events: make(chan Event, sinkBuffer), log: slog.Default()}
+}
+
+// EnsureTable creates the table if it does not exist. Rows are kept 25
+// months — long enough to answer a billing dispute over last year's invoice.
+func (s *ClickHouseSink) EnsureTable(ctx context.Context) error {
+	if err := s.conn.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	event_time DateTime64(3, 'UTC'),
+	company_id String,
+	provider   LowCardinality(String),
+	op         LowCardinality(String),
+	status     UInt16,
+	billed     UInt8,
+	units      UInt32 DEFAULT 1
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(event_time)
+ORDER BY (company_id, provider, op, event_time)
+TTL toDateTime(event_time) + INTERVAL 25 MONTH`, s.table)); err != nil {
+		return err
+	}
+	// Tables created before units existed gain the column; their rows read 1.
+	return s.conn.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS units UInt32 DEFAULT 1`, s.table))
+}
+
+// Record implements Sink.
+func (s *ClickHouseSink) Record(e Event) {
+	select {
+	case s.events <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many events were discarded on a full buffer.
+func (s *ClickHouseSink) Dropped() int64 { return s.dropped.Load() }
+
+// Run flushes buffered events every few seconds or whenever a batch fills,
+// until ctx ends; it then flushes what is left. Run it in its own goroutine.
+func (s *ClickHouseSink) Run(ctx context.Context) {
+	ticker := time.NewTicker(sinkFlush)
+	defer ticker.Stop()
+	batch := make([]Event, 0, sinkBatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.write(ctx, batch); err != nil {
+			s.log.Warn("quota: clickhouse flush failed", "events", len(batch), "err", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			for len(s.events) > 0 {
+				batch = append(batch, <-s.events)
+			}
+			fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			flush(fctx)
+			cancel()
+			return
+		case e := <-s.events:
+			batch = append(batch, e)
+			if len(batch) >= sinkBatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+func (s *ClickHouseSink) write(ctx context.Context, events []Event) error {
+	b, err := s.conn.PrepareBatch(ctx, "INSERT INTO "+s.table)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		var billed uint8
+		if e.Billed {
+			billed = 1
+		}
+		units := e.Units
+		if units <= 0 {
+			units = 1
+		}
+		if err := b.Append(e.Time.UTC(), e.CompanyID, e.Provider, e.Op, uint16(e.Status), billed, uint32(units)); err != nil {
+			_ = b.Abort()
+			return err
+		}
+	}
+	return b.Send()
+}
+
+// ReportRow is one line of a billing report.
+type ReportRow struct {
+	CompanyID string `json:"company_id"`
+	Provider  string `json:"provider"`
+	Op        string `json:"op"`
+	Calls     uint64 `json:"calls"`
+	Billed    uint64 `json:"billed"`
+	// BilledUnits is what Billed calls were billed as — elements for a
+	// matrix, otherwise equal to Billed.
+	BilledUnits uint64 `json:"billed_units"`
+}
+
+// Report aggregates calls in [from, to) by company, provider and op. An
+// empty companyID reports every company — the platform-wide invoice split.
+func (s *ClickHouseSink) Report(ctx context.Context, from, to time.Time, companyID string) ([]ReportRow, error) {
+	query := "SELECT company_id, provider, op, count() AS calls, sum(billed) AS billed, sum(billed * units) AS billed_units FROM " + s.table +
+		" WHERE event_time >= ? AND event_time < ?"
+	args := []interface{}{from.UTC(), to.UTC()}
+	if companyID != "" {
+		query += " AND company_id = ?"
+		args = append(args, companyID)
+	}
+	query += " GROUP BY company_id, provider, op ORDER BY company_id, provider, op"
+
+	rows, err := s.conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("quota: report: %w", err)
+	}
+	defer rows.Close()
+	var out []ReportRow
+	for rows.Next() {
+		var r ReportRow
+		if err := rows.Scan(&r.CompanyID, &r.Provider, &r.Op, &r.Calls, &r.Billed, &r.BilledUnits); err != nil {
+			return nil, fmt.Errorf("quota: report scan: %w", err)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,67 @@
+package quota
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageResponse is the body of UsageHandler.
+type UsageResponse struct {
+	CompanyID string     `json:"company_id"`
+	Period    Period     `json:"period"`
+	Window    string     `json:"window"`
+	Usage     []UsageRow `json:"usage"`
+	Budgets   []Budget   `json:"budgets,omitempty"`
+}
+
+// UsageHandler reports the calling company's billed calls for the window
+// named by ?period=daily|monthly (default monthly) containing ?at=YYYY-MM-DD
+// (default today), together with the budgets that apply. It sits behind the
+// normal auth chain; billing-wide reports come from ClickHouseSink.Report.
+// A nil Meter — metering disabled — answers 404.
+func (m *Meter) UsageHandler(providers ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "usage metering disabled"})
+			return
+		}
+		ctx := c.Request.Context()
+		actor, err := middleware.GetActor(ctx)
+		if err != nil || actor == nil || actor.IsGuest || actor.GetCompanyID() == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		period := Period(c.DefaultQuery("period", string(Monthly)))
+		if period != Daily && period != Monthly {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period must be daily or monthly"})
+			return
+		}
+		at := m.now()
+		if v := c.Query("at"); v != "" {
+			if at, err = time.Parse("2006-01-02", v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "at must be YYYY-MM-DD"})
+				return
+			}
+		}
+
+		company := actor.GetCompanyID().String()
+		usage, err := m.Usage(ctx, company, period, at)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "usage unavailable"})
+			return
+		}
+		resp := UsageResponse{CompanyID: company, Period: period, Window: bucket(period, at), Usage: usage}
+		for _, p := range providers {
+			budgets, _ := m.budgets.Budgets(ctx, company, p)
+			for _, b := range budgets {
+				if b.Period == period {
+					resp.Budgets = append(resp.Budgets, b)
+				}
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,251 @@
+// Package quota meters billed external API calls per tenant and enforces
+// per-company budgets. HERE, Google Maps and USPS charge per request against
+// one shared platform key, so one tenant re-running route calculations in a
+// loop spends everyone's money; the clients call Check before and Record
+// after every request:
+//
+//	if err := quota.Default().Check(ctx, metrics.ProviderHERE, op); err != nil {
+//		return nil, err // *BudgetExceededError — hard limit reached
+//	}
+//	resp, err := httpClient.Do(req)
+//	quota.Default().Record(ctx, metrics.ProviderHERE, op, resp.StatusCode)
+//
+// Calls billed per element rather than per request — distance matrices, one
+// unit per origin × destination — use CheckN and RecordN with the element
+// count, so a budget caps what is actually spent.
+//
+// Counters live in Redis (per company, per UTC day and month) and back both
+// enforcement and the Usage query; every call is also handed to an optional
+// Sink — ClickHouseSink — for long-term billing reports.
+//
+// A nil *Meter is valid and does nothing, so clients meter unconditionally and
+// services that never call SetDefault are unaffected.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/TMS360/backend-pkg/cache"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrBudgetExceeded matches every *BudgetExceededError.
+var ErrBudgetExceeded = errors.New("quota: external API budget exceeded")
+
+// BudgetExceededError is returned by Check when a hard limit is reached.
+type BudgetExceededError struct {
+	CompanyID string
+	Budget    Budget
+	Used      int64
+}
+
+func (e *BudgetExceededError) Error() string {
+	scope := e.Budget.Provider
+	if e.Budget.Op != "" {
+		scope += "/" + e.Budget.Op
+	}
+	return fmt.Sprintf("quota: %s %s budget exhausted for company %s (%d/%d calls)",
+		scope, e.Budget.Period, e.CompanyID, e.Used, e.Budget.Hard)
+}
+
+func (e *BudgetExceededError) Is(target error) bool { return target == ErrBudgetExceeded }
+
+// Meter counts and limits external calls. Build one per service with
+// NewMeter and install it with SetDefault.
+type Meter struct {
+	rdb     redis.Cmdable
+	budgets BudgetSource
+	sink    Sink
+	log     *slog.Logger
+	now     func() time.Time
+}
+
+// Option configures a Meter.
+type Option func(*Meter)
+
+// WithRedis sets the counter store; the default is cache.Client() at call
+// time.
+func WithRedis(rdb redis.Cmdable) Option { return func(m *Meter) { m.rdb = rdb } }
+
+// WithBudgets sets where budgets come from. Without it calls are only
+// counted.
+func WithBudgets(src BudgetSource) Option { return func(m *Meter) { m.budgets = src } }
+
+// WithSink forwards every metered call to s (e.g. a ClickHouseSink).
+func WithSink(s Sink) Option { return func(m *Meter) { m.sink = s } }
+
+// WithLogger sets the logger for budget warnings; default slog.Default().
+func WithLogger(l *slog.Logger) Option { return func(m *Meter) { m.log = l } }
+
+// NewMeter builds a Meter.
+func NewMeter(opts ...Option) *Meter {
+	m := &Meter{budgets: StaticBudgets(nil), log: slog.Default(), now: time.Now}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+var defaultMeter atomic.Pointer[Meter]
+
+// SetDefault installs m as the meter the provider clients use. Call it once
+// from main; nil turns metering off.
+func SetDefault(m *Meter) { defaultMeter.Store(m) }
+
+// Default returns the installed meter, or nil.
+func Default() *Meter { return defaultMeter.Load() }
+
+type companyKey struct{}
+
+// WithCompany attributes calls made with ctx to companyID. Background jobs,
+// which run without an actor, must tag their context or their calls are
+// counted as unattributed and never limited.
+func WithCompany(ctx context.Context, companyID string) context.Context {
+	return context.WithValue(ctx, companyKey{}, companyID)
+}
+
+// CompanyFrom returns the company a call is billed to: the WithCompany tag,
+// else the actor's company, else "".
+func CompanyFrom(ctx context.Context) string {
+	if id, _ := ctx.Value(companyKey{}).(string); id != "" {
+		return id
+	}
+	if actor, err := middleware.GetActor(ctx); err == nil && actor != nil {
+		if cid := actor.GetCompanyID(); cid != nil {
+			return cid.String()
+		}
+	}
+	return ""
+}
+
+func (m *Meter) redis() redis.Cmdable {
+	if m.rdb != nil {
+		return m.rdb
+	}
+	if c := cache.Client(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// Check returns a *BudgetExceededError when the caller's company has used up
+// a hard budget covering provider/op. Unattributed calls are never blocked,
+// and a Redis failure fails open — a metering outage must not take routing
+// down with it.
+func (m *Meter) Check(ctx context.Context, provider, op string) error {
+	return m.CheckN(ctx, provider, op, 1)
+}
+
+// CheckN is Check for a call billed as units: it is refused when the units
+// would take a hard budget past its limit.
+func (m *Meter) CheckN(ctx context.Context, provider, op string, units int64) error {
+	if m == nil {
+		return nil
+	}
+	company := CompanyFrom(ctx)
+	if company == "" {
+		return nil
+	}
+	rdb := m.redis()
+	if rdb == nil {
+		return nil
+	}
+	budgets := m.budgetsFor(ctx, company, provider)
+	now := m.now()
+	for _, b := range budgets {
+		if b.Hard <= 0 || !b.covers(provider, op) {
+			continue
+		}
+		used, err := readCounter(ctx, rdb, company, b.Period, now, b.field())
+		if err != nil {
+			m.log.WarnContext(ctx, "quota: counter read failed, not enforcing", "provider", provider, "err", err)
+			return nil
+		}
+		if used+units > b.Hard {
+			metrics.ObserveBudgetEvent(provider, metrics.BudgetBlocked)
+			return &BudgetExceededError{CompanyID: company, Budget: b, Used: used}
+		}
+	}
+	return nil
+}
+
+// Record meters one finished call. status is the HTTP status, 0 when the
+// request never reached the provider. Only 2xx responses are billed and
+// counted against budgets; every call is passed to the Sink.
+func (m *Meter) Record(ctx context.Context, provider, op string, status int) {
+	m.RecordN(ctx, provider, op, status, 1)
+}
+
+// RecordN is Record for a call billed as units; a billed call adds units to
+// the counters.
+func (m *Meter) RecordN(ctx context.Context, provider, op string, status int, units int64) {
+	if m == nil {
+		return
+	}
+	company := CompanyFrom(ctx)
+	billed := status >= 200 && status < 300
+	now := m.now()
+	if m.sink != nil {
+		m.sink.Record(Event{
+			Time:      now,
+			CompanyID: company,
+			Provider:  provider,
+			Op:        op,
+			Status:    status,
+			Billed:    billed,
+			Units:     units,
+		})
+	}
+	if !billed || company == "" {
+		return
+	}
+	rdb := m.redis()
+	if rdb == nil {
+		return
+	}
+	counts, err := incrCounters(ctx, rdb, company, provider, op, units, now)
+	if err != nil {
+		m.log.WarnContext(ctx, "quota: counter update failed", "provider", provider, "op", op, "err", err)
+		return
+	}
+	m.warnSoft(ctx, company, provider, op, units, counts)
+}
+
+// budgetsFor returns the budgets to enforce for company. A source that fails
+// may still return budgets — CompanyBudgets returns its defaults when an
+// override is corrupt or Redis is down — and those are enforced: a broken
+// override must not lift the limits the defaults guarantee. Only when there
+// are none does metering fail open.
+func (m *Meter) budgetsFor(ctx context.Context, company, provider string) []Budget {
+	budgets, err := m.budgets.Budgets(ctx, company, provider)
+	if err != nil {
+		if len(budgets) == 0 {
+			m.log.WarnContext(ctx, "quota: budgets unavailable, not enforcing", "provider", provider, "err", err)
+		} else {
+			m.log.WarnContext(ctx, "quota: budgets partially unavailable, enforcing defaults", "provider", provider, "err", err)
+		}
+	}
+	return budgets
+}
+
+// warnSoft logs once per period, on the call that reaches a soft limit.
+func (m *Meter) warnSoft(ctx context.Context, company, provider, op string, units int64, counts map[counterRef]int64) {
+	for _, b := range m.budgetsFor(ctx, company, provider) {
+		if b.Soft <= 0 || !b.covers(provider, op) {
+			continue
+		}
+		if n := counts[counterRef{b.Period, b.field()}]; n >= b.Soft && n-units < b.Soft {
+			metrics.ObserveBudgetEvent(provider, metrics.BudgetSoftLimit)
+			m.log.WarnContext(ctx, "external_api_budget_soft_limit",
+				"company_id", company, "provider", provider, "op", b.Op,
+				"period", string(b.Period), "soft", b.Soft, "hard", b.Hard)
+		}
+	}
+}
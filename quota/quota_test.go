@@ -0,0 +1,237 @@
+package quota
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *memSink) Record(e Event) {
+	s.mu.Lock()
+	s.events = append(s.events, e)
+	s.mu.Unlock()
+}
+
+func newTestMeter(t *testing.T, opts ...Option) (*Meter, *redis.Client, *bytes.Buffer) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	var logs bytes.Buffer
+	m := NewMeter(append([]Option{WithRedis(rdb), WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))}, opts...)...)
+	m.now = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+	return m, rdb, &logs
+}
+
+func TestHardBudgetBlocks(t *testing.T) {
+	m, _, logs := newTestMeter(t, WithBudgets(StaticBudgets{
+		{Provider: "here", Period: Daily, Soft: 2, Hard: 3},
+	}))
+	ctx := WithCompany(context.Background(), "acme")
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.Check(ctx, "here", "routes"))
+		m.Record(ctx, "here", "routes", http.StatusOK)
+	}
+	err := m.Check(ctx, "here", "geocode")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	var be *BudgetExceededError
+	require.True(t, errors.As(err, &be))
+	assert.EqualValues(t, 3, be.Used)
+	assert.Equal(t, "acme", be.CompanyID)
+
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("external_api_budget_soft_limit")), "soft limit warns once")
+	assert.NoError(t, m.Check(WithCompany(context.Background(), "other"), "here", "routes"), "budgets are per company")
+	assert.NoError(t, m.Check(ctx, "googlemaps", "geocode"), "and per provider")
+}
+
+func TestOpBudgetOnlyCoversItsOp(t *testing.T) {
+	m, _, _ := newTestMeter(t, WithBudgets(StaticBudgets{
+		{Provider: "here", Op: "routes", Period: Monthly, Hard: 1},
+	}))
+	ctx := WithCompany(context.Background(), "acme")
+	m.Record(ctx, "here", "routes", http.StatusOK)
+	assert.ErrorIs(t, m.Check(ctx, "here", "routes"), ErrBudgetExceeded)
+	assert.NoError(t, m.Check(ctx, "here", "geocode"))
+}
+
+func TestUnitsCountAgainstBudgets(t *testing.T) {
+	sink := &memSink{}
+	m, _, logs := newTestMeter(t, WithSink(sink), WithBudgets(StaticBudgets{
+		{Provider: "here", Period: Daily, Soft: 50, Hard: 100},
+	}))
+	ctx := WithCompany(context.Background(), "acme")
+
+	require.NoError(t, m.CheckN(ctx, "here", "matrix", 60))
+	m.RecordN(ctx, "here", "matrix", http.StatusOK, 60)
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("external_api_budget_soft_limit")), "a block that jumps past the soft limit still warns")
+	assert.ErrorIs(t, m.CheckN(ctx, "here", "matrix", 60), ErrBudgetExceeded, "60 more elements would pass the hard limit")
+	assert.NoError(t, m.Check(ctx, "here", "routes"), "a single call still fits")
+
+	rows, err := m.Usage(ctx, "acme", Daily, m.now())
+	require.NoError(t, err)
+	assert.EqualValues(t, 60, rows[0].Calls)
+	assert.EqualValues(t, 60, sink.events[0].Units)
+}
+
+func TestOnlyBilledAttributedCallsCount(t *testing.T) {
+	sink := &memSink{}
+	m, _, _ := newTestMeter(t, WithSink(sink), WithBudgets(StaticBudgets{{Provider: "here", Period: Daily, Hard: 1}}))
+	ctx := WithCompany(context.Background(), "acme")
+
+	m.Record(ctx, "here", "routes", http.StatusInternalServerError)
+	m.Record(ctx, "here", "routes", 0)
+	m.Record(context.Background(), "here", "routes", http.StatusOK)
+	assert.NoError(t, m.Check(ctx, "here", "routes"))
+	assert.NoError(t, m.Check(context.Background(), "here", "routes"), "unattributed calls are never blocked")
+
+	require.Len(t, sink.events, 3, "every call reaches the sink")
+	assert.False(t, sink.events[0].Billed)
+	assert.True(t, sink.events[2].Billed)
+	assert.Equal(t, "", sink.events[2].CompanyID)
+}
+
+func TestCompanyFromActor(t *testing.T) {
+	cid := uuid.New()
+	ctx := middleware.WithActor(context.Background(), &consts.Actor{
+		ID:     uuid.New(),
+		Claims: &consts.UserClaims{CompanyID: &cid},
+	})
+	assert.Equal(t, cid.String(), CompanyFrom(ctx))
+	assert.Equal(t, "job", CompanyFrom(WithCompany(ctx, "job")), "explicit tag wins")
+	assert.Equal(t, "", CompanyFrom(context.Background()))
+}
+
+func TestCompanyBudgetsOverrideDefaults(t *testing.T) {
+	_, rdb, _ := newTestMeter(t)
+	src := CompanyBudgets{
+		Defaults: StaticBudgets{
+			{Provider: "here", Period: Monthly, Hard: 1000},
+			{Provider: "here", Period: Daily, Hard: 100},
+		},
+		Redis: rdb,
+	}
+	ctx := context.Background()
+	got, err := src.Budgets(ctx, "acme", "here")
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	raw, _ := json.Marshal([]Budget{{Provider: "here", Period: Daily, Hard: 5}})
+	require.NoError(t, rdb.Set(ctx, "acme:setting:"+SettingKey, raw, 0).Err())
+	got, err = src.Budgets(ctx, "acme", "here")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []Budget{
+		{Provider: "here", Period: Daily, Hard: 5},
+		{Provider: "here", Period: Monthly, Hard: 1000},
+	}, got)
+
+	require.NoError(t, rdb.Set(ctx, "acme:setting:"+SettingKey, "{broken", 0).Err())
+	got, err = src.Budgets(ctx, "acme", "here")
+	assert.Error(t, err)
+	assert.Len(t, got, 2, "defaults still returned on a broken override")
+}
+
+func TestBrokenOverrideStillEnforcesDefaults(t *testing.T) {
+	m, rdb, logs := newTestMeter(t)
+	m.budgets = CompanyBudgets{Defaults: StaticBudgets{{Provider: "here", Period: Daily, Soft: 1, Hard: 2}}, Redis: rdb}
+	ctx := WithCompany(context.Background(), "acme")
+	require.NoError(t, rdb.Set(ctx, "acme:setting:"+SettingKey, "{broken", 0).Err())
+
+	m.Record(ctx, "here", "routes", http.StatusOK)
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("external_api_budget_soft_limit")), "soft limit still warns")
+	m.Record(ctx, "here", "routes", http.StatusOK)
+	assert.ErrorIs(t, m.Check(ctx, "here", "routes"), ErrBudgetExceeded, "defaults still block")
+}
+
+func TestRedisFailureFailsOpen(t *testing.T) {
+	m, rdb, _ := newTestMeter(t, WithBudgets(StaticBudgets{{Provider: "here", Period: Daily, Hard: 1}}))
+	_ = rdb.Close()
+	ctx := WithCompany(context.Background(), "acme")
+	assert.NoError(t, m.Check(ctx, "here", "routes"))
+	m.Record(ctx, "here", "routes", http.StatusOK)
+}
+
+func TestNilMeterIsNoop(t *testing.T) {
+	var m *Meter
+	assert.NoError(t, m.Check(context.Background(), "here", "routes"))
+	m.Record(context.Background(), "here", "routes", http.StatusOK)
+}
+
+func TestUsage(t *testing.T) {
+	m, _, _ := newTestMeter(t)
+	ctx := WithCompany(context.Background(), "acme")
+	m.Record(ctx, "here", "routes", http.StatusOK)
+	m.Record(ctx, "here", "routes", http.StatusOK)
+	m.Record(ctx, "here", "geocode", http.StatusOK)
+	m.Record(ctx, "usps", "addresses/v3/address", http.StatusOK)
+
+	rows, err := m.Usage(context.Background(), "acme", Monthly, m.now())
+	require.NoError(t, err)
+	assert.Equal(t, []UsageRow{
+		{Provider: "here", Calls: 3},
+		{Provider: "here", Op: "geocode", Calls: 1},
+		{Provider: "here", Op: "routes", Calls: 2},
+		{Provider: "usps", Calls: 1},
+		{Provider: "usps", Op: "addresses/v3/address", Calls: 1},
+	}, rows)
+
+	rows, err = m.Usage(context.Background(), "acme", Daily, m.now().AddDate(0, 0, -1))
+	require.NoError(t, err)
+	assert.Empty(t, rows, "yesterday's window is separate")
+}
+
+func TestUsageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m, _, _ := newTestMeter(t, WithBudgets(StaticBudgets{{Provider: "here", Period: Monthly, Soft: 50, Hard: 100}}))
+	cid := uuid.New()
+	m.Record(WithCompany(context.Background(), cid.String()), "here", "routes", http.StatusOK)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(middleware.WithActor(c.Request.Context(), &consts.Actor{
+			ID: uuid.New(), Claims: &consts.UserClaims{CompanyID: &cid},
+		}))
+	})
+	r.GET("/usage", m.UsageHandler("here"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/usage?period=monthly&at=2026-10-01", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body UsageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "2026-10", body.Window)
+	assert.Len(t, body.Usage, 2)
+	assert.Len(t, body.Budgets, 1)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/usage?period=weekly", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var disabled *Meter
+	r.GET("/disabled", disabled.UsageHandler("here"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/disabled", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,125 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis layout: one hash per company and window,
+//
+//	quota:{company}:daily:2026-10-18   here → 412, here:route → 380, …
+//	quota:{company}:monthly:2026-10    here → 9120, …
+//
+// with a provider total field and a provider:op field per call, so a budget
+// check is one HGET and a usage report one HGETALL. Windows expire well after
+// they close so last month stays queryable.
+const keyPrefix = "quota:"
+
+var retention = map[Period]time.Duration{
+	Daily:   40 * 24 * time.Hour,
+	Monthly: 400 * 24 * time.Hour,
+}
+
+func bucket(p Period, t time.Time) string {
+	t = t.UTC()
+	if p == Monthly {
+		return t.Format("2006-01")
+	}
+	return t.Format("2006-01-02")
+}
+
+func counterKey(company string, p Period, t time.Time) string {
+	return keyPrefix + company + ":" + string(p) + ":" + bucket(p, t)
+}
+
+type counterRef struct {
+	period Period
+	field  string
+}
+
+func readCounter(ctx context.Context, rdb redis.Cmdable, company string, p Period, now time.Time, field string) (int64, error) {
+	n, err := rdb.HGet(ctx, counterKey(company, p, now), field).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// incrCounters adds units to the provider and provider:op fields of both
+// windows in one round trip and returns the new values.
+func incrCounters(ctx context.Context, rdb redis.Cmdable, company, provider, op string, units int64, now time.Time) (map[counterRef]int64, error) {
+	fields := []string{provider}
+	if op != "" {
+		fields = append(fields, provider+":"+op)
+	}
+	type pending struct {
+		ref counterRef
+		cmd *redis.IntCmd
+	}
+	var cmds []pending
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, p := range []Period{Daily, Monthly} {
+			key := counterKey(company, p, now)
+			for _, f := range fields {
+				cmds = append(cmds, pending{counterRef{p, f}, pipe.HIncrBy(ctx, key, f, units)})
+			}
+			pipe.Expire(ctx, key, retention[p])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[counterRef]int64, len(cmds))
+	for _, c := range cmds {
+		out[c.ref] = c.cmd.Val()
+	}
+	return out, nil
+}
+
+// UsageRow is one company's billed calls to provider (and op, when set) in a
+// window — counted in units, so a matrix counts its elements. Rows with an
+// empty Op are the provider totals.
+type UsageRow struct {
+	Provider string `json:"provider"`
+	Op       string `json:"op,omitempty"`
+	Calls    int64  `json:"calls"`
+}
+
+// Usage returns companyID's billed calls in the period window containing at,
+// sorted by provider with each provider's total first.
+func (m *Meter) Usage(ctx context.Context, companyID string, p Period, at time.Time) ([]UsageRow, error) {
+	if m == nil {
+		return nil, nil
+	}
+	rdb := m.redis()
+	if rdb == nil {
+		return nil, errors.New("quota: redis not configured")
+	}
+	raw, err := rdb.HGetAll(ctx, counterKey(companyID, p, at)).Result()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]UsageRow, 0, len(raw))
+	for field, v := range raw {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		provider, op, _ := strings.Cut(field, ":")
+		rows = append(rows, UsageRow{Provider: provider, Op: op, Calls: n})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Provider != rows[j].Provider {
+			return rows[i].Provider < rows[j].Provider
+		}
+		return rows[i].Op < rows[j].Op
+	})
+	return rows, nil
+}
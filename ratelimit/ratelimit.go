@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/TMS360/backend-pkg/cache"
+	"github.com/TMS360/backend-pkg/observability/metrics"
 	"github.com/go-redis/redis/v8"
 )
 
@@ -25,6 +26,9 @@ return n
 // Fail-open: if Redis is not initialized or returns an error, Allow returns true
 // so transient infra problems don't lock out legitimate traffic. Callers that
 // need fail-closed semantics should check the returned error.
+//
+// Each decision is counted in tms_ratelimit_decisions_total under the key's
+// leading segment, so keys should start with a fixed limiter name ("auth:…").
 func Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
 	rdb := cache.Client()
 	if rdb == nil {
@@ -33,7 +37,10 @@ func Allow(ctx context.Context, key string, limit int, window time.Duration) (bo
 
 	n, err := incrExpireScript.Run(ctx, rdb, []string{"ratelimit:" + key}, window.Milliseconds()).Int64()
 	if err != nil {
+		metrics.ObserveRateLimit(key, true, err)
 		return true, err
 	}
-	return n <= int64(limit), nil
+	allowed := n <= int64(limit)
+	metrics.ObserveRateLimit(key, allowed, nil)
+	return allowed, nil
 }
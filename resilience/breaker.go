@@ -0,0 +1,247 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TMS360/backend-pkg/observability/metrics"
+)
+
+// State is a breaker's position.
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// BreakerConfig tunes a Breaker; zero fields take the defaults.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive failures that opens the
+	// breaker. Default 5.
+	Threshold int
+	// Cooldown is how long the breaker stays open before letting probes
+	// through. Default 5s.
+	Cooldown time.Duration
+	// HalfOpenProbes is how many calls may be in flight while half-open; the
+	// first success closes the breaker, the first failure reopens it.
+	// Default 1.
+	HalfOpenProbes int
+}
+
+// Breaker is a consecutive-failure circuit breaker. While open it rejects
+// calls with ErrCircuitOpen instead of piling latency onto a dependency that
+// is down. Its state is exported as tms_circuit_breaker_open{breaker=name}.
+type Breaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openUntil time.Time
+	probes    int
+}
+
+// NewBreaker returns a closed breaker. name labels its metrics — one breaker
+// per dependency, shared by every client of it in the process.
+func NewBreaker(name string, cfg BreakerConfig) *Breaker {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 5 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &Breaker{name: name, cfg: cfg, now: time.Now}
+}
+
+// Name returns the breaker's metrics label.
+func (b *Breaker) Name() string { return b.name }
+
+// State returns the current state, moving open to half-open once the
+// cooldown has passed.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+func (b *Breaker) advance() {
+	if b.state == StateOpen && !b.now().Before(b.openUntil) {
+		b.state = StateHalfOpen
+		b.probes = 0
+	}
+}
+
+// Allow reserves a call. It returns ErrCircuitOpen when the call must not be
+// made; otherwise the caller must report the outcome with Success or Failure.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	switch b.state {
+	case StateOpen:
+		return ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// Success records a healthy call and closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	if b.state != StateClosed {
+		b.state = StateClosed
+		b.probes = 0
+		metrics.SetBreakerOpen(b.name, false)
+	}
+}
+
+// Failure records a failed call; the Threshold-th in a row, or any failed
+// half-open probe, opens the breaker for Cooldown.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.cfg.Threshold) {
+		b.state = StateOpen
+		b.openUntil = b.now().Add(b.cfg.Cooldown)
+		b.failures = 0
+		metrics.SetBreakerOpen(b.name, true)
+	}
+}
+
+// Release gives back a reservation whose outcome says nothing about the
+// dependency — the caller gave up. A half-open probe slot is freed for the
+// next caller.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// Execute runs fn under the breaker: ErrCircuitOpen without calling fn while
+// open, otherwise fn's error, counted as a failure when isFailure says so
+// (nil isFailure counts every error except the caller's own cancellation).
+func (b *Breaker) Execute(ctx context.Context, isFailure func(error) bool, fn func(context.Context) error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn(ctx)
+	b.record(ctx, err, isFailure)
+	return err
+}
+
+func (b *Breaker) record(ctx context.Context, err error, isFailure func(error) bool) {
+	switch {
+	case err == nil:
+		b.Success()
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		b.Release()
+	case isFailure == nil || isFailure(err):
+		b.Failure()
+	default:
+		b.Success()
+	}
+}
+
+// FailureResponse is the default HTTP failure classification for a breaker:
+// transport errors and 5xx. 4xx (including 429) are the caller's problem and
+// say the dependency is up.
+func FailureResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// CircuitBreaker guards a transport with b; isFailure nil uses
+// FailureResponse. A rejected request returns ErrCircuitOpen (wrapped by
+// http.Client into a *url.Error, so match with errors.Is).
+func CircuitBreaker(b *Breaker, isFailure func(*http.Response, error) bool) Middleware {
+	if isFailure == nil {
+		isFailure = FailureResponse
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := b.Allow(); err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			switch {
+			case err != nil && req.Context().Err() != nil:
+				b.Release()
+			case isFailure(resp, err):
+				b.Failure()
+			default:
+				b.Success()
+			}
+			return resp, err
+		})
+	}
+}
+
+// BreakerSet hands out one Breaker per host of a provider, so an outage of
+// one regional endpoint does not cut off the others. Breakers are labelled
+// "name:host" in metrics.
+type BreakerSet struct {
+	name string
+	cfg  BreakerConfig
+
+	mu sync.Mutex
+	m  map[string]*Breaker
+}
+
+// NewBreakerSet returns an empty set; breakers are created on first use.
+func NewBreakerSet(name string, cfg BreakerConfig) *BreakerSet {
+	return &BreakerSet{name: name, cfg: cfg, m: make(map[string]*Breaker)}
+}
+
+// Get returns host's breaker.
+func (s *BreakerSet) Get(host string) *Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.m[host]
+	if !ok {
+		b = NewBreaker(s.name+":"+host, s.cfg)
+		s.m[host] = b
+	}
+	return b
+}
+
+// HostCircuitBreaker is CircuitBreaker with the breaker picked from s by the
+// request's host.
+func HostCircuitBreaker(s *BreakerSet, isFailure func(*http.Response, error) bool) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return CircuitBreaker(s.Get(req.URL.Host), isFailure)(next).RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package resilience
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Limiter is a counting semaphore — the bulkhead. One Limiter shared by the
+// clients of a dependency caps how many goroutines it can tie up, so a slow
+// provider exhausts its own slots and not the whole service.
+type Limiter struct {
+	slots chan struct{}
+	wait  time.Duration
+}
+
+// NewLimiter allows limit concurrent calls; a call waits up to wait for a
+// slot (0 fails immediately when full).
+func NewLimiter(limit int, wait time.Duration) *Limiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &Limiter{slots: make(chan struct{}, limit), wait: wait}
+}
+
+// Acquire takes a slot, returning ErrBulkheadFull after the wait or ctx's
+// error. On success the caller must call Release.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if l.wait <= 0 {
+		return ErrBulkheadFull
+	}
+	t := time.NewTimer(l.wait)
+	defer t.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-t.C:
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (l *Limiter) Release() { <-l.slots }
+
+// InFlight returns the number of slots in use.
+func (l *Limiter) InFlight() int { return len(l.slots) }
+
+// Bulkhead limits concurrent requests through the transport to l. The slot
+// is held until the response body is closed, since reading the body still
+// occupies the provider connection.
+func Bulkhead(l *Limiter) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := l.Acquire(req.Context()); err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil || resp.Body == nil {
+				l.Release()
+				return resp, err
+			}
+			resp.Body = &onCloseBody{ReadCloser: resp.Body, onClose: l.Release}
+			return resp, nil
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package resilience
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClientOptions is the transport setup a provider client is built with: its
+// metering base, its default middleware stack and its timeout. Each client
+// package declares one as its vendor policy and builds on it:
+//
+//	var transport = resilience.ClientOptions{
+//		Base:     metrics.Transport(metrics.ProviderSamsara, nil, nil),
+//		Defaults: resilience.Standard(resilience.NewBreakerSet(metrics.ProviderSamsara, resilience.BreakerConfig{})),
+//		Timeout:  30 * time.Second,
+//	}
+//
+//	c := &Client{httpClient: transport.HTTPClient()}
+//
+// The package's WithResilience option is then transport.Replace. The breaker
+// set lives in Defaults, so every client of the vendor in the process shares
+// it: tenants bring their own keys but hit the same hosts, and an outage
+// trips one breaker instead of being rediscovered by each request.
+type ClientOptions struct {
+	// Base is the innermost transport — usually metrics.Transport; nil is
+	// http.DefaultTransport.
+	Base http.RoundTripper
+	// Defaults is the middleware stack used unless the caller replaces it.
+	Defaults []Middleware
+	// Timeout is the http.Client timeout. With a retry in Defaults it must
+	// cover every attempt, not one (see Policy.TotalTimeout).
+	Timeout time.Duration
+}
+
+// Transport chains mws around Base.
+func (o ClientOptions) Transport(mws ...Middleware) http.RoundTripper {
+	return Chain(o.Base, mws...)
+}
+
+// HTTPClient returns a new client with the default stack.
+func (o ClientOptions) HTTPClient() *http.Client {
+	return &http.Client{Timeout: o.Timeout, Transport: o.Transport(o.Defaults...)}
+}
+
+// Replace returns a copy of hc whose transport is mws around Base — with no
+// mws, one attempt per call. Metering in Base is unaffected; hc's other
+// settings are kept.
+func (o ClientOptions) Replace(hc *http.Client, mws ...Middleware) *http.Client {
+	c := *hc
+	c.Transport = o.Transport(mws...)
+	return &c
+}
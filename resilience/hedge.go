@@ -0,0 +1,126 @@
+package resilience
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Hedge sends a duplicate of an idempotent request when the first has not
+// answered within delay, up to maxHedges extra copies, and returns whichever
+// response arrives first; the others are canceled. It trims tail latency on
+// read-heavy lookups at the cost of extra load, so use it only on cheap,
+// unbilled GETs. Non-idempotent or non-replayable requests pass through.
+func Hedge(delay time.Duration, maxHedges int) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if maxHedges < 1 || !IsIdempotent(req) || !replayable(req) {
+				return next.RoundTrip(req)
+			}
+			return hedge(next, req, delay, maxHedges)
+		})
+	}
+}
+
+type hedgeResult struct {
+	i    int
+	resp *http.Response
+	err  error
+}
+
+func hedge(next http.RoundTripper, req *http.Request, delay time.Duration, maxHedges int) (*http.Response, error) {
+	results := make(chan hedgeResult, maxHedges+1)
+	var cancels []context.CancelFunc
+	launch := func() error {
+		ctx, cancel := context.WithCancel(req.Context())
+		r, err := cloneForRetry(req)
+		if err != nil {
+			cancel()
+			return err
+		}
+		r = r.WithContext(ctx)
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := next.RoundTrip(r)
+			results <- hedgeResult{i: i, resp: resp, err: err}
+		}()
+		return nil
+	}
+	if err := launch(); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var last hedgeResult
+	pending := 1
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if len(cancels) <= maxHedges && launch() == nil {
+				pending++
+				if len(cancels) <= maxHedges {
+					timer.Reset(delay)
+				}
+			}
+		case res := <-results:
+			pending--
+			if res.err != nil && pending > 0 {
+				last = res
+				continue
+			}
+			if res.err != nil && len(cancels) <= maxHedges && req.Context().Err() == nil {
+				// Every copy so far failed; send the next right away rather
+				// than wait out the timer.
+				last = res
+				if launch() == nil {
+					pending++
+				}
+				continue
+			}
+			winner := res.i
+			for i, cancel := range cancels {
+				if i != winner {
+					cancel()
+				}
+			}
+			go drainLosers(results, pending)
+			if res.err != nil {
+				cancels[winner]()
+				return nil, res.err
+			}
+			if res.resp.Body != nil {
+				res.resp.Body = &onCloseBody{ReadCloser: res.resp.Body, onClose: cancels[winner]}
+			} else {
+				cancels[winner]()
+			}
+			return res.resp, nil
+		}
+	}
+	return last.resp, last.err
+}
+
+// drainLosers closes the bodies of responses that lost the race.
+func drainLosers(results <-chan hedgeResult, n int) {
+	for ; n > 0; n-- {
+		if res := <-results; res.resp != nil {
+			drain(res.resp)
+		}
+	}
+}
+
+// onCloseBody runs onClose once when the body is closed.
+type onCloseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	onClose func()
+}
+
+func (b *onCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.onClose)
+	return err
+}
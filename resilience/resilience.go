@@ -0,0 +1,93 @@
+// Package resilience is the shared fault-handling toolkit for outbound calls:
+// retry with jittered exponential backoff (Retry-After and idempotency
+// aware), a circuit breaker, a bulkhead (concurrency limit) and request
+// hedging. Each is an http.RoundTripper middleware, so a client adopts them
+// by wrapping its transport:
+//
+//	rt := resilience.Chain(http.DefaultTransport,
+//		resilience.Retry(resilience.DefaultPolicy()),
+//		resilience.CircuitBreaker(resilience.NewBreaker("samsara", resilience.BreakerConfig{})),
+//		resilience.Bulkhead(resilience.NewLimiter(16, time.Second)),
+//	)
+//
+// Non-HTTP callers (gRPC clients, hand-rolled loops) use Do and Breaker
+// directly with the same policies.
+package resilience
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Middleware decorates a RoundTripper.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Chain wraps base (nil means http.DefaultTransport) in mws, the first
+// middleware outermost: Chain(base, Retry, Breaker) retries around the
+// breaker, so every attempt is seen — and counted — by it.
+func Chain(base http.RoundTripper, mws ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] != nil {
+			base = mws[i](base)
+		}
+	}
+	return base
+}
+
+var (
+	// ErrCircuitOpen is returned without calling the dependency while its
+	// breaker is open.
+	ErrCircuitOpen = errors.New("resilience: circuit open")
+	// ErrBulkheadFull is returned when no concurrency slot frees up in time.
+	ErrBulkheadFull = errors.New("resilience: bulkhead full")
+)
+
+// IsIdempotent reports whether req may be sent more than once: a safe or
+// idempotent method (RFC 9110 §9.2.2), or any method carrying an
+// Idempotency-Key header.
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// replayable reports whether req's body can be sent again.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// cloneForRetry returns a copy of req with a fresh body.
+func cloneForRetry(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// Standard is the stack a provider client uses unless told otherwise: Retry
+// with DefaultPolicy around a per-host breaker from breakers. Retry sits
+// outside the breaker so each attempt counts towards it, and an open breaker
+// ends the retries at once.
+func Standard(breakers *BreakerSet) []Middleware {
+	return []Middleware{
+		Retry(DefaultPolicy()),
+		HostCircuitBreaker(breakers, nil),
+	}
+}
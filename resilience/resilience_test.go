@@ -0,0 +1,364 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastPolicy = Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond, MaxRetryAfter: time.Second}
+
+// statusSeq answers with the given statuses in turn, then 200.
+func statusSeq(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		body, _ := io.ReadAll(r.Body)
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRetryRetriesIdempotentRequests(t *testing.T) {
+	srv, hits := statusSeq(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	hc := &http.Client{Transport: Chain(nil, Retry(fastPolicy))}
+
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 3, hits.Load())
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	srv, hits := statusSeq(t, 503, 503, 503, 503)
+	hc := &http.Client{Transport: Chain(nil, Retry(fastPolicy))}
+
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "last response is returned as is")
+	assert.EqualValues(t, 3, hits.Load())
+}
+
+func TestRetrySkipsNonIdempotentUnlessKeyed(t *testing.T) {
+	srv, hits := statusSeq(t, 503)
+	hc := &http.Client{Transport: Chain(nil, Retry(fastPolicy))}
+
+	resp, err := hc.Post(srv.URL, "text/plain", strings.NewReader("x"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, hits.Load(), "POST without Idempotency-Key is sent once")
+
+	srv, hits = statusSeq(t, 503)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	req.Header.Set("Idempotency-Key", "k1")
+	resp, err = hc.Do(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.EqualValues(t, 2, hits.Load())
+	assert.Equal(t, "payload", string(body), "body is replayed on retry")
+}
+
+func TestRetryDoesNotRetryClientErrors(t *testing.T) {
+	srv, hits := statusSeq(t, http.StatusBadRequest)
+	hc := &http.Client{Transport: Chain(nil, Retry(fastPolicy))}
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.EqualValues(t, 1, hits.Load())
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	var gap time.Duration
+	var last time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			last = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		gap = time.Since(last)
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Transport: Chain(nil, Retry(fastPolicy))}
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.EqualValues(t, 2, hits.Load())
+	assert.GreaterOrEqual(t, gap, 900*time.Millisecond)
+
+	// A Retry-After beyond MaxRetryAfter ends the retries.
+	p := fastPolicy
+	p.MaxRetryAfter = 100 * time.Millisecond
+	hits.Store(0)
+	hc = &http.Client{Transport: Chain(nil, Retry(p))}
+	resp, err = hc.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.EqualValues(t, 1, hits.Load())
+}
+
+func TestRetryAttemptTimeoutRetriesASlowAttempt(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	p := fastPolicy
+	p.AttemptTimeout = 50 * time.Millisecond
+	hc := &http.Client{Timeout: p.TotalTimeout(), Transport: Chain(nil, Retry(p))}
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "the attempt's deadline outlives RoundTrip until the body is read")
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+	assert.EqualValues(t, 2, hits.Load())
+
+	// The caller's own deadline is not an attempt timeout and ends the retries.
+	hits.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	_, err = hc.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 1, hits.Load())
+
+	assert.Equal(t, 3*50*time.Millisecond+time.Millisecond+2*time.Millisecond, p.TotalTimeout())
+	assert.Zero(t, fastPolicy.TotalTimeout())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	d, ok := ParseRetryAfter("7", now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, d)
+
+	d, ok = ParseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	_, ok = ParseRetryAfter("soon", now)
+	assert.False(t, ok)
+	_, ok = ParseRetryAfter("", now)
+	assert.False(t, ok)
+}
+
+func TestBackoffIsBoundedAndJittered(t *testing.T) {
+	p := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: 400 * time.Millisecond}
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 200; i++ {
+		d := p.Backoff(5)
+		assert.LessOrEqual(t, d, 400*time.Millisecond)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		seen[d] = true
+	}
+	assert.Greater(t, len(seen), 1, "full jitter spreads delays")
+	for i := 0; i < 50; i++ {
+		assert.LessOrEqual(t, p.Backoff(1), 100*time.Millisecond)
+	}
+}
+
+type retryAfterErr struct{ d time.Duration }
+
+func (e retryAfterErr) Error() string             { return "slow down" }
+func (e retryAfterErr) RetryAfter() time.Duration { return e.d }
+
+func TestDo(t *testing.T) {
+	permanent := errors.New("permanent")
+	calls := 0
+	err := Do(context.Background(), fastPolicy, func(err error) bool { return !errors.Is(err, permanent) },
+		func(context.Context) error {
+			calls++
+			if calls == 1 {
+				return retryAfterErr{d: time.Millisecond}
+			}
+			return permanent
+		})
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	err = Do(context.Background(), fastPolicy, nil, func(context.Context) error {
+		calls++
+		return errors.New("boom")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Do(ctx, Policy{MaxAttempts: 3, BaseDelay: time.Hour}, nil, func(context.Context) error { return errors.New("x") })
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBreaker("test", BreakerConfig{Threshold: 3, Cooldown: time.Second})
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+	require.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, StateClosed, b.State(), "a success resets the count")
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Allow())
+		b.Failure()
+	}
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+	require.NoError(t, b.Allow(), "one probe")
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen, "only one probe at a time")
+	b.Failure()
+	assert.Equal(t, StateOpen, b.State(), "failed probe reopens")
+
+	now = now.Add(time.Second)
+	require.NoError(t, b.Allow())
+	b.Release()
+	require.NoError(t, b.Allow(), "released probe slot is reusable")
+	b.Success()
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	srv, hits := statusSeq(t, 500, 500, 500, 500)
+	set := NewBreakerSet("test", BreakerConfig{Threshold: 2, Cooldown: time.Minute})
+	hc := &http.Client{Transport: Chain(nil, Retry(fastPolicy), HostCircuitBreaker(set, nil))}
+
+	for i := 0; i < 2; i++ {
+		resp, err := hc.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	_, err := hc.Get(srv.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 2, hits.Load(), "500 is not retried by default; open breaker short-circuits")
+
+	other := NewBreakerSet("test", BreakerConfig{})
+	assert.NotSame(t, set.Get("a"), other.Get("a"))
+	assert.Same(t, set.Get("a"), set.Get("a"))
+	assert.NotSame(t, set.Get("a"), set.Get("b"))
+}
+
+func TestBulkhead(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer srv.Close()
+	defer close(release)
+
+	l := NewLimiter(1, 10*time.Millisecond)
+	hc := &http.Client{Transport: Chain(nil, Bulkhead(l))}
+	go func() {
+		if resp, err := hc.Get(srv.URL); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	require.Eventually(t, func() bool { return l.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	_, err := hc.Get(srv.URL)
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+}
+
+func TestBulkheadHoldsSlotUntilBodyClosed(t *testing.T) {
+	srv, _ := statusSeq(t)
+	l := NewLimiter(1, 0)
+	hc := &http.Client{Transport: Chain(nil, Bulkhead(l))}
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 1, l.InFlight())
+	resp.Body.Close()
+	assert.Equal(t, 0, l.InFlight())
+}
+
+func TestHedgeReturnsFastestResponse(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			select { // the first copy stalls until canceled
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("fast"))
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Transport: Chain(nil, Hedge(20*time.Millisecond, 1))}
+	start := time.Now()
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "fast", string(body))
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.EqualValues(t, 2, hits.Load())
+}
+
+func TestHedgeSkipsNonIdempotent(t *testing.T) {
+	srv, hits := statusSeq(t)
+	hc := &http.Client{Transport: Chain(nil, Hedge(0, 2))}
+	resp, err := hc.Post(srv.URL, "text/plain", strings.NewReader("x"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.EqualValues(t, 1, hits.Load())
+}
+
+func TestClientOptions(t *testing.T) {
+	srv, hits := statusSeq(t, http.StatusServiceUnavailable)
+	o := ClientOptions{Defaults: []Middleware{Retry(fastPolicy)}, Timeout: time.Second}
+
+	hc := o.HTTPClient()
+	assert.Equal(t, time.Second, hc.Timeout)
+	resp, err := hc.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "the default stack retries")
+
+	hits.Store(0)
+	one := o.Replace(hc)
+	assert.Equal(t, time.Second, one.Timeout, "other settings are kept")
+	assert.NotSame(t, hc, one)
+	resp, err = one.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "no middlewares, one attempt")
+	assert.EqualValues(t, 1, hits.Load())
+}
@@ -0,0 +1,249 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Policy is a retry schedule.
+type Policy struct {
+	// MaxAttempts counts the first try; 1 disables retrying.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles per
+	// attempt up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxRetryAfter caps how long a server's Retry-After may make us wait;
+	// a longer ask ends the retries and returns the response as is.
+	MaxRetryAfter time.Duration
+	// RetryNonIdempotent allows retrying POST/PATCH without an
+	// Idempotency-Key. Only for endpoints known to be safe to repeat.
+	RetryNonIdempotent bool
+	// RetryOn classifies an HTTP attempt; nil uses RetryableResponse.
+	RetryOn func(resp *http.Response, err error) bool
+	// AttemptTimeout bounds each attempt, response body included; zero
+	// leaves attempts bounded only by the caller's context. An attempt that
+	// runs out is retried even though RetryOn never retries a context
+	// error — the caller's own deadline still ends the retries. Give the
+	// http.Client a Timeout of at least TotalTimeout, or the client-wide
+	// deadline cuts the retries short.
+	AttemptTimeout time.Duration
+}
+
+// DefaultPolicy is three attempts, 100ms doubling to 2s with full jitter,
+// honouring Retry-After up to 10s.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:   3,
+		BaseDelay:     100 * time.Millisecond,
+		MaxDelay:      2 * time.Second,
+		MaxRetryAfter: 10 * time.Second,
+	}
+}
+
+func (p Policy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// TotalTimeout is the longest one call through Retry can take when every
+// attempt runs to AttemptTimeout and every wait to its full backoff:
+// MaxAttempts × AttemptTimeout plus the backoffs in between. A Retry-After
+// longer than the backoff is not covered. Zero without an AttemptTimeout.
+func (p Policy) TotalTimeout() time.Duration {
+	if p.AttemptTimeout <= 0 {
+		return 0
+	}
+	total := time.Duration(p.attempts()) * p.AttemptTimeout
+	for attempt := 1; attempt < p.attempts(); attempt++ {
+		d := p.BaseDelay << (attempt - 1)
+		if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+			d = p.MaxDelay
+		}
+		total += d
+	}
+	return total
+}
+
+// Backoff returns the delay before attempt (1-based: the wait after the
+// first failure is Backoff(1)), with full jitter: uniform in
+// [0, min(MaxDelay, BaseDelay·2^(attempt-1))]. Jitter keeps a fleet of
+// replicas from retrying in lockstep after a shared outage.
+func (p Policy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 || attempt < 1 {
+		return 0
+	}
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(d) + 1))
+}
+
+// RetryableResponse is the default HTTP classification: transport errors,
+// 429, 502, 503 and 504. A context error from the caller is never retried.
+func RetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, ErrCircuitOpen) && !errors.Is(err, ErrBulkheadFull)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ParseRetryAfter reads a Retry-After header in either form — delay-seconds
+// or an HTTP-date.
+func ParseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// Retry retries failed attempts per p. Requests that are not idempotent (see
+// IsIdempotent) or whose body cannot be replayed are sent once. A Retry-After
+// on a 429/503 replaces the backoff for that wait.
+func Retry(p Policy) Middleware {
+	retryOn := p.RetryOn
+	if retryOn == nil {
+		retryOn = RetryableResponse
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if p.attempts() == 1 || !replayable(req) || (!p.RetryNonIdempotent && !IsIdempotent(req)) {
+				resp, _, err := roundTripWithin(next, req, p.AttemptTimeout)
+				return resp, err
+			}
+			ctx := req.Context()
+			for attempt := 1; ; attempt++ {
+				try := req
+				if attempt > 1 {
+					var err error
+					if try, err = cloneForRetry(req); err != nil {
+						return nil, err
+					}
+				}
+				resp, timedOut, err := roundTripWithin(next, try, p.AttemptTimeout)
+				if attempt >= p.attempts() || !(timedOut || retryOn(resp, err)) {
+					return resp, err
+				}
+
+				wait := p.Backoff(attempt)
+				if resp != nil {
+					if ra, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+						if p.MaxRetryAfter > 0 && ra > p.MaxRetryAfter {
+							return resp, err
+						}
+						wait = ra
+					}
+					drain(resp)
+				}
+				if !sleep(ctx, wait) {
+					return nil, ctx.Err()
+				}
+			}
+		})
+	}
+}
+
+// roundTripWithin sends req with at most timeout for the attempt (none when
+// zero). The deadline stays in force while the response body is read and is
+// released when it is closed. timedOut reports that the attempt's own
+// deadline, not the caller's context, ended it.
+func roundTripWithin(next http.RoundTripper, req *http.Request, timeout time.Duration) (resp *http.Response, timedOut bool, err error) {
+	if timeout <= 0 {
+		resp, err = next.RoundTrip(req)
+		return resp, false, err
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err = next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		timedOut = ctx.Err() != nil && req.Context().Err() == nil
+		cancel()
+		return nil, timedOut, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, false, nil
+}
+
+// cancelBody releases an attempt's deadline once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Do runs fn until it succeeds, retryable says its error is final, the
+// attempts run out or ctx ends. It returns fn's last error. An error that
+// carries a RetryAfter() duration overrides the backoff.
+func Do(ctx context.Context, p Policy, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt >= p.attempts() || (retryable != nil && !retryable(err)) {
+			return err
+		}
+		wait := p.Backoff(attempt)
+		var ra interface{ RetryAfter() time.Duration }
+		if errors.As(err, &ra) {
+			wait = ra.RetryAfter()
+		}
+		if !sleep(ctx, wait) {
+			return ctx.Err()
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// drain discards a response that is being retried so its connection can be
+// reused.
+func drain(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+}
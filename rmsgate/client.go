@@ -3,7 +3,6 @@ package rmsgate
 import (
 	"context"
 	"log/slog"
-	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -12,7 +11,10 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/TMS360/backend-pkg/observability/metrics"
+	"github.com/TMS360/backend-pkg/observability/telemetry"
 	pb "github.com/TMS360/backend-pkg/proto/rmsgate"
+	"github.com/TMS360/backend-pkg/resilience"
 )
 
 // Decision — вердикт гейта для доменного сервиса.
@@ -28,9 +30,9 @@ type Decision struct {
 const (
 	defaultTimeout = 150 * time.Millisecond // fact-only SLO гейта
 
-	// circuit-ish: после circuitThreshold подряд транспортных ошибок клиент
+	// circuit: после circuitThreshold подряд транспортных ошибок клиент
 	// circuitCooldown не ходит в RMS вовсе (сразу FailMode) — не копим latency
-	// на каждом вызове, пока RMS лежит.
+	// на каждом вызове, пока RMS лежит; затем одна пробная попытка (half-open).
 	circuitThreshold = 5
 	circuitCooldown  = 2 * time.Second
 )
@@ -65,8 +67,7 @@ type Client struct {
 	gate pb.RmsGateClient
 	opt  options
 
-	failures  atomic.Int32 // подряд идущие транспортные ошибки
-	openUntil atomic.Int64 // unix-nano, до которого circuit открыт
+	breaker *resilience.Breaker
 }
 
 // Dial подключается к RmsGate (например ":7080" RMS).
@@ -75,12 +76,13 @@ func Dial(addr string, opts ...Option) (*Client, error) {
 	for _, fn := range opts {
 		fn(&o)
 	}
-	dialOpts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, o.dialOpt...)
+	dialOpts := append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, telemetry.ClientDialOptions()...)
+	dialOpts = append(dialOpts, o.dialOpt...)
 	conn, err := grpc.NewClient(addr, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{conn: conn, gate: pb.NewRmsGateClient(conn), opt: o}, nil
+	return newClient(conn, o), nil
 }
 
 // Warmup форсирует установку gRPC-соединения В ФОНЕ старта сервиса, чтобы
@@ -112,7 +114,19 @@ func NewFromConn(conn *grpc.ClientConn, opts ...Option) *Client {
 	for _, fn := range opts {
 		fn(&o)
 	}
-	return &Client{conn: conn, gate: pb.NewRmsGateClient(conn), opt: o}
+	return newClient(conn, o)
+}
+
+func newClient(conn *grpc.ClientConn, o options) *Client {
+	return &Client{
+		conn: conn,
+		gate: pb.NewRmsGateClient(conn),
+		opt:  o,
+		breaker: resilience.NewBreaker(breakerName, resilience.BreakerConfig{
+			Threshold: circuitThreshold,
+			Cooldown:  circuitCooldown,
+		}),
+	}
 }
 
 func (c *Client) Close() error { return c.conn.Close() }
@@ -148,8 +162,10 @@ func (c *Client) Evaluate(ctx context.Context, process, transition, tenant strin
 //	if !dec.Allow { return dec.Reasons }
 //	applySteps(dec.RequiredSteps) // идемпотентно, в своей транзакции
 func (c *Client) Decide(ctx context.Context, process, transition, tenant string, facts map[string]any) Decision {
-	if until := c.openUntil.Load(); until > 0 && time.Now().UnixNano() < until {
-		return c.failDecision(process, "gate circuit open")
+	if c.breaker.Allow() != nil {
+		dec := c.failDecision(process, "gate circuit open")
+		metrics.ObserveGateDecision(process, decisionOutcome(dec), 0)
+		return dec
 	}
 
 	timeout := c.opt.reg.Timeout(process)
@@ -159,21 +175,39 @@ func (c *Client) Decide(ctx context.Context, process, transition, tenant string,
 	cctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	started := time.Now()
 	dec, err := c.Evaluate(cctx, process, transition, tenant, facts)
 	if err != nil {
-		n := c.failures.Add(1)
-		if n >= circuitThreshold {
-			c.openUntil.Store(time.Now().Add(circuitCooldown).UnixNano())
-			c.failures.Store(0)
-		}
+		c.breaker.Failure()
 		c.opt.log.Warn("rmsgate: evaluate failed, применяю FailMode процесса",
 			"process", process, "transition", transition, "mode", c.opt.reg.Mode(process).String(), "err", err)
-		return c.failDecision(process, "gate unavailable: "+err.Error())
+		fail := c.failDecision(process, "gate unavailable: "+err.Error())
+		metrics.ObserveGateDecision(process, decisionOutcome(fail), time.Since(started))
+		return fail
 	}
-	c.failures.Store(0)
+	c.breaker.Success()
+	metrics.ObserveGateDecision(process, decisionOutcome(dec), time.Since(started))
 	return dec
 }
 
+// breakerName — значение label breaker в метриках circuit-а гейта.
+const breakerName = "rmsgate"
+
+// decisionOutcome — label outcome для tms_rmsgate_decisions_total: allow/deny
+// для ответа RMS, fail_open/fail_closed для вердикта по FailMode.
+func decisionOutcome(d Decision) string {
+	switch {
+	case d.FailedOpen && d.Allow:
+		return "fail_open"
+	case d.FailedOpen:
+		return "fail_closed"
+	case d.Allow:
+		return "allow"
+	default:
+		return "deny"
+	}
+}
+
 // failDecision — вердикт по FailMode процесса при недоступном гейте.
 func (c *Client) failDecision(process, reason string) Decision {
 	mode := c.opt.reg.Mode(process)
@@ -30,6 +30,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/TMS360/backend-pkg/resilience"
 )
 
 // FactDef — декларация факта гейта. JSON-теги зеркалят приёмник RMS
@@ -66,9 +68,19 @@ type ContractDef struct {
 	Steps       []ContractStepDef `json:"steps,omitempty"`
 }
 
-// registerHTTPTimeout — потолок одной попытки регистрации.
+// registerHTTPTimeout — потолок одной попытки регистрации; общий дедлайн
+// клиента — registerPolicy.TotalTimeout() (все попытки плюс бэкофф).
 const registerHTTPTimeout = 5 * time.Second
 
+var registerPolicy = resilience.Policy{
+	MaxAttempts:        3,
+	BaseDelay:          500 * time.Millisecond,
+	MaxDelay:           time.Second,
+	RetryNonIdempotent: true,
+	RetryOn:            resilience.FailureResponse,
+	AttemptTimeout:     registerHTTPTimeout,
+}
+
 // RegisterContracts отправляет декларации в RMS (POST /contracts/register,
 // заголовок X-Registry-Token). Ретраи с бэкоффом внутри; итоговая ошибка —
 // для warn-лога вызывающего (не для остановки сервиса).
@@ -83,36 +95,26 @@ func registerJSON(ctx context.Context, url, token string, payload any) error {
 	if err != nil {
 		return fmt.Errorf("rmsgate: marshal: %w", err)
 	}
-	client := &http.Client{Timeout: registerHTTPTimeout}
-	const attempts = 3
-	var lastErr error
-	for i := 1; i <= attempts; i++ {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Registry-Token", token)
-		resp, err := client.Do(req)
-		if err == nil {
-			raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
-			resp.Body.Close()
-			if resp.StatusCode < 300 {
-				return nil
-			}
-			// 4xx — ретраи бессмысленны (токен/формат), отдаём сразу.
-			lastErr = fmt.Errorf("rmsgate: register %s: %s: %s", url, resp.Status, bytes.TrimSpace(raw))
-			if resp.StatusCode < 500 {
-				return lastErr
-			}
-		} else {
-			lastErr = err
-		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(time.Duration(i) * 500 * time.Millisecond):
-		}
+	// Регистрация — идемпотентный upsert, поэтому POST ретраим (до 3 попыток);
+	// 4xx — ретраи бессмысленны (токен/формат), отдаём сразу.
+	client := &http.Client{
+		Timeout:   registerPolicy.TotalTimeout(),
+		Transport: resilience.Chain(nil, resilience.Retry(registerPolicy)),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Registry-Token", token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
 	}
-	return lastErr
+	return fmt.Errorf("rmsgate: register %s: %s: %s", url, resp.Status, bytes.TrimSpace(raw))
 }
@@ -27,6 +27,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/TMS360/backend-pkg/resilience"
 )
 
 // FieldDef — плоское описание поля входа/выхода операции (advisory-схема).
@@ -63,8 +65,19 @@ type CapabilityDef struct {
 	Enabled *bool `json:"enabled,omitempty"`
 }
 
+// registerHTTPTimeout — потолок одной попытки регистрации; общий дедлайн
+// клиента — registerPolicy.TotalTimeout() (все попытки плюс бэкофф).
 const registerHTTPTimeout = 5 * time.Second
 
+var registerPolicy = resilience.Policy{
+	MaxAttempts:        3,
+	BaseDelay:          500 * time.Millisecond,
+	MaxDelay:           time.Second,
+	RetryNonIdempotent: true,
+	RetryOn:            resilience.FailureResponse,
+	AttemptTimeout:     registerHTTPTimeout,
+}
+
 // RegisterCapabilities отправляет декларации в RMS
 // (POST /capabilities/register, заголовок X-Registry-Token). Ретраи внутри.
 func RegisterCapabilities(ctx context.Context, rmsURL, token string, defs []CapabilityDef) error {
@@ -72,36 +85,26 @@ func RegisterCapabilities(ctx context.Context, rmsURL, token string, defs []Capa
 	if err != nil {
 		return fmt.Errorf("rmsreg: marshal: %w", err)
 	}
-	client := &http.Client{Timeout: registerHTTPTimeout}
-	url := rmsURL + "/capabilities/register"
-	const attempts = 3
-	var lastErr error
-	for i := 1; i <= attempts; i++ {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Registry-Token", token)
-		resp, err := client.Do(req)
-		if err == nil {
-			raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
-			resp.Body.Close()
-			if resp.StatusCode < 300 {
-				return nil
-			}
-			lastErr = fmt.Errorf("rmsreg: register: %s: %s", resp.Status, bytes.TrimSpace(raw))
-			if resp.StatusCode < 500 { // токен/формат — ретраи бессмысленны
-				return lastErr
-			}
-		} else {
-			lastErr = err
-		}
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(time.Duration(i) * 500 * time.Millisecond):
-		}
+	// Регистрация — идемпотентный upsert: POST ретраим (до 3 попыток) на
+	// 5xx/сеть; 4xx (токен/формат) отдаём сразу.
+	client := &http.Client{
+		Timeout:   registerPolicy.TotalTimeout(),
+		Transport: resilience.Chain(nil, resilience.Retry(registerPolicy)),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rmsURL+"/capabilities/register", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Registry-Token", token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
 	}
-	return lastErr
+	return fmt.Errorf("rmsreg: register: %s: %s", resp.Status, bytes.TrimSpace(raw))
 }
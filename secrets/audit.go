@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/TMS360/backend-pkg/consts"
+)
+
+// AuditEvent describes one reference read. It never carries the value.
+type AuditEvent struct {
+	Backend   string
+	Path      string
+	ActorID   string
+	CompanyID string
+	System    bool
+	Found     bool
+	Err       error
+	Duration  time.Duration
+}
+
+// Auditor records reference reads.
+type Auditor interface {
+	Audit(ctx context.Context, e AuditEvent)
+}
+
+// SlogAuditor writes one "credential_read" line per read — Info on success,
+// Warn on failure.
+type SlogAuditor struct {
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Audit implements Auditor.
+func (a SlogAuditor) Audit(ctx context.Context, e AuditEvent) {
+	l := a.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+	attrs := []slog.Attr{
+		slog.String("backend", e.Backend),
+		slog.String("path", e.Path),
+		slog.Bool("found", e.Found),
+		slog.Int64("dur_ms", e.Duration.Milliseconds()),
+	}
+	if e.ActorID != "" {
+		attrs = append(attrs, slog.String("actor_id", e.ActorID))
+	}
+	if e.CompanyID != "" {
+		attrs = append(attrs, slog.String("company_id", e.CompanyID))
+	}
+	if e.System {
+		attrs = append(attrs, slog.Bool("system", true))
+	}
+	level := slog.LevelInfo
+	if e.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("err", e.Err.Error()))
+	}
+	l.LogAttrs(ctx, level, "credential_read", attrs...)
+}
+
+func newAuditEvent(ctx context.Context, backend, path string, started time.Time, err error) AuditEvent {
+	e := AuditEvent{
+		Backend:  backend,
+		Path:     path,
+		Found:    err == nil,
+		Err:      err,
+		Duration: time.Since(started),
+	}
+	if errors.Is(err, ErrNotFound) {
+		e.Found = false
+	}
+	// consts rather than middleware, so config can depend on this package.
+	if actor, _ := consts.GetActor(ctx); actor != nil {
+		e.ActorID = actor.ID.String()
+		e.System = actor.IsSystem
+		if cid := actor.GetCompanyID(); cid != nil {
+			e.CompanyID = cid.String()
+		}
+	}
+	return e
+}
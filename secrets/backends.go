@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Env reads secrets from the process environment: secret://env/HERE_API_KEY.
+type Env struct{}
+
+// Get implements Backend.
+func (Env) Get(_ context.Context, name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("%w: env %s", ErrNotFound, name)
+	}
+	return v, nil
+}
+
+// File reads secrets from files under Dir — Docker/Kubernetes secret mounts:
+// secret://file/relay_api_key reads /run/secrets/relay_api_key. A trailing
+// newline, which most tooling adds, is trimmed.
+type File struct {
+	Dir string
+}
+
+// Get implements Backend.
+func (f File) Get(_ context.Context, path string) (string, error) {
+	full, err := f.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(full)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: file %s", ErrNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("secrets: read %s: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolve keeps path inside Dir: a reference is data, and data must not be
+// able to read /etc/shadow.
+func (f File) resolve(path string) (string, error) {
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("secrets: file path %q escapes the secrets directory", path)
+	}
+	return filepath.Join(f.Dir, path), nil
+}
+
+// LocalVault is an in-process stand-in for a vault: a versioned key/value
+// store, optionally persisted to a JSON file, for local runs and tests.
+// Paths are read at their latest version.
+type LocalVault struct {
+	mu   sync.RWMutex
+	file string
+	data map[string][]string
+}
+
+// NewLocalVault opens the vault, loading file when it exists. An empty file
+// keeps the vault in memory only.
+func NewLocalVault(file string) (*LocalVault, error) {
+	v := &LocalVault{file: file, data: map[string][]string{}}
+	if file == "" {
+		return v, nil
+	}
+	raw, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secrets: open vault: %w", err)
+	}
+	if err := json.Unmarshal(raw, &v.data); err != nil {
+		return nil, fmt.Errorf("secrets: parse vault %s: %w", file, err)
+	}
+	return v, nil
+}
+
+// Get implements Backend.
+func (v *LocalVault) Get(_ context.Context, path string) (string, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	versions := v.data[path]
+	if len(versions) == 0 {
+		return "", fmt.Errorf("%w: vault %s", ErrNotFound, path)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Put implements Writer, adding a new version.
+func (v *LocalVault) Put(_ context.Context, path, value string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.data[path] = append(v.data[path], value)
+	return v.persist()
+}
+
+// Versions returns how many versions path has.
+func (v *LocalVault) Versions(path string) int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.data[path])
+}
+
+func (v *LocalVault) persist() error {
+	if v.file == "" {
+		return nil
+	}
+	raw, err := json.Marshal(v.data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(v.file, raw, 0o600)
+}
@@ -0,0 +1,220 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KeyRing holds the key-encryption keys (KEKs) for envelope encryption. Each
+// secret is sealed with its own random data key, and only that data key is
+// wrapped with a KEK — so rotating the KEK re-wraps a few bytes per secret
+// instead of re-encrypting the secrets. New envelopes use the primary key;
+// older keys stay in the ring until Rotate has moved everything off them.
+type KeyRing struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	primary string
+}
+
+// NewKeyRing builds a ring from 32-byte AES-256 keys by id; primary must be
+// one of them.
+func NewKeyRing(primary string, keys map[string][]byte) (*KeyRing, error) {
+	kr := &KeyRing{keys: map[string][]byte{}}
+	for id, k := range keys {
+		if err := kr.Add(id, k); err != nil {
+			return nil, err
+		}
+	}
+	if err := kr.SetPrimary(primary); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// KeyRingFromEnv reads SECRETS_KEYRING, "id:base64key,id:base64key", the
+// first entry primary.
+func KeyRingFromEnv() (*KeyRing, error) {
+	raw := os.Getenv("SECRETS_KEYRING")
+	if raw == "" {
+		return nil, errors.New("secrets: SECRETS_KEYRING is not set")
+	}
+	kr := &KeyRing{keys: map[string][]byte{}}
+	for i, entry := range strings.Split(raw, ",") {
+		id, enc, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("secrets: SECRETS_KEYRING entry %d is not id:key", i)
+		}
+		key, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("secrets: SECRETS_KEYRING key %q: %w", id, err)
+		}
+		if err := kr.Add(id, key); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			kr.primary = id
+		}
+	}
+	return kr, nil
+}
+
+// Add puts a key in the ring without making it primary.
+func (kr *KeyRing) Add(id string, key []byte) error {
+	if id == "" || strings.ContainsAny(id, ":,") {
+		return fmt.Errorf("secrets: invalid key id %q", id)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("secrets: key %q must be 32 bytes, got %d", id, len(key))
+	}
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	kr.keys[id] = append([]byte(nil), key...)
+	return nil
+}
+
+// SetPrimary makes id the key new envelopes are wrapped with.
+func (kr *KeyRing) SetPrimary(id string) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, ok := kr.keys[id]; !ok {
+		return fmt.Errorf("secrets: primary key %q not in ring", id)
+	}
+	kr.primary = id
+	return nil
+}
+
+// Primary returns the primary key id.
+func (kr *KeyRing) Primary() string {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.primary
+}
+
+func (kr *KeyRing) key(id string) ([]byte, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	k, ok := kr.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("secrets: key %q not in ring", id)
+	}
+	return k, nil
+}
+
+// Envelope is a sealed secret as stored.
+type Envelope struct {
+	Version    int    `json:"v"`
+	KeyID      string `json:"kid"`
+	WrappedKey []byte `json:"wk"`
+	Ciphertext []byte `json:"ct"`
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped with the primary
+// KEK. aad — the storage path — binds the envelope to where it is stored, so
+// a sealed value copied to another path fails to open.
+func (kr *KeyRing) Seal(plaintext, aad []byte) (*Envelope, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	ct, err := gcmSeal(dek, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	kid := kr.Primary()
+	kek, err := kr.key(kid)
+	if err != nil {
+		return nil, err
+	}
+	wk, err := gcmSeal(kek, dek, []byte(kid))
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{Version: 1, KeyID: kid, WrappedKey: wk, Ciphertext: ct}, nil
+}
+
+// Open decrypts e.
+func (kr *KeyRing) Open(e *Envelope, aad []byte) ([]byte, error) {
+	dek, err := kr.unwrap(e)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(dek, e.Ciphertext, aad)
+}
+
+// Rewrap moves e onto the primary key, leaving the ciphertext as is. It
+// reports whether anything changed.
+func (kr *KeyRing) Rewrap(e *Envelope) (bool, error) {
+	primary := kr.Primary()
+	if e.KeyID == primary {
+		return false, nil
+	}
+	dek, err := kr.unwrap(e)
+	if err != nil {
+		return false, err
+	}
+	kek, err := kr.key(primary)
+	if err != nil {
+		return false, err
+	}
+	wk, err := gcmSeal(kek, dek, []byte(primary))
+	if err != nil {
+		return false, err
+	}
+	e.KeyID, e.WrappedKey = primary, wk
+	return true, nil
+}
+
+func (kr *KeyRing) unwrap(e *Envelope) ([]byte, error) {
+	if e.Version != 1 {
+		return nil, fmt.Errorf("secrets: unsupported envelope version %d", e.Version)
+	}
+	kek, err := kr.key(e.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := gcmOpen(kek, e.WrappedKey, []byte(e.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("secrets: unwrap data key: %w", err)
+	}
+	return dek, nil
+}
+
+// gcmSeal returns nonce||ciphertext.
+func gcmSeal(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func gcmOpen(key, sealed, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("secrets: ciphertext too short")
+	}
+	nonce, ct := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ct, aad)
+}
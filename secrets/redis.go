@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisPrefix namespaces sealed secrets, apart from the plaintext company
+// settings they replace.
+const redisPrefix = "secrets:"
+
+// RedisBackend stores secrets in Redis sealed with a KeyRing:
+// secret://redis/acme/factoring_credentials reads "secrets:acme/factoring_credentials".
+// Nothing readable lands in Redis, its snapshots or its replicas.
+type RedisBackend struct {
+	rdb  redis.UniversalClient
+	ring *KeyRing
+}
+
+// NewRedisBackend builds the backend.
+func NewRedisBackend(rdb redis.UniversalClient, ring *KeyRing) *RedisBackend {
+	return &RedisBackend{rdb: rdb, ring: ring}
+}
+
+// Get implements Backend.
+func (b *RedisBackend) Get(ctx context.Context, path string) (string, error) {
+	raw, err := b.rdb.Get(ctx, redisPrefix+path).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("%w: redis %s", ErrNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("secrets: redis get %s: %w", path, err)
+	}
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return "", fmt.Errorf("secrets: redis %s: %w", path, err)
+	}
+	pt, err := b.ring.Open(&env, []byte(path))
+	if err != nil {
+		return "", fmt.Errorf("secrets: redis %s: %w", path, err)
+	}
+	return string(pt), nil
+}
+
+// Put implements Writer.
+func (b *RedisBackend) Put(ctx context.Context, path, value string) error {
+	env, err := b.ring.Seal([]byte(value), []byte(path))
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return b.rdb.Set(ctx, redisPrefix+path, raw, 0).Err()
+}
+
+// Rotate re-wraps every stored secret not yet under the ring's primary key
+// and returns how many it changed. Run it after SetPrimary with a new key;
+// once it reports zero, the old key can leave the ring. Safe to re-run.
+func (b *RedisBackend) Rotate(ctx context.Context) (int, error) {
+	var rotated int
+	var cursor uint64
+	for {
+		keys, next, err := b.rdb.Scan(ctx, cursor, redisPrefix+"*", 200).Result()
+		if err != nil {
+			return rotated, err
+		}
+		for _, key := range keys {
+			changed, err := b.rewrap(ctx, key)
+			if err != nil {
+				return rotated, fmt.Errorf("secrets: rotate %s: %w", key, err)
+			}
+			if changed {
+				rotated++
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return rotated, nil
+		}
+	}
+}
+
+// rewrap updates key under WATCH, so a Put racing the rotation wins rather
+// than being overwritten with the old value.
+func (b *RedisBackend) rewrap(ctx context.Context, key string) (bool, error) {
+	var changed bool
+	err := b.rdb.Watch(ctx, func(tx *redis.Tx) error {
+		raw, err := tx.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var env Envelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return err
+		}
+		if changed, err = b.ring.Rewrap(&env); err != nil || !changed {
+			return err
+		}
+		out, err := json.Marshal(&env)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.Set(ctx, key, out, redis.KeepTTL).Err()
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		// Rewritten meanwhile — by a Put, which sealed under the primary.
+		return false, nil
+	}
+	return changed, err
+}
@@ -0,0 +1,203 @@
+// Package secrets resolves credential references instead of plaintext. A
+// value of the form
+//
+//	secret://<backend>/<path>
+//
+// — in a company setting, a Credential field or a config variable — is looked
+// up in the named backend when read; any other value is returned unchanged,
+// so existing plaintext settings keep working while they are migrated.
+//
+// Backends: "env" (process environment), "file" (mounted secret files),
+// "redis" (envelope-encrypted with a KeyRing, rotatable) and "vault" (a
+// LocalVault stand-in until a real vault is wired). Every reference read is
+// audit-logged without its value.
+//
+// References read from tenant-editable settings go through a TenantResolver
+// instead, which serves one company-scoped backend and nothing else.
+//
+//	r := secrets.NewResolver(
+//		secrets.WithBackend("env", secrets.Env{}),
+//		secrets.WithBackend("redis", secrets.NewRedisBackend(rdb, ring)),
+//	)
+//	secrets.SetDefault(r)
+//	key, err := secrets.Resolve(ctx, raw) // "secret://redis/acme/here_api_key" → "abc…"
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Scheme prefixes every secret reference.
+const Scheme = "secret://"
+
+var (
+	// ErrNotFound is returned when a backend has no value at the path.
+	ErrNotFound = errors.New("secrets: not found")
+	// ErrUnknownBackend is returned for a reference to an unregistered backend.
+	ErrUnknownBackend = errors.New("secrets: unknown backend")
+)
+
+// Backend stores secret values by path.
+type Backend interface {
+	Get(ctx context.Context, path string) (string, error)
+}
+
+// Writer is implemented by backends that can store values.
+type Writer interface {
+	Put(ctx context.Context, path, value string) error
+}
+
+// IsRef reports whether v is a secret reference.
+func IsRef(v string) bool { return strings.HasPrefix(v, Scheme) }
+
+// Ref builds the reference to path in backend.
+func Ref(backend, path string) string { return Scheme + backend + "/" + path }
+
+// ParseRef splits a reference into backend and path.
+func ParseRef(v string) (backend, path string, err error) {
+	if !IsRef(v) {
+		return "", "", fmt.Errorf("secrets: %q is not a reference", v)
+	}
+	backend, path, ok := strings.Cut(strings.TrimPrefix(v, Scheme), "/")
+	if !ok || backend == "" || path == "" {
+		return "", "", fmt.Errorf("secrets: malformed reference %q", v)
+	}
+	return backend, path, nil
+}
+
+// Resolver maps backend names to backends and audits every read.
+type Resolver struct {
+	backends map[string]Backend
+	audit    Auditor
+}
+
+// Option configures a Resolver.
+type Option func(*Resolver)
+
+// WithBackend registers b under name.
+func WithBackend(name string, b Backend) Option {
+	return func(r *Resolver) { r.backends[name] = b }
+}
+
+// WithAuditor replaces the default slog auditor.
+func WithAuditor(a Auditor) Option { return func(r *Resolver) { r.audit = a } }
+
+// NewResolver builds a Resolver. With no backends every reference fails with
+// ErrUnknownBackend; plaintext still passes through.
+func NewResolver(opts ...Option) *Resolver {
+	r := &Resolver{backends: map[string]Backend{}, audit: SlogAuditor{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Backend returns the backend registered under name.
+func (r *Resolver) Backend(name string) (Backend, bool) {
+	b, ok := r.backends[name]
+	return b, ok
+}
+
+// Resolve returns v itself unless it is a reference, in which case the
+// referenced value. An empty resolved value is an error: a reference that
+// yields nothing is a misconfiguration, not an unset credential.
+func (r *Resolver) Resolve(ctx context.Context, v string) (string, error) {
+	if !IsRef(v) {
+		return v, nil
+	}
+	backend, path, err := ParseRef(v)
+	if err != nil {
+		return "", err
+	}
+	started := time.Now()
+	b, ok := r.backends[backend]
+	var val string
+	if !ok {
+		err = fmt.Errorf("%w %q", ErrUnknownBackend, backend)
+	} else if val, err = b.Get(ctx, path); err == nil && val == "" {
+		err = fmt.Errorf("%w: %s is empty", ErrNotFound, v)
+	}
+	r.audit.Audit(ctx, newAuditEvent(ctx, backend, path, started, err))
+	if err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// ResolveStruct resolves, in place, every exported string field of the
+// struct v points to that holds a reference — recursing into nested and
+// embedded structs. A *string is resolved directly; pointers to anything else
+// are left alone, so generic callers can pass any credential type. All
+// failures are reported together.
+func (r *Resolver) ResolveStruct(ctx context.Context, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("secrets: ResolveStruct needs a non-nil pointer, got %T", v)
+	}
+	var errs []error
+	switch rv.Elem().Kind() {
+	case reflect.Struct:
+		r.walk(ctx, rv.Elem(), "", &errs)
+	case reflect.String:
+		val, err := r.Resolve(ctx, rv.Elem().String())
+		if err != nil {
+			return err
+		}
+		rv.Elem().SetString(val)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Resolver) walk(ctx context.Context, v reflect.Value, prefix string, errs *[]error) {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		f, sf := v.Field(i), t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := prefix + sf.Name
+		switch {
+		case f.Kind() == reflect.String:
+			if !IsRef(f.String()) {
+				continue
+			}
+			val, err := r.Resolve(ctx, f.String())
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			f.SetString(val)
+		case f.Kind() == reflect.Struct:
+			r.walk(ctx, f, name+".", errs)
+		case f.Kind() == reflect.Pointer && !f.IsNil() && f.Elem().Kind() == reflect.Struct:
+			r.walk(ctx, f.Elem(), name+".", errs)
+		}
+	}
+}
+
+var defaultResolver atomic.Pointer[Resolver]
+
+func init() {
+	defaultResolver.Store(NewResolver(WithBackend("env", Env{})))
+}
+
+// SetDefault installs the resolver used by Resolve and ResolveStruct for
+// operator-controlled values such as config. Call it once from main. Tenant
+// settings never reach it; see SetTenantDefault.
+func SetDefault(r *Resolver) { defaultResolver.Store(r) }
+
+// Default returns the installed resolver; until SetDefault it knows only the
+// env backend.
+func Default() *Resolver { return defaultResolver.Load() }
+
+// Resolve is Default().Resolve.
+func Resolve(ctx context.Context, v string) (string, error) { return Default().Resolve(ctx, v) }
+
+// ResolveStruct is Default().ResolveStruct.
+func ResolveStruct(ctx context.Context, v any) error { return Default().ResolveStruct(ctx, v) }
@@ -0,0 +1,265 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditor struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (a *recordingAuditor) Audit(_ context.Context, e AuditEvent) {
+	a.mu.Lock()
+	a.events = append(a.events, e)
+	a.mu.Unlock()
+}
+
+func key(b byte) []byte { return bytes.Repeat([]byte{b}, 32) }
+
+func newRedis(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func TestParseRef(t *testing.T) {
+	b, p, err := ParseRef("secret://redis/acme/here_api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", b)
+	assert.Equal(t, "acme/here_api_key", p)
+	assert.Equal(t, "secret://redis/acme/here_api_key", Ref("redis", "acme/here_api_key"))
+
+	for _, bad := range []string{"secret://", "secret://env", "secret://env/", "secret:///x", "plain"} {
+		_, _, err := ParseRef(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestResolvePlaintextPassesThrough(t *testing.T) {
+	audit := &recordingAuditor{}
+	r := NewResolver(WithAuditor(audit))
+	v, err := r.Resolve(context.Background(), "plain-api-key")
+	require.NoError(t, err)
+	assert.Equal(t, "plain-api-key", v)
+	assert.Empty(t, audit.events, "plaintext is not a credential read")
+
+	_, err = r.Resolve(context.Background(), "secret://nowhere/x")
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+	require.Len(t, audit.events, 1)
+	assert.False(t, audit.events[0].Found)
+}
+
+func TestEnvAndFileBackends(t *testing.T) {
+	t.Setenv("TEST_SECRET_VALUE", "from-env")
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "relay_api_key"), []byte("from-file\n"), 0o600))
+
+	r := NewResolver(WithBackend("env", Env{}), WithBackend("file", File{Dir: dir}), WithAuditor(&recordingAuditor{}))
+	ctx := context.Background()
+
+	v, err := r.Resolve(ctx, "secret://env/TEST_SECRET_VALUE")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", v)
+
+	v, err = r.Resolve(ctx, "secret://file/relay_api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", v, "trailing newline trimmed")
+
+	_, err = r.Resolve(ctx, "secret://file/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = r.Resolve(ctx, "secret://file/../../etc/passwd")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound, "traversal is refused, not looked up")
+	_, err = r.Resolve(ctx, "secret://env/TEST_SECRET_UNSET")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalVaultVersionsAndPersists(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "vault.json")
+	v, err := NewLocalVault(file)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, v.Put(ctx, "acme/usps", "v1"))
+	require.NoError(t, v.Put(ctx, "acme/usps", "v2"))
+	assert.Equal(t, 2, v.Versions("acme/usps"))
+
+	reopened, err := NewLocalVault(file)
+	require.NoError(t, err)
+	got, err := reopened.Get(ctx, "acme/usps")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", got)
+}
+
+func TestTenantResolverScopesToCompany(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://prod")
+	vault, err := NewLocalVault("")
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, vault.Put(ctx, "acme/here_api_key", "acme-key"))
+	require.NoError(t, vault.Put(ctx, "other/here_api_key", "other-key"))
+	tr := NewTenantResolver("vault", vault, &recordingAuditor{})
+
+	v, err := tr.Resolve(ctx, "acme", "secret://vault/acme/here_api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-key", v)
+	v, err = tr.Resolve(ctx, "acme", "plain-key")
+	require.NoError(t, err)
+	assert.Equal(t, "plain-key", v)
+
+	_, err = tr.Resolve(ctx, "acme", "secret://env/DATABASE_URL")
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+	_, err = tr.Resolve(ctx, "acme", "secret://file/db_password")
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+	for _, ref := range []string{
+		"secret://vault/other/here_api_key",
+		"secret://vault/acme/../other/here_api_key",
+		"secret://vault/acmecorp/here_api_key",
+	} {
+		_, err = tr.Resolve(ctx, "acme", ref)
+		assert.ErrorIs(t, err, ErrOutOfScope, ref)
+	}
+	_, err = tr.Resolve(ctx, "", "secret://vault/acme/here_api_key")
+	assert.ErrorIs(t, err, ErrOutOfScope)
+
+	// Without an installed tenant resolver every reference is rejected.
+	var none *TenantResolver
+	_, err = none.Resolve(ctx, "acme", "secret://vault/acme/here_api_key")
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+
+	cred := struct{ Password string }{Password: "secret://vault/other/here_api_key"}
+	assert.ErrorIs(t, tr.ResolveStruct(ctx, "acme", &cred), ErrOutOfScope)
+}
+
+func TestRedisBackendEncryptsAtRest(t *testing.T) {
+	rdb := newRedis(t)
+	ring, err := NewKeyRing("k1", map[string][]byte{"k1": key(1)})
+	require.NoError(t, err)
+	b := NewRedisBackend(rdb, ring)
+	ctx := context.Background()
+
+	require.NoError(t, b.Put(ctx, "acme/factoring_password", "hunter2"))
+	raw, err := rdb.Get(ctx, "secrets:acme/factoring_password").Bytes()
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "hunter2")
+
+	got, err := b.Get(ctx, "acme/factoring_password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", got)
+
+	// An envelope copied to another tenant's path does not open.
+	require.NoError(t, rdb.Set(ctx, "secrets:other/factoring_password", raw, 0).Err())
+	_, err = b.Get(ctx, "other/factoring_password")
+	assert.Error(t, err)
+
+	_, err = b.Get(ctx, "acme/missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRedisBackendRotate(t *testing.T) {
+	rdb := newRedis(t)
+	ring, err := NewKeyRing("k1", map[string][]byte{"k1": key(1)})
+	require.NoError(t, err)
+	b := NewRedisBackend(rdb, ring)
+	ctx := context.Background()
+	require.NoError(t, b.Put(ctx, "a/x", "one"))
+	require.NoError(t, b.Put(ctx, "b/y", "two"))
+
+	require.NoError(t, ring.Add("k2", key(2)))
+	require.NoError(t, ring.SetPrimary("k2"))
+	n, err := b.Rotate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = b.Rotate(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "rotation is idempotent")
+
+	// Every envelope is on k2 now, so a ring without k1 still reads them.
+	onlyK2, err := NewKeyRing("k2", map[string][]byte{"k2": key(2)})
+	require.NoError(t, err)
+	got, err := NewRedisBackend(rdb, onlyK2).Get(ctx, "a/x")
+	require.NoError(t, err)
+	assert.Equal(t, "one", got)
+
+	var env Envelope
+	raw, _ := rdb.Get(ctx, "secrets:b/y").Bytes()
+	require.NoError(t, json.Unmarshal(raw, &env))
+	assert.Equal(t, "k2", env.KeyID)
+}
+
+func TestKeyRingFromEnv(t *testing.T) {
+	t.Setenv("SECRETS_KEYRING", "new:"+base64.StdEncoding.EncodeToString(key(2))+",old:"+base64.StdEncoding.EncodeToString(key(1)))
+	ring, err := KeyRingFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "new", ring.Primary())
+
+	t.Setenv("SECRETS_KEYRING", "short:"+base64.StdEncoding.EncodeToString([]byte("x")))
+	_, err = KeyRingFromEnv()
+	assert.Error(t, err)
+}
+
+type testCred struct {
+	Username string
+	Password string
+	Nested   struct{ Token string }
+	Ptr      *struct{ Key string }
+	hidden   string
+}
+
+func TestResolveStruct(t *testing.T) {
+	t.Setenv("TEST_PW", "pw")
+	t.Setenv("TEST_TOKEN", "tok")
+	audit := &recordingAuditor{}
+	r := NewResolver(WithBackend("env", Env{}), WithAuditor(audit))
+
+	c := testCred{Username: "carrier", Password: "secret://env/TEST_PW", Ptr: &struct{ Key string }{Key: "secret://env/TEST_TOKEN"}}
+	c.Nested.Token = "secret://env/TEST_TOKEN"
+	c.hidden = "secret://env/TEST_PW"
+	require.NoError(t, r.ResolveStruct(context.Background(), &c))
+	assert.Equal(t, "carrier", c.Username)
+	assert.Equal(t, "pw", c.Password)
+	assert.Equal(t, "tok", c.Nested.Token)
+	assert.Equal(t, "tok", c.Ptr.Key)
+	assert.Equal(t, "secret://env/TEST_PW", c.hidden, "unexported fields are untouched")
+	assert.Len(t, audit.events, 3)
+
+	bad := testCred{Password: "secret://env/TEST_NOPE_1"}
+	bad.Nested.Token = "secret://env/TEST_NOPE_2"
+	err := r.ResolveStruct(context.Background(), &bad)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Password")
+	assert.Contains(t, err.Error(), "Nested.Token", "errors are aggregated")
+
+	s := "secret://env/TEST_PW"
+	require.NoError(t, r.ResolveStruct(context.Background(), &s))
+	assert.Equal(t, "pw", s)
+	assert.Error(t, r.ResolveStruct(context.Background(), c))
+}
+
+func TestSlogAuditorNeverLogsValue(t *testing.T) {
+	t.Setenv("TEST_AUDITED", "super-sensitive")
+	var buf bytes.Buffer
+	r := NewResolver(WithBackend("env", Env{}), WithAuditor(SlogAuditor{Logger: slog.New(slog.NewTextHandler(&buf, nil))}))
+	_, err := r.Resolve(context.Background(), "secret://env/TEST_AUDITED")
+	require.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "credential_read")
+	assert.Contains(t, out, "path=TEST_AUDITED")
+	assert.False(t, strings.Contains(out, "super-sensitive"))
+}
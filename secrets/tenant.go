@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrOutOfScope is returned when a tenant reference points outside the
+// company it was read for.
+var ErrOutOfScope = errors.New("secrets: reference outside company scope")
+
+// TenantResolver resolves references found in tenant-editable data —
+// integration settings a company admin can type into. Unlike Resolver it
+// knows exactly one backend, and every path must live under the company's
+// own prefix: a tenant who saves secret://env/DATABASE_URL or
+// secret://redis/<other-company>/… as an "API key" gets an error, not the
+// value. env and file must never be registered here.
+type TenantResolver struct {
+	name    string
+	backend Backend
+	audit   Auditor
+}
+
+// NewTenantResolver builds a TenantResolver serving backend b under name,
+// e.g. NewTenantResolver("redis", secrets.NewRedisBackend(rdb, ring), nil).
+// A nil auditor means SlogAuditor.
+func NewTenantResolver(name string, b Backend, audit Auditor) *TenantResolver {
+	if audit == nil {
+		audit = SlogAuditor{}
+	}
+	return &TenantResolver{name: name, backend: b, audit: audit}
+}
+
+// For returns a Resolver confined to companyID: its only backend is the
+// tenant backend, and it reads nothing outside "<companyID>/". A nil
+// TenantResolver yields a Resolver that rejects every reference.
+func (t *TenantResolver) For(companyID string) *Resolver {
+	if t == nil {
+		return NewResolver()
+	}
+	return &Resolver{
+		backends: map[string]Backend{t.name: companyScoped{companyID: companyID, b: t.backend}},
+		audit:    t.audit,
+	}
+}
+
+// Resolve is For(companyID).Resolve.
+func (t *TenantResolver) Resolve(ctx context.Context, companyID, v string) (string, error) {
+	return t.For(companyID).Resolve(ctx, v)
+}
+
+// ResolveStruct is For(companyID).ResolveStruct.
+func (t *TenantResolver) ResolveStruct(ctx context.Context, companyID string, v any) error {
+	return t.For(companyID).ResolveStruct(ctx, v)
+}
+
+// companyScoped only passes through paths under "<companyID>/".
+type companyScoped struct {
+	companyID string
+	b         Backend
+}
+
+func (s companyScoped) Get(ctx context.Context, p string) (string, error) {
+	if s.companyID == "" {
+		return "", fmt.Errorf("%w: no company", ErrOutOfScope)
+	}
+	if path.Clean(p) != p || !strings.HasPrefix(p, s.companyID+"/") {
+		return "", fmt.Errorf("%w: %q is not under %s/", ErrOutOfScope, p, s.companyID)
+	}
+	return s.b.Get(ctx, p)
+}
+
+var defaultTenant atomic.Pointer[TenantResolver]
+
+// SetTenantDefault installs the resolver used by ResolveTenant and
+// ResolveTenantStruct — and so by the client providers. Until it is called,
+// tenant references are rejected; plaintext still passes through.
+func SetTenantDefault(t *TenantResolver) { defaultTenant.Store(t) }
+
+// ResolveTenant resolves v read from companyID's settings.
+func ResolveTenant(ctx context.Context, companyID, v string) (string, error) {
+	return defaultTenant.Load().Resolve(ctx, companyID, v)
+}
+
+// ResolveTenantStruct resolves the credential struct v read from companyID's
+// settings.
+func ResolveTenantStruct(ctx context.Context, companyID string, v any) error {
+	return defaultTenant.Load().ResolveStruct(ctx, companyID, v)
+}
@@ -46,6 +46,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/observability/telemetry"
 	pb "github.com/TMS360/backend-pkg/proto/tasks"
 )
 
@@ -138,11 +139,11 @@ func Dial(addr, internalToken string, opts ...Option) (Client, error) {
 	if addr == "" {
 		return nil, errors.New("tasks: gRPC address is empty (set " + EnvAddr + ")")
 	}
-	conn, err := grpc.NewClient(
-		addr,
+	dialOpts := append([]grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithUnaryInterceptor(middleware.AuthClientInterceptor(internalToken)),
-	)
+	}, telemetry.ClientDialOptions()...)
+	conn, err := grpc.NewClient(addr, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("tasks: connect %s: %w", addr, err)
 	}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/enums"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditor struct {
+	mu        sync.Mutex
+	decisions []middleware.AccessDecision
+}
+
+func (r *recordingAuditor) RecordDenial(_ context.Context, d middleware.AccessDecision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.decisions = append(r.decisions, d)
+}
+
+func (r *recordingAuditor) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.decisions)
+}
+
+func TestCheckAccess_Reasons(t *testing.T) {
+	cases := []struct {
+		name       string
+		ctx        func() context.Context
+		wantAllow  bool
+		reason     middleware.AccessReason
+		resolution middleware.PermsResolution
+		grant      string
+		deniedBy   string
+	}{
+		{
+			name:       "anonymous",
+			ctx:        context.Background,
+			reason:     middleware.AccessUnauthenticated,
+			resolution: middleware.ResolutionNotLoaded,
+		},
+		{
+			name: "guest bypass",
+			ctx: func() context.Context {
+				return middleware.WithActor(context.Background(), &consts.Actor{ID: uuid.New(), IsGuest: true, Claims: &consts.UserClaims{}})
+			},
+			wantAllow:  true,
+			reason:     middleware.AccessGuest,
+			resolution: middleware.ResolutionNotLoaded,
+		},
+		{
+			name: "super admin bypass",
+			ctx: func() context.Context {
+				id := uuid.New()
+				return middleware.WithActor(context.Background(), &consts.Actor{ID: id, Claims: &consts.UserClaims{
+					UserID: id, Roles: []string{enums.UserRoleSuperAdmin.String()},
+				}})
+			},
+			wantAllow:  true,
+			reason:     middleware.AccessSuperAdmin,
+			resolution: middleware.ResolutionNotLoaded,
+		},
+		{
+			name: "unresolved",
+			ctx: func() context.Context {
+				return context.WithValue(permCtx(uuid.New(), []string{}), consts.PermsUnresolvedCtx, true)
+			},
+			reason:     middleware.AccessPermsUnresolved,
+			resolution: middleware.ResolutionUnresolved,
+		},
+		{
+			name:       "module grant",
+			ctx:        func() context.Context { return permCtx(uuid.New(), []string{"accounting"}) },
+			wantAllow:  true,
+			reason:     middleware.AccessGranted,
+			resolution: middleware.ResolutionResolved,
+			grant:      "accounting",
+		},
+		{
+			name:       "conditional grant",
+			ctx:        func() context.Context { return permCtx(uuid.New(), []string{"accounting.invoices.delete?own"}) },
+			wantAllow:  true,
+			reason:     middleware.AccessGranted,
+			resolution: middleware.ResolutionResolved,
+			grant:      "accounting.invoices.delete?own",
+		},
+		{
+			name:       "deny entry",
+			ctx:        func() context.Context { return permCtx(uuid.New(), []string{"accounting", "-accounting.invoices"}) },
+			reason:     middleware.AccessDeniedByEntry,
+			resolution: middleware.ResolutionResolved,
+			deniedBy:   "-accounting.invoices",
+		},
+		{
+			name:       "missing",
+			ctx:        func() context.Context { return permCtx(uuid.New(), []string{"loads"}) },
+			reason:     middleware.AccessMissingPermission,
+			resolution: middleware.ResolutionResolved,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := middleware.CheckAccess(tc.ctx(), "rest", "accounting.invoices.delete")
+			assert.Equal(t, tc.wantAllow, d.Allowed)
+			assert.Equal(t, tc.reason, d.Reason)
+			assert.Equal(t, tc.resolution, d.Resolution)
+			assert.Equal(t, tc.grant, d.MatchedGrant)
+			assert.Equal(t, tc.deniedBy, d.DeniedBy)
+			assert.Equal(t, []string{"accounting.invoices.delete"}, d.Required)
+			assert.Equal(t, "rest", d.Gate)
+		})
+	}
+}
+
+func TestCheckAccess_AnyRequiredGrantWins(t *testing.T) {
+	ctx := permCtx(uuid.New(), []string{"accounting", "-accounting.invoices.delete"})
+	d := middleware.CheckAccess(ctx, "graphql", "accounting.invoices.delete", "accounting.invoices.view")
+	assert.True(t, d.Allowed)
+	assert.Equal(t, "accounting", d.MatchedGrant)
+	assert.Empty(t, d.DeniedBy)
+}
+
+func TestCheckAccess_DenialsAreAuditedWithSampling(t *testing.T) {
+	rec := &recordingAuditor{}
+	middleware.SetDenialAuditor(rec, 1)
+	t.Cleanup(func() { middleware.SetDenialAuditor(nil, 0) })
+
+	denied := permCtx(uuid.New(), []string{"loads"})
+	middleware.CheckAccess(denied, "rest", "accounting")
+	middleware.CheckAccess(permCtx(uuid.New(), []string{"accounting"}), "rest", "accounting")
+	require.Equal(t, 1, rec.count(), "only denials are audited")
+	assert.Equal(t, middleware.AccessMissingPermission, rec.decisions[0].Reason)
+	assert.Equal(t, []string{"loads"}, rec.decisions[0].Held)
+
+	middleware.SetDenialAuditor(rec, 0)
+	for range 50 {
+		middleware.CheckAccess(denied, "rest", "accounting")
+	}
+	assert.Equal(t, 1, rec.count(), "a zero sample rate forwards nothing")
+}
+
+func TestAccessTrace_RecordsEveryDecision(t *testing.T) {
+	ctx, trace := middleware.WithAccessTrace(permCtx(uuid.New(), []string{"loads"}))
+	middleware.CheckAccess(ctx, "graphql", "loads.loads.view")
+	middleware.CheckAccess(ctx, "graphql", "accounting")
+
+	ds := trace.Decisions()
+	require.Len(t, ds, 2)
+	assert.True(t, ds[0].Allowed)
+	assert.False(t, ds[1].Allowed)
+}
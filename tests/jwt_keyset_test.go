@@ -0,0 +1,277 @@
+package tests
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Signing-key rotation: IdentifyUser / AuthServerInterceptor verify against a
+// kid-selected KeySet so tms-auth can rotate keys without a fleet redeploy.
+
+func mustRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return k
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key any, kid string, userID uuid.UUID) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, &consts.UserClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+		},
+	})
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+// identify runs IdentifyUserWithKeys over one request and returns the actor it
+// left on the context (nil when the token was rejected).
+func identify(t *testing.T, keys middleware.KeySet, token string) *consts.Actor {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var actor *consts.Actor
+	r := gin.New()
+	r.Use(middleware.IdentifyUserWithKeys(keys))
+	r.GET("/", func(c *gin.Context) {
+		actor, _ = middleware.GetActor(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return actor
+}
+
+func TestKeySet_SelectsKeyByKid(t *testing.T) {
+	oldKey, newKey := mustRSAKey(t), mustRSAKey(t)
+	keys := middleware.NewStaticKeySet(
+		middleware.JWTKey{ID: "2026-01", Key: &oldKey.PublicKey},
+		middleware.JWTKey{ID: "2026-07", Key: &newKey.PublicKey},
+	)
+	uid := uuid.New()
+
+	a := identify(t, keys, signToken(t, jwt.SigningMethodRS256, newKey, "2026-07", uid))
+	require.NotNil(t, a)
+	assert.Equal(t, uid, a.ID)
+
+	assert.NotNil(t, identify(t, keys, signToken(t, jwt.SigningMethodRS256, oldKey, "2026-01", uid)))
+	// Right key, wrong kid: the kid pins the key, no fallback to the others.
+	assert.Nil(t, identify(t, keys, signToken(t, jwt.SigningMethodRS256, oldKey, "2026-07", uid)))
+	assert.Nil(t, identify(t, keys, signToken(t, jwt.SigningMethodRS256, oldKey, "unknown", uid)))
+}
+
+func TestKeySet_LegacyTokenWithoutKidTriesEveryKey(t *testing.T) {
+	oldKey, newKey := mustRSAKey(t), mustRSAKey(t)
+	keys := middleware.NewStaticKeySet(
+		middleware.JWTKey{ID: "a", Key: &newKey.PublicKey},
+		middleware.JWTKey{ID: "b", Key: &oldKey.PublicKey},
+	)
+	assert.NotNil(t, identify(t, keys, signToken(t, jwt.SigningMethodRS256, oldKey, "", uuid.New())))
+}
+
+func TestKeySet_SingleRSAKeyStillVerifiesKiddedTokens(t *testing.T) {
+	key := mustRSAKey(t)
+	assert.NotNil(t, identify(t, middleware.SingleRSAKeySet(&key.PublicKey),
+		signToken(t, jwt.SigningMethodRS256, key, "2026-07", uuid.New())))
+	assert.Nil(t, identify(t, middleware.SingleRSAKeySet(nil),
+		signToken(t, jwt.SigningMethodRS256, key, "", uuid.New())))
+}
+
+func TestKeySet_RetiredKeyIsRefused(t *testing.T) {
+	key := mustRSAKey(t)
+	uid := uuid.New()
+	inGrace := middleware.NewStaticKeySet(middleware.JWTKey{ID: "k", Key: &key.PublicKey, RetireAt: time.Now().Add(time.Hour)})
+	retired := middleware.NewStaticKeySet(middleware.JWTKey{ID: "k", Key: &key.PublicKey, RetireAt: time.Now().Add(-time.Second)})
+
+	tok := signToken(t, jwt.SigningMethodRS256, key, "k", uid)
+	assert.NotNil(t, identify(t, inGrace, tok))
+	assert.Nil(t, identify(t, retired, tok))
+}
+
+func TestKeySet_Ed25519AndNoAlgConfusion(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey := mustRSAKey(t)
+	keys := middleware.NewStaticKeySet(
+		middleware.JWTKey{ID: "ed", Key: pub},
+		middleware.JWTKey{ID: "rsa", Key: &rsaKey.PublicKey},
+	)
+
+	assert.NotNil(t, identify(t, keys, signToken(t, jwt.SigningMethodEdDSA, priv, "ed", uuid.New())))
+	// An EdDSA token naming the RSA kid finds no key of a compatible type.
+	assert.Nil(t, identify(t, keys, signToken(t, jwt.SigningMethodEdDSA, priv, "rsa", uuid.New())))
+	// HMAC is never accepted, whatever the kid.
+	assert.Nil(t, identify(t, keys, signToken(t, jwt.SigningMethodHS256, []byte("secret"), "rsa", uuid.New())))
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey := mustRSAKey(t)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	doc, _ := json.Marshal(map[string]any{"keys": []any{
+		rsaJWK("r1", &rsaKey.PublicKey),
+		map[string]string{"kty": "OKP", "crv": "Ed25519", "kid": "e1", "x": base64.RawURLEncoding.EncodeToString(edPub)},
+		map[string]string{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		map[string]string{"kty": "EC", "kid": "ec", "crv": "P-256"},
+	}})
+	keys, err := middleware.ParseJWKS(doc)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "r1", keys[0].ID)
+	assert.True(t, rsaKey.PublicKey.Equal(keys[0].Key))
+	assert.Equal(t, ed25519.PublicKey(edPub), keys[1].Key)
+
+	_, err = middleware.ParseJWKS([]byte(`{"keys":[{"kty":"RSA","kid":"bad","n":"","e":"AQAB"}]}`))
+	assert.Error(t, err)
+}
+
+// jwksServer serves whatever keys are currently set and counts fetches.
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    []any
+	fetches int
+}
+
+func (s *jwksServer) set(keys ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetches++
+	_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+}
+
+func TestJWKSKeySet_RotationWithGrace(t *testing.T) {
+	oldKey, newKey := mustRSAKey(t), mustRSAKey(t)
+	srv := &jwksServer{}
+	srv.set(rsaJWK("old", &oldKey.PublicKey))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx := context.Background()
+	keys, err := middleware.NewJWKSKeySet(ctx, ts.URL, middleware.WithJWKSGracePeriod(time.Hour))
+	require.NoError(t, err)
+	oldTok := signToken(t, jwt.SigningMethodRS256, oldKey, "old", uuid.New())
+	assert.NotNil(t, identify(t, keys, oldTok))
+
+	// tms-auth publishes the new key and drops the old one.
+	srv.set(rsaJWK("new", &newKey.PublicKey))
+	require.NoError(t, keys.Refresh(ctx))
+
+	assert.NotNil(t, identify(t, keys, signToken(t, jwt.SigningMethodRS256, newKey, "new", uuid.New())))
+	assert.NotNil(t, identify(t, keys, oldTok), "a dropped key keeps verifying through its grace period")
+}
+
+func TestJWKSKeySet_ZeroGraceDropsImmediately(t *testing.T) {
+	oldKey, newKey := mustRSAKey(t), mustRSAKey(t)
+	srv := &jwksServer{}
+	srv.set(rsaJWK("old", &oldKey.PublicKey))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx := context.Background()
+	keys, err := middleware.NewJWKSKeySet(ctx, ts.URL, middleware.WithJWKSGracePeriod(0))
+	require.NoError(t, err)
+	srv.set(rsaJWK("new", &newKey.PublicKey))
+	require.NoError(t, keys.Refresh(ctx))
+
+	assert.Nil(t, identify(t, keys, signToken(t, jwt.SigningMethodRS256, oldKey, "old", uuid.New())))
+}
+
+func TestJWKSKeySet_UnknownKidRefetchIsRateLimited(t *testing.T) {
+	oldKey, newKey := mustRSAKey(t), mustRSAKey(t)
+	srv := &jwksServer{}
+	srv.set(rsaJWK("old", &oldKey.PublicKey))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	keys, err := middleware.NewJWKSKeySet(context.Background(), ts.URL)
+	require.NoError(t, err)
+
+	// The new key is published, but the set fetched moments ago: the on-demand
+	// refetch is suppressed inside the rate-limit window.
+	srv.set(rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey))
+	newTok := signToken(t, jwt.SigningMethodRS256, newKey, "new", uuid.New())
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, identify(t, keys, newTok))
+	}
+	srv.mu.Lock()
+	assert.Equal(t, 1, srv.fetches)
+	srv.mu.Unlock()
+}
+
+func TestNewJWKSKeySet_FailsWhenFirstFetchFails(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+	_, err := middleware.NewJWKSKeySet(context.Background(), ts.URL)
+	assert.Error(t, err)
+}
+
+func TestAuthServerInterceptorWithKeys(t *testing.T) {
+	key := mustRSAKey(t)
+	keys := middleware.NewStaticKeySet(middleware.JWTKey{ID: "k", Key: &key.PublicKey})
+	icpt := middleware.AuthServerInterceptorWithKeys(keys, "")
+	uid := uuid.New()
+
+	call := func(token string) (*consts.Actor, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+		var got *consts.Actor
+		_, err := icpt(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			got, _ = middleware.GetActor(ctx)
+			return nil, nil
+		})
+		return got, err
+	}
+
+	a, err := call(signToken(t, jwt.SigningMethodRS256, key, "k", uid))
+	require.NoError(t, err)
+	assert.Equal(t, uid, a.ID)
+
+	_, err = call(signToken(t, jwt.SigningMethodRS256, mustRSAKey(t), "k", uid))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// Deny entries beat inherited grants; conditional grants pass the gate but are
+// decided per record.
+
+func TestPermissionSet_DenyBeatsInheritedGrant(t *testing.T) {
+	ps := middleware.NewPermissionSet([]string{"accounting", "-accounting.invoices.delete"}, uuid.Nil)
+
+	assert.True(t, ps.Grants("accounting.invoices.view"))
+	assert.True(t, ps.Grants("accounting.invoices.create"))
+	assert.False(t, ps.Grants("accounting.invoices.delete"))
+	assert.False(t, ps.MayGrant("accounting.invoices.delete"))
+	assert.False(t, ps.Allows("accounting.invoices.delete", middleware.Attributes{}))
+	// The deny is on a leaf, so the module itself is still held.
+	assert.True(t, ps.Grants("accounting"))
+}
+
+func TestPermissionSet_DenyOnAncestorCoversDescendants(t *testing.T) {
+	ps := middleware.NewPermissionSet([]string{"accounting", "-accounting.invoices"}, uuid.Nil)
+	assert.False(t, ps.Grants("accounting.invoices.view"))
+	assert.True(t, ps.Grants("accounting.vendors.view"))
+}
+
+func TestHasPermission_HonoursDenyButNotConditions(t *testing.T) {
+	assert.False(t, middleware.HasPermission([]string{"accounting", "-accounting.invoices.delete"}, "accounting.invoices.delete"))
+	// HasPermission is the unconditional question: an ?own grant is not enough.
+	assert.False(t, middleware.HasPermission([]string{"loads.loads.edit?own"}, "loads.loads.edit"))
+	assert.True(t, middleware.HasPermission([]string{"loads"}, "loads.loads.edit"))
+}
+
+func TestPermissionSet_ConditionalGrants(t *testing.T) {
+	me, other := uuid.New(), uuid.New()
+	team := uuid.New()
+	ps := middleware.NewPermissionSet([]string{
+		"loads.loads.edit?own",
+		"loads.loads.view?team_id=" + team.String(),
+		"accounting.invoices.approve?amount<5000",
+	}, me)
+
+	assert.True(t, ps.MayGrant("loads.loads.edit"))
+	assert.False(t, ps.Grants("loads.loads.edit"))
+	assert.True(t, ps.Allows("loads.loads.edit", middleware.Attributes{"owner_id": me}))
+	assert.False(t, ps.Allows("loads.loads.edit", middleware.Attributes{"owner_id": other}))
+	assert.False(t, ps.Allows("loads.loads.edit", middleware.Attributes{}), "missing attribute fails closed")
+
+	assert.True(t, ps.Allows("loads.loads.view", middleware.Attributes{"team_id": []uuid.UUID{uuid.New(), team}}))
+	assert.False(t, ps.Allows("loads.loads.view", middleware.Attributes{"team_id": uuid.New()}))
+
+	assert.True(t, ps.Allows("accounting.invoices.approve", middleware.Attributes{"amount": decimal.NewFromFloat(4999.99)}))
+	assert.False(t, ps.Allows("accounting.invoices.approve", middleware.Attributes{"amount": 5000}))
+
+	sets, unconditional := ps.Conditions("loads.loads.edit")
+	assert.False(t, unconditional)
+	assert.Len(t, sets, 1)
+}
+
+func TestPermissionSet_ConditionalGrantCannotOverrideDeny(t *testing.T) {
+	me := uuid.New()
+	ps := middleware.NewPermissionSet([]string{"loads.loads.edit?own", "-loads.loads"}, me)
+	assert.False(t, ps.MayGrant("loads.loads.edit"))
+	assert.False(t, ps.Allows("loads.loads.edit", middleware.Attributes{"owner_id": me}))
+}
+
+func TestPermissionSet_MalformedEntryIsIgnored(t *testing.T) {
+	ps := middleware.NewPermissionSet([]string{"loads.loads.edit?bogus", "-loads.loads.view?own"}, uuid.New())
+	assert.False(t, ps.MayGrant("loads.loads.edit"))
+	// The malformed deny is dropped too — but there is no grant to deny anyway.
+	assert.False(t, ps.MayGrant("loads.loads.view"))
+}
+
+func permCtx(actorID uuid.UUID, perms []string) context.Context {
+	ctx := middleware.WithActor(context.Background(), &consts.Actor{
+		ID:     actorID,
+		Claims: &consts.UserClaims{UserID: actorID},
+	})
+	return consts.WithUserPerms(ctx, perms)
+}
+
+func TestCanAccess(t *testing.T) {
+	me := uuid.New()
+	ctx := permCtx(me, []string{"loads.loads.edit?own"})
+	assert.True(t, middleware.CanAccess(ctx, "loads.loads.edit", middleware.Attributes{"owner_id": me.String()}))
+	assert.False(t, middleware.CanAccess(ctx, "loads.loads.edit", middleware.Attributes{"owner_id": uuid.New()}))
+
+	unresolved := context.WithValue(ctx, consts.PermsUnresolvedCtx, true)
+	assert.False(t, middleware.CanAccess(unresolved, "loads.loads.edit", middleware.Attributes{"owner_id": me}))
+
+	assert.False(t, middleware.CanAccess(context.Background(), "loads.loads.edit", nil))
+}
+
+func TestRequirePerms_ConditionalPassesGateDenyBlocks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	serve := func(perms []string) int {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(permCtx(uuid.New(), perms))
+			c.Next()
+		})
+		r.GET("/", middleware.RequirePerms("loads.loads.edit"), func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve([]string{"loads"}))
+	assert.Equal(t, http.StatusOK, serve([]string{"loads.loads.edit?own"}))
+	assert.Equal(t, http.StatusForbidden, serve([]string{"loads", "-loads.loads.edit"}))
+	assert.Equal(t, http.StatusForbidden, serve([]string{"loads.loads.view"}))
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TMS360/backend-pkg/auth"
+	"github.com/TMS360/backend-pkg/consts"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// The session id is what a single-session revoke keys on: prefer the explicit
+// sid claim, fall back to jti, and give up (per-user cutoff only) on tokens that
+// carry neither.
+func TestSessionIDFromClaims(t *testing.T) {
+	assert.Equal(t, "", auth.SessionIDFromClaims(nil))
+	assert.Equal(t, "", auth.SessionIDFromClaims(&consts.UserClaims{}))
+	assert.Equal(t, "jti-1", auth.SessionIDFromClaims(&consts.UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: "jti-1"},
+	}))
+	assert.Equal(t, "sid-1", auth.SessionIDFromClaims(&consts.UserClaims{
+		SessionID:        "sid-1",
+		RegisteredClaims: jwt.RegisteredClaims{ID: "jti-1"},
+	}))
+}
+
+// The marker must live exactly as long as the token it blocks (plus skew) —
+// shorter and the token outlives its revocation, longer and Redis fills with
+// inert markers.
+func TestSessionRevocationTTL(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 15*time.Minute+auth.RevocationClockSkew,
+		auth.SessionRevocationTTL(now.Add(15*time.Minute), now))
+	assert.Equal(t, auth.TokensValidAfterTTL, auth.SessionRevocationTTL(time.Time{}, now),
+		"unknown expiry falls back to the per-user cutoff lifetime")
+	assert.Equal(t, auth.RevocationClockSkew, auth.SessionRevocationTTL(now.Add(-time.Hour), now),
+		"an already-expired token still gets a positive TTL")
+}
+
+func TestDescribeDevice(t *testing.T) {
+	cases := map[string]string{
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36":                       "Chrome on macOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36 Edg/124":                     "Edge on Windows",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0":                                                                  "Firefox on Linux",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1": "Safari on iOS",
+		"okhttp/4.12.0": "Mobile app",
+		"":              "Unknown device",
+		"curl/8.5.0":    "Unknown device",
+	}
+	for ua, want := range cases {
+		assert.Equal(t, want, auth.DescribeDevice(ua), ua)
+	}
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"reflect"
 	"strings"
 	"time"
 
 	"github.com/TMS360/backend-pkg/eventlog/events"
 	"github.com/TMS360/backend-pkg/middleware"
+	"github.com/TMS360/backend-pkg/observability/telemetry"
 	"github.com/TMS360/backend-pkg/tmsdb/model"
 	"github.com/TMS360/backend-pkg/utils"
 	"github.com/google/uuid"
@@ -70,7 +71,24 @@ func (m *GormTransactionManager) Event(aggType, evtType string, aggID uuid.UUID)
 	return &EventBuilder{tm: m, aggType: aggType, evtType: evtType, aggID: aggID}
 }
 
+type eventCompanyKey struct{}
+
+// WithEventCompany returns ctx with companyID as the company of events
+// published under it. It is for producers that act for a known tenant
+// without a user — feed consumers, webhooks, jobs — whose context carries no
+// actor company; it takes precedence over the actor's.
+func WithEventCompany(ctx context.Context, companyID uuid.UUID) context.Context {
+	return context.WithValue(ctx, eventCompanyKey{}, companyID)
+}
+
+// EventCompany returns the company set by WithEventCompany, if any.
+func EventCompany(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(eventCompanyKey{}).(uuid.UUID)
+	return id, ok && id != uuid.Nil
+}
+
 // actorIdentity resolves the (actor, company) pair stamped on an event row.
+// A company set with WithEventCompany wins over the actor's.
 //
 // It is a separate function so the regression it carries can be tested without a
 // database: DEV-1732 crash-looped backend-workspaces because this resolution read
@@ -80,14 +98,17 @@ func (m *GormTransactionManager) Event(aggType, evtType string, aggID uuid.UUID)
 // subgraph. Both returns are nil-able on purpose: an event emitted by a system
 // actor legitimately has no company.
 func actorIdentity(ctx context.Context) (actorID, companyID *uuid.UUID) {
+	if cid, ok := EventCompany(ctx); ok {
+		companyID = utils.Pointer(cid)
+	}
 	actor, _ := middleware.GetActor(ctx)
 	if actor == nil {
-		return nil, nil
+		return nil, companyID
 	}
 	actorID = utils.Pointer(actor.ID)
 	// GetCompanyID is the nil-safe accessor (same guard notify.go uses); never
 	// reach through Claims here.
-	if cid := actor.GetCompanyID(); cid != nil {
+	if cid := actor.GetCompanyID(); cid != nil && companyID == nil {
 		companyID = utils.Pointer(*cid)
 	}
 	return actorID, companyID
@@ -161,6 +182,7 @@ func (m *GormTransactionManager) writeEvent(ctx context.Context, b *EventBuilder
 		RootEntityID:   rootID,
 		Sensitivity:    sensitivity,
 		Participants:   participants,
+		TraceContext:   telemetry.InjectMap(ctx),
 	}
 
 	payloadBytes, err := json.Marshal(eventPayload)
@@ -209,14 +231,14 @@ func (m *GormTransactionManager) writeEvent(ctx context.Context, b *EventBuilder
 
 		// Non-fatal by design: лог + nil, каллер коммитит бизнес-данные.
 		// Trade-off: этот domain-event теряется.
-		log.Printf("outbox publish skipped (non-fatal) entity=%s/%s action=%s: %v",
-			event.EntityType, event.EntityID, event.EventType, createErr)
+		slog.WarnContext(ctx, "outbox publish skipped (non-fatal)",
+			"entity_type", event.EntityType, "entity_id", event.EntityID, "action", event.EventType, "error", createErr)
 		return nil
 	}
 
 	if err := db.Exec("RELEASE SAVEPOINT " + sp).Error; err != nil {
 		// INSERT уже прошёл; невыпущенный savepoint просто доживёт до COMMIT. Не валим каллера.
-		log.Printf("outbox: release savepoint after successful insert: %v", err)
+		slog.WarnContext(ctx, "outbox: release savepoint after successful insert failed", "error", err)
 	}
 
 	return nil
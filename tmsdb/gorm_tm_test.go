@@ -51,6 +51,18 @@ func TestActorIdentity_UserActorKeepsCompany(t *testing.T) {
 	}
 }
 
+// A feed consumer has no actor but knows the tenant; the event still gets it.
+func TestActorIdentity_EventCompanyWithoutActor(t *testing.T) {
+	company := uuid.New()
+	actorID, companyID := actorIdentity(WithEventCompany(context.Background(), company))
+	if actorID != nil {
+		t.Fatalf("no actor must stamp no actor id, got %v", *actorID)
+	}
+	if companyID == nil || *companyID != company {
+		t.Fatalf("event company must be stamped, got %v", companyID)
+	}
+}
+
 // No actor at all (background job with a bare context) is not a crash either.
 func TestActorIdentity_NoActor(t *testing.T) {
 	actorID, companyID := actorIdentity(context.Background())